@@ -1,18 +1,25 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mutsaevz/team-4-dentistry/internal/config"
+	"github.com/mutsaevz/team-4-dentistry/internal/events"
+	"github.com/mutsaevz/team-4-dentistry/internal/jobs"
 	"github.com/mutsaevz/team-4-dentistry/internal/loggers"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/notifications"
+	"github.com/mutsaevz/team-4-dentistry/internal/payments"
+	"github.com/mutsaevz/team-4-dentistry/internal/realtime"
 	"github.com/mutsaevz/team-4-dentistry/internal/repository"
 	"github.com/mutsaevz/team-4-dentistry/internal/seed"
 	"github.com/mutsaevz/team-4-dentistry/internal/services"
 	"github.com/mutsaevz/team-4-dentistry/internal/transports"
+	"github.com/mutsaevz/team-4-dentistry/internal/webhooks"
 )
 
 func main() {
@@ -28,21 +35,90 @@ func main() {
 	patientRecordRepo := repository.NewPatientRecordRepo(db, logger)
 	recommendationRepo := repository.NewRecommendationRepository(db, logger)
 	appointmentRepo := repository.NewAppointmentRepository(db, logger)
+	paymentRepo := repository.NewPaymentRepository(db, logger)
+	insuranceRepo := repository.NewInsuranceRepository(db, logger)
+	notificationRepo := repository.NewNotificationRepository(db, logger)
+	outboxRepo := repository.NewOutboxRepository(db, logger)
+	webhookRepo := repository.NewWebhookRepository(db, logger)
+	calendarRepo := repository.NewCalendarRepository(db, logger)
+	waitlistRepo := repository.NewWaitlistRepository(db, logger)
+	slotHoldRepo := repository.NewSlotHoldRepository(db, logger)
+	seriesRepo := repository.NewAppointmentSeriesRepository(db, logger)
+	bookingPolicyRepo := repository.NewBookingPolicyRepository(db, logger)
+	resourceRepo := repository.NewResourceRepository(db, logger)
+	clinicRepo := repository.NewClinicRepository(db, logger)
+	searchRepo := repository.NewSearchRepository(db, logger)
+	specialtyRepo := repository.NewSpecialtyRepository(db, logger)
+	serviceCategoryRepo := repository.NewServiceCategoryRepository(db, logger)
+	bundleRepo := repository.NewBundleRepository(db, logger)
 
 	if err := db.AutoMigrate(
 		&models.Appointment{},
+		&models.Specialty{},
 		&models.Doctor{},
 		&models.PatientRecord{},
 		&models.Recommendation{},
 		&models.Review{},
+		&models.ReviewFlag{},
 		&models.Schedule{},
+		&models.ServiceCategory{},
 		&models.Service{},
+		&models.DoctorOffering{},
+		&models.ServiceBundle{},
+		&models.ServiceBundleItem{},
+		&models.PatientPackage{},
+		&models.PatientPackageItem{},
 		&models.User{},
+		&models.Payment{},
+		&models.Insurer{},
+		&models.CoverageRule{},
+		&models.InsurancePolicy{},
+		&models.InsuranceClaim{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.OutboxEvent{},
+		&models.OutboxConsumption{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.CalendarToken{},
+		&models.WaitlistEntry{},
+		&models.WaitlistOffer{},
+		&models.SlotHold{},
+		&models.AppointmentSeries{},
+		&models.BookingPolicyOverride{},
+		&models.Room{},
+		&models.Equipment{},
+		&models.Clinic{},
+		&models.ClinicStaff{},
+		&models.DoctorInvitation{},
 	); err != nil {
 		logger.Error("failed to migrate database", "error", err)
 		os.Exit(1)
 	}
 
+	// Номер кабинета раньше был уникален глобально, теперь — в пределах филиала.
+	if db.Migrator().HasIndex(&models.Room{}, "idx_rooms_number") {
+		if err := db.Migrator().DropIndex(&models.Room{}, "idx_rooms_number"); err != nil {
+			logger.Error("failed to drop legacy room index", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := repository.MigrateDoctorServices(db); err != nil {
+		logger.Error("failed to migrate doctor services", "error", err)
+		os.Exit(1)
+	}
+
+	if err := repository.MigrateServiceCategories(db); err != nil {
+		logger.Error("failed to migrate service categories", "error", err)
+		os.Exit(1)
+	}
+
+	if err := repository.MigrateSearch(db); err != nil {
+		logger.Error("failed to create search indexes", "error", err)
+		os.Exit(1)
+	}
+
 	if err := seed.SeedAdmin(userRepo, logger); err != nil {
 		logger.Error("Не удалось заполнить административную панель", "error", err)
 		os.Exit(1)
@@ -58,22 +134,210 @@ func main() {
 		AccessTokenTTL: time.Hour * 24,
 	}
 
+	clinicLocation, err := time.LoadLocation(config.GetEnv("CLINIC_TIMEZONE", "Europe/Moscow"))
+	if err != nil {
+		logger.Error("некорректный часовой пояс клиники", "error", err)
+		os.Exit(1)
+	}
+
 	userService := services.NewUserService(userRepo, logger)
-	servService := services.NewServService(serviceRepo, logger)
-	doctorService := services.NewDoctorService(doctorRepo, serviceRepo, scheduleRepo, logger)
+	servService := services.NewServService(serviceRepo, doctorRepo, serviceCategoryRepo, logger)
+	doctorCfg := services.DoctorConfig{
+		PhotoDir:       config.GetEnv("DOCTOR_PHOTO_DIR", "uploads/doctors"),
+		PhotoURLPrefix: "/uploads/doctors",
+		MaxPhotoBytes:  int64(config.GetEnvInt("DOCTOR_PHOTO_MAX_MB", 5)) << 20,
+	}
+	doctorService := services.NewDoctorService(doctorRepo, serviceRepo, scheduleRepo, specialtyRepo, doctorCfg, logger)
+	specialtyService := services.NewSpecialtyService(specialtyRepo, logger)
+	catalogService := services.NewCatalogService(serviceCategoryRepo, serviceRepo, bundleRepo, logger)
+	bundleService := services.NewBundleService(bundleRepo, serviceRepo, serviceCategoryRepo, userRepo, logger)
 	authService := services.NewAuthService(userRepo, jwtCfg, logger)
-	scheduleService := services.NewScheduleService(scheduleRepo, doctorRepo, logger)
-	reviewService := services.NewReviewService(reviewRepo, doctorRepo, userRepo, logger)
-	patientRecordService := services.NewPatientRecordService(patientRecordRepo, logger)
+	scheduleService := services.NewScheduleService(scheduleRepo, doctorRepo, clinicRepo, appointmentRepo, outboxRepo, services.ScheduleConfig{Location: clinicLocation}, logger)
+	reviewService := services.NewReviewService(reviewRepo, doctorRepo, userRepo, appointmentRepo, outboxRepo, services.ReviewConfig{
+		Window:      time.Duration(config.GetEnvInt("REVIEW_WINDOW_DAYS", 90)) * 24 * time.Hour,
+		AutoPublish: config.GetEnv("REVIEW_AUTO_PUBLISH", "false") == "true",
+		BannedWords: config.GetEnvList("REVIEW_BANNED_WORDS", nil),
+	}, logger)
+	directoryService := services.NewDoctorDirectoryService(doctorRepo, specialtyRepo, scheduleService, reviewService, logger)
+	patientRecordService := services.NewPatientRecordService(patientRecordRepo, outboxRepo, logger)
 	recommendationService := services.NewRecommendationService(
 		recommendationRepo,
 		userRepo,
 		serviceRepo,
 		logger,
 	)
-	appointmentService := services.NewAppointmentService(serviceRepo, appointmentRepo, logger)
+	paymentCfg := services.PaymentConfig{
+		Currency:       config.GetEnv("PAYMENT_CURRENCY", "RUB"),
+		DepositPercent: config.GetEnvFloat("PAYMENT_DEPOSIT_PERCENT", 30),
+		HoldTTL:        config.GetEnvMinutes("PAYMENT_HOLD_MINUTES", 15*time.Minute),
+	}
+
+	// Онлайн-оплата необязательна: без провайдера или секрета webhook
+	// маршруты оплаты и правило предоплаты отключаются, а оплата
+	// принимается в клинике.
+	var paymentProvider payments.Provider
+	var paymentSimulator *payments.FakeProvider
+
+	webhookSecret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	switch providerName := config.GetEnv("PAYMENT_PROVIDER", ""); {
+	case providerName == "":
+		logger.Warn("PAYMENT_PROVIDER не задан: онлайн-оплата отключена")
+	case webhookSecret == "":
+		logger.Warn("не задан PAYMENT_WEBHOOK_SECRET: без него нельзя проверить подпись webhook, онлайн-оплата отключена", "provider", providerName)
+	case providerName == payments.FakeProviderName:
+		// Тестовый провайдер и симулятор оплат подтверждают платежи без
+		// денег, поэтому включаются только явным флагом разработки.
+		if !config.GetEnvBool("PAYMENT_DEV_MODE", false) {
+			logger.Warn("тестовый платёжный провайдер доступен только при PAYMENT_DEV_MODE=true, онлайн-оплата отключена", "provider", providerName)
+			break
+		}
+		paymentSimulator = payments.NewFakeProvider(webhookSecret)
+		paymentProvider = paymentSimulator
+	default:
+		logger.Error("неизвестный платёжный провайдер", "provider", providerName)
+		os.Exit(1)
+	}
+
+	depositAfterNoShows := config.GetEnvInt("POLICY_DEPOSIT_AFTER_NO_SHOWS", 2)
+	if paymentProvider == nil {
+		depositAfterNoShows = 0
+	}
+
+	insuranceService := services.NewInsuranceService(insuranceRepo, appointmentRepo, serviceRepo, logger)
+	paymentService := services.NewPaymentService(paymentRepo, appointmentRepo, insuranceService, paymentProvider, outboxRepo, paymentCfg, logger)
+	bookingPolicyService := services.NewBookingPolicyService(bookingPolicyRepo, services.BookingPolicyConfig{
+		NoShowWindow:         time.Duration(config.GetEnvInt("POLICY_NO_SHOW_WINDOW_DAYS", 365)) * 24 * time.Hour,
+		DepositAfterNoShows:  depositAfterNoShows,
+		ApprovalAfterNoShows: config.GetEnvInt("POLICY_APPROVAL_AFTER_NO_SHOWS", 3),
+		MaxFutureBookings:    config.GetEnvInt("POLICY_MAX_FUTURE_BOOKINGS", 0),
+	}, logger)
+
+	appointmentService := services.NewAppointmentService(serviceRepo, appointmentRepo, bundleRepo, paymentService, outboxRepo, slotHoldRepo, bookingPolicyService, logger)
+	searchService := services.NewSearchService(searchRepo, services.SearchConfig{
+		MinSimilarity: config.GetEnvFloat("SEARCH_MIN_SIMILARITY", 0.4),
+	}, logger)
+	appointmentSearchService := services.NewAppointmentSearchService(appointmentRepo, clinicRepo, services.AppointmentSearchConfig{Location: clinicLocation}, logger)
+
+	calendarService := services.NewCalendarService(calendarRepo, userRepo, doctorRepo, appointmentRepo, services.CalendarConfig{
+		BaseURL:   config.GetEnv("PUBLIC_BASE_URL", "http://localhost:8080"),
+		UIDDomain: config.GetEnv("CALENDAR_UID_DOMAIN", "team-4-dentistry"),
+		Timezone:  config.GetEnv("CLINIC_TIMEZONE", "Europe/Moscow"),
+	}, logger)
+
+	notificationDispatcher := notifications.NewDispatcher(
+		notifications.NewLogSender(models.ChannelEmail, logger),
+		notifications.NewLogSender(models.ChannelSMS, logger),
+	)
+	notificationService := services.NewNotificationService(
+		notificationRepo,
+		appointmentRepo,
+		clinicRepo,
+		notificationDispatcher,
+		services.NotificationConfig{
+			Offsets:     config.GetEnvDurations("REMINDER_OFFSETS", []time.Duration{24 * time.Hour, 2 * time.Hour}),
+			MaxAttempts: config.GetEnvInt("REMINDER_MAX_ATTEMPTS", 3),
+			Location:    clinicLocation,
+		},
+		logger,
+	)
+
+	waitlistService := services.NewWaitlistService(
+		waitlistRepo,
+		appointmentRepo,
+		serviceRepo,
+		scheduleRepo,
+		clinicRepo,
+		userRepo,
+		appointmentService,
+		notificationService,
+		notificationDispatcher,
+		services.WaitlistConfig{
+			OfferTTL: config.GetEnvMinutes("WAITLIST_OFFER_MINUTES", 30*time.Minute),
+			BaseURL:  config.GetEnv("PUBLIC_BASE_URL", "http://localhost:8080"),
+			Location: clinicLocation,
+		},
+		logger,
+	)
+
+	onboardingService := services.NewDoctorOnboardingService(
+		doctorRepo,
+		userRepo,
+		serviceRepo,
+		specialtyRepo,
+		clinicRepo,
+		repository.NewInvitationRepository(db, logger),
+		scheduleService,
+		notificationDispatcher,
+		services.DoctorOnboardingConfig{
+			InvitationTTL: time.Duration(config.GetEnvInt("DOCTOR_INVITATION_TTL_HOURS", 72)) * time.Hour,
+			AcceptURL:     config.GetEnv("DOCTOR_INVITATION_URL", config.GetEnv("PUBLIC_BASE_URL", "http://localhost:8080")+"/invitation"),
+			Location:      clinicLocation,
+		},
+		logger,
+	)
+
+	slotHoldService := services.NewSlotHoldService(
+		slotHoldRepo,
+		appointmentRepo,
+		serviceRepo,
+		scheduleRepo,
+		waitlistRepo,
+		services.SlotHoldConfig{TTL: config.GetEnvMinutes("SLOT_HOLD_MINUTES", 10*time.Minute)},
+		logger,
+	)
+
+	resourceService := services.NewResourceService(resourceRepo, clinicRepo, logger)
+
+	clinicService := services.NewClinicService(clinicRepo, services.ClinicConfig{
+		DefaultTimezone: config.GetEnv("CLINIC_TIMEZONE", "Europe/Moscow"),
+	}, logger)
+
+	seriesService := services.NewAppointmentSeriesService(seriesRepo, appointmentRepo, serviceRepo, doctorRepo, scheduleRepo, clinicRepo, outboxRepo, bookingPolicyService, services.AppointmentSeriesConfig{Location: clinicLocation}, logger)
+
+	queueService := services.NewQueueService(
+		appointmentRepo,
+		scheduleRepo,
+		clinicRepo,
+		realtime.NewHub[models.QueueEvent](32, logger),
+		services.QueueConfig{Location: clinicLocation},
+		logger,
+	)
+
+	// Табло открыто без входа в систему, поэтому без токена поток очереди
+	// не публикуется.
+	queueDisplayToken := config.GetEnv("QUEUE_DISPLAY_TOKEN", "")
+	if queueDisplayToken == "" {
+		logger.Warn("QUEUE_DISPLAY_TOKEN не задан: поток очереди для табло отключён")
+	}
+
+	eventDispatcher := events.NewDispatcher(outboxRepo, events.DispatcherConfig{
+		MaxAttempts: config.GetEnvInt("OUTBOX_MAX_ATTEMPTS", 10),
+	}, logger)
+	eventDispatcher.Subscribe(events.AppointmentCancelled, "waitlist.offer_freed_slot", waitlistService.HandleAppointmentCancelled)
+	eventDispatcher.Subscribe(events.ScheduleCreated, "waitlist.offer_new_slots", waitlistService.HandleScheduleCreated)
+
+	webhookService := services.NewWebhookService(
+		webhookRepo,
+		webhooks.NewClient(10*time.Second),
+		services.WebhookConfig{MaxAttempts: config.GetEnvInt("WEBHOOK_MAX_ATTEMPTS", 8)},
+		logger,
+	)
+	for _, eventType := range services.WebhookEventTypes {
+		eventDispatcher.Subscribe(eventType, "webhooks.fanout", webhookService.HandleEvent)
+	}
+
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	jobs.Every(jobCtx, logger, "payments.expire_unpaid", time.Minute, paymentService.ExpireUnpaid)
+	jobs.Every(jobCtx, logger, "outbox.dispatch", 5*time.Second, eventDispatcher.Dispatch)
+	jobs.Every(jobCtx, logger, "webhooks.deliver", 10*time.Second, webhookService.DeliverDue)
+	jobs.Every(jobCtx, logger, "waitlist.expire_offers", time.Minute, waitlistService.ExpireOffers)
+	jobs.Every(jobCtx, logger, "appointments.expire_holds", time.Minute, slotHoldService.ExpireHolds)
+	jobs.Every(jobCtx, logger, "notifications.reminders", config.GetEnvMinutes("REMINDER_INTERVAL_MINUTES", time.Minute), notificationService.SendDueReminders)
 
 	r := gin.Default()
+	r.Static(doctorCfg.PhotoURLPrefix, doctorCfg.PhotoDir)
 
 	r.GET("/health", func(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -92,6 +356,27 @@ func main() {
 		reviewService,
 		patientRecordService,
 		appointmentService,
+		paymentService,
+		paymentSimulator,
+		insuranceService,
+		notificationService,
+		webhookService,
+		calendarService,
+		waitlistService,
+		slotHoldService,
+		seriesService,
+		queueService,
+		queueDisplayToken,
+		bookingPolicyService,
+		resourceService,
+		clinicService,
+		appointmentSearchService,
+		searchService,
+		specialtyService,
+		directoryService,
+		onboardingService,
+		catalogService,
+		bundleService,
 	)
 
 	addr := ":8080"
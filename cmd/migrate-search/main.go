@@ -0,0 +1,25 @@
+// Команда migrate-search включает расширение pg_trgm, нужное поиску.
+// Запускается один раз при развёртывании от имени владельца базы: сервер
+// при старте только проверяет, что расширение есть.
+package main
+
+import (
+	"os"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/config"
+	"github.com/mutsaevz/team-4-dentistry/internal/loggers"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+)
+
+func main() {
+	logger := loggers.InitLogger()
+
+	db := config.SetUpDatabaseConnection(logger)
+
+	if err := repository.EnableSearchExtension(db); err != nil {
+		logger.Error("Не удалось включить pg_trgm", "error", err)
+		os.Exit(1)
+	}
+
+	logger.Info("Расширение pg_trgm включено")
+}
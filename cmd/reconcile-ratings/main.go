@@ -0,0 +1,42 @@
+// Команда reconcile-ratings пересчитывает средний рейтинг и число отзывов
+// всех врачей по опубликованным отзывам. Запускается вручную после
+// миграций или при подозрении на рассинхронизацию.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/config"
+	"github.com/mutsaevz/team-4-dentistry/internal/loggers"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+func main() {
+	logger := loggers.InitLogger()
+
+	db := config.SetUpDatabaseConnection(logger)
+
+	reviewService := services.NewReviewService(
+		repository.NewReviewRepository(db, logger),
+		repository.NewDoctorRepository(db, logger),
+		repository.NewUserRepository(db, logger),
+		repository.NewAppointmentRepository(db, logger),
+		repository.NewOutboxRepository(db, logger),
+		services.ReviewConfig{},
+		logger,
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	changed, err := reviewService.ReconcileRatings(ctx)
+	if err != nil {
+		logger.Error("Пересчёт рейтингов прерван", "error", err, "changed", changed)
+		os.Exit(1)
+	}
+
+	logger.Info("Рейтинги врачей пересчитаны", "changed", changed)
+}
@@ -0,0 +1,111 @@
+package calendar
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusTentative = "TENTATIVE"
+	StatusCancelled = "CANCELLED"
+)
+
+const icsTimeLayout = "20060102T150405Z"
+
+type Event struct {
+	// UID должен быть стабильным для одного и того же приёма, иначе
+	// календарь клиента создаст дубликат вместо обновления.
+	UID         string
+	Sequence    int
+	Stamp       time.Time
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	Status      string
+}
+
+type Calendar struct {
+	Name     string
+	Timezone string
+	Events   []Event
+}
+
+// Render собирает документ RFC 5545. Время событий пишется в UTC,
+// поэтому клиенты сами переводят его в локальный часовой пояс.
+func Render(cal Calendar) []byte {
+	var b bytes.Buffer
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:-//team-4-dentistry//appointments//RU")
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	if cal.Name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+escapeText(cal.Name))
+	}
+	if cal.Timezone != "" {
+		writeLine(&b, "X-WR-TIMEZONE:"+cal.Timezone)
+	}
+
+	for _, e := range cal.Events {
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+e.UID)
+		writeLine(&b, fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+		writeLine(&b, "DTSTAMP:"+formatTime(e.Stamp))
+		writeLine(&b, "DTSTART:"+formatTime(e.Start))
+		writeLine(&b, "DTEND:"+formatTime(e.End))
+		writeLine(&b, "SUMMARY:"+escapeText(e.Summary))
+		if e.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+escapeText(e.Description))
+		}
+		if e.Location != "" {
+			writeLine(&b, "LOCATION:"+escapeText(e.Location))
+		}
+		if e.Status != "" {
+			writeLine(&b, "STATUS:"+e.Status)
+		}
+		writeLine(&b, "END:VEVENT")
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+	return b.Bytes()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(icsTimeLayout)
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeLine пишет строку с переносом по 75 октетов (RFC 5545, 3.1),
+// не разрывая многобайтовые символы.
+func writeLine(b *bytes.Buffer, line string) {
+	const limit = 75
+
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+}
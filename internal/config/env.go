@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func GetEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func GetEnvInt(key string, fallback int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+func GetEnvFloat(key string, fallback float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+// GetEnvBool читает флаг в формате strconv.ParseBool ("true", "1" и т.п.).
+func GetEnvBool(key string, fallback bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+// GetEnvMinutes читает длительность, заданную целым числом минут.
+func GetEnvMinutes(key string, fallback time.Duration) time.Duration {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v <= 0 {
+		return fallback
+	}
+	return time.Duration(v) * time.Minute
+}
+
+// GetEnvDurations читает список длительностей через запятую, например "24h,2h".
+// Некорректные и неположительные значения пропускаются.
+func GetEnvDurations(key string, fallback []time.Duration) []time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+
+	var out []time.Duration
+	for _, part := range strings.Split(raw, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || d <= 0 {
+			continue
+		}
+		out = append(out, d)
+	}
+
+	if len(out) == 0 {
+		return fallback
+	}
+	return out
+}
+
+// GetEnvList читает список строк через запятую; пустые элементы пропускаются.
+func GetEnvList(key string, fallback []string) []string {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+
+	if len(out) == 0 {
+		return fallback
+	}
+	return out
+}
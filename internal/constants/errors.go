@@ -3,31 +3,43 @@ package constants
 import "errors"
 
 var (
-	Appointments_IS_nil             = errors.New("структура записи о приёме не задана: передайте непустой объект appointment для создания или обновления записи")
-	PatientRecord_IS_nil            = errors.New("patientRecord is nil ")
-	User_IS_nil                     = errors.New("user is nil")
-	Service_IS_nil                  = errors.New("service is nil")
-	ErrTimeNotInSchedule            = errors.New("выбранное время не входит в рабочее расписание врача: проверьте дату и время, или обновите расписание врача перед созданием приёма")
-	ErrTimeConflict                 = errors.New("конфликт времени: выбранный интервал пересекается с существующей записью (у врача или у пациента). Выберите другое время или отмените/перенесите существующий приём")
-	AppointmentCreateRequest_IS_nil = errors.New("appointment create request is nil")
-	DoctorIDIsIncorrect             = errors.New("doctor id is incorrect")
-	PatientIDIsIncorrect            = errors.New("patient id is incorrect")
-	ServiceIDIsIncorrect            = errors.New("service id is incorrect")
-	ErrInvalidAppointmentTime       = errors.New("invalid appointment time")
-	ErrInvalidPrice                 = errors.New("invalid price")
-	ErrInvalidAppointmentID         = errors.New("invalid appointment id")
-	ErrUpdateAppointments           = errors.New("update error")
-	ErrDeleteAppointments           = errors.New("delete error")
-	ErrGetByIDAppointments          = errors.New("appointment not found ")
-	ErrGetAppointments              = errors.New("error getting appointments")
-	Rec_IS_nil                      = errors.New("recommendation is nil")
-	PatientID_IS_incorrect          = errors.New("patientID is incorrect")
-	Diagnosis_IS_empty              = errors.New("diagnosis is empty")
-	DoctorID_IS_incorrect           = errors.New("doctorID is incorrect")
-	Parse_ID_Error                  = errors.New("parse id  error")
-	Invalid_JSON_Error              = errors.New("invalid json error")
-	ErrCreateAppointment            = errors.New("error creating appointment")
-	User_appointments_Not_Found     = errors.New("записи о приёмах для указанного пациента не найдены: проверьте корректность patientID или создайте приёмы для этого пациента")
+	Appointments_IS_nil              = errors.New("структура записи о приёме не задана: передайте непустой объект appointment для создания или обновления записи")
+	PatientRecord_IS_nil             = errors.New("patientRecord is nil ")
+	User_IS_nil                      = errors.New("user is nil")
+	Service_IS_nil                   = errors.New("service is nil")
+	ErrTimeNotInSchedule             = errors.New("выбранное время не входит в рабочее расписание врача: проверьте дату и время, или обновите расписание врача перед созданием приёма")
+	ErrTimeConflict                  = errors.New("конфликт времени: выбранный интервал пересекается с существующей записью (у врача или у пациента). Выберите другое время или отмените/перенесите существующий приём")
+	AppointmentCreateRequest_IS_nil  = errors.New("appointment create request is nil")
+	DoctorIDIsIncorrect              = errors.New("doctor id is incorrect")
+	PatientIDIsIncorrect             = errors.New("patient id is incorrect")
+	ServiceIDIsIncorrect             = errors.New("service id is incorrect")
+	ErrInvalidAppointmentTime        = errors.New("invalid appointment time")
+	ErrInvalidPrice                  = errors.New("invalid price")
+	ErrInvalidAppointmentID          = errors.New("invalid appointment id")
+	ErrUpdateAppointments            = errors.New("update error")
+	ErrDeleteAppointments            = errors.New("delete error")
+	ErrGetByIDAppointments           = errors.New("appointment not found ")
+	ErrGetAppointments               = errors.New("error getting appointments")
+	Rec_IS_nil                       = errors.New("recommendation is nil")
+	PatientID_IS_incorrect           = errors.New("patientID is incorrect")
+	Diagnosis_IS_empty               = errors.New("diagnosis is empty")
+	DoctorID_IS_incorrect            = errors.New("doctorID is incorrect")
+	Parse_ID_Error                   = errors.New("parse id  error")
+	Invalid_JSON_Error               = errors.New("invalid json error")
+	ErrCreateAppointment             = errors.New("error creating appointment")
+	User_appointments_Not_Found      = errors.New("записи о приёмах для указанного пациента не найдены: проверьте корректность patientID или создайте приёмы для этого пациента")
+	ErrAppointmentNotCompletable     = errors.New("завершить можно только запланированный приём")
+	ErrSlotHeld                      = errors.New("это время временно зарезервировано для другого пациента")
+	ErrAppointmentNotNoShow          = errors.New("отметить неявку можно только для запланированного приёма, время которого уже наступило")
+	ErrAppointmentNotPendingApproval = errors.New("приём не ожидает подтверждения")
+	ErrRoomDoubleBooked              = errors.New("кабинет уже занят другим расписанием в это время")
+	ErrRoomInactive                  = errors.New("кабинет выведен из работы")
+	ErrRoomUnsuitable                = errors.New("кабинет в расписании врача не подходит для этой услуги")
+	ErrEquipmentUnavailable          = errors.New("нет свободного оборудования, необходимого для услуги, на это время")
+	ErrServiceNotInClinic            = errors.New("услуга недоступна в филиале, где работает смена врача")
+	ErrServiceNotOffered             = errors.New("врач не оказывает эту услугу")
+	ErrDoctorDoubleBooked            = errors.New("у врача уже есть смена, пересекающаяся по времени")
+	ErrInvalidListQuery              = errors.New("некорректные параметры списка")
 )
 
 // Schedule errors
@@ -47,3 +59,6 @@ var ErrInvalidDoctorID = errors.New("invalid doctor ID")
 
 // Review errors
 var Review_IS_nil = errors.New("review is nil")
+
+// Payment errors
+var Payment_IS_nil = errors.New("payment is nil")
@@ -0,0 +1,148 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+)
+
+// Handler обрабатывает событие. Обработчик может быть вызван повторно,
+// если упал другой обработчик того же события, поэтому успешные вызовы
+// запоминаются и больше не повторяются.
+type Handler func(ctx context.Context, event *models.OutboxEvent) error
+
+type subscription struct {
+	name    string
+	handler Handler
+}
+
+type DispatcherConfig struct {
+	BatchSize   int
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Dispatcher читает outbox и раздаёт события подписчикам внутри процесса.
+type Dispatcher struct {
+	outbox repository.OutboxRepository
+	cfg    DispatcherConfig
+	logger *slog.Logger
+
+	handlers map[string][]subscription
+}
+
+func NewDispatcher(outbox repository.OutboxRepository, cfg DispatcherConfig, logger *slog.Logger) *Dispatcher {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 10 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Hour
+	}
+
+	return &Dispatcher{
+		outbox:   outbox,
+		cfg:      cfg,
+		logger:   logger,
+		handlers: make(map[string][]subscription),
+	}
+}
+
+// Subscribe регистрирует обработчик. Имя должно быть уникальным и стабильным:
+// по нему отслеживается, какие события обработчик уже получил.
+func (d *Dispatcher) Subscribe(eventType, name string, handler Handler) {
+	d.handlers[eventType] = append(d.handlers[eventType], subscription{name: name, handler: handler})
+}
+
+// Dispatch обрабатывает очередную пачку событий; предназначен для jobs.Every.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	due, err := d.outbox.ListDue(ctx, time.Now(), d.cfg.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return nil
+		}
+		d.process(ctx, &due[i])
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) process(ctx context.Context, event *models.OutboxEvent) {
+	var failed error
+
+	for _, sub := range d.handlers[event.Type] {
+		if err := d.deliver(ctx, event, sub); err != nil {
+			d.logger.Warn("обработчик события завершился с ошибкой",
+				"error", err,
+				"event_id", event.EventID,
+				"type", event.Type,
+				"handler", sub.name,
+			)
+			if failed == nil {
+				failed = fmt.Errorf("%s: %w", sub.name, err)
+			}
+		}
+	}
+
+	now := time.Now()
+	event.Attempts++
+
+	switch {
+	case failed == nil:
+		event.Status = models.OutboxProcessed
+		event.LastError = ""
+		event.ProcessedAt = &now
+	case event.Attempts >= d.cfg.MaxAttempts:
+		event.Status = models.OutboxFailed
+		event.LastError = failed.Error()
+		d.logger.Error("событие outbox не обработано после всех попыток", "event_id", event.EventID, "type", event.Type, "error", failed)
+	default:
+		event.LastError = failed.Error()
+		event.NextAttemptAt = now.Add(d.backoff(event.Attempts))
+	}
+
+	if err := d.outbox.Update(ctx, event); err != nil {
+		d.logger.Error("не удалось сохранить состояние события outbox", "error", err, "event_id", event.EventID)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, event *models.OutboxEvent, sub subscription) error {
+	consumed, err := d.outbox.IsConsumed(ctx, event.EventID, sub.name)
+	if err != nil {
+		return err
+	}
+	if consumed {
+		return nil
+	}
+
+	if err := sub.handler(ctx, event); err != nil {
+		return err
+	}
+
+	return d.outbox.MarkConsumed(ctx, event.EventID, sub.name)
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+)
+
+const (
+	AppointmentBooked      = "appointment.booked"
+	AppointmentCancelled   = "appointment.cancelled"
+	AppointmentRescheduled = "appointment.rescheduled"
+	AppointmentCompleted   = "appointment.completed"
+	AppointmentNoShow      = "appointment.no_show"
+	ReviewCreated          = "review.created"
+	RecordUpdated          = "record.updated"
+	ScheduleCreated        = "schedule.created"
+)
+
+const (
+	AggregateAppointment   = "appointment"
+	AggregateReview        = "review"
+	AggregatePatientRecord = "patient_record"
+	AggregateDoctor        = "doctor"
+)
+
+type AppointmentPayload struct {
+	AppointmentID uint      `json:"appointment_id"`
+	PatientID     uint      `json:"patient_id"`
+	DoctorID      uint      `json:"doctor_id"`
+	ServiceID     uint      `json:"service_id"`
+	StartAt       time.Time `json:"start_at"`
+	EndAt         time.Time `json:"end_at"`
+	Status        string    `json:"status"`
+}
+
+// AppointmentRescheduledPayload — запись после переноса и слот, который она
+// освободила.
+type AppointmentRescheduledPayload struct {
+	AppointmentPayload
+	PreviousDoctorID uint      `json:"previous_doctor_id"`
+	PreviousStartAt  time.Time `json:"previous_start_at"`
+	PreviousEndAt    time.Time `json:"previous_end_at"`
+}
+
+type ReviewPayload struct {
+	ReviewID      uint `json:"review_id"`
+	AppointmentID uint `json:"appointment_id"`
+	DoctorID      uint `json:"doctor_id"`
+	UserID        uint `json:"user_id"`
+	Rating        int  `json:"rating"`
+}
+
+type RecordPayload struct {
+	RecordID  uint `json:"record_id"`
+	PatientID uint `json:"patient_id"`
+	DoctorID  uint `json:"doctor_id"`
+}
+
+type ScheduleSlot struct {
+	ScheduleID uint      `json:"schedule_id"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+}
+
+type SchedulePayload struct {
+	DoctorID uint           `json:"doctor_id"`
+	Slots    []ScheduleSlot `json:"slots"`
+}
+
+func NewAppointmentPayload(a *models.Appointment) AppointmentPayload {
+	return AppointmentPayload{
+		AppointmentID: a.ID,
+		PatientID:     a.PatientID,
+		DoctorID:      a.DoctorID,
+		ServiceID:     a.ServiceID,
+		StartAt:       a.StartAt,
+		EndAt:         a.EndAt,
+		Status:        a.Status,
+	}
+}
+
+func NewAppointmentRescheduledPayload(a *models.Appointment, previous *models.Appointment) AppointmentRescheduledPayload {
+	return AppointmentRescheduledPayload{
+		AppointmentPayload: NewAppointmentPayload(a),
+		PreviousDoctorID:   previous.DoctorID,
+		PreviousStartAt:    previous.StartAt,
+		PreviousEndAt:      previous.EndAt,
+	}
+}
+
+// New готовит запись outbox; сохранить её нужно в той же транзакции,
+// что и само изменение.
+func New(eventType, aggregateType string, aggregateID uint, payload any) (*models.OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OutboxEvent{
+		EventID:       newEventID(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       string(data),
+		Status:        models.OutboxPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// Decode разбирает полезную нагрузку события в v.
+func Decode(event *models.OutboxEvent, v any) error {
+	return json.Unmarshal([]byte(event.Payload), v)
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("evt_%d", time.Now().UnixNano())
+	}
+	return "evt_" + hex.EncodeToString(b)
+}
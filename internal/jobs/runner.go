@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type Func func(ctx context.Context) error
+
+// Every запускает fn сразу и затем с заданным интервалом, пока ctx не отменён.
+func Every(ctx context.Context, logger *slog.Logger, name string, interval time.Duration, fn Func) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := fn(ctx); err != nil {
+				logger.Error("ошибка фоновой задачи", "job", name, "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				logger.Info("фоновая задача остановлена", "job", name)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	logger.Info("фоновая задача запущена", "job", name, "interval", interval.String())
+}
@@ -0,0 +1,59 @@
+// Package localtime переводит настенное время клиники в моменты времени и
+// обратно с учётом перехода на летнее время.
+package localtime
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DateLayout  = "2006-01-02"
+	ClockLayout = "15:04"
+)
+
+var (
+	ErrInvalidDate  = errors.New("некорректная дата: ожидается формат ГГГГ-ММ-ДД")
+	ErrInvalidClock = errors.New("некорректное время: ожидается формат ЧЧ:ММ")
+	// ErrNonexistentTime — такого времени в этот день нет: часы переводились
+	// вперёд.
+	ErrNonexistentTime = errors.New("указанного местного времени не существует из-за перевода часов")
+)
+
+// StartOfDay возвращает местную полночь дня, в который попадает t.
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
+
+// AddDays сдвигает момент на n календарных дней, сохраняя настенное время:
+// сутки, в которые переводят часы, длятся 23 или 25 часов.
+func AddDays(t time.Time, loc *time.Location, n int) time.Time {
+	return t.In(loc).AddDate(0, 0, n)
+}
+
+// At собирает момент времени из местной даты ("2006-01-02") и времени
+// ("15:04"). Время, попавшее в пропуск при переводе часов вперёд,
+// отклоняется; при переводе назад берётся первое из двух вхождений.
+func At(date, clock string, loc *time.Location) (time.Time, error) {
+	day, err := time.ParseInLocation(DateLayout, date, loc)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+	hm, err := time.Parse(ClockLayout, clock)
+	if err != nil {
+		return time.Time{}, ErrInvalidClock
+	}
+
+	t := time.Date(day.Year(), day.Month(), day.Day(), hm.Hour(), hm.Minute(), 0, 0, loc)
+	if t.Hour() != hm.Hour() || t.Minute() != hm.Minute() {
+		return time.Time{}, ErrNonexistentTime
+	}
+
+	// Go не гарантирует, какое из двух вхождений выберет time.Date.
+	if earlier := t.Add(-time.Hour); earlier.Hour() == t.Hour() && earlier.Minute() == t.Minute() && earlier.Day() == t.Day() {
+		t = earlier
+	}
+
+	return t, nil
+}
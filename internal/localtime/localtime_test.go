@@ -0,0 +1,147 @@
+package localtime
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func berlin(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("загрузка Europe/Berlin: %v", err)
+	}
+	return loc
+}
+
+func TestAt(t *testing.T) {
+	loc := berlin(t)
+
+	tests := []struct {
+		name  string
+		date  string
+		clock string
+		want  time.Time
+		err   error
+	}{
+		{
+			name:  "обычный день",
+			date:  "2025-06-10",
+			clock: "09:30",
+			want:  time.Date(2025, 6, 10, 7, 30, 0, 0, time.UTC),
+		},
+		{
+			// 30 марта 2025 в 02:00 часы переводятся на 03:00.
+			name:  "пропуск при переводе вперёд",
+			date:  "2025-03-30",
+			clock: "02:30",
+			err:   ErrNonexistentTime,
+		},
+		{
+			name:  "сразу после перевода вперёд",
+			date:  "2025-03-30",
+			clock: "03:00",
+			want:  time.Date(2025, 3, 30, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			// 26 октября 2025 в 03:00 часы переводятся на 02:00, и 02:30
+			// наступает дважды: в 00:30 и в 01:30 UTC.
+			name:  "перекрытие при переводе назад",
+			date:  "2025-10-26",
+			clock: "02:30",
+			want:  time.Date(2025, 10, 26, 0, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "некорректная дата",
+			date:  "2025-13-01",
+			clock: "10:00",
+			err:   ErrInvalidDate,
+		},
+		{
+			name:  "некорректное время",
+			date:  "2025-06-10",
+			clock: "25:00",
+			err:   ErrInvalidClock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := At(tt.date, tt.clock, loc)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("ошибка = %v, ожидалась %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("At = %v, ожидалось %v", got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestAddDays(t *testing.T) {
+	loc := berlin(t)
+
+	tests := []struct {
+		name    string
+		from    time.Time
+		days    int
+		want    time.Time
+		elapsed time.Duration
+	}{
+		{
+			name:    "через перевод вперёд",
+			from:    time.Date(2025, 3, 29, 10, 0, 0, 0, loc),
+			days:    1,
+			want:    time.Date(2025, 3, 30, 10, 0, 0, 0, loc),
+			elapsed: 23 * time.Hour,
+		},
+		{
+			name:    "через перевод назад",
+			from:    time.Date(2025, 10, 25, 10, 0, 0, 0, loc),
+			days:    1,
+			want:    time.Date(2025, 10, 26, 10, 0, 0, 0, loc),
+			elapsed: 25 * time.Hour,
+		},
+		{
+			name:    "неделя назад через перевод",
+			from:    time.Date(2025, 4, 2, 18, 0, 0, 0, loc),
+			days:    -7,
+			want:    time.Date(2025, 3, 26, 18, 0, 0, 0, loc),
+			elapsed: -(7*24 - 1) * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AddDays(tt.from, loc, tt.days)
+			if !got.Equal(tt.want) {
+				t.Fatalf("AddDays = %v, ожидалось %v", got, tt.want)
+			}
+			if d := got.Sub(tt.from); d != tt.elapsed {
+				t.Fatalf("прошло %v, ожидалось %v", d, tt.elapsed)
+			}
+			if h, m, _ := got.In(loc).Clock(); h != tt.from.Hour() || m != tt.from.Minute() {
+				t.Fatalf("настенное время сдвинулось: %02d:%02d", h, m)
+			}
+		})
+	}
+}
+
+func TestStartOfDay(t *testing.T) {
+	loc := berlin(t)
+
+	// В день перевода вперёд полночь по-прежнему по зимнему времени.
+	got := StartOfDay(time.Date(2025, 3, 30, 12, 0, 0, 0, time.UTC), loc)
+	want := time.Date(2025, 3, 29, 23, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Fatalf("StartOfDay = %v, ожидалось %v", got.UTC(), want)
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+const (
+	BoardDay  = "day"
+	BoardWeek = "week"
+)
+
+// AppointmentSearch — условия поиска приёмов для регистратуры сверх общих
+// фильтров списка.
+type AppointmentSearch struct {
+	// Patient ищется в имени, фамилии и телефоне пациента.
+	Patient string
+	// ClinicIDs ограничивает поиск филиалами сотрудника; nil — без ограничений.
+	ClinicIDs []uint
+}
+
+// AppointmentBoardQuery — период доски приёмов: день или неделя
+// (с понедельника), в которые попадает Date; пустая Date — сегодня.
+type AppointmentBoardQuery struct {
+	View string
+	Date string
+}
+
+// AppointmentBoard — приёмы за день или неделю, сгруппированные по врачам
+// и дням по местному времени филиала.
+type AppointmentBoard struct {
+	View     string                   `json:"view"`
+	From     time.Time                `json:"from"`
+	To       time.Time                `json:"to"`
+	Timezone string                   `json:"timezone"`
+	Days     []string                 `json:"days"`
+	Doctors  []AppointmentBoardDoctor `json:"doctors"`
+}
+
+type AppointmentBoardDoctor struct {
+	DoctorID uint                  `json:"doctor_id"`
+	Doctor   *Doctor               `json:"doctor,omitempty"`
+	Total    int                   `json:"total"`
+	Days     []AppointmentBoardDay `json:"days"`
+}
+
+type AppointmentBoardDay struct {
+	Date         string        `json:"date"`
+	Appointments []Appointment `json:"appointments"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+type SeriesFrequency string
+
+const (
+	SeriesDaily   SeriesFrequency = "daily"
+	SeriesWeekly  SeriesFrequency = "weekly"
+	SeriesMonthly SeriesFrequency = "monthly"
+)
+
+type SeriesStatus string
+
+const (
+	SeriesActive    SeriesStatus = "active"
+	SeriesCancelled SeriesStatus = "cancelled"
+)
+
+// SeriesScope определяет, к каким приёмам серии применяется изменение.
+type SeriesScope string
+
+const (
+	ScopeThis      SeriesScope = "this"
+	ScopeFollowing SeriesScope = "following"
+	ScopeAll       SeriesScope = "all"
+)
+
+// AppointmentSeries — повторяющиеся приёмы (коррекция брекетов, поддерживающая
+// пародонтология). Правило повторения упрощённо повторяет RRULE: частота,
+// интервал и ограничение по количеству или дате.
+type AppointmentSeries struct {
+	Base
+	PatientID uint            `json:"patient_id" gorm:"not null;index"`
+	DoctorID  uint            `json:"doctor_id" gorm:"not null;index"`
+	ServiceID uint            `json:"service_id" gorm:"not null"`
+	StartAt   time.Time       `json:"start_at" gorm:"not null"`
+	Frequency SeriesFrequency `json:"frequency" gorm:"type:varchar(20);not null"`
+	Interval  int             `json:"interval" gorm:"not null"`
+	Count     int             `json:"count,omitempty"`
+	Until     *time.Time      `json:"until,omitempty"`
+	Price     float64         `json:"price_cents,omitempty"`
+	Status    SeriesStatus    `json:"status" gorm:"type:varchar(20);not null;default:'active'"`
+
+	Appointments []Appointment `json:"appointments,omitempty" gorm:"foreignKey:SeriesID"`
+}
+
+type AppointmentSeriesCreateRequest struct {
+	// PatientID учитывается только для администратора и врача.
+	PatientID uint            `json:"patient_id,omitempty"`
+	DoctorID  uint            `json:"doctor_id" validate:"required"`
+	ServiceID uint            `json:"service_id" validate:"required"`
+	StartAt   time.Time       `json:"start_at" validate:"required"`
+	Frequency SeriesFrequency `json:"frequency" validate:"required,oneof=daily weekly monthly"`
+	Interval  int             `json:"interval,omitempty" validate:"omitempty,min=1"`
+	Count     int             `json:"count,omitempty" validate:"omitempty,min=1"`
+	Until     *time.Time      `json:"until,omitempty"`
+
+	// SkipConflicts создаёт серию без занятых дат; иначе при любом
+	// конфликте ничего не создаётся и возвращается список конфликтов.
+	SkipConflicts bool `json:"skip_conflicts,omitempty"`
+}
+
+type AppointmentSeriesUpdateRequest struct {
+	DoctorID  *uint `json:"doctor_id,omitempty"`
+	ServiceID *uint `json:"service_id,omitempty"`
+	// StartAt — новое время выбранного приёма; остальные приёмы в области
+	// изменения сдвигаются на ту же величину.
+	StartAt *time.Time `json:"start_at,omitempty"`
+	Price   *float64   `json:"price_cents,omitempty" validate:"omitempty,min=0.0"`
+}
+
+type SeriesOccurrenceConflict struct {
+	Index   int       `json:"index"`
+	StartAt time.Time `json:"start_at"`
+	Error   string    `json:"error"`
+}
+
+type AppointmentSeriesResult struct {
+	Series       *AppointmentSeries         `json:"series,omitempty"`
+	Appointments []Appointment              `json:"appointments"`
+	Conflicts    []SeriesOccurrenceConflict `json:"conflicts"`
+}
@@ -2,20 +2,59 @@ package models
 
 import "time"
 
+const (
+	AppointmentScheduled      = "scheduled"
+	AppointmentPendingPayment = "pending_payment"
+	AppointmentCancelled      = "cancelled"
+	AppointmentCompleted      = "completed"
+	// AppointmentNoShow — пациент не пришёл на приём.
+	AppointmentNoShow = "no_show"
+	// AppointmentPendingApproval — запись ждёт подтверждения персоналом
+	// из-за правил бронирования (например, после нескольких неявок).
+	AppointmentPendingApproval = "pending_approval"
+)
+
 type Appointment struct {
 	Base
-	PatientID   uint       `json:"patient_id" gorm:"not null"`
-	Patient     *User      `json:"patient,omitempty" gorm:"foreignKey:PatientID"`
-	DoctorID    uint       `json:"doctor_id" gorm:"not null"`
-	Doctor      *Doctor    `json:"doctor,omitempty" gorm:"foreignKey:DoctorID"`
-	ServiceID   uint       `json:"service_id,omitempty"`
-	Service     *Service   `json:"service,omitempty" gorm:"foreignKey:ServiceID"`
-	StartAt     time.Time  `json:"start_at" gorm:"not null"`
-	EndAt       time.Time  `json:"end_at" gorm:"not null"`
-	Status      string     `json:"status" gorm:"type:varchar(50);default:'scheduled'"`
-	Price       float64    `json:"price_cents,omitempty"`
-	Paid        bool       `json:"paid" gorm:"default:false"`
-	IsAvailable bool       `json:"is_available"`
+	PatientID   uint      `json:"patient_id" gorm:"not null"`
+	Patient     *User     `json:"patient,omitempty" gorm:"foreignKey:PatientID"`
+	DoctorID    uint      `json:"doctor_id" gorm:"not null"`
+	Doctor      *Doctor   `json:"doctor,omitempty" gorm:"foreignKey:DoctorID"`
+	ServiceID   uint      `json:"service_id,omitempty"`
+	Service     *Service  `json:"service,omitempty" gorm:"foreignKey:ServiceID"`
+	StartAt     time.Time `json:"start_at" gorm:"not null"`
+	EndAt       time.Time `json:"end_at" gorm:"not null"`
+	Status      string    `json:"status" gorm:"type:varchar(50);default:'scheduled'"`
+	Price       float64   `json:"price_cents,omitempty"`
+	Paid        bool      `json:"paid" gorm:"default:false"`
+	IsAvailable bool      `json:"is_available"`
+
+	// CheckedInAt — отметка о приходе пациента в клинику, CalledAt — когда
+	// его пригласили в кабинет.
+	CheckedInAt *time.Time `json:"checked_in_at,omitempty"`
+	CalledAt    *time.Time `json:"called_at,omitempty"`
+
+	// RoomID и Equipment — ресурсы, выделенные под приём.
+	RoomID    *uint       `json:"room_id,omitempty" gorm:"index"`
+	Equipment []Equipment `json:"equipment,omitempty" gorm:"many2many:appointment_equipment"`
+
+	// ClinicID — филиал, определяется сменой врача.
+	ClinicID *uint `json:"clinic_id,omitempty" gorm:"index"`
+
+	// SeriesID и SeriesIndex (с единицы) связывают приём с повторяющейся серией.
+	SeriesID    *uint `json:"series_id,omitempty" gorm:"index"`
+	SeriesIndex int   `json:"series_index,omitempty"`
+
+	// BundleID — комплекс, оказываемый за этот приём; PackageID — купленный
+	// набор визитов, в счёт которого записан приём.
+	BundleID  *uint `json:"bundle_id,omitempty" gorm:"index"`
+	PackageID *uint `json:"package_id,omitempty" gorm:"index"`
+
+	// DeferredPayment — оплата, запрошенная при записи, которая ждёт
+	// подтверждения записи персоналом.
+	DeferredPayment PaymentMode `json:"deferred_payment,omitempty" gorm:"type:varchar(20)"`
+
+	Payments []Payment `json:"payments,omitempty" gorm:"foreignKey:AppointmentID"`
 }
 
 type AppointmentCreateRequest struct {
@@ -23,8 +62,24 @@ type AppointmentCreateRequest struct {
 	DoctorID    uint      `json:"doctor_id" validate:"required"`
 	ServiceID   uint      `json:"service_id,omitempty" validate:"omitempty"`
 	StartAt     time.Time `json:"start_at" validate:"required"`
-	Price       float64   `json:"price_cents,omitempty" validate:"omitempty,min=0.0"`
 	IsAvailable bool      `json:"is_available"`
+
+	// BundleID записывает комплекс за один приём вместо ServiceID.
+	// PackageID списывает визит по ServiceID из купленного набора.
+	BundleID  *uint `json:"bundle_id,omitempty"`
+	PackageID *uint `json:"package_id,omitempty"`
+
+	// Payment запрашивает онлайн-оплату при записи: "deposit" или "full".
+	Payment PaymentMode `json:"payment,omitempty" validate:"omitempty,oneof=deposit full"`
+
+	// HoldToken — токен временного удержания слота, полученный при выборе времени.
+	HoldToken string `json:"hold_token,omitempty"`
+}
+
+// AppointmentApproveRequest: Payment заменяет оплату, запрошенную
+// пациентом при записи; без него используется запрошенная.
+type AppointmentApproveRequest struct {
+	Payment PaymentMode `json:"payment,omitempty" validate:"omitempty,oneof=deposit full"`
 }
 
 type AppointmentUpdateRequest struct {
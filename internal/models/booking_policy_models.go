@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// BookingPolicyOverride освобождает пациента от правил бронирования,
+// например после разговора с администратором.
+type BookingPolicyOverride struct {
+	Base
+	PatientID   uint       `json:"patient_id" gorm:"not null;uniqueIndex"`
+	Exempt      bool       `json:"exempt"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Note        string     `json:"note,omitempty" gorm:"type:text"`
+	GrantedByID uint       `json:"granted_by_id"`
+}
+
+type BookingPolicyOverrideRequest struct {
+	Exempt    bool       `json:"exempt"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Note      string     `json:"note,omitempty" validate:"max=1000"`
+}
+
+// PatientNoShowStats — счётчики неявок пациента и применяемые к нему правила.
+type PatientNoShowStats struct {
+	PatientID        uint                   `json:"patient_id"`
+	NoShowsTotal     int64                  `json:"no_shows_total"`
+	NoShowsInWindow  int64                  `json:"no_shows_in_window"`
+	WindowDays       int                    `json:"window_days"`
+	LastNoShowAt     *time.Time             `json:"last_no_show_at,omitempty"`
+	FutureBookings   int64                  `json:"future_bookings"`
+	DepositRequired  bool                   `json:"deposit_required"`
+	ApprovalRequired bool                   `json:"approval_required"`
+	BookingLimitHit  bool                   `json:"booking_limit_reached"`
+	Override         *BookingPolicyOverride `json:"override,omitempty"`
+}
+
+type NoShowReportRow struct {
+	PatientID    uint      `json:"patient_id"`
+	FirstName    string    `json:"first_name"`
+	LastName     string    `json:"last_name"`
+	NoShows      int64     `json:"no_shows"`
+	LastNoShowAt time.Time `json:"last_no_show_at"`
+}
+
+type NoShowReport struct {
+	From          time.Time         `json:"from"`
+	To            time.Time         `json:"to"`
+	TotalNoShows  int64             `json:"total_no_shows"`
+	TotalFinished int64             `json:"total_finished"`
+	NoShowRate    float64           `json:"no_show_rate"`
+	Patients      []NoShowReportRow `json:"patients"`
+}
+
+type NoShowReportParams struct {
+	From     time.Time
+	To       time.Time
+	DoctorID uint
+	// ClinicIDs ограничивает отчёт филиалами сотрудника; nil — вся сеть.
+	ClinicIDs []uint
+}
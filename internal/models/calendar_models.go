@@ -0,0 +1,13 @@
+package models
+
+// CalendarToken — секретный токен, по которому отдаётся iCal-лента
+// пользователя без авторизации (календарные клиенты не умеют JWT).
+type CalendarToken struct {
+	Base
+	UserID uint   `json:"user_id" gorm:"not null;uniqueIndex"`
+	Token  string `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+}
+
+type CalendarFeedResponse struct {
+	URL string `json:"url"`
+}
@@ -0,0 +1,155 @@
+package models
+
+import "time"
+
+// ServiceCategory — узел дерева категорий услуг (Терапия → Эндодонтия).
+// Position задаёт порядок среди соседей в публичном каталоге.
+type ServiceCategory struct {
+	Base
+	ParentID *uint  `json:"parent_id,omitempty" gorm:"index"`
+	Slug     string `json:"slug" gorm:"type:varchar(64);not null;uniqueIndex:idx_service_categories_slug,where:deleted_at IS NULL"`
+	Name     string `json:"name" gorm:"type:varchar(100);not null"`
+	Icon     string `json:"icon,omitempty" gorm:"type:varchar(255)"`
+	Position int    `json:"position" gorm:"not null;default:0"`
+}
+
+type ServiceCategoryCreateRequest struct {
+	ParentID *uint  `json:"parent_id,omitempty"`
+	Slug     string `json:"slug" validate:"required,max=64"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Icon     string `json:"icon,omitempty" validate:"max=255"`
+	Position int    `json:"position"`
+}
+
+// ServiceCategoryUpdateRequest: ParentID = 0 переносит категорию в корень.
+type ServiceCategoryUpdateRequest struct {
+	ParentID *uint   `json:"parent_id,omitempty"`
+	Slug     *string `json:"slug,omitempty" validate:"omitempty,max=64"`
+	Name     *string `json:"name,omitempty" validate:"omitempty,max=100"`
+	Icon     *string `json:"icon,omitempty" validate:"omitempty,max=255"`
+	Position *int    `json:"position,omitempty"`
+}
+
+// CatalogNode — категория публичного каталога со своими услугами,
+// комплексами и подкатегориями.
+type CatalogNode struct {
+	ServiceCategory
+	Services []Service       `json:"services"`
+	Bundles  []ServiceBundle `json:"bundles"`
+	Children []CatalogNode   `json:"children"`
+}
+
+// Catalog — публичный каталог. Other — услуги без категории из
+// справочника.
+type Catalog struct {
+	Categories []CatalogNode `json:"categories"`
+	Other      []Service     `json:"other"`
+}
+
+// BundleKind — как продаётся комплекс услуг.
+type BundleKind string
+
+const (
+	// BundleSingleVisit — все услуги комплекса за один приём.
+	BundleSingleVisit BundleKind = "single_visit"
+	// BundlePrepaidVisits — предоплаченный набор визитов, которые пациент
+	// записывает по одному.
+	BundlePrepaidVisits BundleKind = "prepaid_visits"
+)
+
+// ServiceBundle — комплекс услуг (например, «Гигиена» = осмотр + чистка +
+// фторирование) с общей ценой.
+type ServiceBundle struct {
+	Base
+	CategoryID  *uint      `json:"category_id,omitempty" gorm:"index"`
+	Name        string     `json:"name" gorm:"type:varchar(150);not null"`
+	Description string     `json:"description,omitempty" gorm:"type:text"`
+	Icon        string     `json:"icon,omitempty" gorm:"type:varchar(255)"`
+	Position    int        `json:"position" gorm:"not null;default:0"`
+	Kind        BundleKind `json:"kind" gorm:"type:varchar(20);not null"`
+	Price       float64    `json:"price"`
+	// ValidDays — срок действия купленного набора визитов; 0 — бессрочно.
+	ValidDays int  `json:"valid_days,omitempty"`
+	IsActive  bool `json:"is_active" gorm:"default:true"`
+
+	Items []ServiceBundleItem `json:"items" gorm:"foreignKey:BundleID;constraint:OnDelete:CASCADE"`
+}
+
+type ServiceBundleItem struct {
+	ID        uint     `json:"id" gorm:"primaryKey"`
+	BundleID  uint     `json:"bundle_id" gorm:"not null;index"`
+	ServiceID uint     `json:"service_id" gorm:"not null"`
+	Service   *Service `json:"service,omitempty" gorm:"foreignKey:ServiceID"`
+	Quantity  int      `json:"quantity" gorm:"not null;default:1"`
+	Position  int      `json:"position" gorm:"not null;default:0"`
+}
+
+type ServiceBundleItemRequest struct {
+	ServiceID uint `json:"service_id"`
+	Quantity  int  `json:"quantity"`
+}
+
+type ServiceBundleCreateRequest struct {
+	CategoryID  *uint                      `json:"category_id,omitempty"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description,omitempty"`
+	Icon        string                     `json:"icon,omitempty"`
+	Position    int                        `json:"position"`
+	Kind        BundleKind                 `json:"kind"`
+	Price       float64                    `json:"price"`
+	ValidDays   int                        `json:"valid_days,omitempty"`
+	Items       []ServiceBundleItemRequest `json:"items"`
+}
+
+// ServiceBundleUpdateRequest: Items заменяет состав целиком, CategoryID = 0
+// убирает комплекс из категории.
+type ServiceBundleUpdateRequest struct {
+	CategoryID  *uint                       `json:"category_id,omitempty"`
+	Name        *string                     `json:"name,omitempty"`
+	Description *string                     `json:"description,omitempty"`
+	Icon        *string                     `json:"icon,omitempty"`
+	Position    *int                        `json:"position,omitempty"`
+	Price       *float64                    `json:"price,omitempty"`
+	ValidDays   *int                        `json:"valid_days,omitempty"`
+	IsActive    *bool                       `json:"is_active,omitempty"`
+	Items       *[]ServiceBundleItemRequest `json:"items,omitempty"`
+}
+
+const (
+	PackageActive    = "active"
+	PackageCancelled = "cancelled"
+)
+
+// PatientPackage — купленный пациентом набор визитов. Состав копируется
+// из комплекса при продаже, поэтому изменения комплекса на него не влияют.
+// При отмене RefundedAmount — доля цены за незаписанные визиты, которую
+// клиника возвращает пациенту.
+type PatientPackage struct {
+	Base
+	PatientID uint           `json:"patient_id" gorm:"not null;index"`
+	BundleID  uint           `json:"bundle_id" gorm:"not null;index"`
+	Bundle    *ServiceBundle `json:"bundle,omitempty" gorm:"foreignKey:BundleID"`
+	Price     float64        `json:"price"`
+	Status    string         `json:"status" gorm:"type:varchar(20);not null;default:'active'"`
+	ExpiresAt *time.Time     `json:"expires_at,omitempty"`
+
+	CancelledAt    *time.Time `json:"cancelled_at,omitempty"`
+	RefundedAmount float64    `json:"refunded_amount,omitempty"`
+
+	Items []PatientPackageItem `json:"items" gorm:"foreignKey:PackageID;constraint:OnDelete:CASCADE"`
+}
+
+// PatientPackageItem — остаток визитов по услуге. Used считается по
+// неотменённым приёмам с этим набором.
+type PatientPackageItem struct {
+	ID        uint     `json:"id" gorm:"primaryKey"`
+	PackageID uint     `json:"package_id" gorm:"not null;index"`
+	ServiceID uint     `json:"service_id" gorm:"not null"`
+	Service   *Service `json:"service,omitempty" gorm:"foreignKey:ServiceID"`
+	Quantity  int      `json:"quantity"`
+	Used      int      `json:"used" gorm:"-"`
+}
+
+type PackagePurchaseRequest struct {
+	BundleID uint `json:"bundle_id"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Clinic — филиал сети клиник. Врачи могут работать в нескольких филиалах,
+// смены, кабинеты и передвижное оборудование принадлежат одному филиалу.
+type Clinic struct {
+	Base
+	Name     string `json:"name" gorm:"not null"`
+	Address  string `json:"address" gorm:"type:text"`
+	Timezone string `json:"timezone" gorm:"type:varchar(64);not null"`
+	IsActive bool   `json:"is_active" gorm:"index"`
+
+	// WorkingHours — часы работы по дням недели; день, которого нет
+	// в списке, выходной. Пустой список — часы работы не ограничены.
+	WorkingHours []ClinicWorkingHours `json:"working_hours,omitempty" gorm:"serializer:json"`
+
+	Doctors  []Doctor  `json:"doctors,omitempty" gorm:"many2many:clinic_doctors"`
+	Services []Service `json:"-" gorm:"many2many:clinic_services"`
+}
+
+// ClinicWorkingHours — часы работы филиала в один день недели,
+// время в формате "15:04" по часовому поясу филиала.
+type ClinicWorkingHours struct {
+	Weekday time.Weekday `json:"weekday"`
+	Opens   string       `json:"opens"`
+	Closes  string       `json:"closes"`
+}
+
+// ClinicStaff закрепляет сотрудника за филиалом. Когда филиалы заведены,
+// администратор без закреплений не видит ни одного, если у него нет
+// доступа ко всей сети (User.AllClinics).
+type ClinicStaff struct {
+	Base
+	ClinicID uint `json:"clinic_id" gorm:"not null;uniqueIndex:idx_clinic_staff_user"`
+	UserID   uint `json:"user_id" gorm:"not null;uniqueIndex:idx_clinic_staff_user;index"`
+}
+
+type ClinicCreateRequest struct {
+	Name         string               `json:"name" validate:"required"`
+	Address      string               `json:"address"`
+	Timezone     string               `json:"timezone,omitempty"`
+	WorkingHours []ClinicWorkingHours `json:"working_hours,omitempty"`
+}
+
+type ClinicUpdateRequest struct {
+	Name         *string               `json:"name,omitempty"`
+	Address      *string               `json:"address,omitempty"`
+	Timezone     *string               `json:"timezone,omitempty"`
+	WorkingHours *[]ClinicWorkingHours `json:"working_hours,omitempty"`
+	IsActive     *bool                 `json:"is_active,omitempty"`
+}
+
+// ClinicMembersRequest задаёт полный список врачей, услуг или сотрудников
+// филиала.
+type ClinicMembersRequest struct {
+	IDs []uint `json:"ids"`
+}
+
+// ClinicNetworkAccessRequest открывает или закрывает администратору доступ
+// ко всем филиалам сети.
+type ClinicNetworkAccessRequest struct {
+	AllClinics bool `json:"all_clinics"`
+}
+
+type ClinicQueryParams struct {
+	DoctorID   uint
+	OnlyActive bool
+}
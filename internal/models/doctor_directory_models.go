@@ -0,0 +1,39 @@
+package models
+
+// DoctorDirectoryParams — фильтры публичного каталога врачей сверх общих
+// параметров списка.
+type DoctorDirectoryParams struct {
+	// Specialty — slug специализации.
+	Specialty string
+	Language  string
+	ClinicID  uint
+}
+
+// DoctorDirectoryEntry — карточка врача в публичном каталоге: профиль,
+// имя из учётной записи, ближайшее свободное время и рейтинг.
+type DoctorDirectoryEntry struct {
+	ID              uint       `json:"id"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	PhotoURL        string     `json:"photo_url,omitempty"`
+	Specialty       *Specialty `json:"specialty,omitempty"`
+	Specialization  string     `json:"specialization"`
+	ExperienceYears int        `json:"experience_years"`
+	Bio             string     `json:"bio,omitempty"`
+
+	Education      []DoctorEducation     `json:"education"`
+	Certifications []DoctorCertification `json:"certifications"`
+	Languages      []string              `json:"languages"`
+
+	Rating DoctorRating `json:"rating"`
+	// NextAvailable — ближайший свободный слот по местному времени филиала.
+	NextAvailable *Schedule `json:"next_available,omitempty"`
+}
+
+// DoctorRating — краткая сводка рейтинга для карточки; в профиле
+// дополняется распределением оценок и динамикой.
+type DoctorRating struct {
+	Average float64        `json:"average"`
+	Count   int            `json:"count"`
+	Summary *RatingSummary `json:"summary,omitempty"`
+}
@@ -2,32 +2,66 @@ package models
 
 type Doctor struct {
 	Base
-	UserID          uint    `json:"user_id" gorm:"not null;uniqueIndex"`
-	Specialization  string  `json:"specialization" gorm:"type:text"`
-	ExperienceYears int     `json:"experience_years" gorm:"not null;default:0"`
-	Bio             string  `json:"bio,omitempty" gorm:"type:text"`
-	AvgRating       float64 `json:"avg_rating"`
-	RoomNumber      int     `json:"room_number"`
-
-	Services  []Service  `json:"services,omitempty"`
+	UserID uint  `json:"user_id" gorm:"not null;uniqueIndex"`
+	User   *User `json:"-" gorm:"foreignKey:UserID"`
+
+	// SpecialtyID — специализация из справочника; Specialization хранит её
+	// название для поиска и старых клиентов.
+	SpecialtyID     *uint      `json:"specialty_id,omitempty" gorm:"index"`
+	Specialty       *Specialty `json:"specialty,omitempty" gorm:"foreignKey:SpecialtyID"`
+	Specialization  string     `json:"specialization" gorm:"type:text"`
+	ExperienceYears int        `json:"experience_years" gorm:"not null;default:0"`
+	Bio             string     `json:"bio,omitempty" gorm:"type:text"`
+	AvgRating       float64    `json:"avg_rating"`
+	RoomNumber      int        `json:"room_number"`
+
+	// ReviewCount — число опубликованных отзывов, по которым считается AvgRating.
+	ReviewCount int `json:"review_count" gorm:"not null;default:0"`
+
+	PhotoURL       string                `json:"photo_url,omitempty"`
+	Education      []DoctorEducation     `json:"education,omitempty" gorm:"serializer:json"`
+	Certifications []DoctorCertification `json:"certifications,omitempty" gorm:"serializer:json"`
+	// Languages — коды языков ISO 639-1, на которых врач ведёт приём.
+	Languages []string `json:"languages,omitempty" gorm:"serializer:json"`
+
 	Schedules []Schedule `json:"-"`
 	Reviews   []Review   `json:"-"`
 }
 
+type DoctorEducation struct {
+	Institution string `json:"institution"`
+	Degree      string `json:"degree,omitempty"`
+	Year        int    `json:"year,omitempty"`
+}
+
+type DoctorCertification struct {
+	Title  string `json:"title"`
+	Issuer string `json:"issuer,omitempty"`
+	Year   int    `json:"year,omitempty"`
+}
+
 type DoctorCreateRequest struct {
 	UserID          uint   `json:"user_id" validate:"required"`
-	Specialization  string `json:"specialization" validate:"required,max=100"`
+	SpecialtyID     uint   `json:"specialty_id" validate:"required"`
 	ExperienceYears int    `json:"experience_years" validate:"gte=0"`
 	Bio             string `json:"bio,omitempty" validate:"max=2000"`
 	RoomNumber      int    `json:"room_number"`
+
+	Education      []DoctorEducation     `json:"education,omitempty"`
+	Certifications []DoctorCertification `json:"certifications,omitempty"`
+	Languages      []string              `json:"languages,omitempty"`
 }
 
 type DoctorUpdateRequest struct {
 	UserID          *uint   `json:"user_id,omitempty" validate:"omitempty"`
-	Specialization  *string `json:"specialization,omitempty" validate:"omitempty,max=100"`
+	SpecialtyID     *uint   `json:"specialty_id,omitempty" validate:"omitempty"`
 	ExperienceYears *int    `json:"experience_years,omitempty" validate:"omitempty,gte=0"`
 	Bio             *string `json:"bio,omitempty" validate:"omitempty,max=2000"`
 	RoomNumber      *int    `json:"room_number"`
+
+	Education      *[]DoctorEducation     `json:"education,omitempty"`
+	Certifications *[]DoctorCertification `json:"certifications,omitempty"`
+	Languages      *[]string              `json:"languages,omitempty"`
 }
 
 type DoctorQueryParams struct {
@@ -35,5 +69,8 @@ type DoctorQueryParams struct {
 	ExperienceYears int
 	AvgRating       float64
 
+	// ClinicID оставляет только врачей, работающих в филиале.
+	ClinicID uint
+
 	FilOr bool
 }
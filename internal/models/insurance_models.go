@@ -0,0 +1,127 @@
+package models
+
+import "time"
+
+type ClaimStatus string
+
+const (
+	ClaimSubmitted ClaimStatus = "submitted"
+	ClaimApproved  ClaimStatus = "approved"
+	ClaimRejected  ClaimStatus = "rejected"
+	ClaimPaid      ClaimStatus = "paid"
+)
+
+type Insurer struct {
+	Base
+	Name     string `json:"name" gorm:"not null"`
+	Code     string `json:"code" gorm:"type:varchar(50);not null;uniqueIndex"`
+	Email    string `json:"email,omitempty"`
+	Phone    string `json:"phone,omitempty"`
+	IsActive bool   `json:"is_active" gorm:"default:true"`
+
+	Rules []CoverageRule `json:"rules,omitempty" gorm:"foreignKey:InsurerID"`
+}
+
+// CoverageRule описывает покрытие страховщиком одной категории услуг.
+type CoverageRule struct {
+	Base
+	InsurerID       uint    `json:"insurer_id" gorm:"not null;uniqueIndex:idx_coverage_insurer_category"`
+	Category        string  `json:"category" gorm:"not null;uniqueIndex:idx_coverage_insurer_category"`
+	CoveragePercent float64 `json:"coverage_percent" gorm:"not null"`
+	AnnualLimit     float64 `json:"annual_limit"`
+	CoPay           float64 `json:"co_pay"`
+}
+
+type InsurancePolicy struct {
+	Base
+	PatientID    uint      `json:"patient_id" gorm:"not null;index"`
+	InsurerID    uint      `json:"insurer_id" gorm:"not null;index"`
+	Insurer      *Insurer  `json:"insurer,omitempty" gorm:"foreignKey:InsurerID"`
+	PolicyNumber string    `json:"policy_number" gorm:"not null"`
+	ValidFrom    time.Time `json:"valid_from" gorm:"not null"`
+	ValidTo      time.Time `json:"valid_to" gorm:"not null"`
+	IsActive     bool      `json:"is_active" gorm:"default:true"`
+}
+
+type InsuranceClaim struct {
+	Base
+	AppointmentID   uint        `json:"appointment_id" gorm:"not null;uniqueIndex"`
+	PolicyID        uint        `json:"policy_id" gorm:"not null;index"`
+	InsurerID       uint        `json:"insurer_id" gorm:"not null;index"`
+	PatientID       uint        `json:"patient_id" gorm:"not null;index"`
+	Category        string      `json:"category"`
+	ServiceDate     time.Time   `json:"service_date" gorm:"index"`
+	TotalAmount     float64     `json:"total_amount"`
+	InsurerAmount   float64     `json:"insurer_amount"`
+	PatientAmount   float64     `json:"patient_amount"`
+	Status          ClaimStatus `json:"status" gorm:"type:varchar(20);not null;default:'submitted';index"`
+	RejectionReason string      `json:"rejection_reason,omitempty"`
+	BatchID         string      `json:"batch_id,omitempty" gorm:"index"`
+	SubmittedAt     time.Time   `json:"submitted_at"`
+	DecidedAt       *time.Time  `json:"decided_at,omitempty"`
+	PaidAt          *time.Time  `json:"paid_at,omitempty"`
+}
+
+// CoverageQuote — разбивка стоимости приёма на долю страховщика и пациента.
+type CoverageQuote struct {
+	AppointmentID  uint    `json:"appointment_id"`
+	PolicyID       uint    `json:"policy_id,omitempty"`
+	InsurerID      uint    `json:"insurer_id,omitempty"`
+	Category       string  `json:"category"`
+	TotalAmount    float64 `json:"total_amount"`
+	InsurerAmount  float64 `json:"insurer_amount"`
+	PatientAmount  float64 `json:"patient_amount"`
+	LimitRemaining float64 `json:"limit_remaining,omitempty"`
+	Covered        bool    `json:"covered"`
+}
+
+type InsurerCreateRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Code  string `json:"code" validate:"required"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+type InsurerUpdateRequest struct {
+	Name     *string `json:"name,omitempty"`
+	Email    *string `json:"email,omitempty"`
+	Phone    *string `json:"phone,omitempty"`
+	IsActive *bool   `json:"is_active,omitempty"`
+}
+
+type CoverageRuleRequest struct {
+	Category        string  `json:"category" validate:"required"`
+	CoveragePercent float64 `json:"coverage_percent" validate:"gte=0,lte=100"`
+	AnnualLimit     float64 `json:"annual_limit" validate:"gte=0"`
+	CoPay           float64 `json:"co_pay" validate:"gte=0"`
+}
+
+type PolicyCreateRequest struct {
+	PatientID    uint      `json:"patient_id" validate:"required"`
+	InsurerID    uint      `json:"insurer_id" validate:"required"`
+	PolicyNumber string    `json:"policy_number" validate:"required"`
+	ValidFrom    time.Time `json:"valid_from" validate:"required"`
+	ValidTo      time.Time `json:"valid_to" validate:"required,gtfield=ValidFrom"`
+}
+
+type PolicyUpdateRequest struct {
+	PolicyNumber *string    `json:"policy_number,omitempty"`
+	ValidFrom    *time.Time `json:"valid_from,omitempty"`
+	ValidTo      *time.Time `json:"valid_to,omitempty"`
+	IsActive     *bool      `json:"is_active,omitempty"`
+}
+
+type ClaimCreateRequest struct {
+	AppointmentID uint `json:"appointment_id" validate:"required"`
+}
+
+type ClaimStatusRequest struct {
+	Status ClaimStatus `json:"status" validate:"required,oneof=approved rejected paid"`
+	Reason string      `json:"reason,omitempty"`
+}
+
+type ClaimQueryParams struct {
+	InsurerID uint
+	PatientID uint
+	Status    ClaimStatus
+}
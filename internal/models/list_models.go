@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ListQuery — общий запрос списка: курсорная пагинация, сортировка,
+// фильтры по полям и диапазон дат. Допустимые поля сортировки и фильтры
+// задаёт репозиторий конкретного списка.
+type ListQuery struct {
+	// Limit — размер страницы; 0 — размер по умолчанию.
+	Limit int
+	// Cursor — значение next_cursor предыдущей страницы.
+	Cursor string
+	Sort   []SortField
+	// Filters — точные совпадения по полям: ?status=scheduled&doctor_id=3.
+	Filters map[string]string
+	// From и To ограничивают основную дату списка: From включительно,
+	// To — не включительно.
+	From *time.Time
+	To   *time.Time
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Page — общий ответ списка. Total считается с учётом фильтров, но без
+// курсора; NextCursor пуст на последней странице.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+type NotificationChannel string
+
+const (
+	ChannelEmail NotificationChannel = "email"
+	ChannelSMS   NotificationChannel = "sms"
+)
+
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "pending"
+	NotificationSent    NotificationStatus = "sent"
+	NotificationFailed  NotificationStatus = "failed"
+)
+
+const NotificationKindReminder = "reminder"
+
+type Notification struct {
+	Base
+	UserID        uint                `json:"user_id" gorm:"not null;index"`
+	AppointmentID uint                `json:"appointment_id" gorm:"not null;uniqueIndex:idx_notification_dedup"`
+	Kind          string              `json:"kind" gorm:"type:varchar(50);not null;uniqueIndex:idx_notification_dedup"`
+	OffsetMinutes int                 `json:"offset_minutes" gorm:"not null;uniqueIndex:idx_notification_dedup"`
+	Channel       NotificationChannel `json:"channel" gorm:"type:varchar(20);not null;uniqueIndex:idx_notification_dedup"`
+	Language      string              `json:"language" gorm:"type:varchar(5)"`
+	Recipient     string              `json:"recipient"`
+	Subject       string              `json:"subject,omitempty"`
+	Body          string              `json:"body" gorm:"type:text"`
+	Status        NotificationStatus  `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	Attempts      int                 `json:"attempts"`
+	LastError     string              `json:"last_error,omitempty"`
+	SentAt        *time.Time          `json:"sent_at,omitempty"`
+}
+
+type NotificationPreference struct {
+	Base
+	UserID          uint   `json:"user_id" gorm:"not null;uniqueIndex"`
+	Language        string `json:"language" gorm:"type:varchar(5);default:'ru'"`
+	EmailEnabled    bool   `json:"email_enabled"`
+	SMSEnabled      bool   `json:"sms_enabled"`
+	RemindersOptOut bool   `json:"reminders_opt_out"`
+}
+
+type NotificationPreferenceUpdateRequest struct {
+	Language        *string `json:"language,omitempty" validate:"omitempty,oneof=ru en"`
+	EmailEnabled    *bool   `json:"email_enabled,omitempty"`
+	SMSEnabled      *bool   `json:"sms_enabled,omitempty"`
+	RemindersOptOut *bool   `json:"reminders_opt_out,omitempty"`
+}
+
+type NotificationQueryParams struct {
+	UserID        uint
+	AppointmentID uint
+	Status        NotificationStatus
+}
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// DoctorInvitation — приглашение врача задать пароль. В базе хранится
+// только хеш токена, сам токен уходит врачу в письме.
+type DoctorInvitation struct {
+	Base
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	TokenHash string    `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Language — язык письма, с ним же уходят повторные приглашения.
+	Language   string     `json:"language" gorm:"type:varchar(5)"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+}
+
+// DoctorOnboardingUser — учётная запись нового врача. Пароль врач задаёт
+// сам по приглашению.
+type DoctorOnboardingUser struct {
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	Gender      Gender `json:"gender,omitempty"`
+	DateOfBirth string `json:"date_of_birth,omitempty"`
+}
+
+// DoctorOnboardingRequest заводит врача одной операцией. Если user_id
+// задан, существующий пользователь становится врачом; иначе по User
+// создаётся новая учётная запись и ей отправляется приглашение.
+type DoctorOnboardingRequest struct {
+	DoctorCreateRequest
+
+	User *DoctorOnboardingUser `json:"user,omitempty"`
+
+	// ServiceIDs — услуги каталога, которые врач будет оказывать по
+	// каталожным ценам.
+	ServiceIDs []uint `json:"service_ids,omitempty"`
+
+	Schedule *DoctorScheduleTemplate `json:"schedule,omitempty"`
+
+	// Language — язык письма-приглашения, по умолчанию ru.
+	Language string `json:"language,omitempty"`
+}
+
+// DoctorScheduleTemplate — недельный шаблон смен, который разворачивается
+// на Weeks недель начиная с From по местному времени филиала.
+type DoctorScheduleTemplate struct {
+	ClinicID *uint               `json:"clinic_id,omitempty"`
+	From     string              `json:"from"`
+	Weeks    int                 `json:"weeks"`
+	Days     []DoctorScheduleDay `json:"days"`
+}
+
+// DoctorScheduleDay — смена шаблона. Weekday как в часах работы филиала:
+// 0 — воскресенье, 6 — суббота. Без RoomNumber берётся кабинет врача.
+type DoctorScheduleDay struct {
+	Weekday    time.Weekday `json:"weekday"`
+	Start      string       `json:"start"`
+	End        string       `json:"end"`
+	RoomNumber int          `json:"room_number,omitempty"`
+}
+
+type DoctorOnboardingResponse struct {
+	User      *User      `json:"user"`
+	Doctor    *Doctor    `json:"doctor"`
+	Schedules []Schedule `json:"schedules"`
+
+	// Invitation пуст, если пользователь уже был и пароль у него есть.
+	Invitation *DoctorInvitation `json:"invitation,omitempty"`
+	// InvitationSent — удалось ли отправить письмо; при сбое приглашение
+	// можно выслать повторно.
+	InvitationSent bool `json:"invitation_sent"`
+}
+
+type InvitationAcceptRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxProcessed OutboxStatus = "processed"
+	OutboxFailed    OutboxStatus = "failed"
+)
+
+// OutboxEvent — доменное событие, записанное в одной транзакции с изменением.
+type OutboxEvent struct {
+	Base
+	EventID       string       `json:"event_id" gorm:"type:varchar(64);not null;uniqueIndex"`
+	Type          string       `json:"type" gorm:"type:varchar(100);not null;index"`
+	AggregateType string       `json:"aggregate_type" gorm:"type:varchar(50);not null"`
+	AggregateID   uint         `json:"aggregate_id" gorm:"not null;index"`
+	Payload       string       `json:"payload" gorm:"type:text;not null"`
+	Status        OutboxStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	Attempts      int          `json:"attempts"`
+	LastError     string       `json:"last_error,omitempty"`
+	NextAttemptAt time.Time    `json:"next_attempt_at" gorm:"index"`
+	ProcessedAt   *time.Time   `json:"processed_at,omitempty"`
+}
+
+// OutboxConsumption фиксирует, что обработчик уже успешно обработал событие,
+// чтобы повторная доставка не выполняла его дважды.
+type OutboxConsumption struct {
+	ID        uint   `gorm:"primaryKey"`
+	EventID   string `gorm:"type:varchar(64);not null;uniqueIndex:idx_outbox_consumption"`
+	Handler   string `gorm:"type:varchar(100);not null;uniqueIndex:idx_outbox_consumption"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+type PaymentStatus string
+
+const (
+	PaymentPending   PaymentStatus = "pending"
+	PaymentSucceeded PaymentStatus = "succeeded"
+	PaymentFailed    PaymentStatus = "failed"
+	PaymentRefunded  PaymentStatus = "refunded"
+	PaymentExpired   PaymentStatus = "expired"
+)
+
+type PaymentMode string
+
+const (
+	PaymentModeDeposit PaymentMode = "deposit"
+	PaymentModeFull    PaymentMode = "full"
+)
+
+type Payment struct {
+	Base
+	AppointmentID  uint          `json:"appointment_id" gorm:"not null;index"`
+	PatientID      uint          `json:"patient_id" gorm:"not null;index"`
+	Mode           PaymentMode   `json:"mode" gorm:"type:varchar(20);not null"`
+	Amount         float64       `json:"amount" gorm:"not null"`
+	RefundedAmount float64       `json:"refunded_amount"`
+	Currency       string        `json:"currency" gorm:"type:varchar(3);not null"`
+	Provider       string        `json:"provider" gorm:"type:varchar(50);not null"`
+	IntentID       string        `json:"intent_id" gorm:"type:varchar(100);uniqueIndex"`
+	ClientSecret   string        `json:"client_secret,omitempty" gorm:"-"`
+	Status         PaymentStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	ExpiresAt      time.Time     `json:"expires_at" gorm:"index"`
+	PaidAt         *time.Time    `json:"paid_at,omitempty"`
+	LastEventID    string        `json:"-" gorm:"type:varchar(100)"`
+}
+
+type PaymentCreateRequest struct {
+	AppointmentID uint        `json:"appointment_id" validate:"required"`
+	Mode          PaymentMode `json:"mode" validate:"required,oneof=deposit full"`
+}
+
+type PaymentRefundRequest struct {
+	Amount float64 `json:"amount" validate:"omitempty,gt=0"`
+}
+
+type PaymentSimulateRequest struct {
+	Event string `json:"event" validate:"required,oneof=payment.succeeded payment.failed"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+type QueueItemStatus string
+
+const (
+	QueueWaiting QueueItemStatus = "waiting"
+	QueueCalled  QueueItemStatus = "called"
+)
+
+// QueueItem — строка электронной очереди. Для экрана в зале ожидания
+// содержит только имя и первую букву фамилии пациента.
+type QueueItem struct {
+	AppointmentID        uint            `json:"appointment_id"`
+	DoctorID             uint            `json:"doctor_id"`
+	ClinicID             *uint           `json:"clinic_id,omitempty"`
+	RoomNumber           int             `json:"room_number"`
+	PatientName          string          `json:"patient_name"`
+	StartAt              time.Time       `json:"start_at"`
+	CheckedInAt          time.Time       `json:"checked_in_at"`
+	CalledAt             *time.Time      `json:"called_at,omitempty"`
+	Status               QueueItemStatus `json:"status"`
+	EstimatedCallAt      *time.Time      `json:"estimated_call_at,omitempty"`
+	EstimatedWaitMinutes int             `json:"estimated_wait_minutes"`
+}
+
+type QueueQueryParams struct {
+	DoctorID   uint
+	RoomNumber int
+	ClinicID   uint
+}
+
+// QueueEvent отправляется подписчикам потока очереди.
+type QueueEvent struct {
+	Type string    `json:"type"`
+	Item QueueItem `json:"item"`
+}
@@ -0,0 +1,66 @@
+package models
+
+// Room — кабинет клиники. Kind описывает назначение кабинета
+// (например, "general", "surgery"), по нему подбираются услуги.
+type Room struct {
+	Base
+	Number   int    `json:"number" gorm:"not null;uniqueIndex:idx_rooms_clinic_number;uniqueIndex:idx_rooms_number_no_clinic,where:clinic_id IS NULL"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind" gorm:"type:varchar(50);not null;default:'general';index"`
+	IsActive bool   `json:"is_active"`
+
+	// ClinicID — филиал кабинета; номера кабинетов уникальны в пределах
+	// филиала, а у кабинетов вне филиалов — среди них.
+	ClinicID *uint `json:"clinic_id,omitempty" gorm:"uniqueIndex:idx_rooms_clinic_number"`
+
+	Equipment []Equipment `json:"equipment,omitempty" gorm:"foreignKey:RoomID"`
+}
+
+// Equipment — единица оборудования. Стационарное оборудование закреплено за
+// кабинетом (RoomID) и занято вместе с ним; передвижное (RoomID = nil)
+// выделяется на конкретный приём и не может быть в двух местах сразу.
+type Equipment struct {
+	Base
+	Name     string `json:"name"`
+	Kind     string `json:"kind" gorm:"type:varchar(50);not null;index"`
+	RoomID   *uint  `json:"room_id,omitempty" gorm:"index"`
+	IsActive bool   `json:"is_active"`
+
+	// ClinicID — филиал, в котором находится оборудование; для стационарного
+	// совпадает с филиалом кабинета.
+	ClinicID *uint `json:"clinic_id,omitempty" gorm:"index"`
+}
+
+type RoomCreateRequest struct {
+	Number int    `json:"number" validate:"required,min=1"`
+	Name   string `json:"name"`
+	Kind   string `json:"kind,omitempty"`
+
+	ClinicID *uint `json:"clinic_id,omitempty"`
+}
+
+type RoomUpdateRequest struct {
+	Name     *string `json:"name,omitempty"`
+	Kind     *string `json:"kind,omitempty"`
+	IsActive *bool   `json:"is_active,omitempty"`
+}
+
+type EquipmentCreateRequest struct {
+	Name   string `json:"name" validate:"required"`
+	Kind   string `json:"kind" validate:"required"`
+	RoomID *uint  `json:"room_id,omitempty"`
+
+	// ClinicID указывается для передвижного оборудования.
+	ClinicID *uint `json:"clinic_id,omitempty"`
+}
+
+type EquipmentUpdateRequest struct {
+	Name *string `json:"name,omitempty"`
+	Kind *string `json:"kind,omitempty"`
+	// RoomID = 0 делает оборудование передвижным.
+	RoomID   *uint `json:"room_id,omitempty"`
+	IsActive *bool `json:"is_active,omitempty"`
+
+	// ClinicID меняет филиал передвижного оборудования.
+	ClinicID *uint `json:"clinic_id,omitempty"`
+}
@@ -1,26 +1,94 @@
 package models
 
+import "time"
+
+// Статусы модерации отзыва.
+const (
+	ReviewPending   = "pending"
+	ReviewPublished = "published"
+	ReviewRejected  = "rejected"
+)
+
+// Причины, по которым автоматическая проверка отправляет отзыв модератору.
+const (
+	ReviewScreenProfanity    = "profanity"
+	ReviewScreenLink         = "link"
+	ReviewScreenPersonalData = "personal_data"
+)
+
+// Review — отзыв пациента о завершённом приёме. Пациент и врач берутся из
+// приёма; на один приём допускается один отзыв.
 type Review struct {
 	Base
-	AppointmentID uint   `json:"appointment_id" gorm:"not null;index"`
+	AppointmentID uint   `json:"appointment_id" gorm:"not null;uniqueIndex:idx_reviews_appointment,where:deleted_at IS NULL"`
 	UserID        uint   `json:"user_id" gorm:"not null;index"`
 	DoctorID      uint   `json:"doctor_id" gorm:"not null;index"`
 	Rating        int    `json:"rating" gorm:"not null;check:rating >= 0 AND rating <= 5"`
 	Comment       string `json:"comment,omitempty" gorm:"type:text"`
+
+	// Status — состояние модерации. Отзывы, оставленные до появления
+	// модерации, считаются опубликованными.
+	Status          string     `json:"status" gorm:"type:varchar(20);not null;default:'published';index"`
+	RejectionReason string     `json:"rejection_reason,omitempty"`
+	ScreeningFlags  []string   `json:"screening_flags,omitempty" gorm:"serializer:json"`
+	ModeratedBy     *uint      `json:"moderated_by,omitempty"`
+	ModeratedAt     *time.Time `json:"moderated_at,omitempty"`
+
+	// FlagCount — жалобы пациентов с последней модерации.
+	FlagCount int `json:"flag_count" gorm:"not null;default:0"`
+
+	// Reply — единственный публичный ответ врача на отзыв.
+	Reply     string     `json:"reply,omitempty" gorm:"type:text"`
+	RepliedAt *time.Time `json:"replied_at,omitempty"`
+}
+
+// ReviewFlag — жалоба пациента на отзыв; один пациент жалуется на отзыв
+// один раз.
+type ReviewFlag struct {
+	Base
+	ReviewID uint   `json:"review_id" gorm:"not null;uniqueIndex:idx_review_flags_review_user"`
+	UserID   uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_review_flags_review_user"`
+	Reason   string `json:"reason" gorm:"type:text"`
+}
+
+// RatingSummary — сводка оценок врача по опубликованным отзывам.
+type RatingSummary struct {
+	DoctorID uint    `json:"doctor_id"`
+	Count    int64   `json:"count"`
+	Average  float64 `json:"average"`
+
+	// Distribution — число отзывов по оценкам от 1 до 5.
+	Distribution map[int]int64 `json:"distribution"`
+
+	// Trend — оценки по месяцам за последние месяцы, старые первыми.
+	Trend []RatingTrendPoint `json:"trend"`
+}
+
+type RatingTrendPoint struct {
+	Month   string  `json:"month"`
+	Count   int64   `json:"count"`
+	Average float64 `json:"average"`
 }
 
 type ReviewCreateRequest struct {
 	AppointmentID uint   `json:"appointment_id" validate:"required"`
-	UserID        uint   `json:"user_id" validate:"required"`
-	DoctorID      uint   `json:"doctor_id" validate:"required"`
-	Rating        int    `json:"rating" validate:"required,gte=0,lte=5"`
+	Rating        int    `json:"rating" validate:"required,gte=1,lte=5"`
 	Comment       string `json:"comment,omitempty" validate:"max=2000"`
 }
 
 type ReviewUpdateRequest struct {
-	AppointmentID *uint   `json:"appointment_id,omitempty" validate:"omitempty"`
-	UserID        *uint   `json:"user_id,omitempty" validate:"omitempty"`
-	DoctorID      *uint   `json:"doctor_id,omitempty" validate:"omitempty"`
-	Rating        *int    `json:"rating,omitempty" validate:"omitempty,gte=0,lte=5"`
-	Comment       *string `json:"comment,omitempty" validate:"omitempty,max=2000"`
+	Rating  *int    `json:"rating,omitempty" validate:"omitempty,gte=1,lte=5"`
+	Comment *string `json:"comment,omitempty" validate:"omitempty,max=2000"`
+}
+
+type ReviewRejectRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+type ReviewReplyRequest struct {
+	Text string `json:"text" validate:"required,max=2000"`
+}
+
+type ReviewFlagRequest struct {
+	Reason string `json:"reason,omitempty" validate:"max=500"`
 }
@@ -4,6 +4,9 @@ import (
 	"time"
 )
 
+// Schedule — смена врача. Время хранится в UTC; Date — местная полночь дня
+// смены по часовому поясу филиала. В ответах время переводится в часовой
+// пояс филиала, который указывается в Timezone.
 type Schedule struct {
 	Base
 	DoctorID    uint      `json:"doctor_id" gorm:"not null;index"`
@@ -12,6 +15,14 @@ type Schedule struct {
 	EndTime     time.Time `json:"end_time" gorm:"not null"`
 	RoomNumber  int       `json:"room_number" gorm:"not null"`
 	IsAvailable bool      `json:"is_available" gorm:"default:true"`
+
+	// RoomID заполняется, если кабинет с таким номером заведён в справочнике.
+	RoomID *uint `json:"room_id,omitempty" gorm:"index"`
+
+	// ClinicID — филиал, в котором проходит смена.
+	ClinicID *uint `json:"clinic_id,omitempty" gorm:"index"`
+
+	Timezone string `json:"timezone,omitempty" gorm:"-"`
 }
 
 type ScheduleCreateRequest struct {
@@ -21,6 +32,14 @@ type ScheduleCreateRequest struct {
 	EndTime     time.Time `json:"end_time" validate:"required,gtfield=StartTime"`
 	RoomNumber  int       `json:"room_number" validate:"required"`
 	IsAvailable bool      `json:"is_available" validate:"omitempty"`
+
+	ClinicID *uint `json:"clinic_id,omitempty"`
+
+	// LocalDate ("2006-01-02"), LocalStart и LocalEnd ("15:04") задают смену
+	// по настенным часам филиала вместо StartTime и EndTime.
+	LocalDate  string `json:"local_date,omitempty"`
+	LocalStart string `json:"local_start,omitempty"`
+	LocalEnd   string `json:"local_end,omitempty"`
 }
 
 type ScheduleUpdateRequest struct {
@@ -30,4 +49,39 @@ type ScheduleUpdateRequest struct {
 	EndTime     *time.Time `json:"end_time,omitempty" validate:"omitempty,gtfield=StartTime"`
 	RoomNumber  *int       `json:"room_number,omitempty" validate:"omitempty"`
 	IsAvailable *bool      `json:"is_available,omitempty" validate:"omitempty"`
+
+	ClinicID *uint `json:"clinic_id,omitempty"`
+
+	LocalDate  *string `json:"local_date,omitempty"`
+	LocalStart *string `json:"local_start,omitempty"`
+	LocalEnd   *string `json:"local_end,omitempty"`
+
+	// Reschedule разрешает менять смену с записями: приёмы переносятся
+	// вместе со сменой на тот же сдвиг времени.
+	Reschedule bool `json:"reschedule,omitempty"`
+}
+
+const (
+	ScheduleConflictInvalid       = "invalid"
+	ScheduleConflictDateMismatch  = "date_mismatch"
+	ScheduleConflictDoctorOverlap = "doctor_overlap"
+	ScheduleConflictRoomOverlap   = "room_overlap"
+)
+
+// ScheduleConflict — проблема смены с индексом Index в пакетном запросе.
+// Пересечение указывает либо на смену того же запроса (WithIndex), либо
+// на уже сохранённую (ScheduleID).
+type ScheduleConflict struct {
+	Index      int    `json:"index"`
+	Reason     string `json:"reason"`
+	Error      string `json:"error"`
+	WithIndex  *int   `json:"with_index,omitempty"`
+	ScheduleID *uint  `json:"schedule_id,omitempty"`
+}
+
+// ScheduleValidationResult — ответ пробного создания смен (dry run).
+type ScheduleValidationResult struct {
+	Valid     bool               `json:"valid"`
+	Schedules []Schedule         `json:"schedules"`
+	Conflicts []ScheduleConflict `json:"conflicts"`
 }
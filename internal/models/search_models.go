@@ -0,0 +1,32 @@
+package models
+
+const (
+	SearchDoctor  = "doctor"
+	SearchService = "service"
+)
+
+// SearchQueryParams — запрос к общему поиску. Type ограничивает поиск
+// врачами или услугами, пустой — ищет везде.
+type SearchQueryParams struct {
+	Query string
+	Type  string
+	Limit int
+}
+
+// SearchHit — найденный врач или услуга. Score складывается из рангов
+// полнотекстового поиска (русская и английская морфология) и триграммной
+// похожести, которая прощает опечатки.
+type SearchHit struct {
+	Type     string   `json:"type"`
+	ID       uint     `json:"id"`
+	Title    string   `json:"title"`
+	Subtitle string   `json:"subtitle,omitempty"`
+	Score    float64  `json:"score"`
+	Doctor   *Doctor  `json:"doctor,omitempty"`
+	Service  *Service `json:"service,omitempty"`
+}
+
+type SearchResponse struct {
+	Query string      `json:"query"`
+	Hits  []SearchHit `json:"hits"`
+}
@@ -1,29 +1,84 @@
 package models
 
+import "time"
+
+// Service — услуга каталога клиники. Врачи оказывают её через
+// DoctorOffering, каждый со своей ценой и длительностью при необходимости.
 type Service struct {
 	Base
-	Name        string  `json:"name"`
-	DoctorID    uint    `json:"doctor_id" gorm:"not null;index"`
-	Description string  `json:"description"`
-	Category    string  `json:"category"`
-	Duration    int     `json:"duration"`
-	Price       float64 `json:"price"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// CategoryID — категория из дерева; Category хранит её название для
+	// фильтров и правил страхового покрытия.
+	CategoryID *uint   `json:"category_id,omitempty" gorm:"index"`
+	Category   string  `json:"category"`
+	Duration   int     `json:"duration"`
+	Price      float64 `json:"price"`
+
+	// RequiredRoomKind и RequiredEquipment — требования услуги к ресурсам:
+	// тип кабинета и виды оборудования (например, хирургический кабинет и
+	// панорамный рентген).
+	RequiredRoomKind  string   `json:"required_room_kind,omitempty" gorm:"type:varchar(50)"`
+	RequiredEquipment []string `json:"required_equipment,omitempty" gorm:"serializer:json"`
 }
 
 type ServiceCreateRequest struct {
-	DoctorID    uint    `json:"doctor_id"`
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
+	CategoryID  *uint   `json:"category_id,omitempty"`
 	Category    string  `json:"category"`
 	Duration    int     `json:"duration"`
 	Price       float64 `json:"price"`
+
+	RequiredRoomKind  string   `json:"required_room_kind,omitempty"`
+	RequiredEquipment []string `json:"required_equipment,omitempty"`
 }
 
+// ServiceUpdateRequest: CategoryID = 0 убирает услугу из категории, а
+// Category без CategoryID переносит её в корневую категорию с этим
+// названием.
 type ServiceUpdateRequest struct {
-	DoctorID    *uint    `json:"doctor_id,omitempty"`
 	Name        *string  `json:"name"`
 	Description *string  `json:"description"`
+	CategoryID  *uint    `json:"category_id,omitempty"`
 	Category    *string  `json:"category"`
 	Duration    *int     `json:"duration"`
 	Price       *float64 `json:"price"`
+
+	RequiredRoomKind  *string   `json:"required_room_kind,omitempty"`
+	RequiredEquipment *[]string `json:"required_equipment,omitempty"`
+}
+
+// DoctorOffering связывает врача с услугой каталога. Price и Duration
+// переопределяют цену и длительность каталога для этого врача; nil — как
+// в каталоге.
+type DoctorOffering struct {
+	DoctorID  uint      `json:"doctor_id" gorm:"primaryKey"`
+	ServiceID uint      `json:"service_id" gorm:"primaryKey;index"`
+	Price     *float64  `json:"price,omitempty"`
+	Duration  *int      `json:"duration,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Doctor  *Doctor  `json:"doctor,omitempty" gorm:"foreignKey:DoctorID"`
+	Service *Service `json:"service,omitempty" gorm:"foreignKey:ServiceID"`
+}
+
+func (DoctorOffering) TableName() string { return "doctor_services" }
+
+// Apply подставляет в услугу цену и длительность врача.
+func (o *DoctorOffering) Apply(service *Service) {
+	if o.Price != nil {
+		service.Price = *o.Price
+	}
+	if o.Duration != nil {
+		service.Duration = *o.Duration
+	}
+}
+
+// DoctorOfferingRequest задаёт цену и длительность услуги у врача; пустое
+// поле — как в каталоге.
+type DoctorOfferingRequest struct {
+	Price    *float64 `json:"price"`
+	Duration *int     `json:"duration"`
 }
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+type SlotHoldStatus string
+
+const (
+	HoldActive   SlotHoldStatus = "active"
+	HoldConsumed SlotHoldStatus = "consumed"
+	HoldReleased SlotHoldStatus = "released"
+	HoldExpired  SlotHoldStatus = "expired"
+)
+
+// SlotHold временно резервирует время врача, пока пациент оформляет запись.
+type SlotHold struct {
+	Base
+	DoctorID      uint           `json:"doctor_id" gorm:"not null;index"`
+	PatientID     uint           `json:"patient_id" gorm:"not null;index"`
+	ServiceID     uint           `json:"service_id" gorm:"not null"`
+	StartAt       time.Time      `json:"start_at" gorm:"not null"`
+	EndAt         time.Time      `json:"end_at" gorm:"not null"`
+	Token         string         `json:"token" gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt     time.Time      `json:"expires_at" gorm:"not null;index"`
+	Status        SlotHoldStatus `json:"status" gorm:"type:varchar(20);not null;default:'active';index"`
+	AppointmentID *uint          `json:"appointment_id,omitempty"`
+}
+
+type SlotHoldCreateRequest struct {
+	// PatientID учитывается только для администратора.
+	PatientID uint      `json:"patient_id,omitempty"`
+	DoctorID  uint      `json:"doctor_id" validate:"required"`
+	ServiceID uint      `json:"service_id" validate:"required"`
+	StartAt   time.Time `json:"start_at" validate:"required"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// Specialty — специализация из справочника клиники (терапевт, ортодонт,
+// хирург и т.д.). Slug используется в фильтрах публичного каталога.
+type Specialty struct {
+	Base
+	Slug        string `json:"slug" gorm:"type:varchar(64);not null;uniqueIndex:idx_specialties_slug,where:deleted_at IS NULL"`
+	Name        string `json:"name" gorm:"type:varchar(100);not null"`
+	Description string `json:"description,omitempty" gorm:"type:text"`
+}
+
+type SpecialtyCreateRequest struct {
+	Slug        string `json:"slug" validate:"required,max=64"`
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description,omitempty" validate:"max=2000"`
+}
+
+type SpecialtyUpdateRequest struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,max=100"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=2000"`
+}
@@ -21,7 +21,7 @@ type User struct {
 	Base
 	FirstName     string    `json:"first_name"`
 	LastName      string    `json:"last_name"`
-	Email         string    `json:"email"`
+	Email         string    `json:"email" gorm:"uniqueIndex:idx_users_email,where:deleted_at IS NULL"`
 	Phone         string    `json:"phone"`
 	Password      string    `json:"-"`
 	Role          Role      `json:"role"`
@@ -29,6 +29,9 @@ type User struct {
 	EmailVerified bool      `json:"email_verified"`
 	DateOfBirth   time.Time `json:"date_of_birth"`
 	IsActive      bool      `json:"is_active"`
+	// AllClinics — администратор сети: работает со всеми филиалами и с
+	// записями вне филиалов.
+	AllClinics bool `json:"all_clinics" gorm:"not null;default:false"`
 }
 
 type UserCreateRequest struct {
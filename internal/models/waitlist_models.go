@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+type WaitlistStatus string
+
+const (
+	WaitlistWaiting   WaitlistStatus = "waiting"
+	WaitlistOffered   WaitlistStatus = "offered"
+	WaitlistBooked    WaitlistStatus = "booked"
+	WaitlistCancelled WaitlistStatus = "cancelled"
+	WaitlistExpired   WaitlistStatus = "expired"
+)
+
+type WaitlistOfferStatus string
+
+const (
+	OfferPending  WaitlistOfferStatus = "pending"
+	OfferAccepted WaitlistOfferStatus = "accepted"
+	OfferDeclined WaitlistOfferStatus = "declined"
+	OfferExpired  WaitlistOfferStatus = "expired"
+)
+
+type WaitlistEntry struct {
+	Base
+	PatientID uint      `json:"patient_id" gorm:"not null;index"`
+	DoctorID  uint      `json:"doctor_id" gorm:"not null;index"`
+	ServiceID uint      `json:"service_id" gorm:"not null"`
+	DateFrom  time.Time `json:"date_from" gorm:"type:date;not null"`
+	DateTo    time.Time `json:"date_to" gorm:"type:date;not null"`
+	// PreferredFrom/PreferredTo — желаемое время начала приёма, "HH:MM"
+	// в часовом поясе клиники; пустые значения означают "любое время".
+	PreferredFrom string         `json:"preferred_from,omitempty" gorm:"type:varchar(5)"`
+	PreferredTo   string         `json:"preferred_to,omitempty" gorm:"type:varchar(5)"`
+	Status        WaitlistStatus `json:"status" gorm:"type:varchar(20);not null;default:'waiting';index"`
+}
+
+type WaitlistOffer struct {
+	Base
+	EntryID   uint      `json:"entry_id" gorm:"not null;index"`
+	PatientID uint      `json:"patient_id" gorm:"not null;index"`
+	DoctorID  uint      `json:"doctor_id" gorm:"not null;index"`
+	ServiceID uint      `json:"service_id" gorm:"not null"`
+	StartAt   time.Time `json:"start_at" gorm:"not null"`
+	EndAt     time.Time `json:"end_at" gorm:"not null"`
+	// SlotEndAt — конец освободившегося окна; нужен, чтобы при отказе
+	// предложить то же окно следующему в очереди.
+	SlotEndAt     time.Time           `json:"-" gorm:"not null"`
+	Token         string              `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt     time.Time           `json:"expires_at" gorm:"not null;index"`
+	Status        WaitlistOfferStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	AppointmentID *uint               `json:"appointment_id,omitempty"`
+}
+
+type WaitlistJoinRequest struct {
+	// PatientID учитывается только для администратора.
+	PatientID     uint      `json:"patient_id,omitempty"`
+	DoctorID      uint      `json:"doctor_id" validate:"required"`
+	ServiceID     uint      `json:"service_id" validate:"required"`
+	DateFrom      time.Time `json:"date_from" validate:"required"`
+	DateTo        time.Time `json:"date_to" validate:"required"`
+	PreferredFrom string    `json:"preferred_from,omitempty"`
+	PreferredTo   string    `json:"preferred_to,omitempty"`
+}
+
+type WaitlistQueryParams struct {
+	DoctorID  uint
+	PatientID uint
+	Status    WaitlistStatus
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+	// WebhookDeliveryDead — попытки исчерпаны, доставка в dead-letter списке.
+	WebhookDeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+type WebhookSubscription struct {
+	Base
+	Name       string   `json:"name"`
+	URL        string   `json:"url" gorm:"not null"`
+	EventTypes []string `json:"event_types" gorm:"serializer:json;type:text;not null"`
+	Secret     string   `json:"-" gorm:"not null"`
+	IsActive   bool     `json:"is_active"`
+}
+
+type WebhookDelivery struct {
+	Base
+	SubscriptionID uint                  `json:"subscription_id" gorm:"not null;uniqueIndex:idx_webhook_delivery_event"`
+	EventID        string                `json:"event_id" gorm:"type:varchar(64);not null;uniqueIndex:idx_webhook_delivery_event"`
+	EventType      string                `json:"event_type" gorm:"type:varchar(100);not null"`
+	Payload        string                `json:"payload" gorm:"type:text;not null"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	Attempts       int                   `json:"attempts"`
+	ResponseCode   int                   `json:"response_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" gorm:"index"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+}
+
+type WebhookSubscriptionCreateRequest struct {
+	Name       string   `json:"name"`
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types" validate:"required,min=1"`
+	// Secret можно не передавать — тогда он будет сгенерирован.
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookSubscriptionUpdateRequest struct {
+	Name       *string   `json:"name,omitempty"`
+	URL        *string   `json:"url,omitempty" validate:"omitempty,url"`
+	EventTypes *[]string `json:"event_types,omitempty"`
+	Secret     *string   `json:"secret,omitempty"`
+	IsActive   *bool     `json:"is_active,omitempty"`
+}
+
+// WebhookSubscriptionCreated возвращается один раз при создании подписки:
+// секрет больше нигде не отдаётся.
+type WebhookSubscriptionCreated struct {
+	Subscription *WebhookSubscription `json:"subscription"`
+	Secret       string               `json:"secret"`
+}
+
+type WebhookDeliveryQueryParams struct {
+	SubscriptionID uint
+	Status         WebhookDeliveryStatus
+	EventType      string
+}
@@ -0,0 +1,101 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+)
+
+var ErrNoSender = errors.New("для канала не настроен отправитель")
+
+type Message struct {
+	Channel   models.NotificationChannel
+	Recipient string
+	Subject   string
+	Body      string
+}
+
+// Sender доставляет сообщение по одному каналу (email, SMS и т.д.).
+type Sender interface {
+	Channel() models.NotificationChannel
+
+	Send(ctx context.Context, msg Message) error
+}
+
+// Dispatcher выбирает отправителя по каналу сообщения.
+type Dispatcher struct {
+	senders map[models.NotificationChannel]Sender
+}
+
+func NewDispatcher(senders ...Sender) *Dispatcher {
+	d := &Dispatcher{senders: make(map[models.NotificationChannel]Sender, len(senders))}
+	for _, s := range senders {
+		d.senders[s.Channel()] = s
+	}
+	return d
+}
+
+func (d *Dispatcher) Channels() []models.NotificationChannel {
+	channels := make([]models.NotificationChannel, 0, len(d.senders))
+	for _, c := range []models.NotificationChannel{models.ChannelEmail, models.ChannelSMS} {
+		if _, ok := d.senders[c]; ok {
+			channels = append(channels, c)
+		}
+	}
+	return channels
+}
+
+func (d *Dispatcher) Send(ctx context.Context, msg Message) error {
+	sender, ok := d.senders[msg.Channel]
+	if !ok {
+		return ErrNoSender
+	}
+	return sender.Send(ctx, msg)
+}
+
+// LogSender — локальная замена почтового/SMS-шлюза: пишет сообщение в лог и
+// хранит последние отправки в памяти.
+type LogSender struct {
+	channel models.NotificationChannel
+	logger  *slog.Logger
+
+	mu   sync.Mutex
+	sent []Message
+}
+
+func NewLogSender(channel models.NotificationChannel, logger *slog.Logger) *LogSender {
+	return &LogSender{channel: channel, logger: logger}
+}
+
+func (s *LogSender) Channel() models.NotificationChannel {
+	return s.channel
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	s.sent = append(s.sent, msg)
+	if len(s.sent) > 100 {
+		s.sent = s.sent[len(s.sent)-100:]
+	}
+	s.mu.Unlock()
+
+	s.logger.Info("уведомление отправлено (заглушка)",
+		"channel", msg.Channel,
+		"recipient", msg.Recipient,
+		"subject", msg.Subject,
+		"body", msg.Body,
+	)
+	return nil
+}
+
+func (s *LogSender) Sent() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Message, len(s.sent))
+	copy(out, s.sent)
+	return out
+}
@@ -0,0 +1,161 @@
+package notifications
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+)
+
+const DefaultLanguage = "ru"
+
+var ErrNoTemplate = errors.New("шаблон уведомления не найден")
+
+type ReminderData struct {
+	PatientName string
+	ServiceName string
+	StartAt     string
+	TimeLeft    string
+}
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func mustTemplate(subject, body string) messageTemplate {
+	return messageTemplate{
+		subject: template.Must(template.New("subject").Parse(subject)),
+		body:    template.Must(template.New("body").Parse(body)),
+	}
+}
+
+// reminderTemplates: язык -> канал -> шаблон.
+var reminderTemplates = map[string]map[models.NotificationChannel]messageTemplate{
+	"ru": {
+		models.ChannelEmail: mustTemplate(
+			"Напоминание о приёме",
+			"Здравствуйте, {{.PatientName}}! Напоминаем, что вы записаны{{if .ServiceName}} на «{{.ServiceName}}»{{end}} {{.StartAt}} (через {{.TimeLeft}}). Если планы изменились, пожалуйста, отмените запись заранее.",
+		),
+		models.ChannelSMS: mustTemplate(
+			"",
+			"{{.PatientName}}, напоминаем о приёме {{.StartAt}}{{if .ServiceName}} ({{.ServiceName}}){{end}}.",
+		),
+	},
+	"en": {
+		models.ChannelEmail: mustTemplate(
+			"Appointment reminder",
+			"Hello {{.PatientName}}! This is a reminder of your appointment{{if .ServiceName}} for \"{{.ServiceName}}\"{{end}} on {{.StartAt}} (in {{.TimeLeft}}). If your plans have changed, please cancel in advance.",
+		),
+		models.ChannelSMS: mustTemplate(
+			"",
+			"{{.PatientName}}, reminder: your appointment is on {{.StartAt}}{{if .ServiceName}} ({{.ServiceName}}){{end}}.",
+		),
+	},
+}
+
+type WaitlistOfferData struct {
+	PatientName string
+	StartAt     string
+	ExpiresAt   string
+	AcceptURL   string
+}
+
+var waitlistOfferTemplates = map[string]map[models.NotificationChannel]messageTemplate{
+	"ru": {
+		models.ChannelEmail: mustTemplate(
+			"Освободилось время для приёма",
+			"Здравствуйте, {{.PatientName}}! Освободилось время {{.StartAt}}. Мы придержим его для вас до {{.ExpiresAt}}. Подтвердить запись: {{.AcceptURL}}",
+		),
+		models.ChannelSMS: mustTemplate(
+			"",
+			"{{.PatientName}}, освободилось время {{.StartAt}}. Подтвердите до {{.ExpiresAt}}: {{.AcceptURL}}",
+		),
+	},
+	"en": {
+		models.ChannelEmail: mustTemplate(
+			"An appointment slot has opened up",
+			"Hello {{.PatientName}}! A slot on {{.StartAt}} is now available. We will hold it for you until {{.ExpiresAt}}. Confirm your booking: {{.AcceptURL}}",
+		),
+		models.ChannelSMS: mustTemplate(
+			"",
+			"{{.PatientName}}, a slot on {{.StartAt}} opened up. Confirm by {{.ExpiresAt}}: {{.AcceptURL}}",
+		),
+	},
+}
+
+type DoctorInvitationData struct {
+	DoctorName string
+	AcceptURL  string
+	ExpiresAt  string
+}
+
+// doctorInvitationTemplates: приглашение приходит только письмом.
+var doctorInvitationTemplates = map[string]map[models.NotificationChannel]messageTemplate{
+	"ru": {
+		models.ChannelEmail: mustTemplate(
+			"Приглашение в личный кабинет врача",
+			"Здравствуйте, {{.DoctorName}}! Для вас создан кабинет врача. Задайте пароль по ссылке до {{.ExpiresAt}}: {{.AcceptURL}}",
+		),
+	},
+	"en": {
+		models.ChannelEmail: mustTemplate(
+			"Invitation to your doctor account",
+			"Hello {{.DoctorName}}! A doctor account has been created for you. Set your password by {{.ExpiresAt}}: {{.AcceptURL}}",
+		),
+	},
+}
+
+// RenderReminder возвращает тему и текст напоминания; для неизвестного языка
+// используется DefaultLanguage.
+func RenderReminder(language string, channel models.NotificationChannel, data ReminderData) (string, string, error) {
+	return render(reminderTemplates, language, channel, data)
+}
+
+func RenderWaitlistOffer(language string, channel models.NotificationChannel, data WaitlistOfferData) (string, string, error) {
+	return render(waitlistOfferTemplates, language, channel, data)
+}
+
+func RenderDoctorInvitation(language string, data DoctorInvitationData) (string, string, error) {
+	return render(doctorInvitationTemplates, language, models.ChannelEmail, data)
+}
+
+func render(templates map[string]map[models.NotificationChannel]messageTemplate, language string, channel models.NotificationChannel, data any) (string, string, error) {
+	byChannel, ok := templates[language]
+	if !ok {
+		byChannel = templates[DefaultLanguage]
+	}
+
+	tpl, ok := byChannel[channel]
+	if !ok {
+		return "", "", ErrNoTemplate
+	}
+
+	var subject, body bytes.Buffer
+	if err := tpl.subject.Execute(&subject, data); err != nil {
+		return "", "", err
+	}
+	if err := tpl.body.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+
+	return subject.String(), body.String(), nil
+}
+
+// FormatOffset выводит интервал до приёма в часах или минутах на нужном языке.
+func FormatOffset(language string, d time.Duration) string {
+	if language != "en" {
+		if d%time.Hour == 0 {
+			return fmt.Sprintf("%d ч", int(d/time.Hour))
+		}
+		return fmt.Sprintf("%d мин", int(d/time.Minute))
+	}
+
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%d h", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%d min", int(d/time.Minute))
+}
@@ -0,0 +1,153 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+)
+
+const FakeProviderName = "fake"
+
+// FakeProvider — локальный шлюз без сети: хранит намерения в памяти и
+// подписывает webhook-события тем же секретом, что проверяет ParseWebhook.
+type FakeProvider struct {
+	mu      sync.Mutex
+	secret  []byte
+	intents map[string]*Intent
+}
+
+func NewFakeProvider(webhookSecret string) *FakeProvider {
+	return &FakeProvider{
+		secret:  []byte(webhookSecret),
+		intents: make(map[string]*Intent),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+func (p *FakeProvider) CreateIntent(ctx context.Context, params CreateIntentParams) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent := &Intent{
+		ID:           "fake_pi_" + randomHex(12),
+		ClientSecret: "fake_secret_" + randomHex(16),
+		Amount:       params.Amount,
+		Currency:     params.Currency,
+		Status:       IntentRequiresConfirmation,
+	}
+	p.intents[intent.ID] = intent
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakeProvider) Confirm(ctx context.Context, intentID string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+
+	if intent.Status == IntentRequiresConfirmation {
+		intent.Status = IntentSucceeded
+	}
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, intentID string, amount float64) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+
+	if amount > intent.Amount {
+		return nil, ErrRefundTooLarge
+	}
+
+	intent.Status = IntentRefunded
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakeProvider) ParseWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+	if !hmac.Equal([]byte(p.sign(payload)), []byte(signature)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, ErrInvalidPayload
+	}
+
+	if event.ID == "" || event.IntentID == "" || event.Type == "" {
+		return nil, ErrInvalidPayload
+	}
+
+	return &event, nil
+}
+
+// SimulateEvent — симулятор webhook: меняет состояние намерения так, как это
+// сделал бы настоящий шлюз, и возвращает подписанное тело события.
+func (p *FakeProvider) SimulateEvent(intentID, eventType string) ([]byte, string, error) {
+	p.mu.Lock()
+	intent, ok := p.intents[intentID]
+	if !ok {
+		p.mu.Unlock()
+		return nil, "", ErrIntentNotFound
+	}
+
+	switch eventType {
+	case EventPaymentSucceeded:
+		intent.Status = IntentSucceeded
+	case EventPaymentFailed:
+		intent.Status = IntentFailed
+	case EventPaymentRefunded:
+		intent.Status = IntentRefunded
+	default:
+		p.mu.Unlock()
+		return nil, "", ErrInvalidPayload
+	}
+	amount := intent.Amount
+	p.mu.Unlock()
+
+	payload, err := json.Marshal(WebhookEvent{
+		ID:       "fake_evt_" + randomHex(12),
+		Type:     eventType,
+		IntentID: intentID,
+		Amount:   amount,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return payload, p.sign(payload), nil
+}
+
+func (p *FakeProvider) sign(payload []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,63 @@
+package payments
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrIntentNotFound   = errors.New("платёжное намерение не найдено")
+	ErrInvalidSignature = errors.New("неверная подпись webhook")
+	ErrInvalidPayload   = errors.New("некорректное тело webhook")
+	ErrRefundTooLarge   = errors.New("сумма возврата превышает сумму платежа")
+)
+
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+	EventPaymentRefunded  = "payment.refunded"
+)
+
+const (
+	IntentRequiresConfirmation = "requires_confirmation"
+	IntentSucceeded            = "succeeded"
+	IntentFailed               = "failed"
+	IntentRefunded             = "refunded"
+)
+
+type Intent struct {
+	ID           string
+	ClientSecret string
+	Amount       float64
+	Currency     string
+	Status       string
+}
+
+type CreateIntentParams struct {
+	Amount      float64
+	Currency    string
+	Description string
+	Metadata    map[string]string
+}
+
+type WebhookEvent struct {
+	ID       string  `json:"id"`
+	Type     string  `json:"type"`
+	IntentID string  `json:"intent_id"`
+	Amount   float64 `json:"amount"`
+}
+
+// Provider — абстракция платёжного шлюза. Реальные провайдеры и локальный
+// FakeProvider реализуют один и тот же контракт.
+type Provider interface {
+	Name() string
+
+	CreateIntent(ctx context.Context, params CreateIntentParams) (*Intent, error)
+
+	Confirm(ctx context.Context, intentID string) (*Intent, error)
+
+	Refund(ctx context.Context, intentID string, amount float64) (*Intent, error)
+
+	// ParseWebhook проверяет подпись и разбирает событие от провайдера.
+	ParseWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
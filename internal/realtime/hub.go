@@ -0,0 +1,56 @@
+package realtime
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// Hub рассылает события всем подписчикам в пределах процесса. Медленный
+// подписчик не блокирует рассылку: если его буфер заполнен, событие для
+// него отбрасывается.
+type Hub[T any] struct {
+	mu     sync.Mutex
+	subs   map[chan T]struct{}
+	buffer int
+	logger *slog.Logger
+}
+
+func NewHub[T any](buffer int, logger *slog.Logger) *Hub[T] {
+	if buffer <= 0 {
+		buffer = 16
+	}
+	return &Hub[T]{subs: make(map[chan T]struct{}), buffer: buffer, logger: logger}
+}
+
+// Subscribe возвращает канал событий и функцию отписки, которую нужно
+// вызвать, когда клиент отключился.
+func (h *Hub[T]) Subscribe() (<-chan T, func()) {
+	ch := make(chan T, h.buffer)
+
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (h *Hub[T]) Publish(event T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs {
+		select {
+		case ch <- event:
+		default:
+			h.logger.Warn("подписчик не успевает читать события, событие пропущено")
+		}
+	}
+}
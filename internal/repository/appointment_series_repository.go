@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+)
+
+type AppointmentSeriesRepository interface {
+	CreateTx(tx *gorm.DB, series *models.AppointmentSeries) error
+
+	// GetByID возвращает серию вместе с приёмами, упорядоченными по номеру.
+	GetByID(ctx context.Context, id uint) (*models.AppointmentSeries, error)
+
+	UpdateTx(tx *gorm.DB, series *models.AppointmentSeries) error
+}
+
+type gormAppointmentSeriesRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewAppointmentSeriesRepository(db *gorm.DB, logger *slog.Logger) AppointmentSeriesRepository {
+	return &gormAppointmentSeriesRepository{db: db, logger: logger}
+}
+
+func (r *gormAppointmentSeriesRepository) CreateTx(tx *gorm.DB, series *models.AppointmentSeries) error {
+	if err := tx.Omit("Appointments").Create(series).Error; err != nil {
+		r.logger.Error("ошибка при создании серии приёмов", "error", err, "patient_id", series.PatientID)
+		return err
+	}
+
+	r.logger.Info("серия приёмов создана", "series_id", series.ID, "patient_id", series.PatientID)
+	return nil
+}
+
+func (r *gormAppointmentSeriesRepository) GetByID(ctx context.Context, id uint) (*models.AppointmentSeries, error) {
+	var series models.AppointmentSeries
+
+	if err := r.db.WithContext(ctx).
+		Preload("Appointments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("series_index ASC")
+		}).
+		First(&series, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &series, nil
+}
+
+func (r *gormAppointmentSeriesRepository) UpdateTx(tx *gorm.DB, series *models.AppointmentSeries) error {
+	if err := tx.Omit("Appointments").Save(series).Error; err != nil {
+		r.logger.Error("ошибка при обновлении серии приёмов", "error", err, "series_id", series.ID)
+		return err
+	}
+	return nil
+}
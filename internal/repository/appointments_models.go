@@ -1,24 +1,49 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/mutsaevz/team-4-dentistry/internal/constants"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AppointmentRepository interface {
 	Delete(uint) error
+	DeleteTx(tx *gorm.DB, id uint) error
 	GetByID(uint) (*models.Appointment, error)
-	Get() ([]models.Appointment, error)
+	GetByIDForUpdateTx(tx *gorm.DB, id uint) (*models.Appointment, error)
+	// List возвращает страницу приёмов; clinicIDs != nil ограничивает
+	// выборку этими филиалами.
+	List(q models.ListQuery, clinicIDs []uint) (*models.Page[models.Appointment], error)
+	Search(ctx context.Context, q models.ListQuery, search models.AppointmentSearch) (*models.Page[models.Appointment], error)
+	GetBoard(ctx context.Context, q models.ListQuery, search models.AppointmentSearch) ([]models.Appointment, error)
 	Transaction(func(tx *gorm.DB) error) error
 	CreateTx(tx *gorm.DB, appointment *models.Appointment) error
 	UpdateTx(tx *gorm.DB, appointment *models.Appointment) error
 	GetByPatientID(patientID uint) ([]models.Appointment, error)
 	Update(appointment *models.Appointment) error
+	SetPaymentStateTx(tx *gorm.DB, id uint, status string, paid bool) error
+	CancelTx(tx *gorm.DB, appointment *models.Appointment) error
+	SetStatusTx(tx *gorm.DB, id uint, status string) error
+	// SetSlotAvailabilityTx освобождает слот, только если на него нет
+	// действующей записи.
+	SetSlotAvailabilityTx(tx *gorm.DB, doctorID uint, startAt time.Time, available bool) error
+	GetStartingBetween(from, to time.Time) ([]models.Appointment, error)
+	HasDoctorConflict(doctorID uint, start, end time.Time) (bool, error)
+	GetBookedInRangeTx(tx *gorm.DB, doctorID uint, from, to time.Time) ([]models.Appointment, error)
+	GetForQueue(from, to time.Time, doctorID uint) ([]models.Appointment, error)
+	SetCheckedIn(id uint, at time.Time) (bool, error)
+	SetCalled(id uint, at time.Time) (bool, error)
+	GetCalendarByDoctorID(doctorID uint, since time.Time) ([]models.Appointment, error)
+	GetCalendarByPatientID(patientID uint, since time.Time) ([]models.Appointment, error)
 }
 type gormAppointmentRepository struct {
 	DB     *gorm.DB
@@ -41,6 +66,28 @@ func (r *gormAppointmentRepository) Delete(id uint) error {
 
 }
 
+func (r *gormAppointmentRepository) DeleteTx(tx *gorm.DB, id uint) error {
+	var appointment models.Appointment
+	if err := tx.Select("id", "doctor_id", "start_at", "status").First(&appointment, id).Error; err != nil {
+		r.logger.Error("ошибка при получении appointment для удаления", "ошибка", err, "appointments_id", id)
+		return err
+	}
+
+	if err := tx.Delete(&models.Appointment{}, id).Error; err != nil {
+		r.logger.Error("ошибка при удалинии appointments", "ошибка", err, "appointments_id", id)
+		return err
+	}
+
+	if appointment.Status != models.AppointmentCancelled {
+		if err := r.SetSlotAvailabilityTx(tx, appointment.DoctorID, appointment.StartAt, true); err != nil {
+			return err
+		}
+	}
+
+	r.logger.Info("appointment удалён", "appointments_id", id)
+	return nil
+}
+
 func (r *gormAppointmentRepository) GetByID(id uint) (*models.Appointment, error) {
 	r.logger.Debug("получение appointment блягодаря ID", "appointments_id", id)
 	var appointment models.Appointment
@@ -55,19 +102,135 @@ func (r *gormAppointmentRepository) GetByID(id uint) (*models.Appointment, error
 	return &appointment, nil
 }
 
-func (r *gormAppointmentRepository) Get() ([]models.Appointment, error) {
-	r.logger.Debug("получение всех appointments")
+func (r *gormAppointmentRepository) GetByIDForUpdateTx(tx *gorm.DB, id uint) (*models.Appointment, error) {
+	var appointment models.Appointment
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&appointment, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &appointment, nil
+}
+
+var appointmentListSpec = listSpec[models.Appointment]{
+	sorts: map[string]sortColumn[models.Appointment]{
+		"start_at":   {column: "start_at", value: func(a *models.Appointment) any { return a.StartAt }},
+		"created_at": {column: "created_at", value: func(a *models.Appointment) any { return a.CreatedAt }},
+	},
+	filters: map[string]filterColumn{
+		"doctor_id":  {column: "doctor_id", kind: filterUint},
+		"patient_id": {column: "patient_id", kind: filterUint},
+		"service_id": {column: "service_id", kind: filterUint},
+		"clinic_id":  {column: "clinic_id", kind: filterUint},
+		"status":     {column: "status", kind: filterStrings},
+		"paid":       {column: "paid", kind: filterBool},
+	},
+	dateColumn:  "start_at",
+	defaultSort: []models.SortField{{Field: "start_at", Desc: true}},
+	id:          func(a *models.Appointment) uint { return a.ID },
+}
+
+func (r *gormAppointmentRepository) List(q models.ListQuery, clinicIDs []uint) (*models.Page[models.Appointment], error) {
+	r.logger.Debug("получение списка appointments")
+
+	query := r.DB.Model(&models.Appointment{})
+	if clinicIDs != nil {
+		query = query.Where("clinic_id IN ?", clinicIDs)
+	}
+
+	page, err := paginate(query, q, appointmentListSpec)
+	if err != nil {
+		r.logger.Error("ошибка при получении списка appointments", "ошибка", err)
+		return nil, err
+	}
+
+	r.logger.Info("успешное получение списка appointments", "count", len(page.Items), "total", page.Total)
+	return page, nil
+}
+
+// Search — постраничный поиск приёмов для регистратуры вместе с пациентом,
+// врачом и услугой.
+func (r *gormAppointmentRepository) Search(ctx context.Context, q models.ListQuery, search models.AppointmentSearch) (*models.Page[models.Appointment], error) {
+	r.logger.Debug("поиск appointments", "patient", search.Patient)
+
+	spec := appointmentListSpec
+	spec.preloads = []string{"Patient", "Doctor", "Service"}
+
+	page, err := paginate(r.searchQuery(ctx, search), q, spec)
+	if err != nil {
+		r.logger.Error("ошибка при поиске appointments", "ошибка", err)
+		return nil, err
+	}
+
+	r.logger.Info("успешный поиск appointments", "count", len(page.Items), "total", page.Total)
+	return page, nil
+}
+
+// GetBoard возвращает все приёмы, подходящие под фильтры и период q, в
+// порядке врач — время начала.
+func (r *gormAppointmentRepository) GetBoard(ctx context.Context, q models.ListQuery, search models.AppointmentSearch) ([]models.Appointment, error) {
 	var appointments []models.Appointment
 
-	if err := r.DB.Find(&appointments).Error; err != nil {
-		r.logger.Error("ошибка при получении всех appointments", "ошибка", err)
+	query, err := applyListFilters(r.searchQuery(ctx, search), q, appointmentListSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := query.
+		Preload("Patient").
+		Preload("Doctor").
+		Preload("Service").
+		Order("doctor_id ASC").
+		Order("start_at ASC").
+		Find(&appointments).Error; err != nil {
+		r.logger.Error("ошибка при получении appointments для доски", "ошибка", err)
 		return nil, err
 	}
 
-	r.logger.Info("успешное получение всех appointments")
 	return appointments, nil
 }
 
+func (r *gormAppointmentRepository) searchQuery(ctx context.Context, search models.AppointmentSearch) *gorm.DB {
+	query := r.DB.WithContext(ctx).Model(&models.Appointment{})
+
+	if search.ClinicIDs != nil {
+		query = query.Where("clinic_id IN ?", search.ClinicIDs)
+	}
+	if term := strings.TrimSpace(search.Patient); term != "" {
+		query = query.Where("patient_id IN (?)", r.patientsMatching(ctx, term))
+	}
+
+	return query
+}
+
+// patientsMatching подбирает пациентов по части имени, фамилии или
+// «имени фамилии»; строку из цифр и знаков телефона ищет в телефоне без
+// учёта форматирования.
+func (r *gormAppointmentRepository) patientsMatching(ctx context.Context, term string) *gorm.DB {
+	query := r.DB.WithContext(ctx).Model(&models.User{}).Select("id")
+
+	if phoneTerm.MatchString(term) {
+		digits := nonDigits.ReplaceAllString(term, "")
+		return query.Where("regexp_replace(phone, '\\D', '', 'g') LIKE ?", "%"+digits+"%")
+	}
+
+	like := "%" + escapeLike(term) + "%"
+	return query.Where(
+		"first_name ILIKE ? OR last_name ILIKE ? OR CONCAT_WS(' ', first_name, last_name) ILIKE ? OR CONCAT_WS(' ', last_name, first_name) ILIKE ?",
+		like, like, like, like,
+	)
+}
+
+var (
+	phoneTerm = regexp.MustCompile(`^[\d\s()+-]*\d[\d\s()+-]*$`)
+	nonDigits = regexp.MustCompile(`\D`)
+)
+
+// escapeLike экранирует спецсимволы шаблона LIKE.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
 func (r *gormAppointmentRepository) Transaction(fn func(tx *gorm.DB) error) error {
 	err := r.DB.Transaction(fn)
 	if err != nil {
@@ -87,9 +250,10 @@ func (r *gormAppointmentRepository) CreateTx(tx *gorm.DB, appointment *models.Ap
 
 	r.logger.Debug("создание нового appointment", "appointment", appointment)
 
-	dateOnly := time.Date(appointment.StartAt.Year(), appointment.StartAt.Month(), appointment.StartAt.Day(), 0, 0, 0, 0, appointment.StartAt.Location())
+	// Смена ищется только по моментам времени: сравнение с Date зависело бы
+	// от часового пояса, в котором пришло время записи.
 	var schedule models.Schedule
-	if err := tx.Where("doctor_id = ? AND date = ? AND start_time <= ? AND end_time >= ?", appointment.DoctorID, dateOnly, appointment.StartAt, appointment.EndAt).First(&schedule).Error; err != nil {
+	if err := tx.Where("doctor_id = ? AND start_time <= ? AND end_time >= ?", appointment.DoctorID, appointment.StartAt, appointment.EndAt).First(&schedule).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			r.logger.Warn("время appointment не входит в расписание врача", "doctor_id", appointment.DoctorID, "start_at", appointment.StartAt, "end_at", appointment.EndAt)
 			return constants.ErrTimeNotInSchedule
@@ -100,7 +264,7 @@ func (r *gormAppointmentRepository) CreateTx(tx *gorm.DB, appointment *models.Ap
 
 	var count int64
 	if err := tx.Model(&models.Appointment{}).
-		Where("doctor_id = ? AND start_at < ? AND end_at > ?", appointment.DoctorID, appointment.EndAt, appointment.StartAt).
+		Where("doctor_id = ? AND start_at < ? AND end_at > ? AND status <> ?", appointment.DoctorID, appointment.EndAt, appointment.StartAt, models.AppointmentCancelled).
 		Count(&count).Error; err != nil {
 		r.logger.Error("ошибка при проверке конфликтов по времени для нового appointment", "ошибка", err)
 		return err
@@ -111,7 +275,7 @@ func (r *gormAppointmentRepository) CreateTx(tx *gorm.DB, appointment *models.Ap
 	}
 
 	if err := tx.Model(&models.Appointment{}).
-		Where("patient_id = ? AND start_at < ? AND end_at > ?", appointment.PatientID, appointment.EndAt, appointment.StartAt).
+		Where("patient_id = ? AND start_at < ? AND end_at > ? AND status <> ?", appointment.PatientID, appointment.EndAt, appointment.StartAt, models.AppointmentCancelled).
 		Count(&count).Error; err != nil {
 		r.logger.Error("ошибка при проверке конфликтов по времени для нового appointment пациента", "ошибка", err)
 		return err
@@ -122,11 +286,45 @@ func (r *gormAppointmentRepository) CreateTx(tx *gorm.DB, appointment *models.Ap
 		return constants.ErrTimeConflict
 	}
 
-	if err := tx.Create(appointment).Error; err != nil {
+	if err := tx.Model(&models.WaitlistOffer{}).
+		Where("doctor_id = ? AND patient_id <> ? AND status = ? AND expires_at > ? AND start_at < ? AND end_at > ?",
+			appointment.DoctorID, appointment.PatientID, models.OfferPending, time.Now(), appointment.EndAt, appointment.StartAt).
+		Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при проверке удержаний слота для нового appointment", "ошибка", err)
+		return err
+	}
+
+	if count > 0 {
+		r.logger.Warn("слот удерживается предложением из листа ожидания", "doctor_id", appointment.DoctorID, "start_at", appointment.StartAt)
+		return constants.ErrSlotHeld
+	}
+
+	if err := tx.Model(&models.SlotHold{}).
+		Where("doctor_id = ? AND patient_id <> ? AND status = ? AND expires_at > ? AND start_at < ? AND end_at > ?",
+			appointment.DoctorID, appointment.PatientID, models.HoldActive, time.Now(), appointment.EndAt, appointment.StartAt).
+		Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при проверке временных удержаний слота для нового appointment", "ошибка", err)
+		return err
+	}
+
+	if count > 0 {
+		r.logger.Warn("слот временно удерживается другим пациентом", "doctor_id", appointment.DoctorID, "start_at", appointment.StartAt)
+		return constants.ErrSlotHeld
+	}
+
+	if err := r.allocateResourcesTx(tx, appointment, &schedule); err != nil {
+		return err
+	}
+
+	if err := tx.Omit("Equipment.*").Create(appointment).Error; err != nil {
 		r.logger.Error("ошибка при создании нового appointment", "ошибка", err)
 		return err
 	}
 
+	if err := r.SetSlotAvailabilityTx(tx, appointment.DoctorID, appointment.StartAt, false); err != nil {
+		return err
+	}
+
 	r.logger.Info("успешное создание нового appointment", "appointment_id", appointment.ID)
 	return nil
 }
@@ -137,9 +335,8 @@ func (r *gormAppointmentRepository) UpdateTx(tx *gorm.DB, appointment *models.Ap
 		return constants.Appointments_IS_nil
 	}
 
-	dateOnly := time.Date(appointment.StartAt.Year(), appointment.StartAt.Month(), appointment.StartAt.Day(), 0, 0, 0, 0, appointment.StartAt.Location())
 	var schedule models.Schedule
-	if err := tx.Where("doctor_id = ? AND date = ? AND start_time <= ? AND end_time >= ?", appointment.DoctorID, dateOnly, appointment.StartAt, appointment.EndAt).First(&schedule).Error; err != nil {
+	if err := tx.Where("doctor_id = ? AND start_time <= ? AND end_time >= ?", appointment.DoctorID, appointment.StartAt, appointment.EndAt).First(&schedule).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			r.logger.Warn("время appointment не входит в расписание врача", "doctor_id", appointment.DoctorID, "start_at", appointment.StartAt, "end_at", appointment.EndAt)
 			return constants.ErrTimeNotInSchedule
@@ -150,7 +347,7 @@ func (r *gormAppointmentRepository) UpdateTx(tx *gorm.DB, appointment *models.Ap
 
 	var count int64
 	if err := tx.Model(&models.Appointment{}).
-		Where("doctor_id = ? AND id <> ?AND start_at < ? AND end_at > ?", appointment.DoctorID, appointment.ID, appointment.EndAt, appointment.StartAt).
+		Where("doctor_id = ? AND id <> ? AND start_at < ? AND end_at > ? AND status <> ?", appointment.DoctorID, appointment.ID, appointment.EndAt, appointment.StartAt, models.AppointmentCancelled).
 		Count(&count).Error; err != nil {
 		r.logger.Error("ошибка при проверке конфликтов по времени для обновленного appointment", "ошибка", err)
 		return err
@@ -161,7 +358,7 @@ func (r *gormAppointmentRepository) UpdateTx(tx *gorm.DB, appointment *models.Ap
 	}
 
 	if err := tx.Model(&models.Appointment{}).
-		Where("patient_id = ? AND id <> ? AND start_at < ? AND end_at > ?", appointment.PatientID, appointment.ID, appointment.EndAt, appointment.StartAt).
+		Where("patient_id = ? AND id <> ? AND start_at < ? AND end_at > ? AND status <> ?", appointment.PatientID, appointment.ID, appointment.EndAt, appointment.StartAt, models.AppointmentCancelled).
 		Count(&count).Error; err != nil {
 		r.logger.Error("ошибка при проверке конфликтов по времени для обновленного appointment", "ошибка", err)
 		return err
@@ -171,12 +368,36 @@ func (r *gormAppointmentRepository) UpdateTx(tx *gorm.DB, appointment *models.Ap
 		return constants.ErrTimeConflict
 	}
 
-	err := tx.Save(appointment).Error
-	if err != nil {
+	if err := r.allocateResourcesTx(tx, appointment, &schedule); err != nil {
+		return err
+	}
+
+	var previous models.Appointment
+	if err := tx.Select("id", "doctor_id", "start_at").First(&previous, appointment.ID).Error; err != nil {
+		r.logger.Error("ошибка при получении appointment до обновления", "ошибка", err, "appointment_id", appointment.ID)
+		return err
+	}
+
+	if err := tx.Omit("Equipment").Save(appointment).Error; err != nil {
 		r.logger.Error("ошибка при обновлении appointment", "ошибка", err)
 		return err
 	}
 
+	// При переносе старый слот освобождается, новый занимается.
+	if appointment.Status != models.AppointmentCancelled &&
+		(previous.DoctorID != appointment.DoctorID || !previous.StartAt.Equal(appointment.StartAt)) {
+		if err := r.SetSlotAvailabilityTx(tx, previous.DoctorID, previous.StartAt, true); err != nil {
+			return err
+		}
+		if err := r.SetSlotAvailabilityTx(tx, appointment.DoctorID, appointment.StartAt, false); err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(appointment).Association("Equipment").Replace(appointment.Equipment); err != nil {
+		r.logger.Error("ошибка при обновлении оборудования appointment", "ошибка", err)
+		return err
+	}
+
 	r.logger.Info("успешное обновление appointment", "appointment_id", appointment.ID)
 	return nil
 }
@@ -185,7 +406,7 @@ func (r *gormAppointmentRepository) GetByPatientID(patientID uint) ([]models.App
 	r.logger.Debug("получение appointments по patientID", "patient_id", patientID)
 	var appointment []models.Appointment
 
-	if err := r.DB.Where("patient_id = ?", patientID).Find(&appointment); err != nil {
+	if err := r.DB.Where("patient_id = ?", patientID).Order("start_at ASC").Find(&appointment).Error; err != nil {
 		r.logger.Error("ошибка при получении appointments по patientID", "ошибка", err, "patient_id", patientID)
 		return nil, constants.User_appointments_Not_Found
 	}
@@ -208,3 +429,304 @@ func (r *gormAppointmentRepository) Update(appointment *models.Appointment) erro
 	r.logger.Info("успешное обновление appointment", "appointment_id", appointment.ID)
 	return nil
 }
+
+func (r *gormAppointmentRepository) SetPaymentStateTx(tx *gorm.DB, id uint, status string, paid bool) error {
+	if err := tx.Model(&models.Appointment{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "paid": paid}).Error; err != nil {
+		r.logger.Error("ошибка при обновлении статуса оплаты appointment", "ошибка", err, "appointment_id", id)
+		return err
+	}
+
+	r.logger.Info("статус оплаты appointment обновлён", "appointment_id", id, "status", status, "paid", paid)
+	return nil
+}
+
+func (r *gormAppointmentRepository) SetStatusTx(tx *gorm.DB, id uint, status string) error {
+	if err := tx.Model(&models.Appointment{}).Where("id = ?", id).Update("status", status).Error; err != nil {
+		r.logger.Error("ошибка при смене статуса appointment", "ошибка", err, "appointment_id", id)
+		return err
+	}
+
+	r.logger.Info("статус appointment обновлён", "appointment_id", id, "status", status)
+	return nil
+}
+
+func (r *gormAppointmentRepository) CancelTx(tx *gorm.DB, appointment *models.Appointment) error {
+	if appointment == nil {
+		r.logger.Warn("попытка отменить nil appointment")
+		return constants.Appointments_IS_nil
+	}
+
+	appointment.Status = models.AppointmentCancelled
+	if err := tx.Model(appointment).Update("status", appointment.Status).Error; err != nil {
+		r.logger.Error("ошибка при отмене appointment", "ошибка", err, "appointment_id", appointment.ID)
+		return err
+	}
+
+	if err := r.SetSlotAvailabilityTx(tx, appointment.DoctorID, appointment.StartAt, true); err != nil {
+		return err
+	}
+
+	r.logger.Info("appointment отменён", "appointment_id", appointment.ID)
+	return nil
+}
+
+// SetSlotAvailabilityTx отмечает слот расписания, с которого начинается
+// запись, занятым или свободным. Слот не освобождается, пока на него есть
+// другая действующая запись.
+func (r *gormAppointmentRepository) SetSlotAvailabilityTx(tx *gorm.DB, doctorID uint, startAt time.Time, available bool) error {
+	query := tx.Model(&models.Schedule{}).Where("doctor_id = ? AND start_time = ?", doctorID, startAt)
+	if available {
+		query = query.Where("NOT EXISTS (?)", tx.Model(&models.Appointment{}).
+			Select("1").
+			Where("doctor_id = ? AND start_at = ? AND status <> ?", doctorID, startAt, models.AppointmentCancelled))
+	}
+
+	if err := query.Update("is_available", available).Error; err != nil {
+		r.logger.Error("ошибка при обновлении доступности слота расписания", "ошибка", err, "doctor_id", doctorID, "start_at", startAt)
+		return err
+	}
+	return nil
+}
+
+func (r *gormAppointmentRepository) GetStartingBetween(from, to time.Time) ([]models.Appointment, error) {
+	r.logger.Debug("получение предстоящих appointments", "from", from, "to", to)
+	var appointments []models.Appointment
+
+	if err := r.DB.
+		Preload("Patient").
+		Preload("Service").
+		Where("status = ? AND start_at > ? AND start_at <= ?", models.AppointmentScheduled, from, to).
+		Order("start_at").
+		Find(&appointments).Error; err != nil {
+		r.logger.Error("ошибка при получении предстоящих appointments", "ошибка", err)
+		return nil, err
+	}
+
+	return appointments, nil
+}
+
+// GetCalendarByDoctorID возвращает приёмы врача для календарной ленты, включая
+// удалённые: клиент должен увидеть их как отменённые.
+func (r *gormAppointmentRepository) GetCalendarByDoctorID(doctorID uint, since time.Time) ([]models.Appointment, error) {
+	return r.getCalendar("doctor_id = ?", doctorID, since)
+}
+
+func (r *gormAppointmentRepository) GetCalendarByPatientID(patientID uint, since time.Time) ([]models.Appointment, error) {
+	return r.getCalendar("patient_id = ?", patientID, since)
+}
+
+func (r *gormAppointmentRepository) getCalendar(cond string, id uint, since time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+
+	if err := r.DB.Unscoped().
+		Preload("Patient").
+		Preload("Doctor").
+		Preload("Service").
+		Where(cond, id).
+		Where("start_at >= ?", since).
+		Order("start_at").
+		Find(&appointments).Error; err != nil {
+		r.logger.Error("ошибка при получении appointments для календаря", "ошибка", err)
+		return nil, err
+	}
+
+	return appointments, nil
+}
+
+func (r *gormAppointmentRepository) HasDoctorConflict(doctorID uint, start, end time.Time) (bool, error) {
+	var count int64
+
+	if err := r.DB.Model(&models.Appointment{}).
+		Where("doctor_id = ? AND start_at < ? AND end_at > ? AND status <> ?", doctorID, end, start, models.AppointmentCancelled).
+		Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при проверке занятости врача", "ошибка", err, "doctor_id", doctorID)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// GetBookedInRangeTx возвращает действующие приёмы врача, пересекающиеся
+// с интервалом, и блокирует их до конца транзакции.
+func (r *gormAppointmentRepository) GetBookedInRangeTx(tx *gorm.DB, doctorID uint, from, to time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("doctor_id = ? AND start_at < ? AND end_at > ? AND status IN ?", doctorID, to, from,
+			[]string{models.AppointmentScheduled, models.AppointmentPendingPayment, models.AppointmentPendingApproval}).
+		Order("start_at ASC").
+		Find(&appointments).Error; err != nil {
+		r.logger.Error("ошибка при получении приёмов смены", "ошибка", err, "doctor_id", doctorID)
+		return nil, err
+	}
+
+	return appointments, nil
+}
+
+// GetForQueue возвращает активные приёмы за период (как правило, сегодняшний
+// день) вместе с пациентом и врачом; doctorID = 0 — по всем врачам.
+func (r *gormAppointmentRepository) GetForQueue(from, to time.Time, doctorID uint) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+
+	query := r.DB.
+		Preload("Patient").
+		Preload("Doctor").
+		Where("start_at >= ? AND start_at < ? AND status IN ?", from, to, []string{models.AppointmentScheduled, models.AppointmentPendingPayment})
+	if doctorID != 0 {
+		query = query.Where("doctor_id = ?", doctorID)
+	}
+
+	if err := query.Order("start_at ASC").Order("checked_in_at ASC").Find(&appointments).Error; err != nil {
+		r.logger.Error("ошибка при получении приёмов для очереди", "ошибка", err)
+		return nil, err
+	}
+
+	return appointments, nil
+}
+
+// SetCheckedIn отмечает приход пациента; false — отметка уже была.
+func (r *gormAppointmentRepository) SetCheckedIn(id uint, at time.Time) (bool, error) {
+	res := r.DB.Model(&models.Appointment{}).
+		Where("id = ? AND checked_in_at IS NULL", id).
+		Update("checked_in_at", at)
+	if res.Error != nil {
+		r.logger.Error("ошибка при отметке прихода пациента", "ошибка", res.Error, "appointment_id", id)
+		return false, res.Error
+	}
+
+	r.logger.Info("пациент отмечен как пришедший", "appointment_id", id)
+	return res.RowsAffected > 0, nil
+}
+
+// SetCalled фиксирует приглашение пациента в кабинет; повторный вызов
+// обновляет время, чтобы табло могло повторить объявление.
+func (r *gormAppointmentRepository) SetCalled(id uint, at time.Time) (bool, error) {
+	res := r.DB.Model(&models.Appointment{}).
+		Where("id = ? AND checked_in_at IS NOT NULL", id).
+		Update("called_at", at)
+	if res.Error != nil {
+		r.logger.Error("ошибка при вызове пациента", "ошибка", res.Error, "appointment_id", id)
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// visitServicesTx возвращает услуги, которые оказываются на приёме: все
+// услуги комплекса или одну услугу записи.
+func (r *gormAppointmentRepository) visitServicesTx(tx *gorm.DB, appointment *models.Appointment) ([]models.Service, error) {
+	var services []models.Service
+
+	if appointment.BundleID != nil {
+		err := tx.
+			Joins("JOIN service_bundle_items sbi ON sbi.service_id = services.id").
+			Where("sbi.bundle_id = ?", *appointment.BundleID).
+			Order("sbi.position ASC").
+			Find(&services).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(services) > 0 {
+			return services, nil
+		}
+	}
+
+	var service models.Service
+	if err := tx.First(&service, appointment.ServiceID).Error; err != nil {
+		return nil, err
+	}
+	return append(services, service), nil
+}
+
+// allocateResourcesTx закрепляет за приёмом филиал и кабинет смены и
+// оборудование, которого требует услуга, а у комплекса — каждая услуга
+// состава. Стационарное оборудование берётся из кабинета, передвижное —
+// свободное на время приёма в том же филиале; строки оборудования
+// блокируются, чтобы параллельная запись не получила тот же аппарат.
+func (r *gormAppointmentRepository) allocateResourcesTx(tx *gorm.DB, appointment *models.Appointment, schedule *models.Schedule) error {
+	appointment.ClinicID = schedule.ClinicID
+	appointment.RoomID = schedule.RoomID
+	appointment.Equipment = nil
+
+	if appointment.ServiceID == 0 {
+		return nil
+	}
+
+	services, err := r.visitServicesTx(tx, appointment)
+	if err != nil {
+		r.logger.Error("ошибка при получении услуги для подбора ресурсов", "ошибка", err, "service_id", appointment.ServiceID)
+		return err
+	}
+
+	var equipmentKinds []string
+	for _, service := range services {
+		if schedule.ClinicID != nil {
+			var count int64
+			if err := tx.Model(&models.Service{}).
+				Scopes(serviceAvailableAt(*schedule.ClinicID)).
+				Where("services.id = ?", service.ID).
+				Count(&count).Error; err != nil {
+				r.logger.Error("ошибка при проверке доступности услуги в филиале", "ошибка", err, "service_id", service.ID)
+				return err
+			}
+			if count == 0 {
+				r.logger.Warn("услуга недоступна в филиале смены", "service_id", service.ID, "clinic_id", *schedule.ClinicID)
+				return constants.ErrServiceNotInClinic
+			}
+		}
+
+		if service.RequiredRoomKind != "" {
+			var room models.Room
+			if schedule.RoomID == nil || tx.First(&room, *schedule.RoomID).Error != nil || room.Kind != service.RequiredRoomKind {
+				r.logger.Warn("кабинет смены не подходит для услуги", "service_id", service.ID, "room_number", schedule.RoomNumber, "required_kind", service.RequiredRoomKind)
+				return constants.ErrRoomUnsuitable
+			}
+		}
+
+		for _, kind := range service.RequiredEquipment {
+			if !slices.Contains(equipmentKinds, kind) {
+				equipmentKinds = append(equipmentKinds, kind)
+			}
+		}
+	}
+
+	for _, kind := range equipmentKinds {
+		var equipment models.Equipment
+
+		if schedule.RoomID != nil {
+			err := tx.Where("kind = ? AND room_id = ? AND is_active = ?", kind, *schedule.RoomID, true).First(&equipment).Error
+			if err == nil {
+				appointment.Equipment = append(appointment.Equipment, equipment)
+				continue
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		busy := tx.Table("appointment_equipment AS ae").
+			Select("ae.equipment_id").
+			Joins("JOIN appointments a ON a.id = ae.appointment_id").
+			Where("a.deleted_at IS NULL AND a.id <> ? AND a.status <> ? AND a.start_at < ? AND a.end_at > ?",
+				appointment.ID, models.AppointmentCancelled, appointment.EndAt, appointment.StartAt)
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(clinicScope("clinic_id", schedule.ClinicID)).
+			Where("kind = ? AND room_id IS NULL AND is_active = ? AND id NOT IN (?)", kind, true, busy).
+			Order("id ASC").
+			First(&equipment).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				r.logger.Warn("нет свободного оборудования для приёма", "kind", kind, "start_at", appointment.StartAt)
+				return constants.ErrEquipmentUnavailable
+			}
+			r.logger.Error("ошибка при подборе оборудования", "ошибка", err, "kind", kind)
+			return err
+		}
+		appointment.Equipment = append(appointment.Equipment, equipment)
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookingPolicyRepository interface {
+	CountNoShows(ctx context.Context, patientID uint, since time.Time) (int64, error)
+
+	LastNoShowAt(ctx context.Context, patientID uint) (*time.Time, error)
+
+	// CountFutureBookings считает предстоящие активные записи пациента.
+	CountFutureBookings(ctx context.Context, patientID uint, now time.Time) (int64, error)
+
+	// LockPatientTx блокирует строку пациента до конца транзакции, чтобы
+	// параллельные записи одного пациента проверяли лимит по очереди.
+	LockPatientTx(tx *gorm.DB, patientID uint) error
+
+	CountFutureBookingsTx(tx *gorm.DB, patientID uint, now time.Time) (int64, error)
+
+	GetOverride(ctx context.Context, patientID uint) (*models.BookingPolicyOverride, error)
+
+	SaveOverride(ctx context.Context, override *models.BookingPolicyOverride) error
+
+	NoShowReport(ctx context.Context, params models.NoShowReportParams) ([]models.NoShowReportRow, error)
+
+	// CountFinished считает приёмы периода с известным исходом:
+	// завершённые и неявки.
+	CountFinished(ctx context.Context, params models.NoShowReportParams) (int64, error)
+}
+
+type gormBookingPolicyRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewBookingPolicyRepository(db *gorm.DB, logger *slog.Logger) BookingPolicyRepository {
+	return &gormBookingPolicyRepository{db: db, logger: logger}
+}
+
+func (r *gormBookingPolicyRepository) CountNoShows(ctx context.Context, patientID uint, since time.Time) (int64, error) {
+	var count int64
+
+	if err := r.db.WithContext(ctx).
+		Model(&models.Appointment{}).
+		Where("patient_id = ? AND status = ? AND start_at >= ?", patientID, models.AppointmentNoShow, since).
+		Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при подсчёте неявок пациента", "error", err, "patient_id", patientID)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *gormBookingPolicyRepository) LastNoShowAt(ctx context.Context, patientID uint) (*time.Time, error) {
+	var appointment models.Appointment
+
+	err := r.db.WithContext(ctx).
+		Where("patient_id = ? AND status = ?", patientID, models.AppointmentNoShow).
+		Order("start_at DESC").
+		First(&appointment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &appointment.StartAt, nil
+}
+
+func (r *gormBookingPolicyRepository) CountFutureBookings(ctx context.Context, patientID uint, now time.Time) (int64, error) {
+	return r.CountFutureBookingsTx(r.db.WithContext(ctx), patientID, now)
+}
+
+func (r *gormBookingPolicyRepository) LockPatientTx(tx *gorm.DB, patientID uint) error {
+	var user models.User
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&user, patientID).Error; err != nil {
+		r.logger.Error("ошибка при блокировке пациента", "error", err, "patient_id", patientID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *gormBookingPolicyRepository) CountFutureBookingsTx(tx *gorm.DB, patientID uint, now time.Time) (int64, error) {
+	var count int64
+
+	if err := tx.
+		Model(&models.Appointment{}).
+		Where("patient_id = ? AND start_at > ? AND status IN ?", patientID, now,
+			[]string{models.AppointmentScheduled, models.AppointmentPendingPayment, models.AppointmentPendingApproval}).
+		Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при подсчёте будущих записей пациента", "error", err, "patient_id", patientID)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *gormBookingPolicyRepository) GetOverride(ctx context.Context, patientID uint) (*models.BookingPolicyOverride, error) {
+	var override models.BookingPolicyOverride
+
+	if err := r.db.WithContext(ctx).Where("patient_id = ?", patientID).First(&override).Error; err != nil {
+		return nil, err
+	}
+
+	return &override, nil
+}
+
+func (r *gormBookingPolicyRepository) SaveOverride(ctx context.Context, override *models.BookingPolicyOverride) error {
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "patient_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"exempt", "expires_at", "note", "granted_by_id", "updated_at"}),
+		}).
+		Create(override).Error; err != nil {
+		r.logger.Error("ошибка при сохранении исключения из правил бронирования", "error", err, "patient_id", override.PatientID)
+		return err
+	}
+
+	r.logger.Info("исключение из правил бронирования сохранено", "patient_id", override.PatientID, "exempt", override.Exempt)
+	return nil
+}
+
+func (r *gormBookingPolicyRepository) NoShowReport(ctx context.Context, params models.NoShowReportParams) ([]models.NoShowReportRow, error) {
+	var rows []models.NoShowReportRow
+
+	query := r.db.WithContext(ctx).
+		Table("appointments AS a").
+		Select("a.patient_id, u.first_name, u.last_name, COUNT(*) AS no_shows, MAX(a.start_at) AS last_no_show_at").
+		Joins("JOIN users u ON u.id = a.patient_id").
+		Where("a.deleted_at IS NULL AND a.status = ? AND a.start_at >= ? AND a.start_at < ?", models.AppointmentNoShow, params.From, params.To)
+	if params.DoctorID != 0 {
+		query = query.Where("a.doctor_id = ?", params.DoctorID)
+	}
+	if params.ClinicIDs != nil {
+		query = query.Where("a.clinic_id IN ?", params.ClinicIDs)
+	}
+
+	if err := query.
+		Group("a.patient_id, u.first_name, u.last_name").
+		Order("no_shows DESC, last_no_show_at DESC").
+		Scan(&rows).Error; err != nil {
+		r.logger.Error("ошибка при построении отчёта по неявкам", "error", err)
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func (r *gormBookingPolicyRepository) CountFinished(ctx context.Context, params models.NoShowReportParams) (int64, error) {
+	var count int64
+
+	query := r.db.WithContext(ctx).
+		Model(&models.Appointment{}).
+		Where("status IN ? AND start_at >= ? AND start_at < ?",
+			[]string{models.AppointmentCompleted, models.AppointmentNoShow}, params.From, params.To)
+	if params.DoctorID != 0 {
+		query = query.Where("doctor_id = ?", params.DoctorID)
+	}
+	if params.ClinicIDs != nil {
+		query = query.Where("clinic_id IN ?", params.ClinicIDs)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при подсчёте завершённых приёмов", "error", err)
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BundleRepository interface {
+	Create(ctx context.Context, bundle *models.ServiceBundle) error
+
+	// GetByID возвращает комплекс с составом по порядку.
+	GetByID(ctx context.Context, id uint) (*models.ServiceBundle, error)
+
+	// List возвращает комплексы в порядке каталога; activeOnly оставляет
+	// только продающиеся.
+	List(ctx context.Context, activeOnly bool) ([]models.ServiceBundle, error)
+
+	// Update сохраняет комплекс; при replaceItems состав заменяется на
+	// bundle.Items.
+	Update(ctx context.Context, bundle *models.ServiceBundle, replaceItems bool) error
+
+	Delete(ctx context.Context, id uint) error
+
+	CreatePackage(ctx context.Context, pkg *models.PatientPackage) error
+
+	// ListPackages возвращает наборы пациента с посчитанными визитами.
+	ListPackages(ctx context.Context, patientID uint) ([]models.PatientPackage, error)
+
+	// GetPackageTx находит набор с составом и блокирует его до конца
+	// транзакции, чтобы два приёма не списали последний визит.
+	GetPackageTx(tx *gorm.DB, id uint) (*models.PatientPackage, error)
+
+	// UsedVisitsTx считает неотменённые приёмы по услуге из набора.
+	UsedVisitsTx(tx *gorm.DB, packageID, serviceID uint) (int, error)
+
+	UpdatePackageTx(tx *gorm.DB, pkg *models.PatientPackage) error
+
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+}
+
+type gormBundleRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewBundleRepository(db *gorm.DB, logger *slog.Logger) BundleRepository {
+	return &gormBundleRepository{db: db, logger: logger}
+}
+
+func orderedItems(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC, id ASC")
+}
+
+func (r *gormBundleRepository) Create(ctx context.Context, bundle *models.ServiceBundle) error {
+	if err := r.db.WithContext(ctx).Omit("Items.Service").Create(bundle).Error; err != nil {
+		r.logger.Error("ошибка при создании комплекса", "error", err, "name", bundle.Name)
+		return err
+	}
+
+	r.logger.Info("комплекс создан", "bundle_id", bundle.ID, "items", len(bundle.Items))
+	return nil
+}
+
+func (r *gormBundleRepository) GetByID(ctx context.Context, id uint) (*models.ServiceBundle, error) {
+	var bundle models.ServiceBundle
+
+	if err := r.db.WithContext(ctx).
+		Preload("Items", orderedItems).
+		Preload("Items.Service").
+		First(&bundle, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &bundle, nil
+}
+
+func (r *gormBundleRepository) List(ctx context.Context, activeOnly bool) ([]models.ServiceBundle, error) {
+	var bundles []models.ServiceBundle
+
+	query := r.db.WithContext(ctx).
+		Preload("Items", orderedItems).
+		Preload("Items.Service")
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	if err := query.Order("position ASC, name ASC").Find(&bundles).Error; err != nil {
+		r.logger.Error("ошибка при получении комплексов", "error", err)
+		return nil, err
+	}
+
+	return bundles, nil
+}
+
+func (r *gormBundleRepository) Update(ctx context.Context, bundle *models.ServiceBundle, replaceItems bool) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(bundle).Error; err != nil {
+			return err
+		}
+		if !replaceItems {
+			return nil
+		}
+
+		if err := tx.Where("bundle_id = ?", bundle.ID).Delete(&models.ServiceBundleItem{}).Error; err != nil {
+			return err
+		}
+		for i := range bundle.Items {
+			bundle.Items[i].ID = 0
+			bundle.Items[i].BundleID = bundle.ID
+		}
+		return tx.Omit("Service").Create(&bundle.Items).Error
+	})
+	if err != nil {
+		r.logger.Error("ошибка при обновлении комплекса", "error", err, "bundle_id", bundle.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormBundleRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&models.ServiceBundle{}, id).Error; err != nil {
+		r.logger.Error("ошибка при удалении комплекса", "error", err, "bundle_id", id)
+		return err
+	}
+
+	r.logger.Info("комплекс удалён", "bundle_id", id)
+	return nil
+}
+
+func (r *gormBundleRepository) CreatePackage(ctx context.Context, pkg *models.PatientPackage) error {
+	if err := r.db.WithContext(ctx).Omit("Bundle", "Items.Service").Create(pkg).Error; err != nil {
+		r.logger.Error("ошибка при продаже набора визитов", "error", err, "patient_id", pkg.PatientID, "bundle_id", pkg.BundleID)
+		return err
+	}
+
+	r.logger.Info("набор визитов продан", "package_id", pkg.ID, "patient_id", pkg.PatientID, "bundle_id", pkg.BundleID)
+	return nil
+}
+
+func (r *gormBundleRepository) ListPackages(ctx context.Context, patientID uint) ([]models.PatientPackage, error) {
+	var packages []models.PatientPackage
+
+	db := r.db.WithContext(ctx)
+	if err := db.
+		Preload("Bundle").
+		Preload("Items.Service").
+		Where("patient_id = ?", patientID).
+		Order("created_at DESC").
+		Find(&packages).Error; err != nil {
+		r.logger.Error("ошибка при получении наборов пациента", "error", err, "patient_id", patientID)
+		return nil, err
+	}
+	if len(packages) == 0 {
+		return packages, nil
+	}
+
+	ids := make([]uint, len(packages))
+	for i := range packages {
+		ids[i] = packages[i].ID
+	}
+
+	var used []struct {
+		PackageID uint
+		ServiceID uint
+		Used      int
+	}
+	if err := db.Model(&models.Appointment{}).
+		Select("package_id, service_id, COUNT(*) AS used").
+		Where("package_id IN ? AND status <> ?", ids, models.AppointmentCancelled).
+		Group("package_id, service_id").
+		Scan(&used).Error; err != nil {
+		r.logger.Error("ошибка при подсчёте визитов наборов", "error", err, "patient_id", patientID)
+		return nil, err
+	}
+
+	counts := make(map[[2]uint]int, len(used))
+	for _, u := range used {
+		counts[[2]uint{u.PackageID, u.ServiceID}] = u.Used
+	}
+	for i := range packages {
+		for j := range packages[i].Items {
+			item := &packages[i].Items[j]
+			item.Used = counts[[2]uint{item.PackageID, item.ServiceID}]
+		}
+	}
+
+	return packages, nil
+}
+
+func (r *gormBundleRepository) GetPackageTx(tx *gorm.DB, id uint) (*models.PatientPackage, error) {
+	var pkg models.PatientPackage
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Items").
+		First(&pkg, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &pkg, nil
+}
+
+func (r *gormBundleRepository) UsedVisitsTx(tx *gorm.DB, packageID, serviceID uint) (int, error) {
+	var count int64
+
+	if err := tx.Model(&models.Appointment{}).
+		Where("package_id = ? AND service_id = ? AND status <> ?", packageID, serviceID, models.AppointmentCancelled).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+func (r *gormBundleRepository) UpdatePackageTx(tx *gorm.DB, pkg *models.PatientPackage) error {
+	if err := tx.Omit(clause.Associations).Save(pkg).Error; err != nil {
+		r.logger.Error("ошибка при обновлении набора визитов", "error", err, "package_id", pkg.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormBundleRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	if err := r.db.WithContext(ctx).Transaction(fn); err != nil {
+		r.logger.Error("ошибка при выполнении транзакции наборов визитов", "error", err)
+		return err
+	}
+	return nil
+}
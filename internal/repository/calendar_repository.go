@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+)
+
+type CalendarRepository interface {
+	GetByUserID(ctx context.Context, userID uint) (*models.CalendarToken, error)
+
+	GetByToken(ctx context.Context, token string) (*models.CalendarToken, error)
+
+	Save(ctx context.Context, token *models.CalendarToken) error
+}
+
+type gormCalendarRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewCalendarRepository(db *gorm.DB, logger *slog.Logger) CalendarRepository {
+	return &gormCalendarRepository{db: db, logger: logger}
+}
+
+func (r *gormCalendarRepository) GetByUserID(ctx context.Context, userID uint) (*models.CalendarToken, error) {
+	var token models.CalendarToken
+
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&token).Error; err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *gormCalendarRepository) GetByToken(ctx context.Context, value string) (*models.CalendarToken, error) {
+	var token models.CalendarToken
+
+	if err := r.db.WithContext(ctx).Where("token = ?", value).First(&token).Error; err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *gormCalendarRepository) Save(ctx context.Context, token *models.CalendarToken) error {
+	if err := r.db.WithContext(ctx).Save(token).Error; err != nil {
+		r.logger.Error("ошибка при сохранении calendar token", "error", err, "user_id", token.UserID)
+		return err
+	}
+
+	r.logger.Info("calendar token сохранён", "user_id", token.UserID)
+	return nil
+}
@@ -0,0 +1,358 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+)
+
+type ClinicRepository interface {
+	// Create заводит филиал. Первый филиал принимает всё, что появилось до
+	// филиалов: врачей, администраторов без доступа ко всей сети, смены,
+	// записи, кабинеты и оборудование.
+	Create(ctx context.Context, clinic *models.Clinic) error
+
+	// HasClinics сообщает, заведён ли хотя бы один филиал.
+	HasClinics(ctx context.Context) (bool, error)
+
+	GetByID(ctx context.Context, id uint) (*models.Clinic, error)
+
+	List(ctx context.Context, params models.ClinicQueryParams) ([]models.Clinic, error)
+
+	Update(ctx context.Context, clinic *models.Clinic) error
+
+	Delete(ctx context.Context, id uint) error
+
+	SetDoctors(ctx context.Context, clinic *models.Clinic, doctorIDs []uint) error
+
+	SetServices(ctx context.Context, clinic *models.Clinic, serviceIDs []uint) error
+
+	SetStaff(ctx context.Context, clinicID uint, userIDs []uint) error
+
+	// StaffClinicIDs возвращает филиалы, за которыми закреплён сотрудник.
+	StaffClinicIDs(ctx context.Context, userID uint) ([]uint, error)
+
+	// HasAllClinics сообщает, открыт ли администратору доступ ко всей сети.
+	HasAllClinics(ctx context.Context, userID uint) (bool, error)
+
+	// SetAllClinics открывает или закрывает доступ ко всей сети; вернёт
+	// gorm.ErrRecordNotFound, если администратора с таким id нет.
+	SetAllClinics(ctx context.Context, userID uint, allClinics bool) error
+
+	// DoctorClinicIDsByUser возвращает филиалы врача по его пользователю.
+	DoctorClinicIDsByUser(ctx context.Context, userID uint) ([]uint, error)
+
+	DoctorWorksAt(ctx context.Context, doctorID, clinicID uint) (bool, error)
+
+	// AddDoctorTx закрепляет врача за филиалом; повторное закрепление
+	// ничего не меняет.
+	AddDoctorTx(tx *gorm.DB, clinicID, doctorID uint) error
+}
+
+type gormClinicRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewClinicRepository(db *gorm.DB, logger *slog.Logger) ClinicRepository {
+	return &gormClinicRepository{db: db, logger: logger}
+}
+
+func (r *gormClinicRepository) Create(ctx context.Context, clinic *models.Clinic) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Блокировка таблицы не даёт двум первым филиалам поделить данные.
+		if err := tx.Exec("LOCK TABLE clinics IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.Clinic{}).Count(&existing).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Omit("Doctors", "Services").Create(clinic).Error; err != nil {
+			return err
+		}
+
+		if existing > 0 {
+			return nil
+		}
+		return adoptUnassignedTx(tx, clinic.ID)
+	})
+	if err != nil {
+		r.logger.Error("ошибка при создании филиала", "error", err, "name", clinic.Name)
+		return err
+	}
+
+	r.logger.Info("филиал создан", "clinic_id", clinic.ID)
+	return nil
+}
+
+func (r *gormClinicRepository) HasClinics(ctx context.Context) (bool, error) {
+	var count int64
+
+	if err := r.db.WithContext(ctx).Model(&models.Clinic{}).Limit(1).Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при проверке наличия филиалов", "error", err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// adoptUnassignedTx закрепляет за филиалом врачей, администраторов и
+// данные, заведённые до появления филиалов: без этого после первого
+// филиала они пропали бы из области видимости сотрудников.
+func adoptUnassignedTx(tx *gorm.DB, clinicID uint) error {
+	if err := tx.Exec(
+		"INSERT INTO clinic_doctors (clinic_id, doctor_id) SELECT ?, id FROM doctors WHERE deleted_at IS NULL ON CONFLICT DO NOTHING",
+		clinicID,
+	).Error; err != nil {
+		return err
+	}
+
+	var adminIDs []uint
+	if err := tx.Model(&models.User{}).
+		Where("role = ? AND all_clinics = ?", models.Admin, false).
+		Where("NOT EXISTS (SELECT 1 FROM clinic_staff cs WHERE cs.user_id = users.id AND cs.deleted_at IS NULL)").
+		Pluck("id", &adminIDs).Error; err != nil {
+		return err
+	}
+	if len(adminIDs) > 0 {
+		staff := make([]models.ClinicStaff, 0, len(adminIDs))
+		for _, userID := range adminIDs {
+			staff = append(staff, models.ClinicStaff{ClinicID: clinicID, UserID: userID})
+		}
+		if err := tx.Create(&staff).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, model := range []any{&models.Schedule{}, &models.Appointment{}, &models.Room{}, &models.Equipment{}} {
+		if err := tx.Model(model).Where("clinic_id IS NULL").Update("clinic_id", clinicID).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *gormClinicRepository) GetByID(ctx context.Context, id uint) (*models.Clinic, error) {
+	var clinic models.Clinic
+
+	if err := r.db.WithContext(ctx).Preload("Doctors").First(&clinic, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &clinic, nil
+}
+
+func (r *gormClinicRepository) List(ctx context.Context, params models.ClinicQueryParams) ([]models.Clinic, error) {
+	var clinics []models.Clinic
+
+	query := r.db.WithContext(ctx).Model(&models.Clinic{})
+	if params.OnlyActive {
+		query = query.Where("is_active = ?", true)
+	}
+	if params.DoctorID != 0 {
+		query = query.Where("id IN (?)", r.db.Table("clinic_doctors").Select("clinic_id").Where("doctor_id = ?", params.DoctorID))
+	}
+
+	if err := query.Order("name ASC").Find(&clinics).Error; err != nil {
+		r.logger.Error("ошибка при получении филиалов", "error", err)
+		return nil, err
+	}
+
+	return clinics, nil
+}
+
+func (r *gormClinicRepository) Update(ctx context.Context, clinic *models.Clinic) error {
+	if err := r.db.WithContext(ctx).Omit("Doctors", "Services").Save(clinic).Error; err != nil {
+		r.logger.Error("ошибка при обновлении филиала", "error", err, "clinic_id", clinic.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormClinicRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&models.Clinic{}, id).Error; err != nil {
+		r.logger.Error("ошибка при удалении филиала", "error", err, "clinic_id", id)
+		return err
+	}
+
+	r.logger.Info("филиал удалён", "clinic_id", id)
+	return nil
+}
+
+func (r *gormClinicRepository) SetDoctors(ctx context.Context, clinic *models.Clinic, doctorIDs []uint) error {
+	doctors := make([]models.Doctor, 0, len(doctorIDs))
+	if len(doctorIDs) > 0 {
+		if err := r.db.WithContext(ctx).Find(&doctors, doctorIDs).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := r.db.WithContext(ctx).Model(clinic).Association("Doctors").Replace(doctors); err != nil {
+		r.logger.Error("ошибка при обновлении врачей филиала", "error", err, "clinic_id", clinic.ID)
+		return err
+	}
+
+	r.logger.Info("врачи филиала обновлены", "clinic_id", clinic.ID, "count", len(doctors))
+	return nil
+}
+
+func (r *gormClinicRepository) SetServices(ctx context.Context, clinic *models.Clinic, serviceIDs []uint) error {
+	services := make([]models.Service, 0, len(serviceIDs))
+	if len(serviceIDs) > 0 {
+		if err := r.db.WithContext(ctx).Find(&services, serviceIDs).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := r.db.WithContext(ctx).Model(clinic).Association("Services").Replace(services); err != nil {
+		r.logger.Error("ошибка при обновлении услуг филиала", "error", err, "clinic_id", clinic.ID)
+		return err
+	}
+
+	r.logger.Info("услуги филиала обновлены", "clinic_id", clinic.ID, "count", len(services))
+	return nil
+}
+
+func (r *gormClinicRepository) SetStaff(ctx context.Context, clinicID uint, userIDs []uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("clinic_id = ?", clinicID).Delete(&models.ClinicStaff{}).Error; err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+
+		staff := make([]models.ClinicStaff, 0, len(userIDs))
+		for _, userID := range userIDs {
+			staff = append(staff, models.ClinicStaff{ClinicID: clinicID, UserID: userID})
+		}
+		return tx.Create(&staff).Error
+	})
+	if err != nil {
+		r.logger.Error("ошибка при обновлении сотрудников филиала", "error", err, "clinic_id", clinicID)
+		return err
+	}
+
+	r.logger.Info("сотрудники филиала обновлены", "clinic_id", clinicID, "count", len(userIDs))
+	return nil
+}
+
+func (r *gormClinicRepository) StaffClinicIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+
+	if err := r.db.WithContext(ctx).Model(&models.ClinicStaff{}).
+		Where("user_id = ?", userID).
+		Pluck("clinic_id", &ids).Error; err != nil {
+		r.logger.Error("ошибка при получении филиалов сотрудника", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (r *gormClinicRepository) HasAllClinics(ctx context.Context, userID uint) (bool, error) {
+	var user models.User
+
+	if err := r.db.WithContext(ctx).Select("id", "all_clinics").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		r.logger.Error("ошибка при проверке доступа ко всем филиалам", "error", err, "user_id", userID)
+		return false, err
+	}
+
+	return user.AllClinics, nil
+}
+
+func (r *gormClinicRepository) SetAllClinics(ctx context.Context, userID uint, allClinics bool) error {
+	res := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND role = ?", userID, models.Admin).
+		Update("all_clinics", allClinics)
+	if res.Error != nil {
+		r.logger.Error("ошибка при изменении доступа ко всем филиалам", "error", res.Error, "user_id", userID)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	r.logger.Info("доступ ко всем филиалам изменён", "user_id", userID, "all_clinics", allClinics)
+	return nil
+}
+
+func (r *gormClinicRepository) DoctorClinicIDsByUser(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+
+	if err := r.db.WithContext(ctx).Table("clinic_doctors").
+		Joins("JOIN doctors ON doctors.id = clinic_doctors.doctor_id").
+		Where("doctors.user_id = ? AND doctors.deleted_at IS NULL", userID).
+		Pluck("clinic_doctors.clinic_id", &ids).Error; err != nil {
+		r.logger.Error("ошибка при получении филиалов врача", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (r *gormClinicRepository) DoctorWorksAt(ctx context.Context, doctorID, clinicID uint) (bool, error) {
+	var count int64
+
+	if err := r.db.WithContext(ctx).Table("clinic_doctors").
+		Where("doctor_id = ? AND clinic_id = ?", doctorID, clinicID).
+		Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при проверке филиала врача", "error", err, "doctor_id", doctorID, "clinic_id", clinicID)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *gormClinicRepository) AddDoctorTx(tx *gorm.DB, clinicID, doctorID uint) error {
+	if err := tx.Exec(
+		"INSERT INTO clinic_doctors (clinic_id, doctor_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		clinicID, doctorID,
+	).Error; err != nil {
+		r.logger.Error("ошибка при закреплении врача за филиалом", "error", err, "doctor_id", doctorID, "clinic_id", clinicID)
+		return err
+	}
+	return nil
+}
+
+// serviceAvailableAt оставляет услуги, доступные в филиале: услугу
+// оказывает хотя бы один врач филиала, и она либо не ограничена филиалами,
+// либо явно разрешена в этом.
+func serviceAvailableAt(clinicID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("EXISTS (SELECT 1 FROM doctor_services ds JOIN clinic_doctors cd ON cd.doctor_id = ds.doctor_id WHERE ds.service_id = services.id AND cd.clinic_id = ?)", clinicID).
+			Where("(NOT EXISTS (SELECT 1 FROM clinic_services cs WHERE cs.service_id = services.id) OR EXISTS (SELECT 1 FROM clinic_services cs WHERE cs.service_id = services.id AND cs.clinic_id = ?))", clinicID)
+	}
+}
+
+// clinicScope ограничивает выборку филиалом; nil — записи вне филиалов.
+func clinicScope(column string, clinicID *uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if clinicID == nil {
+			return db.Where(column + " IS NULL")
+		}
+		return db.Where(column+" = ?", *clinicID)
+	}
+}
+
+// doctorsAtClinics ограничивает выборку записями врачей, работающих в
+// филиалах clinicIDs; nil — без ограничений.
+func doctorsAtClinics(column string, clinicIDs []uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if clinicIDs == nil {
+			return db
+		}
+		return db.Where(column+" IN (SELECT doctor_id FROM clinic_doctors WHERE clinic_id IN ?)", clinicIDs)
+	}
+}
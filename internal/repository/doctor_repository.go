@@ -2,12 +2,14 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var validate = validator.New()
@@ -15,15 +17,37 @@ var validate = validator.New()
 type DoctorRepository interface {
 	Create(context.Context, *models.Doctor) error
 
+	CreateTx(tx *gorm.DB, doctor *models.Doctor) error
+
 	GetAll(models.DoctorQueryParams, context.Context) ([]models.Doctor, error)
 
+	// ListDirectory возвращает страницу публичного каталога вместе с
+	// учётной записью и специализацией врача. Специализацию по slug
+	// сервис передаёт фильтром specialty_id.
+	ListDirectory(ctx context.Context, q models.ListQuery, params models.DoctorDirectoryParams) (*models.Page[models.Doctor], error)
+
+	// GetProfile возвращает врача вместе с учётной записью и специализацией.
+	GetProfile(ctx context.Context, id uint) (*models.Doctor, error)
+
 	GetByID(uint, context.Context) (*models.Doctor, error)
 
+	GetByUserID(context.Context, uint) (*models.Doctor, error)
+
 	Update(context.Context, *models.Doctor) error
 
 	UpdateAvgRating(context.Context, uint, float64) error
 
+	// LockTx блокирует строку врача до конца транзакции.
+	LockTx(tx *gorm.DB, id uint) error
+
+	UpdateRatingTx(tx *gorm.DB, id uint, avg float64, count int64) error
+
+	// ListIDs возвращает идентификаторы всех врачей.
+	ListIDs(context.Context) ([]uint, error)
+
 	Delete(context.Context, uint) error
+
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
 }
 
 type gormDoctorRepository struct {
@@ -50,6 +74,21 @@ func (r *gormDoctorRepository) Create(ctx context.Context, doctor *models.Doctor
 	return nil
 }
 
+func (r *gormDoctorRepository) CreateTx(tx *gorm.DB, doctor *models.Doctor) error {
+	if doctor == nil {
+		r.logger.Warn("doctor равен nil")
+		return errors.New("doctor is nil")
+	}
+
+	if err := tx.Omit(clause.Associations).Create(doctor).Error; err != nil {
+		r.logger.Error("ошибка при создании doctor", "ошибка", err)
+		return err
+	}
+
+	r.logger.Info("doctor успешно создан", "doctor_id", doctor.ID)
+	return nil
+}
+
 func (r *gormDoctorRepository) GetAll(params models.DoctorQueryParams, ctx context.Context) ([]models.Doctor, error) {
 	r.logger.Debug("Получение всех doctors с параметрами", "params", params)
 	var doctors []models.Doctor
@@ -80,6 +119,10 @@ func (r *gormDoctorRepository) GetAll(params models.DoctorQueryParams, ctx conte
 		}
 	}
 
+	if params.ClinicID != 0 {
+		q = q.Where("id IN (?)", r.DB.Table("clinic_doctors").Select("doctor_id").Where("clinic_id = ?", params.ClinicID))
+	}
+
 	if err := q.Find(&doctors).Error; err != nil {
 		r.logger.Error("ошибка при получении всех doctors", "ошибка", err, "params", params)
 		return nil, err
@@ -90,6 +133,54 @@ func (r *gormDoctorRepository) GetAll(params models.DoctorQueryParams, ctx conte
 	return doctors, nil
 }
 
+var doctorDirectorySpec = listSpec[models.Doctor]{
+	sorts: map[string]sortColumn[models.Doctor]{
+		"rating":     {column: "avg_rating", value: func(d *models.Doctor) any { return d.AvgRating }},
+		"experience": {column: "experience_years", value: func(d *models.Doctor) any { return d.ExperienceYears }},
+		"created_at": {column: "created_at", value: func(d *models.Doctor) any { return d.CreatedAt }},
+	},
+	filters: map[string]filterColumn{
+		"specialty_id": {column: "specialty_id", kind: filterUint},
+	},
+	defaultSort: []models.SortField{{Field: "rating", Desc: true}},
+	id:          func(d *models.Doctor) uint { return d.ID },
+	preloads:    []string{"User", "Specialty"},
+}
+
+func (r *gormDoctorRepository) ListDirectory(ctx context.Context, q models.ListQuery, params models.DoctorDirectoryParams) (*models.Page[models.Doctor], error) {
+	r.logger.Debug("получение каталога doctors", "params", params)
+
+	query := r.DB.WithContext(ctx).Model(&models.Doctor{})
+	if params.Language != "" {
+		language, err := json.Marshal([]string{params.Language})
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("languages::jsonb @> ?::jsonb", string(language))
+	}
+	if params.ClinicID != 0 {
+		query = query.Where("id IN (?)", r.DB.Table("clinic_doctors").Select("doctor_id").Where("clinic_id = ?", params.ClinicID))
+	}
+
+	page, err := paginate(query, q, doctorDirectorySpec)
+	if err != nil {
+		r.logger.Error("ошибка при получении каталога doctors", "ошибка", err)
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (r *gormDoctorRepository) GetProfile(ctx context.Context, id uint) (*models.Doctor, error) {
+	var doctor models.Doctor
+
+	if err := r.DB.WithContext(ctx).Preload("User").Preload("Specialty").First(&doctor, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &doctor, nil
+}
+
 func (r *gormDoctorRepository) GetByID(id uint, ctx context.Context) (*models.Doctor, error) {
 
 	r.logger.Debug("Получение doctor по ID", "doctor_id", id)
@@ -105,13 +196,24 @@ func (r *gormDoctorRepository) GetByID(id uint, ctx context.Context) (*models.Do
 	return &doctor, nil
 }
 
+func (r *gormDoctorRepository) GetByUserID(ctx context.Context, userID uint) (*models.Doctor, error) {
+	var doctor models.Doctor
+
+	if err := r.DB.WithContext(ctx).Where("user_id = ?", userID).First(&doctor).Error; err != nil {
+		r.logger.Error("ошибка при получении doctor по user_id", "ошибка", err, "user_id", userID)
+		return nil, err
+	}
+
+	return &doctor, nil
+}
+
 func (r *gormDoctorRepository) Update(ctx context.Context, doctor *models.Doctor) error {
 	if doctor == nil {
 		r.logger.Warn("doctor равен nil")
 		return errors.New("doctor is nil")
 	}
 
-	if err := r.DB.WithContext(ctx).Save(doctor).Error; err != nil {
+	if err := r.DB.WithContext(ctx).Omit(clause.Associations).Save(doctor).Error; err != nil {
 		r.logger.Error("ошибка при обновлении doctor", "ошибка", err, "doctor_id", doctor.ID)
 		return err
 	}
@@ -135,6 +237,38 @@ func (r *gormDoctorRepository) UpdateAvgRating(ctx context.Context, id uint, avg
 	return nil
 }
 
+func (r *gormDoctorRepository) LockTx(tx *gorm.DB, id uint) error {
+	var doctor models.Doctor
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&doctor, id).Error; err != nil {
+		r.logger.Error("ошибка при блокировке doctor", "error", err, "doctor_id", id)
+		return err
+	}
+	return nil
+}
+
+func (r *gormDoctorRepository) UpdateRatingTx(tx *gorm.DB, id uint, avg float64, count int64) error {
+	if err := tx.Model(&models.Doctor{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"avg_rating": avg, "review_count": count}).Error; err != nil {
+		r.logger.Error("ошибка при обновлении рейтинга doctor", "error", err, "doctor_id", id)
+		return err
+	}
+
+	r.logger.Debug("рейтинг doctor обновлён", "doctor_id", id, "avg_rating", avg, "review_count", count)
+	return nil
+}
+
+func (r *gormDoctorRepository) ListIDs(ctx context.Context) ([]uint, error) {
+	var ids []uint
+
+	if err := r.DB.WithContext(ctx).Model(&models.Doctor{}).Order("id").Pluck("id", &ids).Error; err != nil {
+		r.logger.Error("ошибка при получении списка doctor", "error", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
 func (r *gormDoctorRepository) Delete(ctx context.Context, id uint) error {
 	r.logger.Debug("Удаление doctor по ID", "doctor_id", id)
 	if err := r.DB.WithContext(ctx).Delete(&models.Doctor{}, id).Error; err != nil {
@@ -144,3 +278,11 @@ func (r *gormDoctorRepository) Delete(ctx context.Context, id uint) error {
 	r.logger.Info("успешное удаление doctor", "doctor_id", id)
 	return nil
 }
+
+func (r *gormDoctorRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	if err := r.DB.WithContext(ctx).Transaction(fn); err != nil {
+		r.logger.Error("ошибка при выполнении транзакции doctor", "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InsuranceRepository interface {
+	CreateInsurer(ctx context.Context, insurer *models.Insurer) error
+
+	GetInsurerByID(ctx context.Context, id uint) (*models.Insurer, error)
+
+	ListInsurers(ctx context.Context) ([]models.Insurer, error)
+
+	UpdateInsurer(ctx context.Context, insurer *models.Insurer) error
+
+	UpsertRule(ctx context.Context, rule *models.CoverageRule) error
+
+	DeleteRule(ctx context.Context, insurerID uint, category string) error
+
+	GetRule(ctx context.Context, insurerID uint, category string) (*models.CoverageRule, error)
+
+	CreatePolicy(ctx context.Context, policy *models.InsurancePolicy) error
+
+	GetPolicyByID(ctx context.Context, id uint) (*models.InsurancePolicy, error)
+
+	ListPoliciesByPatientID(ctx context.Context, patientID uint) ([]models.InsurancePolicy, error)
+
+	GetActivePolicy(ctx context.Context, patientID uint, at time.Time) (*models.InsurancePolicy, error)
+
+	UpdatePolicy(ctx context.Context, policy *models.InsurancePolicy) error
+
+	CreateClaim(ctx context.Context, claim *models.InsuranceClaim) error
+
+	GetClaimByID(ctx context.Context, id uint) (*models.InsuranceClaim, error)
+
+	ListClaims(ctx context.Context, params models.ClaimQueryParams) ([]models.InsuranceClaim, error)
+
+	UpdateClaim(ctx context.Context, claim *models.InsuranceClaim) error
+
+	SetClaimsBatch(ctx context.Context, ids []uint, batchID string) error
+
+	// UsedCoverage — сумма, уже покрытая страховщиком по полису и категории за
+	// календарный год (отклонённые заявки не учитываются).
+	UsedCoverage(ctx context.Context, policyID uint, category string, year int) (float64, error)
+}
+
+type gormInsuranceRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewInsuranceRepository(db *gorm.DB, logger *slog.Logger) InsuranceRepository {
+	return &gormInsuranceRepository{db: db, logger: logger}
+}
+
+func (r *gormInsuranceRepository) CreateInsurer(ctx context.Context, insurer *models.Insurer) error {
+	if err := r.db.WithContext(ctx).Create(insurer).Error; err != nil {
+		r.logger.Error("ошибка при создании insurer", "error", err, "code", insurer.Code)
+		return err
+	}
+
+	r.logger.Info("insurer создан", "insurer_id", insurer.ID, "code", insurer.Code)
+	return nil
+}
+
+func (r *gormInsuranceRepository) GetInsurerByID(ctx context.Context, id uint) (*models.Insurer, error) {
+	var insurer models.Insurer
+
+	if err := r.db.WithContext(ctx).Preload("Rules").First(&insurer, id).Error; err != nil {
+		r.logger.Error("ошибка при получении insurer по ID", "error", err, "insurer_id", id)
+		return nil, err
+	}
+
+	return &insurer, nil
+}
+
+func (r *gormInsuranceRepository) ListInsurers(ctx context.Context) ([]models.Insurer, error) {
+	var insurers []models.Insurer
+
+	if err := r.db.WithContext(ctx).Preload("Rules").Order("name ASC").Find(&insurers).Error; err != nil {
+		r.logger.Error("ошибка при получении списка insurers", "error", err)
+		return nil, err
+	}
+
+	return insurers, nil
+}
+
+func (r *gormInsuranceRepository) UpdateInsurer(ctx context.Context, insurer *models.Insurer) error {
+	if err := r.db.WithContext(ctx).Omit("Rules").Save(insurer).Error; err != nil {
+		r.logger.Error("ошибка при обновлении insurer", "error", err, "insurer_id", insurer.ID)
+		return err
+	}
+
+	r.logger.Info("insurer обновлён", "insurer_id", insurer.ID)
+	return nil
+}
+
+func (r *gormInsuranceRepository) UpsertRule(ctx context.Context, rule *models.CoverageRule) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "insurer_id"}, {Name: "category"}},
+		DoUpdates: clause.AssignmentColumns([]string{"coverage_percent", "annual_limit", "co_pay", "updated_at", "deleted_at"}),
+	}).Create(rule).Error
+	if err != nil {
+		r.logger.Error("ошибка при сохранении coverage rule", "error", err, "insurer_id", rule.InsurerID, "category", rule.Category)
+		return err
+	}
+
+	r.logger.Info("coverage rule сохранено", "insurer_id", rule.InsurerID, "category", rule.Category)
+	return nil
+}
+
+func (r *gormInsuranceRepository) DeleteRule(ctx context.Context, insurerID uint, category string) error {
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("insurer_id = ? AND category = ?", insurerID, category).
+		Delete(&models.CoverageRule{}).Error; err != nil {
+		r.logger.Error("ошибка при удалении coverage rule", "error", err, "insurer_id", insurerID, "category", category)
+		return err
+	}
+	return nil
+}
+
+func (r *gormInsuranceRepository) GetRule(ctx context.Context, insurerID uint, category string) (*models.CoverageRule, error) {
+	var rule models.CoverageRule
+
+	if err := r.db.WithContext(ctx).
+		Where("insurer_id = ? AND category = ?", insurerID, category).
+		First(&rule).Error; err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func (r *gormInsuranceRepository) CreatePolicy(ctx context.Context, policy *models.InsurancePolicy) error {
+	if err := r.db.WithContext(ctx).Create(policy).Error; err != nil {
+		r.logger.Error("ошибка при создании policy", "error", err, "patient_id", policy.PatientID)
+		return err
+	}
+
+	r.logger.Info("policy создан", "policy_id", policy.ID, "patient_id", policy.PatientID)
+	return nil
+}
+
+func (r *gormInsuranceRepository) GetPolicyByID(ctx context.Context, id uint) (*models.InsurancePolicy, error) {
+	var policy models.InsurancePolicy
+
+	if err := r.db.WithContext(ctx).Preload("Insurer").First(&policy, id).Error; err != nil {
+		r.logger.Error("ошибка при получении policy по ID", "error", err, "policy_id", id)
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+func (r *gormInsuranceRepository) ListPoliciesByPatientID(ctx context.Context, patientID uint) ([]models.InsurancePolicy, error) {
+	var policies []models.InsurancePolicy
+
+	if err := r.db.WithContext(ctx).
+		Preload("Insurer").
+		Where("patient_id = ?", patientID).
+		Order("valid_to DESC").
+		Find(&policies).Error; err != nil {
+		r.logger.Error("ошибка при получении policies пациента", "error", err, "patient_id", patientID)
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+func (r *gormInsuranceRepository) GetActivePolicy(ctx context.Context, patientID uint, at time.Time) (*models.InsurancePolicy, error) {
+	var policy models.InsurancePolicy
+
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN insurers ON insurers.id = insurance_policies.insurer_id AND insurers.is_active = ? AND insurers.deleted_at IS NULL", true).
+		Where("insurance_policies.patient_id = ? AND insurance_policies.is_active = ?", patientID, true).
+		Where("insurance_policies.valid_from <= ? AND insurance_policies.valid_to >= ?", at, at).
+		Order("insurance_policies.valid_to DESC").
+		First(&policy).Error; err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+func (r *gormInsuranceRepository) UpdatePolicy(ctx context.Context, policy *models.InsurancePolicy) error {
+	if err := r.db.WithContext(ctx).Omit("Insurer").Save(policy).Error; err != nil {
+		r.logger.Error("ошибка при обновлении policy", "error", err, "policy_id", policy.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormInsuranceRepository) CreateClaim(ctx context.Context, claim *models.InsuranceClaim) error {
+	if err := r.db.WithContext(ctx).Create(claim).Error; err != nil {
+		r.logger.Error("ошибка при создании claim", "error", err, "appointment_id", claim.AppointmentID)
+		return err
+	}
+
+	r.logger.Info("claim создан", "claim_id", claim.ID, "appointment_id", claim.AppointmentID)
+	return nil
+}
+
+func (r *gormInsuranceRepository) GetClaimByID(ctx context.Context, id uint) (*models.InsuranceClaim, error) {
+	var claim models.InsuranceClaim
+
+	if err := r.db.WithContext(ctx).First(&claim, id).Error; err != nil {
+		r.logger.Error("ошибка при получении claim по ID", "error", err, "claim_id", id)
+		return nil, err
+	}
+
+	return &claim, nil
+}
+
+func (r *gormInsuranceRepository) ListClaims(ctx context.Context, params models.ClaimQueryParams) ([]models.InsuranceClaim, error) {
+	var claims []models.InsuranceClaim
+
+	q := r.db.WithContext(ctx).Model(&models.InsuranceClaim{})
+
+	if params.InsurerID != 0 {
+		q = q.Where("insurer_id = ?", params.InsurerID)
+	}
+	if params.PatientID != 0 {
+		q = q.Where("patient_id = ?", params.PatientID)
+	}
+	if params.Status != "" {
+		q = q.Where("status = ?", params.Status)
+	}
+
+	if err := q.Order("submitted_at ASC").Find(&claims).Error; err != nil {
+		r.logger.Error("ошибка при получении списка claims", "error", err)
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (r *gormInsuranceRepository) UpdateClaim(ctx context.Context, claim *models.InsuranceClaim) error {
+	if err := r.db.WithContext(ctx).Save(claim).Error; err != nil {
+		r.logger.Error("ошибка при обновлении claim", "error", err, "claim_id", claim.ID)
+		return err
+	}
+
+	r.logger.Info("claim обновлён", "claim_id", claim.ID, "status", claim.Status)
+	return nil
+}
+
+func (r *gormInsuranceRepository) SetClaimsBatch(ctx context.Context, ids []uint, batchID string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := r.db.WithContext(ctx).Model(&models.InsuranceClaim{}).
+		Where("id IN ?", ids).
+		Update("batch_id", batchID).Error; err != nil {
+		r.logger.Error("ошибка при проставлении batch_id", "error", err, "batch_id", batchID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormInsuranceRepository) UsedCoverage(ctx context.Context, policyID uint, category string, year int) (float64, error) {
+	var sum sql.NullFloat64
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+
+	if err := r.db.WithContext(ctx).Model(&models.InsuranceClaim{}).
+		Select("SUM(insurer_amount)").
+		Where("policy_id = ? AND category = ? AND status <> ?", policyID, category, models.ClaimRejected).
+		Where("service_date >= ? AND service_date < ?", from, to).
+		Scan(&sum).Error; err != nil {
+		r.logger.Error("ошибка при подсчёте использованного лимита", "error", err, "policy_id", policyID)
+		return 0, err
+	}
+
+	if !sum.Valid {
+		return 0, nil
+	}
+	return sum.Float64, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvitationRepository interface {
+	CreateTx(tx *gorm.DB, invitation *models.DoctorInvitation) error
+
+	// RevokePendingTx удаляет непринятые приглашения пользователя, чтобы
+	// действовала только последняя ссылка.
+	RevokePendingTx(tx *gorm.DB, userID uint) error
+
+	// GetByTokenHashTx находит приглашение и блокирует его до конца
+	// транзакции.
+	GetByTokenHashTx(tx *gorm.DB, tokenHash string) (*models.DoctorInvitation, error)
+
+	// LatestTx возвращает последнее приглашение пользователя или nil.
+	LatestTx(tx *gorm.DB, userID uint) (*models.DoctorInvitation, error)
+
+	UpdateTx(tx *gorm.DB, invitation *models.DoctorInvitation) error
+
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+}
+
+type gormInvitationRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewInvitationRepository(db *gorm.DB, logger *slog.Logger) InvitationRepository {
+	return &gormInvitationRepository{db: db, logger: logger}
+}
+
+func (r *gormInvitationRepository) CreateTx(tx *gorm.DB, invitation *models.DoctorInvitation) error {
+	if err := tx.Create(invitation).Error; err != nil {
+		r.logger.Error("ошибка при создании приглашения", "error", err, "user_id", invitation.UserID)
+		return err
+	}
+
+	r.logger.Info("приглашение создано", "invitation_id", invitation.ID, "user_id", invitation.UserID)
+	return nil
+}
+
+func (r *gormInvitationRepository) RevokePendingTx(tx *gorm.DB, userID uint) error {
+	if err := tx.Where("user_id = ? AND accepted_at IS NULL", userID).
+		Delete(&models.DoctorInvitation{}).Error; err != nil {
+		r.logger.Error("ошибка при отзыве приглашений", "error", err, "user_id", userID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormInvitationRepository) GetByTokenHashTx(tx *gorm.DB, tokenHash string) (*models.DoctorInvitation, error) {
+	var invitation models.DoctorInvitation
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&invitation).Error; err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+func (r *gormInvitationRepository) LatestTx(tx *gorm.DB, userID uint) (*models.DoctorInvitation, error) {
+	var invitation models.DoctorInvitation
+
+	err := tx.Where("user_id = ?", userID).Order("id DESC").First(&invitation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+func (r *gormInvitationRepository) UpdateTx(tx *gorm.DB, invitation *models.DoctorInvitation) error {
+	if err := tx.Save(invitation).Error; err != nil {
+		r.logger.Error("ошибка при обновлении приглашения", "error", err, "invitation_id", invitation.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormInvitationRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	if err := r.db.WithContext(ctx).Transaction(fn); err != nil {
+		r.logger.Error("ошибка при выполнении транзакции приглашения", "error", err)
+		return err
+	}
+	return nil
+}
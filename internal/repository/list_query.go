@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+type filterKind int
+
+const (
+	filterString filterKind = iota
+	filterUint
+	filterInt
+	filterBool
+	// filterStrings — одно или несколько значений через запятую (IN).
+	filterStrings
+)
+
+type filterColumn struct {
+	column string
+	kind   filterKind
+}
+
+type sortColumn[T any] struct {
+	column string
+	// value достаёт из строки значение колонки для курсора.
+	value func(*T) any
+}
+
+// listSpec описывает, как ListQuery переводится в SQL для модели T: какие
+// поля можно сортировать и фильтровать и по какой колонке идут from/to.
+type listSpec[T any] struct {
+	sorts       map[string]sortColumn[T]
+	filters     map[string]filterColumn
+	dateColumn  string
+	defaultSort []models.SortField
+	id          func(*T) uint
+	// preloads подгружаются только для строк страницы, не для счётчика.
+	preloads []string
+}
+
+// listCursor — позиция последней строки страницы: значения полей
+// сортировки и id. Sort защищает от курсора, выданного для другой
+// сортировки.
+type listCursor struct {
+	Sort   string `json:"s"`
+	Values []any  `json:"v"`
+}
+
+// paginate выполняет запрос списка по спецификации: фильтры и диапазон
+// дат, общий счётчик, сортировку с id для однозначности и keyset-курсор.
+// db должен быть уже ограничен моделью и дополнительными условиями.
+func paginate[T any](db *gorm.DB, q models.ListQuery, spec listSpec[T]) (*models.Page[T], error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	sort := q.Sort
+	if len(sort) == 0 {
+		sort = spec.defaultSort
+	}
+	columns := make([]sortColumn[T], 0, len(sort)+1)
+	desc := make([]bool, 0, len(sort)+1)
+	keys := make([]string, 0, len(sort))
+	for _, f := range sort {
+		col, ok := spec.sorts[f.Field]
+		if !ok {
+			return nil, fmt.Errorf("%w: сортировка по полю %q не поддерживается", constants.ErrInvalidListQuery, f.Field)
+		}
+		columns = append(columns, col)
+		desc = append(desc, f.Desc)
+		if f.Desc {
+			keys = append(keys, "-"+f.Field)
+		} else {
+			keys = append(keys, f.Field)
+		}
+	}
+	columns = append(columns, sortColumn[T]{column: "id", value: func(row *T) any { return spec.id(row) }})
+	desc = append(desc, false)
+	sortKey := strings.Join(keys, ",")
+
+	query, err := applyListFilters(db, q, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	page := query.Session(&gorm.Session{})
+	if q.Cursor != "" {
+		values, err := decodeListCursor(q.Cursor, sortKey, len(columns))
+		if err != nil {
+			return nil, err
+		}
+		where, args := keysetCondition(columns, desc, values)
+		page = page.Where(where, args...)
+	}
+	for _, name := range spec.preloads {
+		page = page.Preload(name)
+	}
+	for i, col := range columns {
+		if desc[i] {
+			page = page.Order(col.column + " DESC")
+		} else {
+			page = page.Order(col.column + " ASC")
+		}
+	}
+
+	var items []T
+	if err := page.Limit(limit + 1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	result := &models.Page[T]{Items: items, Total: total}
+	if len(items) > limit {
+		result.Items = items[:limit]
+		last := &result.Items[limit-1]
+
+		values := make([]any, len(columns))
+		for i, col := range columns {
+			values[i] = col.value(last)
+		}
+		cursor, err := json.Marshal(listCursor{Sort: sortKey, Values: values})
+		if err != nil {
+			return nil, err
+		}
+		result.NextCursor = base64.RawURLEncoding.EncodeToString(cursor)
+	}
+	if result.Items == nil {
+		result.Items = []T{}
+	}
+
+	return result, nil
+}
+
+// applyListFilters добавляет к запросу фильтры и диапазон дат из ListQuery,
+// проверяя их по спецификации.
+func applyListFilters[T any](db *gorm.DB, q models.ListQuery, spec listSpec[T]) (*gorm.DB, error) {
+	query := db
+	for name, value := range q.Filters {
+		filter, ok := spec.filters[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: фильтр %q не поддерживается", constants.ErrInvalidListQuery, name)
+		}
+		parsed, err := parseFilterValue(filter.kind, value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: некорректное значение фильтра %q", constants.ErrInvalidListQuery, name)
+		}
+		if filter.kind == filterStrings {
+			query = query.Where(filter.column+" IN ?", parsed)
+		} else {
+			query = query.Where(filter.column+" = ?", parsed)
+		}
+	}
+	if q.From != nil || q.To != nil {
+		if spec.dateColumn == "" {
+			return nil, fmt.Errorf("%w: список не поддерживает from и to", constants.ErrInvalidListQuery)
+		}
+		if q.From != nil {
+			query = query.Where(spec.dateColumn+" >= ?", *q.From)
+		}
+		if q.To != nil {
+			query = query.Where(spec.dateColumn+" < ?", *q.To)
+		}
+	}
+
+	return query, nil
+}
+
+// keysetCondition строит условие «строка после курсора» для сортировки по
+// нескольким колонкам с разными направлениями:
+// (a > ?) OR (a = ? AND b < ?) OR ...
+func keysetCondition[T any](columns []sortColumn[T], desc []bool, values []any) (string, []any) {
+	var (
+		parts []string
+		args  []any
+	)
+
+	for i := range columns {
+		var conds []string
+		for j := 0; j < i; j++ {
+			conds = append(conds, columns[j].column+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if desc[i] {
+			op = " < ?"
+		}
+		conds = append(conds, columns[i].column+op)
+		args = append(args, values[i])
+
+		parts = append(parts, "("+strings.Join(conds, " AND ")+")")
+	}
+
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+func decodeListCursor(raw, sortKey string, size int) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: некорректный курсор", constants.ErrInvalidListQuery)
+	}
+
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || len(cursor.Values) != size {
+		return nil, fmt.Errorf("%w: некорректный курсор", constants.ErrInvalidListQuery)
+	}
+	if cursor.Sort != sortKey {
+		return nil, fmt.Errorf("%w: курсор выдан для другой сортировки", constants.ErrInvalidListQuery)
+	}
+
+	return cursor.Values, nil
+}
+
+func parseFilterValue(kind filterKind, value string) (any, error) {
+	switch kind {
+	case filterUint:
+		return strconv.ParseUint(value, 10, 64)
+	case filterInt:
+		return strconv.Atoi(value)
+	case filterBool:
+		return strconv.ParseBool(value)
+	case filterStrings:
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			return nil, errors.New("пустой список значений")
+		}
+		return values, nil
+	default:
+		return value, nil
+	}
+}
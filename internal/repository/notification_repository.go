@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	// CreateIfAbsent создаёт уведомление, если такого (appointment, kind,
+	// offset, channel) ещё нет. Возвращает true, если запись была создана.
+	CreateIfAbsent(ctx context.Context, notification *models.Notification) (bool, error)
+
+	Update(ctx context.Context, notification *models.Notification) error
+
+	List(ctx context.Context, params models.NotificationQueryParams) ([]models.Notification, error)
+
+	ListRetryable(ctx context.Context, maxAttempts int) ([]models.Notification, error)
+
+	GetPreference(ctx context.Context, userID uint) (*models.NotificationPreference, error)
+
+	SavePreference(ctx context.Context, pref *models.NotificationPreference) error
+}
+
+type gormNotificationRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewNotificationRepository(db *gorm.DB, logger *slog.Logger) NotificationRepository {
+	return &gormNotificationRepository{db: db, logger: logger}
+}
+
+func (r *gormNotificationRepository) CreateIfAbsent(ctx context.Context, notification *models.Notification) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(notification)
+	if res.Error != nil {
+		r.logger.Error("ошибка при создании notification", "error", res.Error, "appointment_id", notification.AppointmentID)
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+func (r *gormNotificationRepository) Update(ctx context.Context, notification *models.Notification) error {
+	if err := r.db.WithContext(ctx).Save(notification).Error; err != nil {
+		r.logger.Error("ошибка при обновлении notification", "error", err, "notification_id", notification.ID)
+		return err
+	}
+
+	r.logger.Debug("notification обновлено", "notification_id", notification.ID, "status", notification.Status)
+	return nil
+}
+
+func (r *gormNotificationRepository) List(ctx context.Context, params models.NotificationQueryParams) ([]models.Notification, error) {
+	var notifications []models.Notification
+
+	query := r.db.WithContext(ctx).Model(&models.Notification{})
+
+	if params.UserID != 0 {
+		query = query.Where("user_id = ?", params.UserID)
+	}
+	if params.AppointmentID != 0 {
+		query = query.Where("appointment_id = ?", params.AppointmentID)
+	}
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+
+	if err := query.Order("created_at DESC").Find(&notifications).Error; err != nil {
+		r.logger.Error("ошибка при получении notifications", "error", err)
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (r *gormNotificationRepository) ListRetryable(ctx context.Context, maxAttempts int) ([]models.Notification, error) {
+	var notifications []models.Notification
+
+	if err := r.db.WithContext(ctx).
+		Where("status IN ? AND attempts < ?", []models.NotificationStatus{models.NotificationPending, models.NotificationFailed}, maxAttempts).
+		Order("created_at ASC").
+		Find(&notifications).Error; err != nil {
+		r.logger.Error("ошибка при получении notifications для повторной отправки", "error", err)
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (r *gormNotificationRepository) GetPreference(ctx context.Context, userID uint) (*models.NotificationPreference, error) {
+	var pref models.NotificationPreference
+
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&pref).Error; err != nil {
+		return nil, err
+	}
+
+	return &pref, nil
+}
+
+func (r *gormNotificationRepository) SavePreference(ctx context.Context, pref *models.NotificationPreference) error {
+	if err := r.db.WithContext(ctx).Save(pref).Error; err != nil {
+		r.logger.Error("ошибка при сохранении настроек уведомлений", "error", err, "user_id", pref.UserID)
+		return err
+	}
+
+	r.logger.Info("настройки уведомлений сохранены", "user_id", pref.UserID)
+	return nil
+}
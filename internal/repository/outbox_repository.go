@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	// AddTx записывает событие в рамках транзакции изменения.
+	AddTx(tx *gorm.DB, event *models.OutboxEvent) error
+
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error)
+
+	Update(ctx context.Context, event *models.OutboxEvent) error
+
+	IsConsumed(ctx context.Context, eventID, handler string) (bool, error)
+
+	MarkConsumed(ctx context.Context, eventID, handler string) error
+}
+
+type gormOutboxRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewOutboxRepository(db *gorm.DB, logger *slog.Logger) OutboxRepository {
+	return &gormOutboxRepository{db: db, logger: logger}
+}
+
+func (r *gormOutboxRepository) AddTx(tx *gorm.DB, event *models.OutboxEvent) error {
+	if err := tx.Create(event).Error; err != nil {
+		r.logger.Error("ошибка при записи события в outbox", "error", err, "type", event.Type, "aggregate_id", event.AggregateID)
+		return err
+	}
+
+	r.logger.Debug("событие записано в outbox", "event_id", event.EventID, "type", event.Type)
+	return nil
+}
+
+func (r *gormOutboxRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.OutboxPending, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		r.logger.Error("ошибка при получении событий outbox", "error", err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *gormOutboxRepository) Update(ctx context.Context, event *models.OutboxEvent) error {
+	if err := r.db.WithContext(ctx).Save(event).Error; err != nil {
+		r.logger.Error("ошибка при обновлении события outbox", "error", err, "event_id", event.EventID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormOutboxRepository) IsConsumed(ctx context.Context, eventID, handler string) (bool, error) {
+	var count int64
+
+	if err := r.db.WithContext(ctx).
+		Model(&models.OutboxConsumption{}).
+		Where("event_id = ? AND handler = ?", eventID, handler).
+		Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при проверке обработки события", "error", err, "event_id", eventID, "handler", handler)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *gormOutboxRepository) MarkConsumed(ctx context.Context, eventID, handler string) error {
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.OutboxConsumption{EventID: eventID, Handler: handler}).Error; err != nil {
+		r.logger.Error("ошибка при отметке обработки события", "error", err, "event_id", eventID, "handler", handler)
+		return err
+	}
+	return nil
+}
@@ -10,14 +10,21 @@ import (
 
 type PatientRecordRepo interface {
 	Create(*models.PatientRecord) error
-	GetID(uint) (*models.PatientRecord, error)
-	Get() ([]models.PatientRecord, error)
+	// GetID и List при clinicIDs != nil видят только записи врачей этих
+	// филиалов.
+	GetID(id uint, clinicIDs []uint) (*models.PatientRecord, error)
+	List(q models.ListQuery, clinicIDs []uint) (*models.Page[models.PatientRecord], error)
+	// DoctorInClinics сообщает, работает ли врач в одном из филиалов;
+	// при clinicIDs == nil — всегда да.
+	DoctorInClinics(doctorID uint, clinicIDs []uint) (bool, error)
 	Update(*models.PatientRecord) error
+	UpdateTx(*gorm.DB, *models.PatientRecord) error
+	Transaction(func(tx *gorm.DB) error) error
 	Delete(uint) error
 }
 
 type gormPatientRecordRepo struct {
-	DB *gorm.DB
+	DB     *gorm.DB
 	logger *slog.Logger
 }
 
@@ -40,11 +47,11 @@ func (r *gormPatientRecordRepo) Create(patientRecord *models.PatientRecord) erro
 	return nil
 }
 
-func (r *gormPatientRecordRepo) GetID(ID uint) (*models.PatientRecord, error) {
+func (r *gormPatientRecordRepo) GetID(ID uint, clinicIDs []uint) (*models.PatientRecord, error) {
 	r.logger.Debug("получение patientRecord благодаря ID", "patientRecord_id", ID)
 	var patientRecord models.PatientRecord
 
-	if err := r.DB.Preload("patient").First(&patientRecord, ID).Error; err != nil {
+	if err := r.DB.Scopes(doctorsAtClinics("doctor_id", clinicIDs)).Preload("patient").First(&patientRecord, ID).Error; err != nil {
 		r.logger.Error("ошибка при получении patientRecord по ID", "ошибка", err, "patientRecord_id", ID)
 		return nil, err
 	}
@@ -54,17 +61,47 @@ func (r *gormPatientRecordRepo) GetID(ID uint) (*models.PatientRecord, error) {
 	return &patientRecord, nil
 }
 
-func (r *gormPatientRecordRepo) Get() ([]models.PatientRecord, error) {
-	r.logger.Warn("Получение всех patient_records")
-	var patientRecord []models.PatientRecord
+var patientRecordListSpec = listSpec[models.PatientRecord]{
+	sorts: map[string]sortColumn[models.PatientRecord]{
+		"created_at": {column: "created_at", value: func(p *models.PatientRecord) any { return p.CreatedAt }},
+	},
+	filters: map[string]filterColumn{
+		"patient_id": {column: "patient_id", kind: filterUint},
+		"doctor_id":  {column: "doctor_id", kind: filterUint},
+	},
+	dateColumn:  "created_at",
+	defaultSort: []models.SortField{{Field: "created_at", Desc: true}},
+	id:          func(p *models.PatientRecord) uint { return p.ID },
+}
+
+func (r *gormPatientRecordRepo) List(q models.ListQuery, clinicIDs []uint) (*models.Page[models.PatientRecord], error) {
+	r.logger.Debug("получение списка patient_records")
 
-	if err := r.DB.Preload("patient").Find(&patientRecord).Error; err != nil {
-		r.logger.Error("ошибка при получении всех patient_records", "ошибка", err)
+	query := r.DB.Model(&models.PatientRecord{}).Scopes(doctorsAtClinics("doctor_id", clinicIDs)).Preload("Patient")
+	page, err := paginate(query, q, patientRecordListSpec)
+	if err != nil {
+		r.logger.Error("ошибка при получении списка patient_records", "ошибка", err)
 		return nil, err
 	}
 
-	r.logger.Info("успешное получение всех patient_records")
-	return patientRecord, nil
+	r.logger.Info("успешное получение списка patient_records", "count", len(page.Items), "total", page.Total)
+	return page, nil
+}
+
+func (r *gormPatientRecordRepo) DoctorInClinics(doctorID uint, clinicIDs []uint) (bool, error) {
+	if clinicIDs == nil {
+		return true, nil
+	}
+
+	var count int64
+	if err := r.DB.Table("clinic_doctors").
+		Where("doctor_id = ? AND clinic_id IN ?", doctorID, clinicIDs).
+		Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при проверке филиала врача", "ошибка", err, "doctor_id", doctorID)
+		return false, err
+	}
+
+	return count > 0, nil
 }
 
 func (r *gormPatientRecordRepo) Update(patientRecord *models.PatientRecord) error {
@@ -84,10 +121,33 @@ func (r *gormPatientRecordRepo) Update(patientRecord *models.PatientRecord) erro
 	return nil
 }
 
+func (r *gormPatientRecordRepo) UpdateTx(tx *gorm.DB, patientRecord *models.PatientRecord) error {
+	if patientRecord == nil {
+		r.logger.Warn("patientRecord равен nil")
+		return constants.PatientRecord_IS_nil
+	}
+
+	if err := tx.Save(patientRecord).Error; err != nil {
+		r.logger.Error("ошибка при обновлении patientRecord", "ошибка", err, "patientRecord_id", patientRecord.ID)
+		return err
+	}
+
+	r.logger.Info("успешное обновление patientRecord", "patientRecord_id", patientRecord.ID)
+	return nil
+}
+
+func (r *gormPatientRecordRepo) Transaction(fn func(tx *gorm.DB) error) error {
+	if err := r.DB.Transaction(fn); err != nil {
+		r.logger.Error("ошибка при выполнении транзакции patientRecord", "ошибка", err)
+		return err
+	}
+	return nil
+}
+
 func (r *gormPatientRecordRepo) Delete(ID uint) error {
 
 	r.logger.Info("Удаление patientRecord по ID", "patientRecord_id", ID)
-	
+
 	if err := r.DB.Delete(&models.PatientRecord{}, ID).Error; err != nil {
 		r.logger.Error("ошибка при удалении patientRecord", "ошибка", err, "patientRecord_id", ID)
 		return err
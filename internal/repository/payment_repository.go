@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *models.Payment) error
+
+	GetByID(ctx context.Context, id uint) (*models.Payment, error)
+
+	GetByIntentID(ctx context.Context, intentID string) (*models.Payment, error)
+
+	// GetByIDForUpdateTx читает платёж с блокировкой строки до конца
+	// транзакции.
+	GetByIDForUpdateTx(tx *gorm.DB, id uint) (*models.Payment, error)
+
+	ListByAppointmentID(ctx context.Context, appointmentID uint) ([]models.Payment, error)
+
+	ListExpired(ctx context.Context, now time.Time) ([]models.Payment, error)
+
+	Update(ctx context.Context, payment *models.Payment) error
+
+	UpdateTx(tx *gorm.DB, payment *models.Payment) error
+
+	CreateTx(tx *gorm.DB, payment *models.Payment) error
+
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+}
+
+type gormPaymentRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewPaymentRepository(db *gorm.DB, logger *slog.Logger) PaymentRepository {
+	return &gormPaymentRepository{db: db, logger: logger}
+}
+
+func (r *gormPaymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	return r.CreateTx(r.db.WithContext(ctx), payment)
+}
+
+func (r *gormPaymentRepository) CreateTx(tx *gorm.DB, payment *models.Payment) error {
+	if payment == nil {
+		r.logger.Warn("попытка создать nil payment")
+		return constants.Payment_IS_nil
+	}
+
+	r.logger.Debug("создание payment", "appointment_id", payment.AppointmentID, "amount", payment.Amount)
+
+	if err := tx.Create(payment).Error; err != nil {
+		r.logger.Error("ошибка при создании payment", "error", err, "appointment_id", payment.AppointmentID)
+		return err
+	}
+
+	r.logger.Info("payment создан", "payment_id", payment.ID, "intent_id", payment.IntentID)
+	return nil
+}
+
+func (r *gormPaymentRepository) GetByID(ctx context.Context, id uint) (*models.Payment, error) {
+	r.logger.Debug("получение payment по ID", "payment_id", id)
+	var payment models.Payment
+
+	if err := r.db.WithContext(ctx).First(&payment, id).Error; err != nil {
+		r.logger.Error("ошибка при получении payment по ID", "error", err, "payment_id", id)
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+func (r *gormPaymentRepository) GetByIntentID(ctx context.Context, intentID string) (*models.Payment, error) {
+	r.logger.Debug("получение payment по intent_id", "intent_id", intentID)
+	var payment models.Payment
+
+	if err := r.db.WithContext(ctx).Where("intent_id = ?", intentID).First(&payment).Error; err != nil {
+		r.logger.Error("ошибка при получении payment по intent_id", "error", err, "intent_id", intentID)
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+func (r *gormPaymentRepository) GetByIDForUpdateTx(tx *gorm.DB, id uint) (*models.Payment, error) {
+	var payment models.Payment
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, id).Error; err != nil {
+		r.logger.Error("ошибка при блокировке payment", "error", err, "payment_id", id)
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+func (r *gormPaymentRepository) ListByAppointmentID(ctx context.Context, appointmentID uint) ([]models.Payment, error) {
+	r.logger.Debug("получение payments по appointment_id", "appointment_id", appointmentID)
+	var payments []models.Payment
+
+	if err := r.db.WithContext(ctx).
+		Where("appointment_id = ?", appointmentID).
+		Order("created_at ASC").
+		Find(&payments).Error; err != nil {
+		r.logger.Error("ошибка при получении payments по appointment_id", "error", err, "appointment_id", appointmentID)
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (r *gormPaymentRepository) ListExpired(ctx context.Context, now time.Time) ([]models.Payment, error) {
+	var payments []models.Payment
+
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", models.PaymentPending, now).
+		Find(&payments).Error; err != nil {
+		r.logger.Error("ошибка при получении просроченных payments", "error", err)
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (r *gormPaymentRepository) Update(ctx context.Context, payment *models.Payment) error {
+	return r.UpdateTx(r.db.WithContext(ctx), payment)
+}
+
+func (r *gormPaymentRepository) UpdateTx(tx *gorm.DB, payment *models.Payment) error {
+	if payment == nil {
+		r.logger.Warn("попытка обновить nil payment")
+		return constants.Payment_IS_nil
+	}
+
+	if err := tx.Save(payment).Error; err != nil {
+		r.logger.Error("ошибка при обновлении payment", "error", err, "payment_id", payment.ID)
+		return err
+	}
+
+	r.logger.Info("payment обновлён", "payment_id", payment.ID, "status", payment.Status)
+	return nil
+}
+
+func (r *gormPaymentRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	if err := r.db.WithContext(ctx).Transaction(fn); err != nil {
+		r.logger.Error("ошибка при выполнении транзакции payment", "error", err)
+		return err
+	}
+	return nil
+}
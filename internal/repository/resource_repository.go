@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+)
+
+type ResourceRepository interface {
+	CreateRoom(ctx context.Context, room *models.Room) error
+
+	GetRoom(ctx context.Context, id uint) (*models.Room, error)
+
+	// ListRooms и ListEquipment при clinicIDs != nil возвращают только
+	// ресурсы этих филиалов.
+	ListRooms(ctx context.Context, clinicIDs []uint) ([]models.Room, error)
+
+	UpdateRoom(ctx context.Context, room *models.Room) error
+
+	DeleteRoom(ctx context.Context, id uint) error
+
+	CreateEquipment(ctx context.Context, equipment *models.Equipment) error
+
+	GetEquipment(ctx context.Context, id uint) (*models.Equipment, error)
+
+	ListEquipment(ctx context.Context, clinicIDs []uint) ([]models.Equipment, error)
+
+	UpdateEquipment(ctx context.Context, equipment *models.Equipment) error
+
+	DeleteEquipment(ctx context.Context, id uint) error
+}
+
+type gormResourceRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewResourceRepository(db *gorm.DB, logger *slog.Logger) ResourceRepository {
+	return &gormResourceRepository{db: db, logger: logger}
+}
+
+func (r *gormResourceRepository) CreateRoom(ctx context.Context, room *models.Room) error {
+	if err := r.db.WithContext(ctx).Omit("Equipment").Create(room).Error; err != nil {
+		r.logger.Error("ошибка при создании кабинета", "error", err, "number", room.Number)
+		return err
+	}
+
+	r.logger.Info("кабинет создан", "room_id", room.ID, "number", room.Number)
+	return nil
+}
+
+func (r *gormResourceRepository) GetRoom(ctx context.Context, id uint) (*models.Room, error) {
+	var room models.Room
+
+	if err := r.db.WithContext(ctx).Preload("Equipment").First(&room, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &room, nil
+}
+
+func (r *gormResourceRepository) ListRooms(ctx context.Context, clinicIDs []uint) ([]models.Room, error) {
+	var rooms []models.Room
+
+	query := r.db.WithContext(ctx).Preload("Equipment")
+	if clinicIDs != nil {
+		query = query.Where("clinic_id IN ?", clinicIDs)
+	}
+
+	if err := query.Order("clinic_id ASC, number ASC").Find(&rooms).Error; err != nil {
+		r.logger.Error("ошибка при получении кабинетов", "error", err)
+		return nil, err
+	}
+
+	return rooms, nil
+}
+
+func (r *gormResourceRepository) UpdateRoom(ctx context.Context, room *models.Room) error {
+	if err := r.db.WithContext(ctx).Omit("Equipment").Save(room).Error; err != nil {
+		r.logger.Error("ошибка при обновлении кабинета", "error", err, "room_id", room.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormResourceRepository) DeleteRoom(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&models.Room{}, id).Error; err != nil {
+		r.logger.Error("ошибка при удалении кабинета", "error", err, "room_id", id)
+		return err
+	}
+
+	r.logger.Info("кабинет удалён", "room_id", id)
+	return nil
+}
+
+func (r *gormResourceRepository) CreateEquipment(ctx context.Context, equipment *models.Equipment) error {
+	if err := r.db.WithContext(ctx).Create(equipment).Error; err != nil {
+		r.logger.Error("ошибка при создании оборудования", "error", err, "kind", equipment.Kind)
+		return err
+	}
+
+	r.logger.Info("оборудование создано", "equipment_id", equipment.ID, "kind", equipment.Kind)
+	return nil
+}
+
+func (r *gormResourceRepository) GetEquipment(ctx context.Context, id uint) (*models.Equipment, error) {
+	var equipment models.Equipment
+
+	if err := r.db.WithContext(ctx).First(&equipment, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &equipment, nil
+}
+
+func (r *gormResourceRepository) ListEquipment(ctx context.Context, clinicIDs []uint) ([]models.Equipment, error) {
+	var equipment []models.Equipment
+
+	query := r.db.WithContext(ctx)
+	if clinicIDs != nil {
+		query = query.Where("clinic_id IN ?", clinicIDs)
+	}
+
+	if err := query.Order("kind ASC, id ASC").Find(&equipment).Error; err != nil {
+		r.logger.Error("ошибка при получении оборудования", "error", err)
+		return nil, err
+	}
+
+	return equipment, nil
+}
+
+func (r *gormResourceRepository) UpdateEquipment(ctx context.Context, equipment *models.Equipment) error {
+	if err := r.db.WithContext(ctx).Save(equipment).Error; err != nil {
+		r.logger.Error("ошибка при обновлении оборудования", "error", err, "equipment_id", equipment.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormResourceRepository) DeleteEquipment(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&models.Equipment{}, id).Error; err != nil {
+		r.logger.Error("ошибка при удалении оборудования", "error", err, "equipment_id", id)
+		return err
+	}
+
+	r.logger.Info("оборудование удалено", "equipment_id", id)
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"gorm.io/gorm"
@@ -13,21 +14,51 @@ import (
 type ReviewRepository interface {
 	Create(*models.Review) error
 
+	CreateTx(*gorm.DB, *models.Review) error
+
+	Transaction(context.Context, func(tx *gorm.DB) error) error
+
 	GetByID(context.Context, uint) (*models.Review, error)
 
-	GetByDoctorID(context.Context, uint) ([]models.Review, error)
+	// ListByDoctor возвращает опубликованные отзывы врача.
+	ListByDoctor(ctx context.Context, doctorID uint, q models.ListQuery) (*models.Page[models.Review], error)
+
+	// ListModerationQueue возвращает отзывы, ждущие модерации, и
+	// опубликованные отзывы с жалобами, по умолчанию старые первыми.
+	ListModerationQueue(ctx context.Context, q models.ListQuery) (*models.Page[models.Review], error)
+
+	UpdateTx(*gorm.DB, *models.Review) error
+
+	// AddFlagTx сохраняет жалобу и увеличивает счётчик жалоб отзыва.
+	AddFlagTx(*gorm.DB, *models.ReviewFlag) error
+
+	HasFlagTx(tx *gorm.DB, reviewID, userID uint) (bool, error)
 
-	GetByPatientID(context.Context, uint) ([]models.Review, error)
+	// ExistsForAppointmentTx проверяет, оставлен ли уже отзыв на приём.
+	ExistsForAppointmentTx(tx *gorm.DB, appointmentID uint) (bool, error)
+
+	ListByPatient(ctx context.Context, patientID uint, q models.ListQuery) (*models.Page[models.Review], error)
 
 	Update(*models.Review) error
 
 	Delete(context.Context, uint) error
 
+	DeleteTx(*gorm.DB, uint) error
+
 	GetAverageRating(context.Context, uint) (float64, error)
+
+	// GetRatingStatsTx считает число и среднюю оценку опубликованных отзывов врача.
+	GetRatingStatsTx(tx *gorm.DB, doctorID uint) (count int64, avg float64, err error)
+
+	// GetRatingDistribution возвращает число опубликованных отзывов по оценкам.
+	GetRatingDistribution(ctx context.Context, doctorID uint) (map[int]int64, error)
+
+	// GetRatingTrend группирует опубликованные отзывы с since по месяцам.
+	GetRatingTrend(ctx context.Context, doctorID uint, since time.Time) ([]models.RatingTrendPoint, error)
 }
 
 type gormReviewRepository struct {
-	DB *gorm.DB
+	DB     *gorm.DB
 	logger *slog.Logger
 }
 
@@ -51,6 +82,29 @@ func (r *gormReviewRepository) Create(review *models.Review) error {
 	return nil
 }
 
+func (r *gormReviewRepository) CreateTx(tx *gorm.DB, review *models.Review) error {
+	if review == nil {
+		r.logger.Error("передан nil review")
+		return errors.New("review is nil")
+	}
+
+	if err := tx.Create(review).Error; err != nil {
+		r.logger.Error("ошибка при создании review", "error", err)
+		return err
+	}
+
+	r.logger.Info("review создан", "review_id", review.ID)
+	return nil
+}
+
+func (r *gormReviewRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	if err := r.DB.WithContext(ctx).Transaction(fn); err != nil {
+		r.logger.Error("ошибка при выполнении транзакции review", "error", err)
+		return err
+	}
+	return nil
+}
+
 func (r *gormReviewRepository) GetByID(ctx context.Context, id uint) (*models.Review, error) {
 	r.logger.Debug("получаем review по ID в репозитории")
 	var review models.Review
@@ -63,31 +117,112 @@ func (r *gormReviewRepository) GetByID(ctx context.Context, id uint) (*models.Re
 	return &review, nil
 }
 
-func (r *gormReviewRepository) GetByDoctorID(ctx context.Context, doctorID uint) ([]models.Review, error) {
+var reviewListSpec = listSpec[models.Review]{
+	sorts: map[string]sortColumn[models.Review]{
+		"created_at": {column: "created_at", value: func(r *models.Review) any { return r.CreatedAt }},
+		"rating":     {column: "rating", value: func(r *models.Review) any { return r.Rating }},
+	},
+	filters: map[string]filterColumn{
+		"doctor_id": {column: "doctor_id", kind: filterUint},
+		"rating":    {column: "rating", kind: filterInt},
+		"status":    {column: "status", kind: filterString},
+	},
+	dateColumn:  "created_at",
+	defaultSort: []models.SortField{{Field: "created_at", Desc: true}},
+	id:          func(r *models.Review) uint { return r.ID },
+}
+
+func (r *gormReviewRepository) ListByDoctor(ctx context.Context, doctorID uint, q models.ListQuery) (*models.Page[models.Review], error) {
 	r.logger.Debug("получаем список review по doctorID в репозитории")
-	var reviews []models.Review
 
-	if err := r.DB.WithContext(ctx).Where("doctor_id = ?", doctorID).Find(&reviews).Error; err != nil {
+	query := r.DB.WithContext(ctx).Model(&models.Review{}).
+		Where("doctor_id = ? AND status = ?", doctorID, models.ReviewPublished)
+
+	page, err := paginate(query, q, reviewListSpec)
+	if err != nil {
 		r.logger.Error("ошибка при получении списка review по doctorID", "error", err)
 		return nil, err
 	}
 
-	r.logger.Info("список review получен по doctorID")
+	r.logger.Info("список review получен по doctorID", "count", len(page.Items))
+	return page, nil
+}
+
+func (r *gormReviewRepository) ListModerationQueue(ctx context.Context, q models.ListQuery) (*models.Page[models.Review], error) {
+	query := r.DB.WithContext(ctx).Model(&models.Review{}).
+		Where("status = ? OR (status = ? AND flag_count > 0)", models.ReviewPending, models.ReviewPublished)
+
+	spec := reviewListSpec
+	spec.defaultSort = []models.SortField{{Field: "created_at"}}
+
+	page, err := paginate(query, q, spec)
+	if err != nil {
+		r.logger.Error("ошибка при получении очереди модерации отзывов", "error", err)
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (r *gormReviewRepository) UpdateTx(tx *gorm.DB, review *models.Review) error {
+	if err := tx.Save(review).Error; err != nil {
+		r.logger.Error("ошибка при обновлении review", "error", err, "review_id", review.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormReviewRepository) AddFlagTx(tx *gorm.DB, flag *models.ReviewFlag) error {
+	if err := tx.Create(flag).Error; err != nil {
+		r.logger.Error("ошибка при сохранении жалобы на отзыв", "error", err, "review_id", flag.ReviewID)
+		return err
+	}
+
+	if err := tx.Model(&models.Review{}).Where("id = ?", flag.ReviewID).
+		UpdateColumn("flag_count", gorm.Expr("flag_count + 1")).Error; err != nil {
+		r.logger.Error("ошибка при обновлении счётчика жалоб", "error", err, "review_id", flag.ReviewID)
+		return err
+	}
 
-	return reviews, nil
+	r.logger.Info("жалоба на отзыв сохранена", "review_id", flag.ReviewID, "user_id", flag.UserID)
+	return nil
 }
 
-func (r *gormReviewRepository) GetByPatientID(ctx context.Context, patient_id uint) ([]models.Review, error) {
+func (r *gormReviewRepository) HasFlagTx(tx *gorm.DB, reviewID, userID uint) (bool, error) {
+	var count int64
+
+	if err := tx.Model(&models.ReviewFlag{}).
+		Where("review_id = ? AND user_id = ?", reviewID, userID).
+		Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при проверке жалобы на отзыв", "error", err, "review_id", reviewID)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *gormReviewRepository) ExistsForAppointmentTx(tx *gorm.DB, appointmentID uint) (bool, error) {
+	var count int64
+
+	if err := tx.Model(&models.Review{}).Where("appointment_id = ?", appointmentID).Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при проверке отзыва на приём", "error", err, "appointment_id", appointmentID)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *gormReviewRepository) ListByPatient(ctx context.Context, patientID uint, q models.ListQuery) (*models.Page[models.Review], error) {
 	r.logger.Debug("получаем список review по patientID в репозитории")
-	var reviews []models.Review
 
-	if err := r.DB.WithContext(ctx).Where("id = ?", patient_id).Find(&reviews).Error; err != nil {
+	page, err := paginate(r.DB.WithContext(ctx).Model(&models.Review{}).Where("user_id = ?", patientID), q, reviewListSpec)
+	if err != nil {
 		r.logger.Error("ошибка при получении списка review по patientID", "error", err)
 		return nil, err
 	}
 
-	r.logger.Info("список review получен по patientID")
-	return reviews, nil
+	r.logger.Info("список review получен по patientID", "count", len(page.Items))
+	return page, nil
 }
 
 func (r *gormReviewRepository) Update(req *models.Review) error {
@@ -112,13 +247,78 @@ func (r *gormReviewRepository) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
+func (r *gormReviewRepository) DeleteTx(tx *gorm.DB, id uint) error {
+	if err := tx.Delete(&models.Review{}, id).Error; err != nil {
+		r.logger.Error("ошибка при удалении review", "error", err, "review_id", id)
+		return err
+	}
+
+	r.logger.Info("review удален", "review_id", id)
+	return nil
+}
+
+func (r *gormReviewRepository) GetRatingStatsTx(tx *gorm.DB, doctorID uint) (int64, float64, error) {
+	var stats struct {
+		Count int64
+		Avg   sql.NullFloat64
+	}
+
+	if err := tx.Model(&models.Review{}).
+		Select("COUNT(*) AS count, AVG(rating) AS avg").
+		Where("doctor_id = ? AND status = ?", doctorID, models.ReviewPublished).
+		Scan(&stats).Error; err != nil {
+		r.logger.Error("ошибка при подсчёте рейтинга врача", "error", err, "doctor_id", doctorID)
+		return 0, 0, err
+	}
+
+	return stats.Count, stats.Avg.Float64, nil
+}
+
+func (r *gormReviewRepository) GetRatingDistribution(ctx context.Context, doctorID uint) (map[int]int64, error) {
+	var rows []struct {
+		Rating int
+		Count  int64
+	}
+
+	if err := r.DB.WithContext(ctx).Model(&models.Review{}).
+		Select("rating, COUNT(*) AS count").
+		Where("doctor_id = ? AND status = ?", doctorID, models.ReviewPublished).
+		Group("rating").
+		Scan(&rows).Error; err != nil {
+		r.logger.Error("ошибка при получении распределения оценок", "error", err, "doctor_id", doctorID)
+		return nil, err
+	}
+
+	distribution := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		distribution[row.Rating] = row.Count
+	}
+	return distribution, nil
+}
+
+func (r *gormReviewRepository) GetRatingTrend(ctx context.Context, doctorID uint, since time.Time) ([]models.RatingTrendPoint, error) {
+	var points []models.RatingTrendPoint
+
+	if err := r.DB.WithContext(ctx).Model(&models.Review{}).
+		Select("to_char(date_trunc('month', created_at), 'YYYY-MM') AS month, COUNT(*) AS count, AVG(rating) AS average").
+		Where("doctor_id = ? AND status = ? AND created_at >= ?", doctorID, models.ReviewPublished, since).
+		Group("month").
+		Order("month").
+		Scan(&points).Error; err != nil {
+		r.logger.Error("ошибка при получении динамики оценок", "error", err, "doctor_id", doctorID)
+		return nil, err
+	}
+
+	return points, nil
+}
+
 func (r *gormReviewRepository) GetAverageRating(ctx context.Context, doctorID uint) (float64, error) {
 	r.logger.Debug("получаем средний рейтинг по doctorID в репозитории")
 	var avg sql.NullFloat64
 
 	err := r.DB.WithContext(ctx).Model(&models.Review{}).
 		Select("AVG(rating)").
-		Where("doctor_id = ?", doctorID).
+		Where("doctor_id = ? AND status = ?", doctorID, models.ReviewPublished).
 		Scan(&avg).Error
 	if err != nil {
 		r.logger.Error("ошибка при получении среднего рейтинга по doctorID", "error", err)
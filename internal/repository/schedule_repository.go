@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"gorm.io/gorm"
 )
@@ -13,7 +14,13 @@ import (
 type ScheduleRepository interface {
 	Create(context.Context, []models.Schedule) error
 
-	GetAll(context.Context) ([]models.Schedule, error)
+	CreateTx(*gorm.DB, []models.Schedule) error
+
+	Transaction(context.Context, func(tx *gorm.DB) error) error
+
+	// List возвращает страницу смен; clinicIDs != nil ограничивает выборку
+	// этими филиалами.
+	List(ctx context.Context, q models.ListQuery, clinicIDs []uint) (*models.Page[models.Schedule], error)
 
 	GetByID(context.Context, uint) (*models.Schedule, error)
 
@@ -21,11 +28,27 @@ type ScheduleRepository interface {
 
 	Update(context.Context, *models.Schedule) error
 
+	UpdateTx(*gorm.DB, *models.Schedule) error
+
+	// Overlaps возвращает сохранённые смены, пересекающиеся со сменой по
+	// врачу и по кабинету филиала.
+	Overlaps(ctx context.Context, schedule *models.Schedule) (byDoctor, byRoom []models.Schedule, err error)
+
 	Delete(context.Context, uint) error
 
+	DeleteTx(*gorm.DB, uint) error
+
 	DeleteByDoctorID(context.Context, uint) error
 
-	GetAvailableSlots(context.Context, uint, time.Time) ([]models.Schedule, error)
+	// GetAvailableSlots возвращает свободные слоты врача в интервале
+	// [from, to); clinicID = 0 — во всех филиалах.
+	GetAvailableSlots(ctx context.Context, doctorID uint, clinicID uint, from, to time.Time) ([]models.Schedule, error)
+
+	// GetNextAvailable возвращает ближайший свободный слот каждого из врачей,
+	// начинающийся не раньше from; clinicID = 0 — в любом филиале.
+	GetNextAvailable(ctx context.Context, doctorIDs []uint, clinicID uint, from time.Time) ([]models.Schedule, error)
+
+	GetBetween(ctx context.Context, from, to time.Time) ([]models.Schedule, error)
 }
 
 type gormScheduleRepository struct {
@@ -56,31 +79,43 @@ func (r *gormScheduleRepository) Create(
 		"from", first.Date,
 	)
 
-	if err := r.DB.WithContext(ctx).Create(&schedules).Error; err != nil {
-		r.logger.Error("ошибка при создании schedules", "error", err)
-		return err
-	}
-
-	r.logger.Info(
-		"schedules успешно созданы",
-		"count", len(schedules),
-	)
+	return r.Transaction(ctx, func(tx *gorm.DB) error {
+		return r.CreateTx(tx, schedules)
+	})
+}
 
-	return nil
+var scheduleListSpec = listSpec[models.Schedule]{
+	sorts: map[string]sortColumn[models.Schedule]{
+		"start_time": {column: "start_time", value: func(s *models.Schedule) any { return s.StartTime }},
+		"created_at": {column: "created_at", value: func(s *models.Schedule) any { return s.CreatedAt }},
+	},
+	filters: map[string]filterColumn{
+		"doctor_id":    {column: "doctor_id", kind: filterUint},
+		"clinic_id":    {column: "clinic_id", kind: filterUint},
+		"room_number":  {column: "room_number", kind: filterInt},
+		"is_available": {column: "is_available", kind: filterBool},
+	},
+	dateColumn:  "start_time",
+	defaultSort: []models.SortField{{Field: "start_time"}},
+	id:          func(s *models.Schedule) uint { return s.ID },
 }
 
+func (r *gormScheduleRepository) List(ctx context.Context, q models.ListQuery, clinicIDs []uint) (*models.Page[models.Schedule], error) {
+	r.logger.Debug("получение списка schedules")
 
-func (r *gormScheduleRepository) GetAll(ctx context.Context) ([]models.Schedule, error) {
-	r.logger.Debug("получение всех schedules")
-	var schedules []models.Schedule
+	query := r.DB.WithContext(ctx).Model(&models.Schedule{})
+	if clinicIDs != nil {
+		query = query.Where("clinic_id IN ?", clinicIDs)
+	}
 
-	if err := r.DB.WithContext(ctx).Find(&schedules).Error; err != nil {
-		r.logger.Error("ошибка при получении всех schedules", "error", err)
+	page, err := paginate(query, q, scheduleListSpec)
+	if err != nil {
+		r.logger.Error("ошибка при получении списка schedules", "error", err)
 		return nil, err
 	}
 
-	r.logger.Info("успешное получение всех schedules", "count", len(schedules))
-	return schedules, nil
+	r.logger.Info("успешное получение списка schedules", "count", len(page.Items), "total", page.Total)
+	return page, nil
 }
 
 func (r *gormScheduleRepository) GetByID(ctx context.Context, id uint) (*models.Schedule, error) {
@@ -115,7 +150,20 @@ func (r *gormScheduleRepository) Update(ctx context.Context, schedule *models.Sc
 		return nil
 	}
 	r.logger.Debug("обновление schedule", "schedule_id", schedule.ID)
-	if err := r.DB.WithContext(ctx).Save(schedule).Error; err != nil {
+	return r.Transaction(ctx, func(tx *gorm.DB) error {
+		return r.UpdateTx(tx, schedule)
+	})
+}
+
+func (r *gormScheduleRepository) UpdateTx(tx *gorm.DB, schedule *models.Schedule) error {
+	if err := r.checkDoctorOverlapTx(tx, schedule); err != nil {
+		return err
+	}
+	if err := r.assignRoomTx(tx, schedule); err != nil {
+		return err
+	}
+
+	if err := tx.Save(schedule).Error; err != nil {
 		r.logger.Error("ошибка при обновлении schedule", "error", err, "schedule_id", schedule.ID)
 		return err
 	}
@@ -124,9 +172,29 @@ func (r *gormScheduleRepository) Update(ctx context.Context, schedule *models.Sc
 	return nil
 }
 
+func (r *gormScheduleRepository) Overlaps(ctx context.Context, schedule *models.Schedule) ([]models.Schedule, []models.Schedule, error) {
+	var byDoctor, byRoom []models.Schedule
+
+	db := r.DB.WithContext(ctx)
+	if err := doctorOverlap(db, schedule).Order("start_time ASC").Find(&byDoctor).Error; err != nil {
+		r.logger.Error("ошибка при поиске пересечений смен врача", "error", err, "doctor_id", schedule.DoctorID)
+		return nil, nil, err
+	}
+	if err := roomOverlap(db, schedule).Order("start_time ASC").Find(&byRoom).Error; err != nil {
+		r.logger.Error("ошибка при поиске пересечений смен в кабинете", "error", err, "room_number", schedule.RoomNumber)
+		return nil, nil, err
+	}
+
+	return byDoctor, byRoom, nil
+}
+
 func (r *gormScheduleRepository) Delete(ctx context.Context, id uint) error {
+	return r.DeleteTx(r.DB.WithContext(ctx), id)
+}
+
+func (r *gormScheduleRepository) DeleteTx(tx *gorm.DB, id uint) error {
 	r.logger.Debug("удаление schedule по ID", "schedule_id", id)
-	if err := r.DB.WithContext(ctx).Delete(&models.Schedule{}, id).Error; err != nil {
+	if err := tx.Delete(&models.Schedule{}, id).Error; err != nil {
 		r.logger.Error("ошибка при удалении schedule", "error", err, "schedule_id", id)
 		return err
 	}
@@ -147,20 +215,24 @@ func (r *gormScheduleRepository) DeleteByDoctorID(ctx context.Context, doctorID
 func (r *gormScheduleRepository) GetAvailableSlots(
 	ctx context.Context,
 	doctorID uint,
-	start time.Time,
+	clinicID uint,
+	from, to time.Time,
 ) ([]models.Schedule, error) {
 
-	start = start.Truncate(24 * time.Hour)
-	end := start.AddDate(0, 0, 7)
-
 	var schedules []models.Schedule
 
-	r.logger.Debug("получение доступных слотов", "doctor_id", doctorID, "start", start, "end", end)
+	r.logger.Debug("получение доступных слотов", "doctor_id", doctorID, "from", from, "to", to)
 
-	err := r.DB.WithContext(ctx).
+	query := r.DB.WithContext(ctx).
 		Where("doctor_id = ?", doctorID).
-		Where("is_available = ?", true).
-		Where("start_time >= ? AND end_time <= ?", start, end).
+		Where("is_available = ?", true)
+	if clinicID != 0 {
+		query = query.Where("clinic_id = ?", clinicID)
+	}
+
+	err := query.
+		Where("start_time >= ? AND start_time < ?", from, to).
+		Where("NOT EXISTS (?)", r.activeHolds()).
 		Order("start_time ASC").
 		Find(&schedules).Error
 
@@ -172,3 +244,175 @@ func (r *gormScheduleRepository) GetAvailableSlots(
 	r.logger.Info("доступные слоты получены", "doctor_id", doctorID, "count", len(schedules))
 	return schedules, nil
 }
+
+func (r *gormScheduleRepository) GetNextAvailable(ctx context.Context, doctorIDs []uint, clinicID uint, from time.Time) ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	if len(doctorIDs) == 0 {
+		return schedules, nil
+	}
+
+	query := r.DB.WithContext(ctx).
+		Select("DISTINCT ON (doctor_id) *").
+		Where("doctor_id IN ?", doctorIDs).
+		Where("is_available = ?", true).
+		Where("start_time >= ?", from).
+		Where("NOT EXISTS (?)", r.activeHolds())
+	if clinicID != 0 {
+		query = query.Where("clinic_id = ?", clinicID)
+	}
+
+	if err := query.Order("doctor_id ASC").Order("start_time ASC").Find(&schedules).Error; err != nil {
+		r.logger.Error("ошибка при получении ближайших слотов", "error", err)
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// activeHolds — подзапрос действующих удержаний, пересекающихся со сменой.
+func (r *gormScheduleRepository) activeHolds() *gorm.DB {
+	return r.DB.Model(&models.SlotHold{}).
+		Select("1").
+		Where("slot_holds.doctor_id = schedules.doctor_id AND slot_holds.status = ? AND slot_holds.expires_at > ?", models.HoldActive, time.Now()).
+		Where("slot_holds.start_at < schedules.end_time AND slot_holds.end_at > schedules.start_time")
+}
+
+func (r *gormScheduleRepository) CreateTx(tx *gorm.DB, schedules []models.Schedule) error {
+	if len(schedules) == 0 {
+		r.logger.Warn("попытка создать пустой список schedules")
+		return errors.New("schedules slice is empty")
+	}
+
+	for i := range schedules {
+		// Пересечения внутри одного запроса база ещё не видит.
+		for j := 0; j < i; j++ {
+			if !schedulesOverlap(&schedules[i], &schedules[j]) {
+				continue
+			}
+			if schedules[i].DoctorID == schedules[j].DoctorID {
+				r.logger.Warn("пересечение смен врача внутри запроса", "doctor_id", schedules[i].DoctorID)
+				return constants.ErrDoctorDoubleBooked
+			}
+			if sameRoom(&schedules[i], &schedules[j]) {
+				r.logger.Warn("пересечение расписаний в одном кабинете внутри запроса", "room_number", schedules[i].RoomNumber)
+				return constants.ErrRoomDoubleBooked
+			}
+		}
+
+		if err := r.checkDoctorOverlapTx(tx, &schedules[i]); err != nil {
+			return err
+		}
+		if err := r.assignRoomTx(tx, &schedules[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Create(&schedules).Error; err != nil {
+		r.logger.Error("ошибка при создании schedules", "error", err)
+		return err
+	}
+
+	r.logger.Info("schedules успешно созданы", "count", len(schedules))
+	return nil
+}
+
+// assignRoomTx связывает смену с кабинетом филиала из справочника и
+// проверяет, что кабинет не занят другой сменой в то же время.
+func (r *gormScheduleRepository) assignRoomTx(tx *gorm.DB, schedule *models.Schedule) error {
+	schedule.RoomID = nil
+
+	var room models.Room
+	err := tx.Scopes(clinicScope("clinic_id", schedule.ClinicID)).
+		Where("number = ?", schedule.RoomNumber).
+		First(&room).Error
+	switch {
+	case err == nil:
+		if !room.IsActive {
+			return constants.ErrRoomInactive
+		}
+		schedule.RoomID = &room.ID
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		r.logger.Error("ошибка при поиске кабинета", "error", err, "room_number", schedule.RoomNumber)
+		return err
+	}
+
+	var count int64
+	if err := roomOverlap(tx, schedule).Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при проверке занятости кабинета", "error", err, "room_number", schedule.RoomNumber)
+		return err
+	}
+	if count > 0 {
+		r.logger.Warn("кабинет уже занят в это время", "room_number", schedule.RoomNumber, "start_time", schedule.StartTime)
+		return constants.ErrRoomDoubleBooked
+	}
+
+	return nil
+}
+
+// checkDoctorOverlapTx не даёт врачу две смены в одно и то же время.
+func (r *gormScheduleRepository) checkDoctorOverlapTx(tx *gorm.DB, schedule *models.Schedule) error {
+	var count int64
+	if err := doctorOverlap(tx, schedule).Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при проверке пересечения смен врача", "error", err, "doctor_id", schedule.DoctorID)
+		return err
+	}
+	if count > 0 {
+		r.logger.Warn("у врача уже есть смена в это время", "doctor_id", schedule.DoctorID, "start_time", schedule.StartTime)
+		return constants.ErrDoctorDoubleBooked
+	}
+	return nil
+}
+
+func doctorOverlap(db *gorm.DB, schedule *models.Schedule) *gorm.DB {
+	return db.Model(&models.Schedule{}).
+		Where("doctor_id = ? AND id <> ? AND start_time < ? AND end_time > ?",
+			schedule.DoctorID, schedule.ID, schedule.EndTime, schedule.StartTime)
+}
+
+func roomOverlap(db *gorm.DB, schedule *models.Schedule) *gorm.DB {
+	return db.Model(&models.Schedule{}).
+		Scopes(clinicScope("clinic_id", schedule.ClinicID)).
+		Where("room_number = ? AND id <> ? AND start_time < ? AND end_time > ?",
+			schedule.RoomNumber, schedule.ID, schedule.EndTime, schedule.StartTime)
+}
+
+// schedulesOverlap сообщает, пересекаются ли смены по времени.
+func schedulesOverlap(a, b *models.Schedule) bool {
+	return a.StartTime.Before(b.EndTime) && a.EndTime.After(b.StartTime)
+}
+
+// sameRoom сообщает, проходят ли смены в одном кабинете одного филиала.
+func sameRoom(a, b *models.Schedule) bool {
+	return a.RoomNumber == b.RoomNumber && SameClinic(a.ClinicID, b.ClinicID)
+}
+
+func (r *gormScheduleRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	if err := r.DB.WithContext(ctx).Transaction(fn); err != nil {
+		r.logger.Error("ошибка при выполнении транзакции schedule", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *gormScheduleRepository) GetBetween(ctx context.Context, from, to time.Time) ([]models.Schedule, error) {
+	var schedules []models.Schedule
+
+	if err := r.DB.WithContext(ctx).
+		Where("start_time < ? AND end_time > ?", to, from).
+		Order("start_time ASC").
+		Find(&schedules).Error; err != nil {
+		r.logger.Error("ошибка при получении schedules за период", "error", err)
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// SameClinic сравнивает филиалы; смены вне филиалов считаются одним
+// филиалом.
+func SameClinic(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+)
+
+// searchConfigs — конфигурации полнотекстового поиска Postgres: запрос
+// разбирается в обеих, документ находится, если совпал хотя бы в одной.
+var searchConfigs = []string{"russian", "english"}
+
+// ErrSearchExtensionMissing — в базе нет pg_trgm, без которого не работает
+// нечёткий поиск.
+var ErrSearchExtensionMissing = errors.New("расширение pg_trgm не установлено: выполните make migrate-search от имени владельца базы")
+
+type SearchRepository interface {
+	// SearchDoctors ищет врачей по имени, специализации и описанию.
+	SearchDoctors(ctx context.Context, query string, minSimilarity float64, limit int) ([]models.SearchHit, error)
+
+	// SearchServices ищет услуги по названию, категории и описанию.
+	SearchServices(ctx context.Context, query string, minSimilarity float64, limit int) ([]models.SearchHit, error)
+}
+
+type gormSearchRepository struct {
+	DB     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewSearchRepository(db *gorm.DB, logger *slog.Logger) SearchRepository {
+	return &gormSearchRepository{DB: db, logger: logger}
+}
+
+// EnableSearchExtension включает pg_trgm. Для CREATE EXTENSION нужны права
+// владельца базы, поэтому оно выполняется отдельной командой migrate-search,
+// а не при запуске сервера.
+func EnableSearchExtension(db *gorm.DB) error {
+	return db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error
+}
+
+// MigrateSearch проверяет, что pg_trgm включён, и строит GIN-индексы по
+// документам услуг. Документ врача включает имя из users, поэтому индексом
+// не покрывается.
+func MigrateSearch(db *gorm.DB) error {
+	var installed bool
+	if err := db.Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm')").Scan(&installed).Error; err != nil {
+		return err
+	}
+	if !installed {
+		return ErrSearchExtensionMissing
+	}
+
+	for _, cfg := range searchConfigs {
+		stmt := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_services_search_%s ON services USING GIN ((%s))", cfg[:2], serviceDocument(cfg, "services"))
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// serviceDocument — документ услуги с весами: название, категория, описание.
+// Выражение должно совпадать с индексом из MigrateSearch.
+func serviceDocument(cfg, table string) string {
+	return fmt.Sprintf(
+		"setweight(to_tsvector('%[1]s', coalesce(%[2]s.name, '')), 'A') || "+
+			"setweight(to_tsvector('%[1]s', coalesce(%[2]s.category, '')), 'B') || "+
+			"setweight(to_tsvector('%[1]s', coalesce(%[2]s.description, '')), 'C')",
+		cfg, table)
+}
+
+// doctorDocument — документ врача: имя и фамилия, специализация, описание.
+func doctorDocument(cfg string) string {
+	return fmt.Sprintf(
+		"setweight(to_tsvector('%[1]s', coalesce(u.first_name, '') || ' ' || coalesce(u.last_name, '')), 'A') || "+
+			"setweight(to_tsvector('%[1]s', coalesce(d.specialization, '')), 'B') || "+
+			"setweight(to_tsvector('%[1]s', coalesce(d.bio, '')), 'C')",
+		cfg)
+}
+
+// matchAndScore строит условие совпадения и оценку: полнотекстовое
+// совпадение в любой из конфигураций или похожая по триграммам фраза в
+// коротком тексте (имени, названии).
+func matchAndScore(document func(cfg string) string, text string) (string, string) {
+	var match, score []string
+	for _, cfg := range searchConfigs {
+		query := fmt.Sprintf("websearch_to_tsquery('%s', @q)", cfg)
+		match = append(match, "("+document(cfg)+") @@ "+query)
+		score = append(score, "ts_rank("+document(cfg)+", "+query+")")
+	}
+
+	similarity := "word_similarity(@q, " + text + ")"
+	match = append(match, similarity+" >= @min")
+	score = append(score, similarity)
+
+	return strings.Join(match, " OR "), strings.Join(score, " + ")
+}
+
+type doctorSearchRow struct {
+	models.Doctor
+	FirstName string
+	LastName  string
+	Score     float64
+}
+
+func (r *gormSearchRepository) SearchDoctors(ctx context.Context, query string, minSimilarity float64, limit int) ([]models.SearchHit, error) {
+	r.logger.Debug("поиск doctors", "query", query)
+
+	match, score := matchAndScore(doctorDocument,
+		"coalesce(u.first_name, '') || ' ' || coalesce(u.last_name, '') || ' ' || coalesce(d.specialization, '')")
+
+	var rows []doctorSearchRow
+	if err := r.DB.WithContext(ctx).Raw(
+		"SELECT d.*, u.first_name, u.last_name, "+score+" AS score "+
+			"FROM doctors d JOIN users u ON u.id = d.user_id AND u.deleted_at IS NULL "+
+			"WHERE d.deleted_at IS NULL AND ("+match+") "+
+			"ORDER BY score DESC, d.id ASC LIMIT @limit",
+		sql.Named("q", query), sql.Named("min", minSimilarity), sql.Named("limit", limit),
+	).Scan(&rows).Error; err != nil {
+		r.logger.Error("ошибка при поиске doctors", "ошибка", err, "query", query)
+		return nil, err
+	}
+
+	hits := make([]models.SearchHit, len(rows))
+	for i := range rows {
+		doctor := rows[i].Doctor
+		hits[i] = models.SearchHit{
+			Type:     models.SearchDoctor,
+			ID:       doctor.ID,
+			Title:    strings.TrimSpace(rows[i].FirstName + " " + rows[i].LastName),
+			Subtitle: doctor.Specialization,
+			Score:    rows[i].Score,
+			Doctor:   &doctor,
+		}
+	}
+
+	return hits, nil
+}
+
+type serviceSearchRow struct {
+	models.Service
+	Score float64
+}
+
+func (r *gormSearchRepository) SearchServices(ctx context.Context, query string, minSimilarity float64, limit int) ([]models.SearchHit, error) {
+	r.logger.Debug("поиск services", "query", query)
+
+	match, score := matchAndScore(func(cfg string) string { return serviceDocument(cfg, "s") },
+		"coalesce(s.name, '') || ' ' || coalesce(s.category, '')")
+
+	var rows []serviceSearchRow
+	if err := r.DB.WithContext(ctx).Raw(
+		"SELECT s.*, "+score+" AS score "+
+			"FROM services s "+
+			"WHERE s.deleted_at IS NULL AND ("+match+") "+
+			"ORDER BY score DESC, s.id ASC LIMIT @limit",
+		sql.Named("q", query), sql.Named("min", minSimilarity), sql.Named("limit", limit),
+	).Scan(&rows).Error; err != nil {
+		r.logger.Error("ошибка при поиске services", "ошибка", err, "query", query)
+		return nil, err
+	}
+
+	hits := make([]models.SearchHit, len(rows))
+	for i := range rows {
+		service := rows[i].Service
+		hits[i] = models.SearchHit{
+			Type:     models.SearchService,
+			ID:       service.ID,
+			Title:    service.Name,
+			Subtitle: service.Category,
+			Score:    rows[i].Score,
+			Service:  &service,
+		}
+	}
+
+	return hits, nil
+}
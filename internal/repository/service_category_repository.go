@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+)
+
+type ServiceCategoryRepository interface {
+	Create(ctx context.Context, category *models.ServiceCategory) error
+
+	GetByID(ctx context.Context, id uint) (*models.ServiceCategory, error)
+
+	GetBySlug(ctx context.Context, slug string) (*models.ServiceCategory, error)
+
+	// EnsureRoot возвращает корневую категорию с этим названием и заводит
+	// её, если такой ещё нет.
+	EnsureRoot(ctx context.Context, name string) (*models.ServiceCategory, error)
+
+	// List возвращает все категории в порядке каталога.
+	List(ctx context.Context) ([]models.ServiceCategory, error)
+
+	// Update сохраняет категорию и обновляет её название у услуг.
+	Update(ctx context.Context, category *models.ServiceCategory) error
+
+	Delete(ctx context.Context, id uint) error
+
+	// InUse сообщает, есть ли у категории подкатегории, услуги или
+	// комплексы.
+	InUse(ctx context.Context, id uint) (bool, error)
+}
+
+type gormServiceCategoryRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewServiceCategoryRepository(db *gorm.DB, logger *slog.Logger) ServiceCategoryRepository {
+	return &gormServiceCategoryRepository{db: db, logger: logger}
+}
+
+func (r *gormServiceCategoryRepository) Create(ctx context.Context, category *models.ServiceCategory) error {
+	if err := r.db.WithContext(ctx).Create(category).Error; err != nil {
+		r.logger.Error("ошибка при создании категории услуг", "error", err, "slug", category.Slug)
+		return err
+	}
+
+	r.logger.Info("категория услуг создана", "category_id", category.ID)
+	return nil
+}
+
+func (r *gormServiceCategoryRepository) GetByID(ctx context.Context, id uint) (*models.ServiceCategory, error) {
+	var category models.ServiceCategory
+
+	if err := r.db.WithContext(ctx).First(&category, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (r *gormServiceCategoryRepository) GetBySlug(ctx context.Context, slug string) (*models.ServiceCategory, error) {
+	var category models.ServiceCategory
+
+	if err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&category).Error; err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (r *gormServiceCategoryRepository) EnsureRoot(ctx context.Context, name string) (*models.ServiceCategory, error) {
+	var category *models.ServiceCategory
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		category, err = rootCategory(tx, name)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("ошибка при получении корневой категории услуг", "error", err, "name", name)
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (r *gormServiceCategoryRepository) List(ctx context.Context) ([]models.ServiceCategory, error) {
+	var categories []models.ServiceCategory
+
+	if err := r.db.WithContext(ctx).Order("position ASC, name ASC").Find(&categories).Error; err != nil {
+		r.logger.Error("ошибка при получении категорий услуг", "error", err)
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (r *gormServiceCategoryRepository) Update(ctx context.Context, category *models.ServiceCategory) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(category).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Service{}).
+			Where("category_id = ?", category.ID).
+			Update("category", category.Name).Error
+	})
+	if err != nil {
+		r.logger.Error("ошибка при обновлении категории услуг", "error", err, "category_id", category.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormServiceCategoryRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&models.ServiceCategory{}, id).Error; err != nil {
+		r.logger.Error("ошибка при удалении категории услуг", "error", err, "category_id", id)
+		return err
+	}
+
+	r.logger.Info("категория услуг удалена", "category_id", id)
+	return nil
+}
+
+func (r *gormServiceCategoryRepository) InUse(ctx context.Context, id uint) (bool, error) {
+	db := r.db.WithContext(ctx)
+
+	for _, query := range []*gorm.DB{
+		db.Model(&models.ServiceCategory{}).Where("parent_id = ?", id),
+		db.Model(&models.Service{}).Where("category_id = ?", id),
+		db.Model(&models.ServiceBundle{}).Where("category_id = ?", id),
+	} {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// MigrateServiceCategories переносит строковые категории услуг в дерево:
+// для каждого названия без категории заводится корневая категория.
+// Повторный запуск ничего не делает.
+func MigrateServiceCategories(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var names []string
+		if err := tx.Model(&models.Service{}).
+			Where("category_id IS NULL AND category <> ''").
+			Distinct().
+			Pluck("category", &names).Error; err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			category, err := rootCategory(tx, name)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Model(&models.Service{}).
+				Where("category_id IS NULL AND category = ?", name).
+				Update("category_id", category.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// rootCategory находит корневую категорию по названию или заводит её.
+func rootCategory(tx *gorm.DB, name string) (*models.ServiceCategory, error) {
+	var category models.ServiceCategory
+	err := tx.Where("parent_id IS NULL AND name = ?", name).First(&category).Error
+	if err == nil {
+		return &category, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Названия бывают кириллицей, поэтому slug — по id; его можно поменять
+	// в справочнике.
+	category = models.ServiceCategory{Slug: fmt.Sprintf("migrating-%d", time.Now().UnixNano()), Name: name}
+	if err := tx.Create(&category).Error; err != nil {
+		return nil, err
+	}
+	category.Slug = fmt.Sprintf("category-%d", category.ID)
+	if err := tx.Model(&category).Update("slug", category.Slug).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
@@ -3,10 +3,12 @@ package repository
 import (
 	"context"
 	"log/slog"
+	"slices"
 
 	"github.com/mutsaevz/team-4-dentistry/internal/constants"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ServiceRepository interface {
@@ -14,15 +16,38 @@ type ServiceRepository interface {
 
 	GetByID(id uint) (*models.Service, error)
 
-	List(offset, limit int) ([]models.Service, error)
+	// List и ListByCategory при clinicID != 0 возвращают только услуги,
+	// доступные в филиале.
+	List(offset, limit int, clinicID uint) ([]models.Service, error)
 
-	ListByCategory(category string, offset, limit int) ([]models.Service, error)
+	ListByCategory(category string, offset, limit int, clinicID uint) ([]models.Service, error)
+
+	// ListByCategoryTree возвращает услуги категории и всех её подкатегорий.
+	ListByCategoryTree(categoryID uint, offset, limit int, clinicID uint) ([]models.Service, error)
 
 	Update(service *models.Service) error
 
 	Delete(id uint) error
 
+	// GetServicesByDoctorID возвращает услуги врача с его ценой и
+	// длительностью.
 	GetServicesByDoctorID(ctx context.Context, doctorID uint) ([]models.Service, error)
+
+	// GetForDoctor возвращает услугу с ценой и длительностью врача или
+	// gorm.ErrRecordNotFound, если врач её не оказывает.
+	GetForDoctor(ctx context.Context, doctorID, serviceID uint) (*models.Service, error)
+
+	// ListOfferings возвращает врачей, оказывающих услугу, с их ценами.
+	ListOfferings(ctx context.Context, serviceID uint) ([]models.DoctorOffering, error)
+
+	// SetOffering добавляет услугу врачу или меняет его цену и длительность.
+	SetOffering(ctx context.Context, offering *models.DoctorOffering) error
+
+	RemoveOffering(ctx context.Context, doctorID, serviceID uint) error
+
+	// AssignDoctorTx добавляет услуги врачу по ценам каталога. Если какой-то
+	// услуги нет, возвращает gorm.ErrRecordNotFound и ничего не меняет.
+	AssignDoctorTx(tx *gorm.DB, serviceIDs []uint, doctorID uint) error
 }
 
 type gormServiceRepository struct {
@@ -64,11 +89,16 @@ func (r *gormServiceRepository) GetByID(id uint) (*models.Service, error) {
 	return &service, nil
 }
 
-func (r *gormServiceRepository) List(offset, limit int) ([]models.Service, error) {
+func (r *gormServiceRepository) List(offset, limit int, clinicID uint) ([]models.Service, error) {
 	var services []models.Service
-	r.logger.Debug("получение списка services", "offset", offset, "limit", limit)
+	r.logger.Debug("получение списка services", "offset", offset, "limit", limit, "clinic_id", clinicID)
 
-	if err := r.db.
+	query := r.db.Model(&models.Service{})
+	if clinicID != 0 {
+		query = query.Scopes(serviceAvailableAt(clinicID))
+	}
+
+	if err := query.
 		Offset(offset).
 		Limit(limit).
 		Find(&services).Error; err != nil {
@@ -84,11 +114,17 @@ func (r *gormServiceRepository) ListByCategory(
 	category string,
 	offset,
 	limit int,
+	clinicID uint,
 ) ([]models.Service, error) {
 	var services []models.Service
-	r.logger.Debug("получение services по категории", "category", category, "offset", offset, "limit", limit)
+	r.logger.Debug("получение services по категории", "category", category, "offset", offset, "limit", limit, "clinic_id", clinicID)
 
-	if err := r.db.
+	query := r.db.Model(&models.Service{})
+	if clinicID != 0 {
+		query = query.Scopes(serviceAvailableAt(clinicID))
+	}
+
+	if err := query.
 		Where("category = ?", category).
 		Offset(offset).
 		Limit(limit).
@@ -101,6 +137,37 @@ func (r *gormServiceRepository) ListByCategory(
 	return services, nil
 }
 
+func (r *gormServiceRepository) ListByCategoryTree(
+	categoryID uint,
+	offset,
+	limit int,
+	clinicID uint,
+) ([]models.Service, error) {
+	var services []models.Service
+	r.logger.Debug("получение services по дереву категорий", "category_id", categoryID, "offset", offset, "limit", limit, "clinic_id", clinicID)
+
+	query := r.db.Model(&models.Service{})
+	if clinicID != 0 {
+		query = query.Scopes(serviceAvailableAt(clinicID))
+	}
+
+	if err := query.
+		Where("category_id IN (WITH RECURSIVE tree AS ("+
+			"SELECT id FROM service_categories WHERE id = ? AND deleted_at IS NULL "+
+			"UNION ALL "+
+			"SELECT c.id FROM service_categories c JOIN tree ON c.parent_id = tree.id WHERE c.deleted_at IS NULL"+
+			") SELECT id FROM tree)", categoryID).
+		Offset(offset).
+		Limit(limit).
+		Find(&services).Error; err != nil {
+		r.logger.Error("ошибка при получении services по дереву категорий", "error", err, "category_id", categoryID)
+		return nil, err
+	}
+
+	r.logger.Info("services по дереву категорий получены", "category_id", categoryID, "count", len(services))
+	return services, nil
+}
+
 func (r *gormServiceRepository) Update(service *models.Service) error {
 	if service == nil {
 		r.logger.Warn("попытка обновить nil service")
@@ -131,14 +198,271 @@ func (r *gormServiceRepository) Delete(id uint) error {
 }
 
 func (r *gormServiceRepository) GetServicesByDoctorID(ctx context.Context, doctorID uint) ([]models.Service, error) {
-	var services []models.Service
+	var rows []offeringRow
 
 	if err := r.db.
 		WithContext(ctx).
-		Where("doctor_id = ?", doctorID).
-		Find(&services).Error; err != nil {
+		Model(&models.Service{}).
+		Scopes(offeredBy(doctorID)).
+		Order("services.id").
+		Find(&rows).Error; err != nil {
 		return nil, err
 	}
 
+	services := make([]models.Service, len(rows))
+	for i := range rows {
+		services[i] = rows[i].service()
+	}
 	return services, nil
 }
+
+func (r *gormServiceRepository) GetForDoctor(ctx context.Context, doctorID, serviceID uint) (*models.Service, error) {
+	var row offeringRow
+
+	if err := r.db.
+		WithContext(ctx).
+		Model(&models.Service{}).
+		Scopes(offeredBy(doctorID)).
+		Where("services.id = ?", serviceID).
+		Take(&row).Error; err != nil {
+		r.logger.Warn("услуга врача не найдена", "error", err, "doctor_id", doctorID, "service_id", serviceID)
+		return nil, err
+	}
+
+	service := row.service()
+	return &service, nil
+}
+
+func (r *gormServiceRepository) ListOfferings(ctx context.Context, serviceID uint) ([]models.DoctorOffering, error) {
+	var offerings []models.DoctorOffering
+
+	if err := r.db.WithContext(ctx).
+		Joins("Doctor").
+		Where("doctor_services.service_id = ?", serviceID).
+		Order("doctor_services.doctor_id").
+		Find(&offerings).Error; err != nil {
+		r.logger.Error("ошибка при получении врачей услуги", "error", err, "service_id", serviceID)
+		return nil, err
+	}
+
+	return offerings, nil
+}
+
+func (r *gormServiceRepository) SetOffering(ctx context.Context, offering *models.DoctorOffering) error {
+	if err := r.db.WithContext(ctx).
+		Omit(clause.Associations).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "doctor_id"}, {Name: "service_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"price", "duration", "updated_at"}),
+		}).
+		Create(offering).Error; err != nil {
+		r.logger.Error("ошибка при сохранении услуги врача", "error", err, "doctor_id", offering.DoctorID, "service_id", offering.ServiceID)
+		return err
+	}
+
+	r.logger.Info("услуга врача сохранена", "doctor_id", offering.DoctorID, "service_id", offering.ServiceID)
+	return nil
+}
+
+func (r *gormServiceRepository) RemoveOffering(ctx context.Context, doctorID, serviceID uint) error {
+	res := r.db.WithContext(ctx).
+		Where("doctor_id = ? AND service_id = ?", doctorID, serviceID).
+		Delete(&models.DoctorOffering{})
+	if res.Error != nil {
+		r.logger.Error("ошибка при удалении услуги врача", "error", res.Error, "doctor_id", doctorID, "service_id", serviceID)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	r.logger.Info("услуга врача удалена", "doctor_id", doctorID, "service_id", serviceID)
+	return nil
+}
+
+func (r *gormServiceRepository) AssignDoctorTx(tx *gorm.DB, serviceIDs []uint, doctorID uint) error {
+	ids := slices.Clone(serviceIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	var count int64
+	if err := tx.Model(&models.Service{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+		r.logger.Error("ошибка при проверке услуг врача", "error", err, "doctor_id", doctorID)
+		return err
+	}
+	if count != int64(len(ids)) {
+		r.logger.Warn("не все услуги найдены при назначении врачу", "doctor_id", doctorID, "found", count, "requested", len(ids))
+		return gorm.ErrRecordNotFound
+	}
+
+	offerings := make([]models.DoctorOffering, 0, len(ids))
+	for _, id := range ids {
+		offerings = append(offerings, models.DoctorOffering{DoctorID: doctorID, ServiceID: id})
+	}
+	if err := tx.Omit(clause.Associations).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&offerings).Error; err != nil {
+		r.logger.Error("ошибка при назначении услуг врачу", "error", err, "doctor_id", doctorID)
+		return err
+	}
+
+	r.logger.Info("услуги назначены врачу", "doctor_id", doctorID, "count", len(ids))
+	return nil
+}
+
+// offeringRow — услуга вместе с ценой и длительностью конкретного врача.
+type offeringRow struct {
+	models.Service
+	DoctorPrice    *float64
+	DoctorDuration *int
+}
+
+func (row *offeringRow) service() models.Service {
+	offering := models.DoctorOffering{Price: row.DoctorPrice, Duration: row.DoctorDuration}
+	service := row.Service
+	offering.Apply(&service)
+	return service
+}
+
+// offeredBy оставляет услуги, которые оказывает врач, и выбирает его цену
+// и длительность.
+func offeredBy(doctorID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Select("services.*, ds.price AS doctor_price, ds.duration AS doctor_duration").
+			Joins("JOIN doctor_services ds ON ds.service_id = services.id AND ds.doctor_id = ?", doctorID)
+	}
+}
+
+// MigrateDoctorServices переносит врача из прежней колонки services.doctor_id
+// в связь doctor_services, сливает одноимённые услуги разных врачей в одну
+// услугу каталога и удаляет колонку. Повторный запуск ничего не делает.
+func MigrateDoctorServices(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.Service{}, "doctor_id") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(
+			"INSERT INTO doctor_services (doctor_id, service_id, created_at, updated_at) " +
+				"SELECT s.doctor_id, s.id, NOW(), NOW() FROM services s " +
+				"JOIN doctors d ON d.id = s.doctor_id " +
+				"ON CONFLICT DO NOTHING",
+		).Error; err != nil {
+			return err
+		}
+
+		var names []string
+		if err := tx.Model(&models.Service{}).
+			Group("LOWER(TRIM(name))").
+			Having("COUNT(*) > 1").
+			Pluck("LOWER(TRIM(name))", &names).Error; err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			var duplicates []models.Service
+			if err := tx.Where("LOWER(TRIM(name)) = ?", name).Order("id").Find(&duplicates).Error; err != nil {
+				return err
+			}
+			for i := 1; i < len(duplicates); i++ {
+				if err := mergeService(tx, &duplicates[0], &duplicates[i]); err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Migrator().DropColumn(&models.Service{}, "doctor_id")
+	})
+}
+
+// serviceReferences — таблицы, ссылающиеся на услугу по service_id.
+var serviceReferences = []any{
+	&models.Appointment{},
+	&models.AppointmentSeries{},
+	&models.WaitlistEntry{},
+	&models.WaitlistOffer{},
+	&models.SlotHold{},
+	&models.Recommendation{},
+	&models.ServiceBundleItem{},
+	&models.PatientPackageItem{},
+}
+
+// mergeService переносит врачей и ссылки услуги duplicate на survivor и
+// удаляет duplicate. Отличающиеся цена и длительность врача становятся его
+// переопределениями в doctor_services.
+func mergeService(tx *gorm.DB, survivor, duplicate *models.Service) error {
+	var offerings []models.DoctorOffering
+	if err := tx.Where("service_id = ?", duplicate.ID).Find(&offerings).Error; err != nil {
+		return err
+	}
+
+	for _, o := range offerings {
+		price, duration := duplicate.Price, duplicate.Duration
+		if o.Price != nil {
+			price = *o.Price
+		}
+		if o.Duration != nil {
+			duration = *o.Duration
+		}
+
+		merged := models.DoctorOffering{DoctorID: o.DoctorID, ServiceID: survivor.ID}
+		if price != survivor.Price {
+			merged.Price = &price
+		}
+		if duration != survivor.Duration {
+			merged.Duration = &duration
+		}
+		// Если врач уже оказывает survivor, его условия по ней остаются.
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&merged).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("service_id = ?", duplicate.ID).Delete(&models.DoctorOffering{}).Error; err != nil {
+		return err
+	}
+
+	for _, model := range serviceReferences {
+		if err := tx.Unscoped().Model(model).
+			Where("service_id = ?", duplicate.ID).
+			Update("service_id", survivor.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := mergeClinicServices(tx, survivor.ID, duplicate.ID); err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Delete(&models.Service{}, duplicate.ID).Error
+}
+
+// mergeClinicServices объединяет филиалы двух услуг. Услуга без записей в
+// clinic_services доступна везде, поэтому если хотя бы одна из услуг не
+// ограничена, не ограничена и объединённая.
+func mergeClinicServices(tx *gorm.DB, survivorID, duplicateID uint) error {
+	var survivorCount, duplicateCount int64
+	if err := tx.Table("clinic_services").Where("service_id = ?", survivorID).Count(&survivorCount).Error; err != nil {
+		return err
+	}
+	if err := tx.Table("clinic_services").Where("service_id = ?", duplicateID).Count(&duplicateCount).Error; err != nil {
+		return err
+	}
+
+	if survivorCount > 0 && duplicateCount > 0 {
+		if err := tx.Exec(
+			"INSERT INTO clinic_services (clinic_id, service_id) "+
+				"SELECT clinic_id, ? FROM clinic_services WHERE service_id = ? "+
+				"ON CONFLICT DO NOTHING",
+			survivorID, duplicateID,
+		).Error; err != nil {
+			return err
+		}
+	} else if survivorCount > 0 {
+		if err := tx.Exec("DELETE FROM clinic_services WHERE service_id = ?", survivorID).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Exec("DELETE FROM clinic_services WHERE service_id = ?", duplicateID).Error
+}
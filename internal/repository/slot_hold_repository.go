@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SlotHoldRepository interface {
+	// CreateIfFree создаёт удержание, только если время врача не удержано
+	// другим активным удержанием. Строка врача блокируется на время проверки,
+	// чтобы два параллельных запроса не удержали один и тот же слот.
+	CreateIfFree(ctx context.Context, hold *models.SlotHold) (bool, error)
+
+	GetByToken(ctx context.Context, token string) (*models.SlotHold, error)
+
+	// GetByTokenForUpdateTx блокирует удержание до конца транзакции, чтобы
+	// один токен нельзя было использовать для двух записей.
+	GetByTokenForUpdateTx(tx *gorm.DB, token string) (*models.SlotHold, error)
+
+	UpdateTx(tx *gorm.DB, hold *models.SlotHold) error
+
+	Update(ctx context.Context, hold *models.SlotHold) error
+
+	ReleaseByPatient(ctx context.Context, patientID uint) error
+
+	ExpireBefore(ctx context.Context, now time.Time) (int64, error)
+}
+
+type gormSlotHoldRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewSlotHoldRepository(db *gorm.DB, logger *slog.Logger) SlotHoldRepository {
+	return &gormSlotHoldRepository{db: db, logger: logger}
+}
+
+func (r *gormSlotHoldRepository) CreateIfFree(ctx context.Context, hold *models.SlotHold) (bool, error) {
+	created := false
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var doctor models.Doctor
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&doctor, hold.DoctorID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.SlotHold{}).
+			Where("doctor_id = ? AND status = ? AND expires_at > ? AND start_at < ? AND end_at > ?",
+				hold.DoctorID, models.HoldActive, time.Now(), hold.EndAt, hold.StartAt).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		if err := tx.Create(hold).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("ошибка при создании удержания слота", "error", err, "doctor_id", hold.DoctorID)
+		return false, err
+	}
+
+	if created {
+		r.logger.Info("слот удержан", "hold_id", hold.ID, "doctor_id", hold.DoctorID, "start_at", hold.StartAt, "expires_at", hold.ExpiresAt)
+	}
+	return created, nil
+}
+
+func (r *gormSlotHoldRepository) GetByToken(ctx context.Context, token string) (*models.SlotHold, error) {
+	var hold models.SlotHold
+
+	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&hold).Error; err != nil {
+		return nil, err
+	}
+
+	return &hold, nil
+}
+
+func (r *gormSlotHoldRepository) GetByTokenForUpdateTx(tx *gorm.DB, token string) (*models.SlotHold, error) {
+	var hold models.SlotHold
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token = ?", token).
+		First(&hold).Error; err != nil {
+		return nil, err
+	}
+
+	return &hold, nil
+}
+
+func (r *gormSlotHoldRepository) UpdateTx(tx *gorm.DB, hold *models.SlotHold) error {
+	if err := tx.Save(hold).Error; err != nil {
+		r.logger.Error("ошибка при обновлении удержания слота", "error", err, "hold_id", hold.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormSlotHoldRepository) Update(ctx context.Context, hold *models.SlotHold) error {
+	return r.UpdateTx(r.db.WithContext(ctx), hold)
+}
+
+func (r *gormSlotHoldRepository) ReleaseByPatient(ctx context.Context, patientID uint) error {
+	if err := r.db.WithContext(ctx).
+		Model(&models.SlotHold{}).
+		Where("patient_id = ? AND status = ?", patientID, models.HoldActive).
+		Update("status", models.HoldReleased).Error; err != nil {
+		r.logger.Error("ошибка при снятии удержаний пациента", "error", err, "patient_id", patientID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormSlotHoldRepository) ExpireBefore(ctx context.Context, now time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Model(&models.SlotHold{}).
+		Where("status = ? AND expires_at <= ?", models.HoldActive, now).
+		Update("status", models.HoldExpired)
+	if res.Error != nil {
+		r.logger.Error("ошибка при снятии просроченных удержаний", "error", res.Error)
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
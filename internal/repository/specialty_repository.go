@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+)
+
+type SpecialtyRepository interface {
+	Create(ctx context.Context, specialty *models.Specialty) error
+
+	GetByID(ctx context.Context, id uint) (*models.Specialty, error)
+
+	GetBySlug(ctx context.Context, slug string) (*models.Specialty, error)
+
+	List(ctx context.Context) ([]models.Specialty, error)
+
+	// Update сохраняет специализацию и обновляет её название у врачей.
+	Update(ctx context.Context, specialty *models.Specialty) error
+
+	Delete(ctx context.Context, id uint) error
+
+	// InUse сообщает, указана ли специализация хотя бы у одного врача.
+	InUse(ctx context.Context, id uint) (bool, error)
+}
+
+type gormSpecialtyRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewSpecialtyRepository(db *gorm.DB, logger *slog.Logger) SpecialtyRepository {
+	return &gormSpecialtyRepository{db: db, logger: logger}
+}
+
+func (r *gormSpecialtyRepository) Create(ctx context.Context, specialty *models.Specialty) error {
+	if err := r.db.WithContext(ctx).Create(specialty).Error; err != nil {
+		r.logger.Error("ошибка при создании специализации", "error", err, "slug", specialty.Slug)
+		return err
+	}
+
+	r.logger.Info("специализация создана", "specialty_id", specialty.ID)
+	return nil
+}
+
+func (r *gormSpecialtyRepository) GetByID(ctx context.Context, id uint) (*models.Specialty, error) {
+	var specialty models.Specialty
+
+	if err := r.db.WithContext(ctx).First(&specialty, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &specialty, nil
+}
+
+func (r *gormSpecialtyRepository) GetBySlug(ctx context.Context, slug string) (*models.Specialty, error) {
+	var specialty models.Specialty
+
+	if err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&specialty).Error; err != nil {
+		return nil, err
+	}
+
+	return &specialty, nil
+}
+
+func (r *gormSpecialtyRepository) List(ctx context.Context) ([]models.Specialty, error) {
+	var specialties []models.Specialty
+
+	if err := r.db.WithContext(ctx).Order("name ASC").Find(&specialties).Error; err != nil {
+		r.logger.Error("ошибка при получении специализаций", "error", err)
+		return nil, err
+	}
+
+	return specialties, nil
+}
+
+func (r *gormSpecialtyRepository) Update(ctx context.Context, specialty *models.Specialty) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(specialty).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Doctor{}).
+			Where("specialty_id = ?", specialty.ID).
+			Update("specialization", specialty.Name).Error
+	})
+	if err != nil {
+		r.logger.Error("ошибка при обновлении специализации", "error", err, "specialty_id", specialty.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormSpecialtyRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&models.Specialty{}, id).Error; err != nil {
+		r.logger.Error("ошибка при удалении специализации", "error", err, "specialty_id", id)
+		return err
+	}
+
+	r.logger.Info("специализация удалена", "specialty_id", id)
+	return nil
+}
+
+func (r *gormSpecialtyRepository) InUse(ctx context.Context, id uint) (bool, error) {
+	var count int64
+
+	if err := r.db.WithContext(ctx).Model(&models.Doctor{}).Where("specialty_id = ?", id).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
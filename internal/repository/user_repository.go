@@ -1,13 +1,26 @@
 package repository
 
 import (
+	"errors"
 	"log/slog"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/mutsaevz/team-4-dentistry/internal/constants"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"gorm.io/gorm"
 )
 
+// ErrEmailExists возвращается, когда email уже занят: проверка до
+// транзакции не спасает от параллельной регистрации, решает уникальный
+// индекс.
+var ErrEmailExists = errors.New("email уже занят")
+
+// isUniqueViolation сообщает, что запись нарушила уникальный индекс.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
 type UserRepository interface {
 	Create(user *models.User) error
 
@@ -20,6 +33,10 @@ type UserRepository interface {
 	Update(user *models.User) error
 
 	Delete(id uint) error
+
+	CreateTx(tx *gorm.DB, user *models.User) error
+
+	UpdateTx(tx *gorm.DB, user *models.User) error
 }
 
 type gormUserRepository struct {
@@ -120,3 +137,37 @@ func (r *gormUserRepository) Delete(id uint) error {
 	r.logger.Info("user успешно удален", "user_id", id)
 	return nil
 }
+
+func (r *gormUserRepository) CreateTx(tx *gorm.DB, user *models.User) error {
+	if user == nil {
+		r.logger.Warn("попытка создать nil user")
+		return constants.User_IS_nil
+	}
+
+	if err := tx.Create(user).Error; err != nil {
+		if isUniqueViolation(err) {
+			r.logger.Warn("email уже занят", "email", user.Email)
+			return ErrEmailExists
+		}
+		r.logger.Error("ошибка при создании user", "error", err, "email", user.Email)
+		return err
+	}
+
+	r.logger.Info("user создан", "user_id", user.ID, "email", user.Email)
+	return nil
+}
+
+func (r *gormUserRepository) UpdateTx(tx *gorm.DB, user *models.User) error {
+	if user == nil {
+		r.logger.Warn("попытка обновить nil user")
+		return constants.User_IS_nil
+	}
+
+	if err := tx.Save(user).Error; err != nil {
+		r.logger.Error("ошибка при обновлении user", "error", err, "user_id", user.ID)
+		return err
+	}
+
+	r.logger.Info("user успешно обновлен", "user_id", user.ID)
+	return nil
+}
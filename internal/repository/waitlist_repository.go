@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WaitlistRepository interface {
+	CreateEntry(ctx context.Context, entry *models.WaitlistEntry) error
+
+	GetEntry(ctx context.Context, id uint) (*models.WaitlistEntry, error)
+
+	// GetEntryForUpdateTx читает заявку с блокировкой строки до конца
+	// транзакции.
+	GetEntryForUpdateTx(tx *gorm.DB, id uint) (*models.WaitlistEntry, error)
+
+	ListEntries(ctx context.Context, params models.WaitlistQueryParams) ([]models.WaitlistEntry, error)
+
+	// ListWaiting возвращает ожидающих к врачу в порядке очереди.
+	ListWaiting(ctx context.Context, doctorID uint) ([]models.WaitlistEntry, error)
+
+	UpdateEntryTx(tx *gorm.DB, entry *models.WaitlistEntry) error
+
+	ExpireEntries(ctx context.Context, before time.Time) (int64, error)
+
+	CreateOfferTx(tx *gorm.DB, offer *models.WaitlistOffer) error
+
+	GetOfferByToken(ctx context.Context, token string) (*models.WaitlistOffer, error)
+
+	// GetOfferForUpdateTx читает предложение с блокировкой строки до конца
+	// транзакции.
+	GetOfferForUpdateTx(tx *gorm.DB, id uint) (*models.WaitlistOffer, error)
+
+	ListOffersByPatient(ctx context.Context, patientID uint) ([]models.WaitlistOffer, error)
+
+	ListExpiredOffers(ctx context.Context, now time.Time) ([]models.WaitlistOffer, error)
+
+	// WasOffered сообщает, предлагалось ли это окно пациенту по заявке.
+	WasOffered(ctx context.Context, entryID, doctorID uint, startAt time.Time) (bool, error)
+
+	HasActiveOffer(ctx context.Context, doctorID uint, startAt, endAt time.Time) (bool, error)
+
+	UpdateOfferTx(tx *gorm.DB, offer *models.WaitlistOffer) error
+
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+}
+
+type gormWaitlistRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewWaitlistRepository(db *gorm.DB, logger *slog.Logger) WaitlistRepository {
+	return &gormWaitlistRepository{db: db, logger: logger}
+}
+
+func (r *gormWaitlistRepository) CreateEntry(ctx context.Context, entry *models.WaitlistEntry) error {
+	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
+		r.logger.Error("ошибка при добавлении в лист ожидания", "error", err, "patient_id", entry.PatientID)
+		return err
+	}
+
+	r.logger.Info("пациент добавлен в лист ожидания", "entry_id", entry.ID, "doctor_id", entry.DoctorID)
+	return nil
+}
+
+func (r *gormWaitlistRepository) GetEntry(ctx context.Context, id uint) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+
+	if err := r.db.WithContext(ctx).First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (r *gormWaitlistRepository) GetEntryForUpdateTx(tx *gorm.DB, id uint) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (r *gormWaitlistRepository) ListEntries(ctx context.Context, params models.WaitlistQueryParams) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+
+	query := r.db.WithContext(ctx).Model(&models.WaitlistEntry{})
+
+	if params.DoctorID != 0 {
+		query = query.Where("doctor_id = ?", params.DoctorID)
+	}
+	if params.PatientID != 0 {
+		query = query.Where("patient_id = ?", params.PatientID)
+	}
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+
+	if err := query.Order("created_at ASC").Find(&entries).Error; err != nil {
+		r.logger.Error("ошибка при получении листа ожидания", "error", err)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (r *gormWaitlistRepository) ListWaiting(ctx context.Context, doctorID uint) ([]models.WaitlistEntry, error) {
+	return r.ListEntries(ctx, models.WaitlistQueryParams{DoctorID: doctorID, Status: models.WaitlistWaiting})
+}
+
+func (r *gormWaitlistRepository) UpdateEntryTx(tx *gorm.DB, entry *models.WaitlistEntry) error {
+	if err := tx.Save(entry).Error; err != nil {
+		r.logger.Error("ошибка при обновлении записи листа ожидания", "error", err, "entry_id", entry.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormWaitlistRepository) ExpireEntries(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Model(&models.WaitlistEntry{}).
+		Where("status = ? AND date_to < ?", models.WaitlistWaiting, before).
+		Update("status", models.WaitlistExpired)
+	if res.Error != nil {
+		r.logger.Error("ошибка при закрытии устаревших заявок листа ожидания", "error", res.Error)
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+func (r *gormWaitlistRepository) CreateOfferTx(tx *gorm.DB, offer *models.WaitlistOffer) error {
+	if err := tx.Create(offer).Error; err != nil {
+		r.logger.Error("ошибка при создании предложения из листа ожидания", "error", err, "entry_id", offer.EntryID)
+		return err
+	}
+
+	r.logger.Info("предложение из листа ожидания создано", "offer_id", offer.ID, "entry_id", offer.EntryID, "start_at", offer.StartAt)
+	return nil
+}
+
+func (r *gormWaitlistRepository) GetOfferByToken(ctx context.Context, token string) (*models.WaitlistOffer, error) {
+	var offer models.WaitlistOffer
+
+	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&offer).Error; err != nil {
+		return nil, err
+	}
+
+	return &offer, nil
+}
+
+func (r *gormWaitlistRepository) GetOfferForUpdateTx(tx *gorm.DB, id uint) (*models.WaitlistOffer, error) {
+	var offer models.WaitlistOffer
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&offer, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &offer, nil
+}
+
+func (r *gormWaitlistRepository) ListOffersByPatient(ctx context.Context, patientID uint) ([]models.WaitlistOffer, error) {
+	var offers []models.WaitlistOffer
+
+	if err := r.db.WithContext(ctx).
+		Where("patient_id = ?", patientID).
+		Order("created_at DESC").
+		Find(&offers).Error; err != nil {
+		r.logger.Error("ошибка при получении предложений пациента", "error", err, "patient_id", patientID)
+		return nil, err
+	}
+
+	return offers, nil
+}
+
+func (r *gormWaitlistRepository) ListExpiredOffers(ctx context.Context, now time.Time) ([]models.WaitlistOffer, error) {
+	var offers []models.WaitlistOffer
+
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", models.OfferPending, now).
+		Find(&offers).Error; err != nil {
+		r.logger.Error("ошибка при получении просроченных предложений", "error", err)
+		return nil, err
+	}
+
+	return offers, nil
+}
+
+func (r *gormWaitlistRepository) WasOffered(ctx context.Context, entryID, doctorID uint, startAt time.Time) (bool, error) {
+	var count int64
+
+	if err := r.db.WithContext(ctx).
+		Model(&models.WaitlistOffer{}).
+		Where("entry_id = ? AND doctor_id = ? AND start_at = ?", entryID, doctorID, startAt).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *gormWaitlistRepository) HasActiveOffer(ctx context.Context, doctorID uint, startAt, endAt time.Time) (bool, error) {
+	var count int64
+
+	if err := r.db.WithContext(ctx).
+		Model(&models.WaitlistOffer{}).
+		Where("doctor_id = ? AND status = ? AND expires_at > ? AND start_at < ? AND end_at > ?",
+			doctorID, models.OfferPending, time.Now(), endAt, startAt).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *gormWaitlistRepository) UpdateOfferTx(tx *gorm.DB, offer *models.WaitlistOffer) error {
+	if err := tx.Save(offer).Error; err != nil {
+		r.logger.Error("ошибка при обновлении предложения из листа ожидания", "error", err, "offer_id", offer.ID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormWaitlistRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	if err := r.db.WithContext(ctx).Transaction(fn); err != nil {
+		r.logger.Error("ошибка при выполнении транзакции листа ожидания", "error", err)
+		return err
+	}
+	return nil
+}
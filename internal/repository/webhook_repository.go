@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+
+	GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error)
+
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+
+	ListActiveSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+
+	UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+
+	DeleteSubscription(ctx context.Context, id uint) error
+
+	// EnqueueDelivery создаёт доставку, если для пары (подписка, событие)
+	// её ещё нет.
+	EnqueueDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	GetDelivery(ctx context.Context, id uint) (*models.WebhookDelivery, error)
+
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error)
+
+	ListDeliveries(ctx context.Context, params models.WebhookDeliveryQueryParams) ([]models.WebhookDelivery, error)
+
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+}
+
+type gormWebhookRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewWebhookRepository(db *gorm.DB, logger *slog.Logger) WebhookRepository {
+	return &gormWebhookRepository{db: db, logger: logger}
+}
+
+func (r *gormWebhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	if err := r.db.WithContext(ctx).Create(sub).Error; err != nil {
+		r.logger.Error("ошибка при создании webhook подписки", "error", err, "url", sub.URL)
+		return err
+	}
+
+	r.logger.Info("webhook подписка создана", "subscription_id", sub.ID)
+	return nil
+}
+
+func (r *gormWebhookRepository) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+
+	if err := r.db.WithContext(ctx).First(&sub, id).Error; err != nil {
+		r.logger.Error("ошибка при получении webhook подписки", "error", err, "subscription_id", id)
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+func (r *gormWebhookRepository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+
+	if err := r.db.WithContext(ctx).Order("id ASC").Find(&subs).Error; err != nil {
+		r.logger.Error("ошибка при получении webhook подписок", "error", err)
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func (r *gormWebhookRepository) ListActiveSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+
+	if err := r.db.WithContext(ctx).Where("is_active = ?", true).Find(&subs).Error; err != nil {
+		r.logger.Error("ошибка при получении активных webhook подписок", "error", err)
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func (r *gormWebhookRepository) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	if err := r.db.WithContext(ctx).Save(sub).Error; err != nil {
+		r.logger.Error("ошибка при обновлении webhook подписки", "error", err, "subscription_id", sub.ID)
+		return err
+	}
+
+	r.logger.Info("webhook подписка обновлена", "subscription_id", sub.ID)
+	return nil
+}
+
+func (r *gormWebhookRepository) DeleteSubscription(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&models.WebhookSubscription{}, id).Error; err != nil {
+		r.logger.Error("ошибка при удалении webhook подписки", "error", err, "subscription_id", id)
+		return err
+	}
+
+	r.logger.Info("webhook подписка удалена", "subscription_id", id)
+	return nil
+}
+
+func (r *gormWebhookRepository) EnqueueDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(delivery).Error; err != nil {
+		r.logger.Error("ошибка при постановке webhook в очередь", "error", err, "subscription_id", delivery.SubscriptionID, "event_id", delivery.EventID)
+		return err
+	}
+	return nil
+}
+
+func (r *gormWebhookRepository) GetDelivery(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+
+	if err := r.db.WithContext(ctx).First(&delivery, id).Error; err != nil {
+		r.logger.Error("ошибка при получении webhook доставки", "error", err, "delivery_id", id)
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func (r *gormWebhookRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	if err := r.db.WithContext(ctx).
+		Where("status IN ? AND next_attempt_at <= ?", []models.WebhookDeliveryStatus{models.WebhookDeliveryPending, models.WebhookDeliveryFailed}, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		r.logger.Error("ошибка при получении webhook доставок", "error", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *gormWebhookRepository) ListDeliveries(ctx context.Context, params models.WebhookDeliveryQueryParams) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	query := r.db.WithContext(ctx).Model(&models.WebhookDelivery{})
+
+	if params.SubscriptionID != 0 {
+		query = query.Where("subscription_id = ?", params.SubscriptionID)
+	}
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+	if params.EventType != "" {
+		query = query.Where("event_type = ?", params.EventType)
+	}
+
+	if err := query.Order("id DESC").Find(&deliveries).Error; err != nil {
+		r.logger.Error("ошибка при получении журнала webhook доставок", "error", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *gormWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if err := r.db.WithContext(ctx).Save(delivery).Error; err != nil {
+		r.logger.Error("ошибка при обновлении webhook доставки", "error", err, "delivery_id", delivery.ID)
+		return err
+	}
+	return nil
+}
@@ -22,6 +22,16 @@ func SeedAdmin(userRepo repository.UserRepository, logger *slog.Logger) error {
 	existing, _ := userRepo.GetByEmail(adminEmail)
 
 	if existing != nil {
+		// Первый администратор — администратор сети: без этого после
+		// появления филиалов никто не смог бы ими управлять.
+		if existing.Role == models.Admin && !existing.AllClinics {
+			existing.AllClinics = true
+			if err := userRepo.Update(existing); err != nil {
+				return err
+			}
+			logger.Info("[seed admin] админу открыт доступ ко всем филиалам", "email", adminEmail)
+			return nil
+		}
 		logger.Info("[seed admin] админ уже существует — skip")
 		return nil
 	}
@@ -32,9 +42,10 @@ func SeedAdmin(userRepo repository.UserRepository, logger *slog.Logger) error {
 	}
 
 	admin := &models.User{
-		Email:    adminEmail,
-		Password: string(hash),
-		Role:     "admin",
+		Email:      adminEmail,
+		Password:   string(hash),
+		Role:       "admin",
+		AllClinics: true,
 	}
 
 	if err := userRepo.Create(admin); err != nil {
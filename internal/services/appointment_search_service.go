@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/localtime"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidBoardView   = errors.New("вид доски должен быть day или week")
+	ErrBoardPeriodInQuery = errors.New("период доски задаётся параметрами view и date, а не from и to")
+)
+
+type AppointmentSearchConfig struct {
+	// Location — часовой пояс доски, если филиал не выбран.
+	Location *time.Location
+}
+
+type AppointmentSearchService interface {
+	// Search ищет приёмы по фильтрам списка и имени или телефону пациента.
+	// Сотрудник, закреплённый за филиалами, видит только их приёмы.
+	Search(ctx context.Context, q models.ListQuery, patient string) (*models.Page[models.Appointment], error)
+
+	// Board собирает доску регистратуры: приёмы за день или неделю,
+	// сгруппированные по врачам и дням по местному времени филиала.
+	Board(ctx context.Context, board models.AppointmentBoardQuery, q models.ListQuery, patient string) (*models.AppointmentBoard, error)
+}
+
+type appointmentSearchService struct {
+	appointments repository.AppointmentRepository
+	clinics      repository.ClinicRepository
+	cfg          AppointmentSearchConfig
+	logger       *slog.Logger
+}
+
+func NewAppointmentSearchService(
+	appointments repository.AppointmentRepository,
+	clinics repository.ClinicRepository,
+	cfg AppointmentSearchConfig,
+	logger *slog.Logger,
+) AppointmentSearchService {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+
+	return &appointmentSearchService{appointments: appointments, clinics: clinics, cfg: cfg, logger: logger}
+}
+
+func (s *appointmentSearchService) Search(ctx context.Context, q models.ListQuery, patient string) (*models.Page[models.Appointment], error) {
+	search, err := s.scope(ctx, q, patient)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.appointments.Search(ctx, q, search)
+	if err != nil {
+		if errors.Is(err, constants.ErrInvalidListQuery) {
+			return nil, err
+		}
+		return nil, constants.ErrGetAppointments
+	}
+
+	return page, nil
+}
+
+func (s *appointmentSearchService) Board(ctx context.Context, board models.AppointmentBoardQuery, q models.ListQuery, patient string) (*models.AppointmentBoard, error) {
+	if q.From != nil || q.To != nil {
+		return nil, ErrBoardPeriodInQuery
+	}
+
+	days := 1
+	switch board.View {
+	case "", models.BoardDay:
+		board.View = models.BoardDay
+	case models.BoardWeek:
+		days = 7
+	default:
+		return nil, ErrInvalidBoardView
+	}
+
+	search, err := s.scope(ctx, q, patient)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := s.location(ctx, q, search.ClinicIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	from := localtime.StartOfDay(time.Now(), loc)
+	if board.Date != "" {
+		if from, err = time.ParseInLocation(localtime.DateLayout, board.Date, loc); err != nil {
+			return nil, localtime.ErrInvalidDate
+		}
+	}
+	if board.View == models.BoardWeek {
+		// Неделя доски начинается с понедельника.
+		from = localtime.AddDays(from, loc, -((int(from.Weekday()) + 6) % 7))
+	}
+	to := localtime.AddDays(from, loc, days)
+	q.From, q.To = &from, &to
+
+	appointments, err := s.appointments.GetBoard(ctx, q, search)
+	if err != nil {
+		if errors.Is(err, constants.ErrInvalidListQuery) {
+			return nil, err
+		}
+		return nil, constants.ErrGetAppointments
+	}
+
+	result := &models.AppointmentBoard{
+		View:     board.View,
+		From:     from,
+		To:       to,
+		Timezone: loc.String(),
+		Days:     make([]string, days),
+		Doctors:  []models.AppointmentBoardDoctor{},
+	}
+	index := make(map[string]int, days)
+	for i := range result.Days {
+		result.Days[i] = localtime.AddDays(from, loc, i).Format(localtime.DateLayout)
+		index[result.Days[i]] = i
+	}
+
+	// Репозиторий отдаёт приёмы по врачам подряд.
+	for _, a := range appointments {
+		a.StartAt = a.StartAt.In(loc)
+		a.EndAt = a.EndAt.In(loc)
+
+		n := len(result.Doctors)
+		if n == 0 || result.Doctors[n-1].DoctorID != a.DoctorID {
+			doctor := models.AppointmentBoardDoctor{
+				DoctorID: a.DoctorID,
+				Doctor:   a.Doctor,
+				Days:     make([]models.AppointmentBoardDay, days),
+			}
+			for i, day := range result.Days {
+				doctor.Days[i] = models.AppointmentBoardDay{Date: day, Appointments: []models.Appointment{}}
+			}
+			result.Doctors = append(result.Doctors, doctor)
+			n++
+		}
+
+		doctor := &result.Doctors[n-1]
+		day := &doctor.Days[index[a.StartAt.Format(localtime.DateLayout)]]
+		day.Appointments = append(day.Appointments, a)
+		doctor.Total++
+	}
+
+	s.logger.Info("доска приёмов собрана", "view", board.View, "from", from, "doctors", len(result.Doctors), "count", len(appointments))
+	return result, nil
+}
+
+// scope ограничивает поиск филиалами сотрудника и проверяет доступ к
+// филиалу из фильтра.
+func (s *appointmentSearchService) scope(ctx context.Context, q models.ListQuery, patient string) (models.AppointmentSearch, error) {
+	search := models.AppointmentSearch{Patient: patient}
+	search.ClinicIDs, _ = ClinicScopeIDs(ctx)
+
+	if clinicID, ok := filterClinicID(q); ok && !ClinicAllowed(ctx, &clinicID) {
+		return search, ErrClinicForbidden
+	}
+
+	return search, nil
+}
+
+// location выбирает часовой пояс доски: филиала из фильтра или
+// единственного филиала сотрудника, иначе пояс по умолчанию.
+func (s *appointmentSearchService) location(ctx context.Context, q models.ListQuery, scope []uint) (*time.Location, error) {
+	clinicID, ok := filterClinicID(q)
+	if !ok && len(scope) == 1 {
+		clinicID, ok = scope[0], true
+	}
+	if !ok {
+		return s.cfg.Location, nil
+	}
+
+	clinic, err := s.clinics.GetByID(ctx, clinicID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClinicNotFound
+		}
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(clinic.Timezone)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+// filterClinicID достаёт филиал из фильтров списка; некорректное значение
+// отклонит сам репозиторий.
+func filterClinicID(q models.ListQuery) (uint, bool) {
+	id, err := strconv.ParseUint(q.Filters["clinic_id"], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
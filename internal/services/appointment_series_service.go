@@ -0,0 +1,581 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/events"
+	"github.com/mutsaevz/team-4-dentistry/internal/localtime"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+// maxSeriesOccurrences ограничивает длину серии, чтобы случайный until
+// через десять лет не занял расписание врача на годы вперёд.
+const maxSeriesOccurrences = 52
+
+var (
+	ErrSeriesNotFound           = errors.New("серия приёмов не найдена")
+	ErrSeriesForbidden          = errors.New("нет доступа к этой серии приёмов")
+	ErrInvalidSeries            = errors.New("некорректная серия: укажите частоту, интервал и count или until")
+	ErrSeriesTooLong            = fmt.Errorf("серия не может содержать больше %d приёмов", maxSeriesOccurrences)
+	ErrSeriesConflicts          = errors.New("часть приёмов серии конфликтует с расписанием")
+	ErrSeriesOccurrenceNotFound = errors.New("приём не относится к этой серии")
+	ErrSeriesOccurrenceLocked   = errors.New("приём уже прошёл, завершён или отменён")
+	ErrInvalidSeriesScope       = errors.New("некорректная область изменения: ожидается this, following или all")
+	ErrSeriesNeedsStaff         = errors.New("по правилам бронирования серию приёмов может оформить только сотрудник клиники")
+	ErrSeriesPolicyRestricted   = errors.New("по правилам бронирования пациенту нужна предоплата или подтверждение записи: приёмы оформляются по одному")
+)
+
+type AppointmentSeriesService interface {
+	// Create создаёт серию и её приёмы. Занятые даты возвращаются в
+	// Conflicts; без SkipConflicts серия при конфликтах не создаётся.
+	Create(ctx context.Context, userID uint, role string, req models.AppointmentSeriesCreateRequest) (*models.AppointmentSeriesResult, error)
+
+	GetByID(ctx context.Context, userID uint, role string, id uint) (*models.AppointmentSeries, error)
+
+	// UpdateOccurrences изменяет приём серии и, в зависимости от scope,
+	// следующие за ним или все предстоящие приёмы.
+	UpdateOccurrences(ctx context.Context, userID uint, role string, seriesID, appointmentID uint, scope models.SeriesScope, req models.AppointmentSeriesUpdateRequest) ([]models.Appointment, error)
+
+	CancelOccurrences(ctx context.Context, userID uint, role string, seriesID, appointmentID uint, scope models.SeriesScope) ([]models.Appointment, error)
+}
+
+type AppointmentSeriesConfig struct {
+	// Location — часовой пояс для приёмов вне филиалов.
+	Location *time.Location
+}
+
+type appointmentSeriesService struct {
+	series       repository.AppointmentSeriesRepository
+	appointments repository.AppointmentRepository
+	services     repository.ServiceRepository
+	doctors      repository.DoctorRepository
+	schedules    repository.ScheduleRepository
+	clinics      repository.ClinicRepository
+	outbox       repository.OutboxRepository
+	policy       BookingPolicyService
+	cfg          AppointmentSeriesConfig
+	logger       *slog.Logger
+}
+
+func NewAppointmentSeriesService(
+	series repository.AppointmentSeriesRepository,
+	appointments repository.AppointmentRepository,
+	serviceRepo repository.ServiceRepository,
+	doctors repository.DoctorRepository,
+	schedules repository.ScheduleRepository,
+	clinics repository.ClinicRepository,
+	outbox repository.OutboxRepository,
+	policy BookingPolicyService,
+	cfg AppointmentSeriesConfig,
+	logger *slog.Logger,
+) AppointmentSeriesService {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	return &appointmentSeriesService{
+		series:       series,
+		appointments: appointments,
+		services:     serviceRepo,
+		doctors:      doctors,
+		schedules:    schedules,
+		clinics:      clinics,
+		outbox:       outbox,
+		policy:       policy,
+		cfg:          cfg,
+		logger:       logger,
+	}
+}
+
+func (s *appointmentSeriesService) Create(
+	ctx context.Context,
+	userID uint,
+	role string,
+	req models.AppointmentSeriesCreateRequest,
+) (*models.AppointmentSeriesResult, error) {
+	patientID := userID
+	if models.Role(role) != models.Patient && req.PatientID != 0 {
+		patientID = req.PatientID
+	}
+
+	if req.Interval == 0 {
+		req.Interval = 1
+	}
+	if req.DoctorID == 0 || req.ServiceID == 0 || req.Interval < 0 || (req.Count == 0 && req.Until == nil) {
+		return nil, ErrInvalidSeries
+	}
+	if !req.StartAt.After(time.Now()) {
+		return nil, constants.ErrInvalidAppointmentTime
+	}
+
+	// Правила бронирования действуют, кто бы ни оформлял серию. Предоплату
+	// и подтверждение серия не поддерживает: такому пациенту приёмы
+	// оформляются по одному.
+	decision, err := s.policy.Evaluate(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	if decision.RequireDeposit || decision.RequireApproval {
+		if models.Role(role) == models.Patient {
+			return nil, ErrSeriesNeedsStaff
+		}
+		return nil, ErrSeriesPolicyRestricted
+	}
+
+	// Приёмы повторяются по настенному времени филиала, где врач работает
+	// в первый приём серии.
+	loc, err := newClinicLocations(s.clinics, s.cfg.Location).forShift(ctx, s.schedules, req.DoctorID, req.StartAt)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences, err := seriesOccurrences(req.StartAt, loc, req.Frequency, req.Interval, req.Count, req.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	// Цена и длительность — из прайса врача.
+	service, err := s.doctorService(ctx, req.DoctorID, req.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	duration := time.Duration(service.Duration) * time.Minute
+
+	series := &models.AppointmentSeries{
+		PatientID: patientID,
+		DoctorID:  req.DoctorID,
+		ServiceID: req.ServiceID,
+		StartAt:   req.StartAt,
+		Frequency: req.Frequency,
+		Interval:  req.Interval,
+		Count:     req.Count,
+		Until:     req.Until,
+		Price:     service.Price,
+		Status:    models.SeriesActive,
+	}
+
+	result := &models.AppointmentSeriesResult{
+		Appointments: []models.Appointment{},
+		Conflicts:    []models.SeriesOccurrenceConflict{},
+	}
+
+	err = s.appointments.Transaction(func(tx *gorm.DB) error {
+		if err := s.series.CreateTx(tx, series); err != nil {
+			return err
+		}
+
+		for i, start := range occurrences {
+			appointment := &models.Appointment{
+				PatientID:   patientID,
+				DoctorID:    req.DoctorID,
+				ServiceID:   req.ServiceID,
+				StartAt:     start,
+				EndAt:       start.Add(duration),
+				Price:       service.Price,
+				Status:      models.AppointmentScheduled,
+				SeriesID:    &series.ID,
+				SeriesIndex: i + 1,
+			}
+
+			// Каждый приём — в своей точке сохранения: конфликт одной даты
+			// откатывает только её, а не всю серию.
+			err := tx.Transaction(func(sp *gorm.DB) error {
+				// Лимит проверяется для каждого приёма: уже созданные приёмы
+				// серии тоже считаются будущими записями.
+				if err := s.policy.CheckBookingLimitTx(ctx, sp, patientID); err != nil {
+					return err
+				}
+				if err := s.appointments.CreateTx(sp, appointment); err != nil {
+					return err
+				}
+				if !ClinicAllowed(ctx, appointment.ClinicID) {
+					return ErrClinicForbidden
+				}
+				return publishTx(sp, s.outbox, events.AppointmentBooked, events.AggregateAppointment, appointment.ID, events.NewAppointmentPayload(appointment))
+			})
+			if err != nil {
+				if !isSlotConflict(err) {
+					return err
+				}
+				result.Conflicts = append(result.Conflicts, models.SeriesOccurrenceConflict{
+					Index:   i + 1,
+					StartAt: start,
+					Error:   err.Error(),
+				})
+				continue
+			}
+
+			result.Appointments = append(result.Appointments, *appointment)
+		}
+
+		if len(result.Appointments) == 0 || (len(result.Conflicts) > 0 && !req.SkipConflicts) {
+			return ErrSeriesConflicts
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrSeriesConflicts) {
+			s.logger.Warn("серия приёмов не создана из-за конфликтов", "patient_id", patientID, "conflicts", len(result.Conflicts))
+			result.Appointments = []models.Appointment{}
+			return result, err
+		}
+		s.logger.Error("не удалось создать серию приёмов", "error", err, "patient_id", patientID)
+		return nil, err
+	}
+
+	result.Series = series
+	s.logger.Info("серия приёмов создана", "series_id", series.ID, "created", len(result.Appointments), "skipped", len(result.Conflicts))
+	return result, nil
+}
+
+func (s *appointmentSeriesService) GetByID(ctx context.Context, userID uint, role string, id uint) (*models.AppointmentSeries, error) {
+	series, err := s.series.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSeriesNotFound
+		}
+		return nil, err
+	}
+
+	switch models.Role(role) {
+	case models.Patient:
+		if series.PatientID != userID {
+			return nil, ErrSeriesForbidden
+		}
+	case models.Doc:
+		doctor, err := s.doctors.GetByUserID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrSeriesForbidden
+			}
+			return nil, err
+		}
+		if series.DoctorID != doctor.ID {
+			return nil, ErrSeriesForbidden
+		}
+	}
+
+	// Сотрудник с ограничением по филиалам видит серию, только если все её
+	// приёмы проходят в его филиалах.
+	for i := range series.Appointments {
+		if !ClinicAllowed(ctx, series.Appointments[i].ClinicID) {
+			return nil, ErrClinicForbidden
+		}
+	}
+
+	return series, nil
+}
+
+func (s *appointmentSeriesService) UpdateOccurrences(
+	ctx context.Context,
+	userID uint,
+	role string,
+	seriesID, appointmentID uint,
+	scope models.SeriesScope,
+	req models.AppointmentSeriesUpdateRequest,
+) ([]models.Appointment, error) {
+	if req.Price != nil && *req.Price < 0 {
+		return nil, constants.ErrInvalidPrice
+	}
+
+	series, target, targets, err := s.resolveScope(ctx, userID, role, seriesID, appointmentID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.StartAt != nil && req.StartAt.Before(time.Now()) {
+		return nil, constants.ErrInvalidAppointmentTime
+	}
+
+	// Перенос задаётся в настенном времени филиала: приёмы сдвигаются на
+	// столько же календарных дней, что и выбранный, и получают его новое
+	// время, даже если между ними переводились часы.
+	locations := newClinicLocations(s.clinics, s.cfg.Location)
+	targetLoc, err := locations.get(ctx, target.ClinicID)
+	if err != nil {
+		return nil, err
+	}
+	move := func(t time.Time, loc *time.Location) (time.Time, error) {
+		if req.StartAt == nil {
+			return t, nil
+		}
+		days := calendarDays(target.StartAt, *req.StartAt, targetLoc)
+		day := localtime.AddDays(t, loc, days).Format(localtime.DateLayout)
+		return localtime.At(day, req.StartAt.In(targetLoc).Format(localtime.ClockLayout), loc)
+	}
+
+	doctorID, serviceID := series.DoctorID, series.ServiceID
+	if req.DoctorID != nil {
+		doctorID = *req.DoctorID
+	}
+	if req.ServiceID != nil {
+		serviceID = *req.ServiceID
+	}
+	service, err := s.doctorService(ctx, doctorID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	duration := time.Duration(service.Duration) * time.Minute
+
+	// Смена врача или услуги пересчитывает цену по прайсу, если цена не
+	// задана явно.
+	price := req.Price
+	if price == nil && (req.DoctorID != nil || req.ServiceID != nil) {
+		price = &service.Price
+	}
+
+	err = s.appointments.Transaction(func(tx *gorm.DB) error {
+		for i := range targets {
+			appointment := &targets[i]
+			previous := *appointment
+
+			loc, err := locations.get(ctx, appointment.ClinicID)
+			if err != nil {
+				return err
+			}
+			start, err := move(appointment.StartAt, loc)
+			if err != nil {
+				return fmt.Errorf("приём №%d (%s): %w", appointment.SeriesIndex, appointment.StartAt.Format("02.01.2006 15:04"), err)
+			}
+
+			if req.DoctorID != nil {
+				appointment.DoctorID = *req.DoctorID
+			}
+			if req.ServiceID != nil {
+				appointment.ServiceID = *req.ServiceID
+			}
+			if price != nil {
+				appointment.Price = *price
+			}
+			appointment.StartAt = start
+			appointment.EndAt = appointment.StartAt.Add(duration)
+
+			if err := s.appointments.UpdateTx(tx, appointment); err != nil {
+				return fmt.Errorf("приём №%d (%s): %w", appointment.SeriesIndex, appointment.StartAt.Format("02.01.2006 15:04"), err)
+			}
+
+			if previous.DoctorID == appointment.DoctorID && previous.StartAt.Equal(appointment.StartAt) {
+				continue
+			}
+			if err := publishTx(tx, s.outbox, events.AppointmentRescheduled, events.AggregateAppointment, appointment.ID,
+				events.NewAppointmentRescheduledPayload(appointment, &previous)); err != nil {
+				return err
+			}
+		}
+
+		// Изменение всей серии меняет и её правило.
+		if scope == models.ScopeAll || (scope == models.ScopeFollowing && target.SeriesIndex == 1) {
+			if req.DoctorID != nil {
+				series.DoctorID = *req.DoctorID
+			}
+			if req.ServiceID != nil {
+				series.ServiceID = *req.ServiceID
+			}
+			if price != nil {
+				series.Price = *price
+			}
+			start, err := move(series.StartAt, targetLoc)
+			if err != nil {
+				return err
+			}
+			series.StartAt = start
+			return s.series.UpdateTx(tx, series)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Warn("не удалось изменить приёмы серии", "error", err, "series_id", seriesID, "scope", scope)
+		return nil, err
+	}
+
+	s.logger.Info("приёмы серии изменены", "series_id", seriesID, "scope", scope, "count", len(targets))
+	return targets, nil
+}
+
+func (s *appointmentSeriesService) CancelOccurrences(
+	ctx context.Context,
+	userID uint,
+	role string,
+	seriesID, appointmentID uint,
+	scope models.SeriesScope,
+) ([]models.Appointment, error) {
+	series, target, targets, err := s.resolveScope(ctx, userID, role, seriesID, appointmentID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.appointments.Transaction(func(tx *gorm.DB) error {
+		for i := range targets {
+			appointment := &targets[i]
+			if err := s.appointments.CancelTx(tx, appointment); err != nil {
+				return err
+			}
+			if err := publishTx(tx, s.outbox, events.AppointmentCancelled, events.AggregateAppointment, appointment.ID, events.NewAppointmentPayload(appointment)); err != nil {
+				return err
+			}
+		}
+
+		if scope == models.ScopeAll || (scope == models.ScopeFollowing && target.SeriesIndex == 1) {
+			series.Status = models.SeriesCancelled
+			return s.series.UpdateTx(tx, series)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("не удалось отменить приёмы серии", "error", err, "series_id", seriesID, "scope", scope)
+		return nil, err
+	}
+
+	s.logger.Info("приёмы серии отменены", "series_id", seriesID, "scope", scope, "count", len(targets))
+	return targets, nil
+}
+
+// resolveScope находит выбранный приём серии и приёмы, которые затрагивает
+// изменение. Прошедшие, завершённые и отменённые приёмы не меняются.
+func (s *appointmentSeriesService) resolveScope(
+	ctx context.Context,
+	userID uint,
+	role string,
+	seriesID, appointmentID uint,
+	scope models.SeriesScope,
+) (*models.AppointmentSeries, *models.Appointment, []models.Appointment, error) {
+	if scope == "" {
+		scope = models.ScopeThis
+	}
+	if scope != models.ScopeThis && scope != models.ScopeFollowing && scope != models.ScopeAll {
+		return nil, nil, nil, ErrInvalidSeriesScope
+	}
+
+	series, err := s.GetByID(ctx, userID, role, seriesID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var target *models.Appointment
+	for i := range series.Appointments {
+		if series.Appointments[i].ID == appointmentID {
+			target = &series.Appointments[i]
+			break
+		}
+	}
+	if target == nil {
+		return nil, nil, nil, ErrSeriesOccurrenceNotFound
+	}
+	if !seriesOccurrenceEditable(target) {
+		return nil, nil, nil, ErrSeriesOccurrenceLocked
+	}
+
+	var targets []models.Appointment
+	for _, a := range series.Appointments {
+		switch scope {
+		case models.ScopeThis:
+			if a.ID != target.ID {
+				continue
+			}
+		case models.ScopeFollowing:
+			if a.SeriesIndex < target.SeriesIndex {
+				continue
+			}
+		}
+		if seriesOccurrenceEditable(&a) {
+			targets = append(targets, a)
+		}
+	}
+
+	return series, target, targets, nil
+}
+
+func seriesOccurrenceEditable(a *models.Appointment) bool {
+	return (a.Status == models.AppointmentScheduled || a.Status == models.AppointmentPendingPayment) &&
+		a.StartAt.After(time.Now())
+}
+
+// seriesOccurrences разворачивает правило повторения в даты приёмов.
+// Даты считаются в настенном времени loc: приём остаётся в то же время
+// после перевода часов. Месячная серия, начатая 31-го, в коротких месяцах
+// попадает на последний день месяца.
+func seriesOccurrences(start time.Time, loc *time.Location, freq models.SeriesFrequency, interval, count int, until *time.Time) ([]time.Time, error) {
+	if count > maxSeriesOccurrences {
+		return nil, ErrSeriesTooLong
+	}
+
+	local := start.In(loc)
+	clock := local.Format(localtime.ClockLayout)
+
+	var occurrences []time.Time
+	for i := 0; ; i++ {
+		if count > 0 && i >= count {
+			break
+		}
+
+		var day time.Time
+		switch freq {
+		case models.SeriesDaily:
+			day = localtime.AddDays(start, loc, i*interval)
+		case models.SeriesWeekly:
+			day = localtime.AddDays(start, loc, 7*i*interval)
+		case models.SeriesMonthly:
+			day = addMonthsClamped(local, i*interval)
+		default:
+			return nil, ErrInvalidSeries
+		}
+
+		next, err := localtime.At(day.Format(localtime.DateLayout), clock, loc)
+		if err != nil {
+			return nil, fmt.Errorf("приём %s: %w", day.Format("02.01.2006"), err)
+		}
+
+		if until != nil && next.After(*until) {
+			break
+		}
+		if len(occurrences) == maxSeriesOccurrences {
+			return nil, ErrSeriesTooLong
+		}
+		occurrences = append(occurrences, next)
+	}
+
+	if len(occurrences) == 0 {
+		return nil, ErrInvalidSeries
+	}
+	return occurrences, nil
+}
+
+// addMonthsClamped сдвигает местную дату на n месяцев; если в целевом
+// месяце нет такого числа, берётся его последний день.
+func addMonthsClamped(local time.Time, n int) time.Time {
+	first := time.Date(local.Year(), local.Month()+time.Month(n), 1, 0, 0, 0, 0, local.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(local.Day(), last)-1)
+}
+
+// calendarDays — число календарных дней между местными датами from и to.
+func calendarDays(from, to time.Time, loc *time.Location) int {
+	a, b := from.In(loc), to.In(loc)
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
+
+func isSlotConflict(err error) bool {
+	return errors.Is(err, constants.ErrTimeConflict) ||
+		errors.Is(err, constants.ErrTimeNotInSchedule) ||
+		errors.Is(err, constants.ErrSlotHeld)
+}
+
+// doctorService возвращает услугу с ценой и длительностью врача.
+func (s *appointmentSeriesService) doctorService(ctx context.Context, doctorID, serviceID uint) (*models.Service, error) {
+	service, err := s.services.GetForDoctor(ctx, doctorID, serviceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, constants.ErrServiceNotOffered
+		}
+		return nil, err
+	}
+	return service, nil
+}
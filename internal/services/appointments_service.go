@@ -1,51 +1,166 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"time"
 
 	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/events"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"github.com/mutsaevz/team-4-dentistry/internal/repository"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrHoldNotFound = errors.New("удержание слота не найдено или уже использовано")
+	ErrHoldExpired  = errors.New("время удержания слота истекло, выберите время заново")
+	ErrHoldMismatch = errors.New("удержание слота не соответствует параметрам записи")
+)
+
 type AppointmentService interface {
 	Create(req *models.AppointmentCreateRequest) (*models.Appointment, error)
+	// CreateTx записывает приём в транзакции вызывающего, вместе с
+	// онлайн-оплатой, если она нужна.
+	CreateTx(ctx context.Context, tx *gorm.DB, req *models.AppointmentCreateRequest) (*models.Appointment, error)
 	Update(id uint, req *models.AppointmentUpdateRequest) error
 	Delete(id uint) error
+	// Complete, MarkNoShow, Approve, Reject и GetAll работают только с
+	// записями филиалов сотрудника (см. ClinicScope).
+	Complete(ctx context.Context, id uint) error
+	MarkNoShow(ctx context.Context, id uint) error
+	// Approve подтверждает запись и создаёт отложенную оплату, Reject
+	// отклоняет её и освобождает слот.
+	Approve(ctx context.Context, id uint, req models.AppointmentApproveRequest) (*models.Appointment, error)
+	Reject(ctx context.Context, id uint) error
 	GetByID(id uint) (*models.Appointment, error)
-	GetAll() ([]models.Appointment, error)
+	GetAll(ctx context.Context, q models.ListQuery) (*models.Page[models.Appointment], error)
 	GetByPatientID(patientID uint) ([]models.Appointment, error)
 }
 
 type appointmentService struct {
 	serviceRepository repository.ServiceRepository
 	appointments      repository.AppointmentRepository
+	bundles           repository.BundleRepository
+	payments          PaymentService
+	outbox            repository.OutboxRepository
+	holds             repository.SlotHoldRepository
+	policy            BookingPolicyService
 	logger            *slog.Logger
 }
 
-func NewAppointmentService(service repository.ServiceRepository, appointments repository.AppointmentRepository, logger *slog.Logger) AppointmentService {
-	return &appointmentService{serviceRepository: service, appointments: appointments, logger: logger}
+func NewAppointmentService(
+	service repository.ServiceRepository,
+	appointments repository.AppointmentRepository,
+	bundles repository.BundleRepository,
+	payments PaymentService,
+	outbox repository.OutboxRepository,
+	holds repository.SlotHoldRepository,
+	policy BookingPolicyService,
+	logger *slog.Logger,
+) AppointmentService {
+	return &appointmentService{serviceRepository: service, appointments: appointments, bundles: bundles, payments: payments, outbox: outbox, holds: holds, policy: policy, logger: logger}
 }
 
 func (r *appointmentService) Create(req *models.AppointmentCreateRequest) (*models.Appointment, error) {
+	if req == nil {
+		r.logger.Warn("получен nil AppointmentCreateRequest")
+		return nil, constants.AppointmentCreateRequest_IS_nil
+	}
 	r.logger.Debug("создание appointment вызвано", "doctor_id", req.DoctorID, "patient_id", req.PatientID, "service_id", req.ServiceID)
 
+	appointment, paymentMode, err := r.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.appointments.Transaction(func(tx *gorm.DB) error {
+		return r.createTx(tx, req, appointment)
+	})
+	if err != nil {
+		r.logger.Error("транзакция создания appointment провалилась", "error", err)
+		return nil, err
+	}
+
+	if paymentMode != "" {
+		payment, err := r.payments.CreateForAppointment(context.Background(), appointment, paymentMode)
+		if err != nil {
+			r.logger.Error("не удалось создать оплату для appointment, запись отменяется", "error", err, "appointment_id", appointment.ID)
+			if cancelErr := r.appointments.Transaction(func(tx *gorm.DB) error {
+				return r.cancelTx(tx, appointment)
+			}); cancelErr != nil {
+				r.logger.Error("не удалось отменить appointment после ошибки оплаты", "error", cancelErr, "appointment_id", appointment.ID)
+			}
+			return nil, err
+		}
+		appointment.Payments = []models.Payment{*payment}
+	}
+
+	r.logger.Info("appointment создан", "appointment_id", appointment.ID, "status", appointment.Status)
+	return appointment, nil
+}
+
+func (r *appointmentService) CreateTx(ctx context.Context, tx *gorm.DB, req *models.AppointmentCreateRequest) (*models.Appointment, error) {
 	if req == nil {
-		r.logger.Warn("получен nil AppointmentCreateRequest")
 		return nil, constants.AppointmentCreateRequest_IS_nil
 	}
+
+	appointment, paymentMode, err := r.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.createTx(tx, req, appointment); err != nil {
+		return nil, err
+	}
+
+	if paymentMode != "" {
+		payment, err := r.payments.CreateForAppointmentTx(ctx, tx, appointment, paymentMode)
+		if err != nil {
+			return nil, err
+		}
+		appointment.Payments = []models.Payment{*payment}
+	}
+
+	r.logger.Info("appointment создан", "appointment_id", appointment.ID, "status", appointment.Status)
+	return appointment, nil
+}
+
+// prepare проверяет запрос по правилам бронирования и собирает запись с
+// ценой и статусом; возвращает режим онлайн-оплаты, которую нужно создать.
+func (r *appointmentService) prepare(req *models.AppointmentCreateRequest) (*models.Appointment, models.PaymentMode, error) {
 	if err := r.validate(req); err != nil {
 		r.logger.Warn("валидация создания appointment провалилась", "error", err)
-		return nil, err
+		return nil, "", err
 	}
 
-	service, err := r.serviceRepository.GetByID(req.ServiceID)
+	decision, err := r.policy.Evaluate(context.Background(), req.PatientID)
 	if err != nil {
-		r.logger.Error("не удалось получить service для appointment", "error", err, "service_id", req.ServiceID)
-		return nil, err
+		return nil, "", err
+	}
+	// Визит из набора уже оплачен.
+	if req.PackageID != nil && req.Payment != "" {
+		return nil, "", ErrPackagePrepaid
+	}
+	if req.Payment != "" && !r.payments.Enabled() {
+		return nil, "", ErrPaymentsDisabled
+	}
+	if decision.RequireDeposit && req.Payment == "" && req.PackageID == nil {
+		r.logger.Warn("запись без предоплаты отклонена правилами бронирования", "patient_id", req.PatientID)
+		return nil, "", ErrDepositRequired
+	}
+
+	// Цена и длительность — из прайса врача, у комплекса — его цена и
+	// суммарная длительность услуг.
+	var service *models.Service
+	if req.BundleID != nil {
+		service, err = r.bundleVisit(req.DoctorID, *req.BundleID)
+	} else {
+		service, err = r.doctorService(req.DoctorID, req.ServiceID)
+	}
+	if err != nil {
+		return nil, "", err
 	}
 
 	duration := service.Duration
@@ -53,36 +168,122 @@ func (r *appointmentService) Create(req *models.AppointmentCreateRequest) (*mode
 	appointment := &models.Appointment{
 		PatientID: req.PatientID,
 		DoctorID:  req.DoctorID,
-		ServiceID: req.ServiceID,
+		ServiceID: service.ID,
 		StartAt:   req.StartAt,
 		EndAt:     req.StartAt.Add(time.Duration(duration) * time.Minute),
-		Price:     req.Price,
+		Price:     service.Price,
+		Status:    models.AppointmentScheduled,
+		BundleID:  req.BundleID,
+		PackageID: req.PackageID,
+	}
+	if req.PackageID != nil {
+		appointment.Price = 0
+		appointment.Paid = true
 	}
 
-	err = r.appointments.Transaction(func(tx *gorm.DB) error {
+	paymentMode := req.Payment
+	if paymentMode != "" {
+		// Страховка покрывает приём целиком — платить онлайн нечего.
+		owes, err := r.payments.Owes(context.Background(), appointment)
+		if err != nil {
+			return nil, "", err
+		}
+		if !owes {
+			paymentMode = ""
+			appointment.Paid = true
+		}
+	}
+	switch {
+	case decision.RequireApproval:
+		// Запись ждёт решения персонала; оплата создаётся при подтверждении.
+		appointment.Status = models.AppointmentPendingApproval
+		appointment.DeferredPayment = paymentMode
+		paymentMode = ""
+	case paymentMode != "":
+		appointment.Status = models.AppointmentPendingPayment
+	}
+
+	return appointment, paymentMode, nil
+}
+
+// createTx сохраняет подготовленную запись: лимит записей, удержание слота
+// и визит из набора проверяются в той же транзакции.
+func (r *appointmentService) createTx(tx *gorm.DB, req *models.AppointmentCreateRequest, appointment *models.Appointment) error {
+	if err := r.policy.CheckBookingLimitTx(context.Background(), tx, appointment.PatientID); err != nil {
+		return err
+	}
 
-		if err := r.appointments.CreateTx(tx, appointment); err != nil {
-			r.logger.Error("ошибка при создании appointment в транзакции", "error", err)
+	var hold *models.SlotHold
+	if req.HoldToken != "" {
+		var err error
+		if hold, err = r.claimHoldTx(tx, req.HoldToken, appointment); err != nil {
 			return err
 		}
+	}
 
-		if err := tx.
-			Model(&models.Schedule{}).
-			Where("doctor_id = ? AND start_time = ?", req.DoctorID, req.StartAt).
-			Update("is_available", false).Error; err != nil {
-			r.logger.Error("ошибка при обновлении доступности расписания", "error", err)
+	if req.PackageID != nil {
+		if err := r.redeemPackageTx(tx, *req.PackageID, appointment); err != nil {
 			return err
 		}
+	}
 
+	if err := r.appointments.CreateTx(tx, appointment); err != nil {
+		r.logger.Error("ошибка при создании appointment в транзакции", "error", err)
+		return err
+	}
+
+	if hold != nil {
+		hold.Status = models.HoldConsumed
+		hold.AppointmentID = &appointment.ID
+		if err := r.holds.UpdateTx(tx, hold); err != nil {
+			return err
+		}
+	}
+
+	// О записи, ждущей решения, объявляется при подтверждении.
+	if appointment.Status == models.AppointmentPendingApproval {
 		return nil
-	})
+	}
+	return publishTx(tx, r.outbox, events.AppointmentBooked, events.AggregateAppointment, appointment.ID, events.NewAppointmentPayload(appointment))
+}
 
+// claimHoldTx блокирует удержание по токену и проверяет, что оно активно
+// и выдано на тот же слот и того же пациента, что и создаваемая запись.
+func (r *appointmentService) claimHoldTx(tx *gorm.DB, token string, appointment *models.Appointment) (*models.SlotHold, error) {
+	hold, err := r.holds.GetByTokenForUpdateTx(tx, token)
 	if err != nil {
-		r.logger.Error("транзакция создания appointment провалилась", "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHoldNotFound
+		}
 		return nil, err
 	}
-	r.logger.Info("appointment создан", "appointment_id", appointment.ID)
-	return appointment, nil
+
+	if hold.Status != models.HoldActive {
+		if hold.Status == models.HoldExpired {
+			return nil, ErrHoldExpired
+		}
+		return nil, ErrHoldNotFound
+	}
+	if !hold.ExpiresAt.After(time.Now()) {
+		return nil, ErrHoldExpired
+	}
+	if hold.DoctorID != appointment.DoctorID ||
+		hold.PatientID != appointment.PatientID ||
+		hold.ServiceID != appointment.ServiceID ||
+		!hold.StartAt.Equal(appointment.StartAt) {
+		r.logger.Warn("удержание не совпадает с запросом записи", "hold_id", hold.ID, "doctor_id", appointment.DoctorID, "patient_id", appointment.PatientID)
+		return nil, ErrHoldMismatch
+	}
+
+	return hold, nil
+}
+
+// cancelTx отменяет запись и публикует AppointmentCancelled в той же транзакции.
+func (r *appointmentService) cancelTx(tx *gorm.DB, appointment *models.Appointment) error {
+	if err := r.appointments.CancelTx(tx, appointment); err != nil {
+		return err
+	}
+	return publishTx(tx, r.outbox, events.AppointmentCancelled, events.AggregateAppointment, appointment.ID, events.NewAppointmentPayload(appointment))
 }
 
 func (r *appointmentService) validate(req *models.AppointmentCreateRequest) error {
@@ -94,7 +295,11 @@ func (r *appointmentService) validate(req *models.AppointmentCreateRequest) erro
 		return constants.PatientIDIsIncorrect
 	}
 
-	if req.ServiceID <= 0 {
+	if req.BundleID != nil {
+		if req.ServiceID != 0 || req.PackageID != nil {
+			return ErrBundleBookingConflict
+		}
+	} else if req.ServiceID <= 0 {
 		return constants.ServiceIDIsIncorrect
 	}
 
@@ -102,13 +307,92 @@ func (r *appointmentService) validate(req *models.AppointmentCreateRequest) erro
 		return constants.ErrInvalidAppointmentTime
 	}
 
-	if req.Price < 0 {
-		return constants.ErrInvalidPrice
+	return nil
+}
+
+// bundleVisit возвращает комплекс как одну услугу: первая услуга состава,
+// цена комплекса и суммарная длительность по прайсу врача. Врач должен
+// оказывать все услуги комплекса; кабинет и оборудование репозиторий
+// подбирает по требованиям каждой из них.
+func (r *appointmentService) bundleVisit(doctorID, bundleID uint) (*models.Service, error) {
+	ctx := context.Background()
+
+	bundle, err := r.bundles.GetByID(ctx, bundleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBundleNotFound
+		}
+		return nil, err
+	}
+	if bundle.Kind != models.BundleSingleVisit || !bundle.IsActive || len(bundle.Items) == 0 {
+		return nil, ErrBundleNotBookable
+	}
+
+	visit := &models.Service{Price: bundle.Price}
+	for i, item := range bundle.Items {
+		service, err := r.doctorService(doctorID, item.ServiceID)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			visit.ID = service.ID
+		}
+		visit.Duration += service.Duration * item.Quantity
+	}
+
+	return visit, nil
+}
+
+// redeemPackageTx списывает визит из набора пациента под блокировкой
+// набора, чтобы параллельные записи не ушли в минус.
+func (r *appointmentService) redeemPackageTx(tx *gorm.DB, packageID uint, appointment *models.Appointment) error {
+	pkg, err := r.bundles.GetPackageTx(tx, packageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPackageNotFound
+		}
+		return err
+	}
+	if pkg.PatientID != appointment.PatientID {
+		return ErrPackageNotFound
+	}
+	if pkg.Status != models.PackageActive || (pkg.ExpiresAt != nil && !appointment.StartAt.Before(*pkg.ExpiresAt)) {
+		return ErrPackageUnavailable
+	}
+
+	quantity := 0
+	for _, item := range pkg.Items {
+		if item.ServiceID == appointment.ServiceID {
+			quantity = item.Quantity
+			break
+		}
+	}
+
+	used, err := r.bundles.UsedVisitsTx(tx, pkg.ID, appointment.ServiceID)
+	if err != nil {
+		return err
+	}
+	if used >= quantity {
+		r.logger.Warn("в наборе нет визитов на услугу", "package_id", pkg.ID, "service_id", appointment.ServiceID, "used", used, "quantity", quantity)
+		return ErrPackageExhausted
 	}
 
 	return nil
 }
 
+// doctorService возвращает услугу с ценой и длительностью врача.
+func (r *appointmentService) doctorService(doctorID, serviceID uint) (*models.Service, error) {
+	service, err := r.serviceRepository.GetForDoctor(context.Background(), doctorID, serviceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, constants.ErrServiceNotOffered
+		}
+		r.logger.Error("не удалось получить service для appointment", "error", err, "service_id", serviceID)
+		return nil, err
+	}
+	return service, nil
+}
+
 func (r *appointmentService) Update(id uint, req *models.AppointmentUpdateRequest) error {
 	r.logger.Debug("обновление appointment вызвано", "appointment_id", id)
 
@@ -142,6 +426,8 @@ func (r *appointmentService) Update(id uint, req *models.AppointmentUpdateReques
 		return constants.ErrInvalidPrice
 	}
 
+	previous := *appointments
+
 	if req.DoctorID != nil {
 		appointments.DoctorID = *req.DoctorID
 	}
@@ -156,13 +442,20 @@ func (r *appointmentService) Update(id uint, req *models.AppointmentUpdateReques
 
 	if req.StartAt != nil {
 		appointments.StartAt = *req.StartAt
+	}
 
-		service, err := r.serviceRepository.GetByID(appointments.ServiceID)
+	// Другой врач или услуга — другие длительность и цена по прайсу врача;
+	// явно переданная цена важнее.
+	if req.StartAt != nil || req.DoctorID != nil || req.ServiceID != nil {
+		service, err := r.doctorService(appointments.DoctorID, appointments.ServiceID)
 		if err != nil {
 			return err
 		}
 		duration := service.Duration
 		appointments.EndAt = appointments.StartAt.Add(time.Duration(duration) * time.Minute)
+		if req.DoctorID != nil || req.ServiceID != nil {
+			appointments.Price = service.Price
+		}
 	}
 
 	if req.Price != nil {
@@ -170,7 +463,14 @@ func (r *appointmentService) Update(id uint, req *models.AppointmentUpdateReques
 	}
 
 	if err := r.appointments.Transaction(func(tx *gorm.DB) error {
-		return r.appointments.UpdateTx(tx, appointments)
+		if err := r.appointments.UpdateTx(tx, appointments); err != nil {
+			return err
+		}
+		if previous.DoctorID == appointments.DoctorID && previous.StartAt.Equal(appointments.StartAt) {
+			return nil
+		}
+		return publishTx(tx, r.outbox, events.AppointmentRescheduled, events.AggregateAppointment, appointments.ID,
+			events.NewAppointmentRescheduledPayload(appointments, &previous))
 	}); err != nil {
 		r.logger.Error("транзакция обновления appointment провалилась", "error", err, "appointment_id", id)
 		return constants.ErrUpdateAppointments
@@ -187,7 +487,24 @@ func (r *appointmentService) Delete(id uint) error {
 		return constants.ErrInvalidAppointmentID
 	}
 
-	if err := r.appointments.Delete(id); err != nil {
+	appointment, err := r.appointments.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return constants.ErrInvalidAppointmentID
+		}
+		return constants.ErrDeleteAppointments
+	}
+
+	if err := r.appointments.Transaction(func(tx *gorm.DB) error {
+		if err := r.appointments.DeleteTx(tx, id); err != nil {
+			return err
+		}
+		if appointment.Status == models.AppointmentCancelled {
+			return nil
+		}
+		appointment.Status = models.AppointmentCancelled
+		return publishTx(tx, r.outbox, events.AppointmentCancelled, events.AggregateAppointment, appointment.ID, events.NewAppointmentPayload(appointment))
+	}); err != nil {
 		r.logger.Error("ошибка при удалении appointment", "error", err, "appointment_id", id)
 		return constants.ErrDeleteAppointments
 	}
@@ -196,6 +513,168 @@ func (r *appointmentService) Delete(id uint) error {
 	return nil
 }
 
+func (r *appointmentService) Complete(ctx context.Context, id uint) error {
+	r.logger.Debug("завершение appointment вызвано", "appointment_id", id)
+
+	appointment, err := r.staffAppointment(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if appointment.Status != models.AppointmentScheduled {
+		r.logger.Warn("попытка завершить appointment в неподходящем статусе", "appointment_id", id, "status", appointment.Status)
+		return constants.ErrAppointmentNotCompletable
+	}
+
+	appointment.Status = models.AppointmentCompleted
+	if err := r.appointments.Transaction(func(tx *gorm.DB) error {
+		if err := r.appointments.SetStatusTx(tx, appointment.ID, appointment.Status); err != nil {
+			return err
+		}
+		return publishTx(tx, r.outbox, events.AppointmentCompleted, events.AggregateAppointment, appointment.ID, events.NewAppointmentPayload(appointment))
+	}); err != nil {
+		r.logger.Error("транзакция завершения appointment провалилась", "error", err, "appointment_id", id)
+		return err
+	}
+
+	r.logger.Info("appointment завершён", "appointment_id", id)
+	return nil
+}
+
+// MarkNoShow отмечает неявку на запланированный приём, время которого уже
+// наступило. Неявки учитываются правилами бронирования.
+func (r *appointmentService) MarkNoShow(ctx context.Context, id uint) error {
+	r.logger.Debug("отметка неявки вызвана", "appointment_id", id)
+
+	appointment, err := r.staffAppointment(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if appointment.Status != models.AppointmentScheduled || appointment.StartAt.After(time.Now()) {
+		r.logger.Warn("попытка отметить неявку в неподходящем статусе", "appointment_id", id, "status", appointment.Status)
+		return constants.ErrAppointmentNotNoShow
+	}
+
+	appointment.Status = models.AppointmentNoShow
+	if err := r.appointments.Transaction(func(tx *gorm.DB) error {
+		if err := r.appointments.SetStatusTx(tx, appointment.ID, appointment.Status); err != nil {
+			return err
+		}
+		return publishTx(tx, r.outbox, events.AppointmentNoShow, events.AggregateAppointment, appointment.ID, events.NewAppointmentPayload(appointment))
+	}); err != nil {
+		r.logger.Error("транзакция отметки неявки провалилась", "error", err, "appointment_id", id)
+		return err
+	}
+
+	r.logger.Info("неявка отмечена", "appointment_id", id, "patient_id", appointment.PatientID)
+	return nil
+}
+
+func (r *appointmentService) Approve(ctx context.Context, id uint, req models.AppointmentApproveRequest) (*models.Appointment, error) {
+	if _, err := r.staffAppointment(ctx, id); err != nil {
+		return nil, err
+	}
+
+	var appointment *models.Appointment
+	err := r.appointments.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if appointment, err = r.appointments.GetByIDForUpdateTx(tx, id); err != nil {
+			return err
+		}
+		if appointment.Status != models.AppointmentPendingApproval {
+			return constants.ErrAppointmentNotPendingApproval
+		}
+
+		// Если онлайн-оплату отключили после записи, отложенная оплата
+		// принимается в клинике.
+		mode := appointment.DeferredPayment
+		if !r.payments.Enabled() {
+			mode = ""
+		}
+		if req.Payment != "" {
+			mode = req.Payment
+		}
+		if mode != "" {
+			owes, err := r.payments.Owes(ctx, appointment)
+			if err != nil {
+				return err
+			}
+			if !owes {
+				mode = ""
+			}
+		}
+
+		appointment.Status = models.AppointmentScheduled
+		if mode != "" {
+			appointment.Status = models.AppointmentPendingPayment
+		}
+		if err := r.appointments.SetStatusTx(tx, appointment.ID, appointment.Status); err != nil {
+			return err
+		}
+
+		if mode != "" {
+			payment, err := r.payments.CreateForAppointmentTx(ctx, tx, appointment, mode)
+			if err != nil {
+				return err
+			}
+			appointment.Payments = []models.Payment{*payment}
+		}
+
+		return publishTx(tx, r.outbox, events.AppointmentBooked, events.AggregateAppointment, appointment.ID, events.NewAppointmentPayload(appointment))
+	})
+	if err != nil {
+		r.logger.Error("не удалось подтвердить appointment", "error", err, "appointment_id", id)
+		return nil, err
+	}
+
+	r.logger.Info("appointment подтверждён", "appointment_id", id, "status", appointment.Status)
+	return appointment, nil
+}
+
+func (r *appointmentService) Reject(ctx context.Context, id uint) error {
+	if _, err := r.staffAppointment(ctx, id); err != nil {
+		return err
+	}
+
+	err := r.appointments.Transaction(func(tx *gorm.DB) error {
+		appointment, err := r.appointments.GetByIDForUpdateTx(tx, id)
+		if err != nil {
+			return err
+		}
+		if appointment.Status != models.AppointmentPendingApproval {
+			return constants.ErrAppointmentNotPendingApproval
+		}
+		return r.cancelTx(tx, appointment)
+	})
+	if err != nil {
+		r.logger.Error("не удалось отклонить appointment", "error", err, "appointment_id", id)
+		return err
+	}
+
+	r.logger.Info("appointment отклонён", "appointment_id", id)
+	return nil
+}
+
+// staffAppointment загружает запись для действия персонала и проверяет,
+// что она относится к филиалу сотрудника.
+func (r *appointmentService) staffAppointment(ctx context.Context, id uint) (*models.Appointment, error) {
+	appointment, err := r.appointments.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, constants.ErrGetByIDAppointments
+		}
+		return nil, err
+	}
+
+	if !ClinicAllowed(ctx, appointment.ClinicID) {
+		r.logger.Warn("попытка изменить запись чужого филиала", "appointment_id", id)
+		return nil, ErrClinicForbidden
+	}
+
+	return appointment, nil
+}
+
 func (r *appointmentService) GetByID(id uint) (*models.Appointment, error) {
 	r.logger.Debug("получение appointment по ID вызвано", "appointment_id", id)
 	if id <= 0 {
@@ -212,15 +691,19 @@ func (r *appointmentService) GetByID(id uint) (*models.Appointment, error) {
 	return appointment, nil
 }
 
-func (r *appointmentService) GetAll() ([]models.Appointment, error) {
+func (r *appointmentService) GetAll(ctx context.Context, q models.ListQuery) (*models.Page[models.Appointment], error) {
 	r.logger.Debug("получение всех appointments вызвано")
-	appointments, err := r.appointments.Get()
+	scope, _ := ClinicScopeIDs(ctx)
+	page, err := r.appointments.List(q, scope)
 	if err != nil {
 		r.logger.Error("ошибка при получении всех appointments", "error", err)
+		if errors.Is(err, constants.ErrInvalidListQuery) {
+			return nil, err
+		}
 		return nil, constants.ErrGetAppointments
 	}
-	r.logger.Info("appointments получены", "count", len(appointments))
-	return appointments, nil
+	r.logger.Info("appointments получены", "count", len(page.Items), "total", page.Total)
+	return page, nil
 }
 
 func (r *appointmentService) GetByPatientID(patientID uint) ([]models.Appointment, error) {
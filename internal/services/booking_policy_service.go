@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDepositRequired       = errors.New("из-за пропущенных приёмов для записи требуется предоплата: укажите payment")
+	ErrInvalidNoShowReport   = errors.New("некорректный период отчёта")
+	ErrTooManyFutureBookings = errors.New("достигнут лимит предстоящих записей пациента")
+)
+
+// BookingPolicyConfig задаёт правила бронирования. Нулевой порог отключает
+// соответствующее правило.
+type BookingPolicyConfig struct {
+	// NoShowWindow — за какой период учитываются неявки.
+	NoShowWindow         time.Duration
+	DepositAfterNoShows  int
+	ApprovalAfterNoShows int
+	MaxFutureBookings    int
+}
+
+// BookingDecision — что требуется от новой записи пациента.
+type BookingDecision struct {
+	RequireDeposit  bool
+	RequireApproval bool
+}
+
+type BookingPolicyService interface {
+	// Evaluate применяет правила к новой записи пациента. Превышение лимита
+	// будущих записей возвращается как ErrTooManyFutureBookings.
+	Evaluate(ctx context.Context, patientID uint) (*BookingDecision, error)
+
+	// CheckBookingLimitTx повторяет проверку лимита будущих записей в
+	// транзакции записи под блокировкой пациента: параллельные записи не
+	// могут превысить лимит вместе.
+	CheckBookingLimitTx(ctx context.Context, tx *gorm.DB, patientID uint) error
+
+	Stats(ctx context.Context, patientID uint) (*models.PatientNoShowStats, error)
+
+	SetOverride(ctx context.Context, adminID, patientID uint, req models.BookingPolicyOverrideRequest) (*models.BookingPolicyOverride, error)
+
+	Report(ctx context.Context, params models.NoShowReportParams) (*models.NoShowReport, error)
+}
+
+type bookingPolicyService struct {
+	repo   repository.BookingPolicyRepository
+	cfg    BookingPolicyConfig
+	logger *slog.Logger
+}
+
+func NewBookingPolicyService(repo repository.BookingPolicyRepository, cfg BookingPolicyConfig, logger *slog.Logger) BookingPolicyService {
+	if cfg.NoShowWindow <= 0 {
+		cfg.NoShowWindow = 365 * 24 * time.Hour
+	}
+
+	return &bookingPolicyService{repo: repo, cfg: cfg, logger: logger}
+}
+
+func (s *bookingPolicyService) Evaluate(ctx context.Context, patientID uint) (*BookingDecision, error) {
+	stats, err := s.Stats(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.BookingLimitHit {
+		s.logger.Warn("запись отклонена: лимит будущих записей", "patient_id", patientID, "future_bookings", stats.FutureBookings)
+		return nil, fmt.Errorf("%w (%d)", ErrTooManyFutureBookings, s.cfg.MaxFutureBookings)
+	}
+
+	return &BookingDecision{
+		RequireDeposit:  stats.DepositRequired,
+		RequireApproval: stats.ApprovalRequired,
+	}, nil
+}
+
+func (s *bookingPolicyService) CheckBookingLimitTx(ctx context.Context, tx *gorm.DB, patientID uint) error {
+	if s.cfg.MaxFutureBookings <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	override, err := s.repo.GetOverride(ctx, patientID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if override != nil && override.Exempt && (override.ExpiresAt == nil || override.ExpiresAt.After(now)) {
+		return nil
+	}
+
+	if err := s.repo.LockPatientTx(tx, patientID); err != nil {
+		return err
+	}
+	future, err := s.repo.CountFutureBookingsTx(tx, patientID, now)
+	if err != nil {
+		return err
+	}
+
+	if future >= int64(s.cfg.MaxFutureBookings) {
+		s.logger.Warn("запись отклонена: лимит будущих записей", "patient_id", patientID, "future_bookings", future)
+		return fmt.Errorf("%w (%d)", ErrTooManyFutureBookings, s.cfg.MaxFutureBookings)
+	}
+	return nil
+}
+
+func (s *bookingPolicyService) Stats(ctx context.Context, patientID uint) (*models.PatientNoShowStats, error) {
+	now := time.Now()
+
+	total, err := s.repo.CountNoShows(ctx, patientID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	inWindow, err := s.repo.CountNoShows(ctx, patientID, now.Add(-s.cfg.NoShowWindow))
+	if err != nil {
+		return nil, err
+	}
+	last, err := s.repo.LastNoShowAt(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	future, err := s.repo.CountFutureBookings(ctx, patientID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.PatientNoShowStats{
+		PatientID:       patientID,
+		NoShowsTotal:    total,
+		NoShowsInWindow: inWindow,
+		WindowDays:      int(s.cfg.NoShowWindow / (24 * time.Hour)),
+		LastNoShowAt:    last,
+		FutureBookings:  future,
+	}
+
+	override, err := s.repo.GetOverride(ctx, patientID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if override != nil {
+		stats.Override = override
+		if override.Exempt && (override.ExpiresAt == nil || override.ExpiresAt.After(now)) {
+			return stats, nil
+		}
+	}
+
+	// Подтверждение персоналом строже предоплаты и заменяет её: оплату
+	// согласуют при подтверждении.
+	stats.ApprovalRequired = s.cfg.ApprovalAfterNoShows > 0 && inWindow >= int64(s.cfg.ApprovalAfterNoShows)
+	stats.DepositRequired = !stats.ApprovalRequired && s.cfg.DepositAfterNoShows > 0 && inWindow >= int64(s.cfg.DepositAfterNoShows)
+	stats.BookingLimitHit = s.cfg.MaxFutureBookings > 0 && future >= int64(s.cfg.MaxFutureBookings)
+
+	return stats, nil
+}
+
+func (s *bookingPolicyService) SetOverride(
+	ctx context.Context,
+	adminID, patientID uint,
+	req models.BookingPolicyOverrideRequest,
+) (*models.BookingPolicyOverride, error) {
+	// Исключение действует во всей сети, поэтому выдаёт его только
+	// администратор сети.
+	if _, scoped := ClinicScopeIDs(ctx); scoped {
+		return nil, ErrClinicForbidden
+	}
+
+	override := &models.BookingPolicyOverride{
+		PatientID:   patientID,
+		Exempt:      req.Exempt,
+		ExpiresAt:   req.ExpiresAt,
+		Note:        req.Note,
+		GrantedByID: adminID,
+	}
+
+	if err := s.repo.SaveOverride(ctx, override); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetOverride(ctx, patientID)
+}
+
+func (s *bookingPolicyService) Report(ctx context.Context, params models.NoShowReportParams) (*models.NoShowReport, error) {
+	if params.To.IsZero() {
+		params.To = time.Now()
+	}
+	if params.From.IsZero() {
+		params.From = params.To.Add(-s.cfg.NoShowWindow)
+	}
+	if !params.From.Before(params.To) {
+		return nil, ErrInvalidNoShowReport
+	}
+	params.ClinicIDs, _ = ClinicScopeIDs(ctx)
+
+	rows, err := s.repo.NoShowReport(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	finished, err := s.repo.CountFinished(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.NoShowReport{
+		From:          params.From,
+		To:            params.To,
+		TotalFinished: finished,
+		Patients:      rows,
+	}
+	for _, row := range rows {
+		report.TotalNoShows += row.NoShows
+	}
+	if finished > 0 {
+		report.NoShowRate = float64(report.TotalNoShows) / float64(finished)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBundleNotFound        = errors.New("комплекс услуг не найден")
+	ErrInvalidBundle         = errors.New("некорректный комплекс: нужны название, тип, неотрицательная цена и хотя бы одна услуга без повторов")
+	ErrBundleNotForSale      = errors.New("комплекс не продаётся как набор визитов")
+	ErrBundleNotBookable     = errors.New("комплекс нельзя записать одним приёмом")
+	ErrPackageNotFound       = errors.New("набор визитов не найден")
+	ErrPackageUnavailable    = errors.New("набор визитов отменён или истёк")
+	ErrPackageExhausted      = errors.New("в наборе не осталось визитов на эту услугу")
+	ErrPackagePrepaid        = errors.New("визит из набора уже оплачен, онлайн-оплата не нужна")
+	ErrBundleBookingConflict = errors.New("при записи на комплекс не указываются услуга и набор визитов")
+)
+
+// BundleService ведёт комплексы услуг и наборы визитов пациентов.
+type BundleService interface {
+	Create(ctx context.Context, req models.ServiceBundleCreateRequest) (*models.ServiceBundle, error)
+
+	Get(ctx context.Context, id uint) (*models.ServiceBundle, error)
+
+	// List возвращает комплексы; сняты с продажи — только при all.
+	List(ctx context.Context, all bool) ([]models.ServiceBundle, error)
+
+	Update(ctx context.Context, id uint, req models.ServiceBundleUpdateRequest) (*models.ServiceBundle, error)
+
+	Delete(ctx context.Context, id uint) error
+
+	// SellPackage оформляет пациенту набор визитов по текущему составу и
+	// цене комплекса. Оплата принимается в клинике.
+	SellPackage(ctx context.Context, patientID uint, req models.PackagePurchaseRequest) (*models.PatientPackage, error)
+
+	ListPackages(ctx context.Context, patientID uint) ([]models.PatientPackage, error)
+
+	// CancelPackage отменяет действующий набор пациента. Уже записанные
+	// визиты остаются в силе, за незаписанные рассчитывается возврат
+	// пропорционально цене набора; деньги возвращаются в клинике.
+	CancelPackage(ctx context.Context, patientID, packageID uint) (*models.PatientPackage, error)
+}
+
+type bundleService struct {
+	bundles    repository.BundleRepository
+	services   repository.ServiceRepository
+	categories repository.ServiceCategoryRepository
+	users      repository.UserRepository
+	logger     *slog.Logger
+}
+
+func NewBundleService(
+	bundles repository.BundleRepository,
+	services repository.ServiceRepository,
+	categories repository.ServiceCategoryRepository,
+	users repository.UserRepository,
+	logger *slog.Logger,
+) BundleService {
+	return &bundleService{bundles: bundles, services: services, categories: categories, users: users, logger: logger}
+}
+
+func (s *bundleService) Create(ctx context.Context, req models.ServiceBundleCreateRequest) (*models.ServiceBundle, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || req.Price < 0 || req.ValidDays < 0 {
+		return nil, ErrInvalidBundle
+	}
+	if req.Kind != models.BundleSingleVisit && req.Kind != models.BundlePrepaidVisits {
+		return nil, ErrInvalidBundle
+	}
+
+	items, err := s.buildItems(req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &models.ServiceBundle{
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+		Icon:        strings.TrimSpace(req.Icon),
+		Position:    req.Position,
+		Kind:        req.Kind,
+		Price:       req.Price,
+		ValidDays:   req.ValidDays,
+		IsActive:    true,
+		Items:       items,
+	}
+	if req.CategoryID != nil && *req.CategoryID != 0 {
+		if err := s.checkCategory(ctx, *req.CategoryID); err != nil {
+			return nil, err
+		}
+		bundle.CategoryID = req.CategoryID
+	}
+
+	if err := s.bundles.Create(ctx, bundle); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, bundle.ID)
+}
+
+func (s *bundleService) Get(ctx context.Context, id uint) (*models.ServiceBundle, error) {
+	bundle, err := s.bundles.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBundleNotFound
+		}
+		return nil, err
+	}
+	return bundle, nil
+}
+
+func (s *bundleService) List(ctx context.Context, all bool) ([]models.ServiceBundle, error) {
+	return s.bundles.List(ctx, !all)
+}
+
+func (s *bundleService) Update(ctx context.Context, id uint, req models.ServiceBundleUpdateRequest) (*models.ServiceBundle, error) {
+	bundle, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, ErrInvalidBundle
+		}
+		bundle.Name = name
+	}
+	if req.Description != nil {
+		bundle.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Icon != nil {
+		bundle.Icon = strings.TrimSpace(*req.Icon)
+	}
+	if req.Position != nil {
+		bundle.Position = *req.Position
+	}
+	if req.Price != nil {
+		if *req.Price < 0 {
+			return nil, ErrInvalidBundle
+		}
+		bundle.Price = *req.Price
+	}
+	if req.ValidDays != nil {
+		if *req.ValidDays < 0 {
+			return nil, ErrInvalidBundle
+		}
+		bundle.ValidDays = *req.ValidDays
+	}
+	if req.IsActive != nil {
+		bundle.IsActive = *req.IsActive
+	}
+	if req.CategoryID != nil {
+		if *req.CategoryID == 0 {
+			bundle.CategoryID = nil
+		} else {
+			if err := s.checkCategory(ctx, *req.CategoryID); err != nil {
+				return nil, err
+			}
+			bundle.CategoryID = req.CategoryID
+		}
+	}
+	if req.Items != nil {
+		items, err := s.buildItems(*req.Items)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Items = items
+	}
+
+	if err := s.bundles.Update(ctx, bundle, req.Items != nil); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, id)
+}
+
+func (s *bundleService) Delete(ctx context.Context, id uint) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.bundles.Delete(ctx, id)
+}
+
+func (s *bundleService) SellPackage(ctx context.Context, patientID uint, req models.PackagePurchaseRequest) (*models.PatientPackage, error) {
+	user, err := s.users.GetByID(patientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.Role != models.Patient {
+		return nil, ErrUserNotFound
+	}
+
+	bundle, err := s.Get(ctx, req.BundleID)
+	if err != nil {
+		return nil, err
+	}
+	if bundle.Kind != models.BundlePrepaidVisits || !bundle.IsActive {
+		return nil, ErrBundleNotForSale
+	}
+
+	pkg := &models.PatientPackage{
+		PatientID: patientID,
+		BundleID:  bundle.ID,
+		Price:     bundle.Price,
+		Status:    models.PackageActive,
+	}
+	if bundle.ValidDays > 0 {
+		expires := time.Now().AddDate(0, 0, bundle.ValidDays)
+		pkg.ExpiresAt = &expires
+	}
+	for _, item := range bundle.Items {
+		pkg.Items = append(pkg.Items, models.PatientPackageItem{
+			ServiceID: item.ServiceID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	if err := s.bundles.CreatePackage(ctx, pkg); err != nil {
+		return nil, err
+	}
+
+	pkg.Bundle = bundle
+	return pkg, nil
+}
+
+func (s *bundleService) ListPackages(ctx context.Context, patientID uint) ([]models.PatientPackage, error) {
+	return s.bundles.ListPackages(ctx, patientID)
+}
+
+func (s *bundleService) CancelPackage(ctx context.Context, patientID, packageID uint) (*models.PatientPackage, error) {
+	var pkg *models.PatientPackage
+
+	err := s.bundles.Transaction(ctx, func(tx *gorm.DB) error {
+		var err error
+		if pkg, err = s.bundles.GetPackageTx(tx, packageID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPackageNotFound
+			}
+			return err
+		}
+		if pkg.PatientID != patientID {
+			return ErrPackageNotFound
+		}
+
+		now := time.Now()
+		if pkg.Status != models.PackageActive || (pkg.ExpiresAt != nil && !now.Before(*pkg.ExpiresAt)) {
+			return ErrPackageUnavailable
+		}
+
+		total, unused := 0, 0
+		for i := range pkg.Items {
+			item := &pkg.Items[i]
+			if item.Used, err = s.bundles.UsedVisitsTx(tx, pkg.ID, item.ServiceID); err != nil {
+				return err
+			}
+			total += item.Quantity
+			unused += max(item.Quantity-item.Used, 0)
+		}
+
+		pkg.Status = models.PackageCancelled
+		pkg.CancelledAt = &now
+		if total > 0 {
+			pkg.RefundedAmount = roundMoney(pkg.Price * float64(unused) / float64(total))
+		}
+		return s.bundles.UpdatePackageTx(tx, pkg)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("набор визитов отменён", "package_id", pkg.ID, "patient_id", patientID, "refunded_amount", pkg.RefundedAmount)
+	return pkg, nil
+}
+
+// buildItems проверяет состав комплекса и сохраняет порядок запроса.
+func (s *bundleService) buildItems(reqs []models.ServiceBundleItemRequest) ([]models.ServiceBundleItem, error) {
+	if len(reqs) == 0 {
+		return nil, ErrInvalidBundle
+	}
+
+	seen := make(map[uint]bool, len(reqs))
+	items := make([]models.ServiceBundleItem, 0, len(reqs))
+	for i, req := range reqs {
+		if req.ServiceID == 0 || seen[req.ServiceID] || req.Quantity < 0 {
+			return nil, ErrInvalidBundle
+		}
+		seen[req.ServiceID] = true
+
+		if _, err := s.services.GetByID(req.ServiceID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrServiceNotfound
+			}
+			return nil, err
+		}
+
+		quantity := req.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+		items = append(items, models.ServiceBundleItem{
+			ServiceID: req.ServiceID,
+			Quantity:  quantity,
+			Position:  i,
+		})
+	}
+
+	return items, nil
+}
+
+func (s *bundleService) checkCategory(ctx context.Context, id uint) error {
+	if _, err := s.categories.GetByID(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/calendar"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCalendarTokenNotFound = errors.New("календарная ссылка недействительна")
+	ErrCalendarForbidden     = errors.New("нет доступа к этой записи")
+)
+
+type CalendarConfig struct {
+	// BaseURL — внешний адрес API для формирования ссылки на ленту.
+	BaseURL string
+	// UIDDomain — правая часть UID событий; менять нельзя, иначе
+	// календари клиентов задублируют все приёмы.
+	UIDDomain string
+	Timezone  string
+	// History — насколько в прошлое включать приёмы в ленту.
+	History time.Duration
+}
+
+type CalendarService interface {
+	GetFeedURL(ctx context.Context, userID uint) (string, error)
+
+	RotateFeedToken(ctx context.Context, userID uint) (string, error)
+
+	Feed(ctx context.Context, token string) ([]byte, error)
+
+	AppointmentICS(ctx context.Context, userID uint, role string, appointmentID uint) ([]byte, error)
+}
+
+type calendarService struct {
+	tokens       repository.CalendarRepository
+	users        repository.UserRepository
+	doctors      repository.DoctorRepository
+	appointments repository.AppointmentRepository
+	cfg          CalendarConfig
+	logger       *slog.Logger
+}
+
+func NewCalendarService(
+	tokens repository.CalendarRepository,
+	users repository.UserRepository,
+	doctors repository.DoctorRepository,
+	appointments repository.AppointmentRepository,
+	cfg CalendarConfig,
+	logger *slog.Logger,
+) CalendarService {
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.History <= 0 {
+		cfg.History = 30 * 24 * time.Hour
+	}
+
+	return &calendarService{
+		tokens:       tokens,
+		users:        users,
+		doctors:      doctors,
+		appointments: appointments,
+		cfg:          cfg,
+		logger:       logger,
+	}
+}
+
+func (s *calendarService) GetFeedURL(ctx context.Context, userID uint) (string, error) {
+	token, err := s.tokens.GetByUserID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", err
+		}
+		token = &models.CalendarToken{UserID: userID}
+		if err := s.issue(ctx, token); err != nil {
+			return "", err
+		}
+	}
+
+	return s.feedURL(token.Token), nil
+}
+
+// RotateFeedToken выдаёт новый токен; старая ссылка перестаёт работать.
+func (s *calendarService) RotateFeedToken(ctx context.Context, userID uint) (string, error) {
+	token, err := s.tokens.GetByUserID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", err
+		}
+		token = &models.CalendarToken{UserID: userID}
+	}
+
+	if err := s.issue(ctx, token); err != nil {
+		return "", err
+	}
+
+	s.logger.Info("календарная ссылка перевыпущена", "user_id", userID)
+	return s.feedURL(token.Token), nil
+}
+
+func (s *calendarService) issue(ctx context.Context, token *models.CalendarToken) error {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	token.Token = hex.EncodeToString(b)
+	return s.tokens.Save(ctx, token)
+}
+
+func (s *calendarService) feedURL(token string) string {
+	return fmt.Sprintf("%s/api/calendar/feed/%s.ics", s.cfg.BaseURL, token)
+}
+
+func (s *calendarService) Feed(ctx context.Context, value string) ([]byte, error) {
+	token, err := s.tokens.GetByToken(ctx, value)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCalendarTokenNotFound
+		}
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(token.UserID)
+	if err != nil {
+		return nil, ErrCalendarTokenNotFound
+	}
+
+	since := time.Now().Add(-s.cfg.History)
+
+	var (
+		appointments []models.Appointment
+		forDoctor    bool
+	)
+
+	if user.Role == models.Doc {
+		doctor, err := s.doctors.GetByUserID(ctx, user.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return s.render("Мои приёмы", nil, true), nil
+			}
+			return nil, err
+		}
+		appointments, err = s.appointments.GetCalendarByDoctorID(doctor.ID, since)
+		if err != nil {
+			return nil, err
+		}
+		forDoctor = true
+	} else {
+		appointments, err = s.appointments.GetCalendarByPatientID(user.ID, since)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.render("Мои приёмы", appointments, forDoctor), nil
+}
+
+func (s *calendarService) AppointmentICS(ctx context.Context, userID uint, role string, appointmentID uint) ([]byte, error) {
+	appointment, err := s.appointments.GetByID(appointmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, constants.ErrGetByIDAppointments
+		}
+		return nil, err
+	}
+
+	forDoctor := false
+	switch models.Role(role) {
+	case models.Admin:
+	case models.Doc:
+		doctor, err := s.doctors.GetByUserID(ctx, userID)
+		if err != nil || doctor.ID != appointment.DoctorID {
+			return nil, ErrCalendarForbidden
+		}
+		forDoctor = true
+	default:
+		if appointment.PatientID != userID {
+			return nil, ErrCalendarForbidden
+		}
+	}
+
+	if appointment.Patient == nil {
+		appointment.Patient, _ = s.users.GetByID(appointment.PatientID)
+	}
+	if appointment.Doctor == nil {
+		appointment.Doctor, _ = s.doctors.GetByID(appointment.DoctorID, ctx)
+	}
+
+	return s.render("", []models.Appointment{*appointment}, forDoctor), nil
+}
+
+func (s *calendarService) render(name string, appointments []models.Appointment, forDoctor bool) []byte {
+	cal := calendar.Calendar{
+		Name:     name,
+		Timezone: s.cfg.Timezone,
+		Events:   make([]calendar.Event, 0, len(appointments)),
+	}
+
+	for i := range appointments {
+		cal.Events = append(cal.Events, s.event(&appointments[i], forDoctor))
+	}
+
+	return calendar.Render(cal)
+}
+
+func (s *calendarService) event(a *models.Appointment, forDoctor bool) calendar.Event {
+	serviceName := "Приём у стоматолога"
+	if a.Service != nil && a.Service.Name != "" {
+		serviceName = a.Service.Name
+	}
+
+	summary := serviceName
+	if forDoctor && a.Patient != nil {
+		summary = fmt.Sprintf("%s — %s %s", serviceName, a.Patient.FirstName, a.Patient.LastName)
+	}
+
+	status := calendar.StatusConfirmed
+	switch {
+	case a.DeletedAt.Valid, a.Status == models.AppointmentCancelled:
+		status = calendar.StatusCancelled
+	case a.Status == models.AppointmentPendingPayment, a.Status == models.AppointmentPendingApproval:
+		status = calendar.StatusTentative
+	}
+
+	location := ""
+	if a.Doctor != nil && a.Doctor.RoomNumber != 0 {
+		location = fmt.Sprintf("Кабинет %d", a.Doctor.RoomNumber)
+	}
+
+	stamp := a.UpdatedAt
+	if a.DeletedAt.Valid && a.DeletedAt.Time.After(stamp) {
+		stamp = a.DeletedAt.Time
+	}
+
+	return calendar.Event{
+		UID: fmt.Sprintf("appointment-%d@%s", a.ID, s.cfg.UIDDomain),
+		// SEQUENCE растёт с каждым изменением записи, чтобы клиент принял обновление.
+		Sequence:    int(stamp.Sub(a.CreatedAt) / time.Second),
+		Stamp:       stamp,
+		Start:       a.StartAt,
+		End:         a.EndAt,
+		Summary:     summary,
+		Description: fmt.Sprintf("Запись №%d", a.ID),
+		Location:    location,
+		Status:      status,
+	}
+}
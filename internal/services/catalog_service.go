@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("категория услуг не найдена")
+	ErrInvalidCategory  = errors.New("некорректная категория: нужны название и slug из латинских букв, цифр и дефисов")
+	ErrCategoryExists   = errors.New("категория с таким slug уже есть")
+	ErrCategoryInUse    = errors.New("в категории есть подкатегории, услуги или комплексы")
+	ErrCategoryCycle    = errors.New("категорию нельзя вложить в саму себя или в её подкатегорию")
+)
+
+// CatalogService ведёт дерево категорий услуг и собирает публичный
+// каталог.
+type CatalogService interface {
+	CreateCategory(ctx context.Context, req models.ServiceCategoryCreateRequest) (*models.ServiceCategory, error)
+
+	ListCategories(ctx context.Context) ([]models.ServiceCategory, error)
+
+	UpdateCategory(ctx context.Context, id uint, req models.ServiceCategoryUpdateRequest) (*models.ServiceCategory, error)
+
+	// DeleteCategory удаляет пустую категорию.
+	DeleteCategory(ctx context.Context, id uint) error
+
+	// Catalog возвращает дерево категорий с услугами и продающимися
+	// комплексами; при clinicID != 0 — только услуги филиала.
+	Catalog(ctx context.Context, clinicID uint) (*models.Catalog, error)
+}
+
+type catalogService struct {
+	categories repository.ServiceCategoryRepository
+	services   repository.ServiceRepository
+	bundles    repository.BundleRepository
+	logger     *slog.Logger
+}
+
+func NewCatalogService(
+	categories repository.ServiceCategoryRepository,
+	services repository.ServiceRepository,
+	bundles repository.BundleRepository,
+	logger *slog.Logger,
+) CatalogService {
+	return &catalogService{categories: categories, services: services, bundles: bundles, logger: logger}
+}
+
+func (s *catalogService) CreateCategory(ctx context.Context, req models.ServiceCategoryCreateRequest) (*models.ServiceCategory, error) {
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	name := strings.TrimSpace(req.Name)
+	if name == "" || !specialtySlug.MatchString(slug) {
+		return nil, ErrInvalidCategory
+	}
+
+	if err := s.ensureSlugFree(ctx, slug, 0); err != nil {
+		return nil, err
+	}
+
+	category := &models.ServiceCategory{
+		Slug:     slug,
+		Name:     name,
+		Icon:     strings.TrimSpace(req.Icon),
+		Position: req.Position,
+	}
+	if req.ParentID != nil && *req.ParentID != 0 {
+		if _, err := s.getCategory(ctx, *req.ParentID); err != nil {
+			return nil, err
+		}
+		category.ParentID = req.ParentID
+	}
+
+	if err := s.categories.Create(ctx, category); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (s *catalogService) ListCategories(ctx context.Context) ([]models.ServiceCategory, error) {
+	return s.categories.List(ctx)
+}
+
+func (s *catalogService) UpdateCategory(ctx context.Context, id uint, req models.ServiceCategoryUpdateRequest) (*models.ServiceCategory, error) {
+	category, err := s.getCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Slug != nil {
+		slug := strings.ToLower(strings.TrimSpace(*req.Slug))
+		if !specialtySlug.MatchString(slug) {
+			return nil, ErrInvalidCategory
+		}
+		if err := s.ensureSlugFree(ctx, slug, id); err != nil {
+			return nil, err
+		}
+		category.Slug = slug
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, ErrInvalidCategory
+		}
+		category.Name = name
+	}
+	if req.Icon != nil {
+		category.Icon = strings.TrimSpace(*req.Icon)
+	}
+	if req.Position != nil {
+		category.Position = *req.Position
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			if err := s.checkParent(ctx, id, *req.ParentID); err != nil {
+				return nil, err
+			}
+			category.ParentID = req.ParentID
+		}
+	}
+
+	if err := s.categories.Update(ctx, category); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (s *catalogService) DeleteCategory(ctx context.Context, id uint) error {
+	if _, err := s.getCategory(ctx, id); err != nil {
+		return err
+	}
+
+	inUse, err := s.categories.InUse(ctx, id)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrCategoryInUse
+	}
+
+	return s.categories.Delete(ctx, id)
+}
+
+func (s *catalogService) Catalog(ctx context.Context, clinicID uint) (*models.Catalog, error) {
+	categories, err := s.categories.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Limit -1 — без ограничения.
+	services, err := s.services.List(0, -1, clinicID)
+	if err != nil {
+		return nil, err
+	}
+	bundles, err := s.bundles.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[uint]bool, len(categories))
+	for _, category := range categories {
+		known[category.ID] = true
+	}
+
+	catalog := &models.Catalog{Categories: []models.CatalogNode{}, Other: []models.Service{}}
+
+	servicesByCategory := make(map[uint][]models.Service)
+	for _, service := range services {
+		if service.CategoryID == nil || !known[*service.CategoryID] {
+			catalog.Other = append(catalog.Other, service)
+			continue
+		}
+		servicesByCategory[*service.CategoryID] = append(servicesByCategory[*service.CategoryID], service)
+	}
+
+	bundlesByCategory := make(map[uint][]models.ServiceBundle)
+	for _, bundle := range bundles {
+		if bundle.CategoryID != nil {
+			bundlesByCategory[*bundle.CategoryID] = append(bundlesByCategory[*bundle.CategoryID], bundle)
+		}
+	}
+
+	// Категории уже отсортированы, поэтому дети собираются в нужном порядке.
+	childrenOf := make(map[uint][]models.ServiceCategory)
+	var roots []models.ServiceCategory
+	for _, category := range categories {
+		if category.ParentID == nil || !known[*category.ParentID] {
+			roots = append(roots, category)
+			continue
+		}
+		childrenOf[*category.ParentID] = append(childrenOf[*category.ParentID], category)
+	}
+
+	var build func(category models.ServiceCategory) models.CatalogNode
+	build = func(category models.ServiceCategory) models.CatalogNode {
+		node := models.CatalogNode{
+			ServiceCategory: category,
+			Services:        servicesByCategory[category.ID],
+			Bundles:         bundlesByCategory[category.ID],
+			Children:        []models.CatalogNode{},
+		}
+		if node.Services == nil {
+			node.Services = []models.Service{}
+		}
+		if node.Bundles == nil {
+			node.Bundles = []models.ServiceBundle{}
+		}
+		for _, child := range childrenOf[category.ID] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+
+	for _, root := range roots {
+		catalog.Categories = append(catalog.Categories, build(root))
+	}
+
+	return catalog, nil
+}
+
+func (s *catalogService) ensureSlugFree(ctx context.Context, slug string, selfID uint) error {
+	existing, err := s.categories.GetBySlug(ctx, slug)
+	if err == nil {
+		if existing.ID != selfID {
+			return ErrCategoryExists
+		}
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// checkParent проверяет, что parentID существует и не лежит в поддереве id.
+func (s *catalogService) checkParent(ctx context.Context, id, parentID uint) error {
+	for current := parentID; ; {
+		if current == id {
+			return ErrCategoryCycle
+		}
+		parent, err := s.getCategory(ctx, current)
+		if err != nil {
+			return err
+		}
+		if parent.ParentID == nil {
+			return nil
+		}
+		current = *parent.ParentID
+	}
+}
+
+func (s *catalogService) getCategory(ctx context.Context, id uint) (*models.ServiceCategory, error) {
+	category, err := s.categories.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	return category, nil
+}
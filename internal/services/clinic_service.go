@@ -0,0 +1,400 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrClinicNotFound      = errors.New("филиал не найден")
+	ErrClinicInactive      = errors.New("филиал не работает")
+	ErrInvalidClinic       = errors.New("некорректный филиал: укажите название")
+	ErrInvalidTimezone     = errors.New("некорректный часовой пояс филиала")
+	ErrInvalidWorkingHours = errors.New("некорректные часы работы: ожидается день недели 0–6 и время в формате ЧЧ:ММ, открытие раньше закрытия")
+	ErrClinicForbidden     = errors.New("нет доступа к этому филиалу")
+	ErrDoctorNotInClinic   = errors.New("врач не работает в этом филиале")
+	ErrOutsideClinicHours  = errors.New("смена выходит за часы работы филиала")
+)
+
+type ClinicConfig struct {
+	// DefaultTimezone используется для филиалов, созданных без часового пояса.
+	DefaultTimezone string
+}
+
+type ClinicService interface {
+	Create(ctx context.Context, req models.ClinicCreateRequest) (*models.Clinic, error)
+
+	GetByID(ctx context.Context, id uint) (*models.Clinic, error)
+
+	List(ctx context.Context, params models.ClinicQueryParams) ([]models.Clinic, error)
+
+	Update(ctx context.Context, id uint, req models.ClinicUpdateRequest) (*models.Clinic, error)
+
+	Delete(ctx context.Context, id uint) error
+
+	SetDoctors(ctx context.Context, id uint, doctorIDs []uint) (*models.Clinic, error)
+
+	// SetServices ограничивает услуги филиала; услуга, не привязанная ни
+	// к одному филиалу, доступна везде, где работает её врач.
+	SetServices(ctx context.Context, id uint, serviceIDs []uint) error
+
+	SetStaff(ctx context.Context, id uint, userIDs []uint) error
+
+	// SetAllClinics открывает администратору доступ ко всей сети или
+	// закрывает его. Менять доступ может только администратор сети.
+	SetAllClinics(ctx context.Context, userID uint, allClinics bool) error
+
+	// Scope возвращает контекст с филиалами, доступными сотруднику. Без
+	// ограничений работает только администратор сети; сотрудник без
+	// закреплений получает пустой список филиалов.
+	Scope(ctx context.Context, userID uint, role string) (context.Context, error)
+}
+
+type clinicService struct {
+	repo   repository.ClinicRepository
+	cfg    ClinicConfig
+	logger *slog.Logger
+}
+
+func NewClinicService(repo repository.ClinicRepository, cfg ClinicConfig, logger *slog.Logger) ClinicService {
+	if cfg.DefaultTimezone == "" {
+		cfg.DefaultTimezone = "UTC"
+	}
+
+	return &clinicService{repo: repo, cfg: cfg, logger: logger}
+}
+
+func (s *clinicService) Create(ctx context.Context, req models.ClinicCreateRequest) (*models.Clinic, error) {
+	if _, scoped := ClinicScopeIDs(ctx); scoped {
+		return nil, ErrClinicForbidden
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrInvalidClinic
+	}
+
+	timezone := strings.TrimSpace(req.Timezone)
+	if timezone == "" {
+		timezone = s.cfg.DefaultTimezone
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
+	if err := validateWorkingHours(req.WorkingHours); err != nil {
+		return nil, err
+	}
+
+	clinic := &models.Clinic{
+		Name:         name,
+		Address:      strings.TrimSpace(req.Address),
+		Timezone:     timezone,
+		WorkingHours: req.WorkingHours,
+		IsActive:     true,
+	}
+
+	if err := s.repo.Create(ctx, clinic); err != nil {
+		return nil, err
+	}
+
+	return clinic, nil
+}
+
+func (s *clinicService) GetByID(ctx context.Context, id uint) (*models.Clinic, error) {
+	clinic, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClinicNotFound
+		}
+		return nil, err
+	}
+	return clinic, nil
+}
+
+func (s *clinicService) List(ctx context.Context, params models.ClinicQueryParams) ([]models.Clinic, error) {
+	return s.repo.List(ctx, params)
+}
+
+func (s *clinicService) Update(ctx context.Context, id uint, req models.ClinicUpdateRequest) (*models.Clinic, error) {
+	clinic, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ClinicAllowed(ctx, &clinic.ID) {
+		return nil, ErrClinicForbidden
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, ErrInvalidClinic
+		}
+		clinic.Name = name
+	}
+	if req.Address != nil {
+		clinic.Address = strings.TrimSpace(*req.Address)
+	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			return nil, ErrInvalidTimezone
+		}
+		clinic.Timezone = *req.Timezone
+	}
+	if req.WorkingHours != nil {
+		if err := validateWorkingHours(*req.WorkingHours); err != nil {
+			return nil, err
+		}
+		clinic.WorkingHours = *req.WorkingHours
+	}
+	if req.IsActive != nil {
+		clinic.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.Update(ctx, clinic); err != nil {
+		return nil, err
+	}
+
+	return clinic, nil
+}
+
+func (s *clinicService) Delete(ctx context.Context, id uint) error {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return err
+	}
+	if !ClinicAllowed(ctx, &id) {
+		return ErrClinicForbidden
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *clinicService) SetDoctors(ctx context.Context, id uint, doctorIDs []uint) (*models.Clinic, error) {
+	clinic, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ClinicAllowed(ctx, &clinic.ID) {
+		return nil, ErrClinicForbidden
+	}
+
+	if err := s.repo.SetDoctors(ctx, clinic, doctorIDs); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(ctx, id)
+}
+
+func (s *clinicService) SetServices(ctx context.Context, id uint, serviceIDs []uint) error {
+	clinic, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ClinicAllowed(ctx, &clinic.ID) {
+		return ErrClinicForbidden
+	}
+
+	return s.repo.SetServices(ctx, clinic, serviceIDs)
+}
+
+func (s *clinicService) SetStaff(ctx context.Context, id uint, userIDs []uint) error {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return err
+	}
+	if !ClinicAllowed(ctx, &id) {
+		return ErrClinicForbidden
+	}
+
+	return s.repo.SetStaff(ctx, id, userIDs)
+}
+
+func (s *clinicService) SetAllClinics(ctx context.Context, userID uint, allClinics bool) error {
+	if _, scoped := ClinicScopeIDs(ctx); scoped {
+		return ErrClinicForbidden
+	}
+
+	if err := s.repo.SetAllClinics(ctx, userID, allClinics); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *clinicService) Scope(ctx context.Context, userID uint, role string) (context.Context, error) {
+	if r := models.Role(role); r != models.Admin && r != models.Doc {
+		return ctx, nil
+	}
+
+	// Пока филиалов нет, сеть одна и ограничивать нечего.
+	configured, err := s.repo.HasClinics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !configured {
+		return ctx, nil
+	}
+
+	var (
+		ids []uint
+		all bool
+	)
+
+	switch models.Role(role) {
+	case models.Admin:
+		if all, err = s.repo.HasAllClinics(ctx, userID); err != nil {
+			return nil, err
+		}
+		if all {
+			return ctx, nil
+		}
+		ids, err = s.repo.StaffClinicIDs(ctx, userID)
+	case models.Doc:
+		ids, err = s.repo.DoctorClinicIDsByUser(ctx, userID)
+	default:
+		return ctx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Пустой, но не nil список: репозитории трактуют nil как «без
+	// ограничений».
+	if ids == nil {
+		ids = []uint{}
+	}
+
+	return context.WithValue(ctx, clinicScopeKey{}, ids), nil
+}
+
+type clinicScopeKey struct{}
+
+// ClinicScopeIDs возвращает филиалы, которыми ограничен текущий сотрудник;
+// false — ограничений нет.
+func ClinicScopeIDs(ctx context.Context) ([]uint, bool) {
+	ids, ok := ctx.Value(clinicScopeKey{}).([]uint)
+	return ids, ok
+}
+
+// ClinicAllowed проверяет доступ текущего сотрудника к филиалу. Записи вне
+// филиалов (clinicID = nil) доступны только сотрудникам без ограничений.
+func ClinicAllowed(ctx context.Context, clinicID *uint) bool {
+	ids, scoped := ClinicScopeIDs(ctx)
+	if !scoped {
+		return true
+	}
+	return clinicID != nil && slices.Contains(ids, *clinicID)
+}
+
+func validateWorkingHours(hours []models.ClinicWorkingHours) error {
+	seen := make(map[time.Weekday]bool, len(hours))
+	for _, h := range hours {
+		if h.Weekday < time.Sunday || h.Weekday > time.Saturday || seen[h.Weekday] {
+			return ErrInvalidWorkingHours
+		}
+		seen[h.Weekday] = true
+
+		opens, err := time.Parse("15:04", h.Opens)
+		if err != nil {
+			return ErrInvalidWorkingHours
+		}
+		closes, err := time.Parse("15:04", h.Closes)
+		if err != nil || !opens.Before(closes) {
+			return ErrInvalidWorkingHours
+		}
+	}
+	return nil
+}
+
+// withinWorkingHours проверяет, что интервал укладывается в часы работы
+// филиала в один день по его часовому поясу.
+func withinWorkingHours(clinic *models.Clinic, start, end time.Time) (bool, error) {
+	if len(clinic.WorkingHours) == 0 {
+		return true, nil
+	}
+
+	loc, err := time.LoadLocation(clinic.Timezone)
+	if err != nil {
+		return false, ErrInvalidTimezone
+	}
+
+	localStart, localEnd := start.In(loc), end.In(loc)
+	day := time.Date(localStart.Year(), localStart.Month(), localStart.Day(), 0, 0, 0, 0, loc)
+
+	for _, h := range clinic.WorkingHours {
+		if h.Weekday != localStart.Weekday() {
+			continue
+		}
+
+		opens, _ := time.Parse("15:04", h.Opens)
+		closes, _ := time.Parse("15:04", h.Closes)
+		openAt := time.Date(day.Year(), day.Month(), day.Day(), opens.Hour(), opens.Minute(), 0, 0, loc)
+		closeAt := time.Date(day.Year(), day.Month(), day.Day(), closes.Hour(), closes.Minute(), 0, 0, loc)
+
+		return !localStart.Before(openAt) && !localEnd.After(closeAt), nil
+	}
+
+	return false, nil
+}
+
+// clinicLocations определяет часовые пояса филиалов и запоминает их на
+// время одного прохода; для приёмов вне филиалов — пояс по умолчанию.
+type clinicLocations struct {
+	clinics  repository.ClinicRepository
+	fallback *time.Location
+	cache    map[uint]*time.Location
+}
+
+func newClinicLocations(clinics repository.ClinicRepository, fallback *time.Location) *clinicLocations {
+	return &clinicLocations{clinics: clinics, fallback: fallback, cache: make(map[uint]*time.Location)}
+}
+
+func (l *clinicLocations) get(ctx context.Context, clinicID *uint) (*time.Location, error) {
+	if clinicID == nil {
+		return l.fallback, nil
+	}
+	if loc, ok := l.cache[*clinicID]; ok {
+		return loc, nil
+	}
+
+	clinic, err := l.clinics.GetByID(ctx, *clinicID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClinicNotFound
+		}
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(clinic.Timezone)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	l.cache[*clinicID] = loc
+	return loc, nil
+}
+
+// forShift возвращает часовой пояс филиала, в смене которого врач работает
+// в момент start.
+func (l *clinicLocations) forShift(ctx context.Context, schedules repository.ScheduleRepository, doctorID uint, start time.Time) (*time.Location, error) {
+	shifts, err := schedules.GetBetween(ctx, start, start.Add(time.Minute))
+	if err != nil {
+		return nil, err
+	}
+
+	var clinicID *uint
+	for _, sch := range shifts {
+		if sch.DoctorID == doctorID {
+			clinicID = sch.ClinicID
+			break
+		}
+	}
+	return l.get(ctx, clinicID)
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+type DoctorDirectoryService interface {
+	// List возвращает страницу публичного каталога: карточки врачей с
+	// именем, ближайшим свободным слотом и рейтингом.
+	List(ctx context.Context, q models.ListQuery, params models.DoctorDirectoryParams) (*models.Page[models.DoctorDirectoryEntry], error)
+
+	// Profile возвращает карточку врача с полной сводкой рейтинга.
+	Profile(ctx context.Context, id uint, clinicID uint) (*models.DoctorDirectoryEntry, error)
+}
+
+type doctorDirectoryService struct {
+	doctors     repository.DoctorRepository
+	specialties repository.SpecialtyRepository
+	schedules   ScheduleService
+	reviews     ReviewService
+	logger      *slog.Logger
+}
+
+func NewDoctorDirectoryService(
+	doctors repository.DoctorRepository,
+	specialties repository.SpecialtyRepository,
+	schedules ScheduleService,
+	reviews ReviewService,
+	logger *slog.Logger,
+) DoctorDirectoryService {
+	return &doctorDirectoryService{doctors: doctors, specialties: specialties, schedules: schedules, reviews: reviews, logger: logger}
+}
+
+func (s *doctorDirectoryService) List(ctx context.Context, q models.ListQuery, params models.DoctorDirectoryParams) (*models.Page[models.DoctorDirectoryEntry], error) {
+	if params.Specialty != "" {
+		specialty, err := s.specialties.GetBySlug(ctx, strings.ToLower(params.Specialty))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrSpecialtyNotFound
+			}
+			return nil, err
+		}
+		if q.Filters == nil {
+			q.Filters = make(map[string]string)
+		}
+		q.Filters["specialty_id"] = strconv.FormatUint(uint64(specialty.ID), 10)
+	}
+	if params.Language != "" {
+		params.Language = strings.ToLower(params.Language)
+		if !languageCode.MatchString(params.Language) {
+			return nil, ErrInvalidDoctorLang
+		}
+	}
+
+	page, err := s.doctors.ListDirectory(ctx, q, params)
+	if err != nil {
+		if !errors.Is(err, constants.ErrInvalidListQuery) {
+			s.logger.Error("ошибка при получении каталога врачей", "error", err)
+		}
+		return nil, err
+	}
+
+	ids := make([]uint, len(page.Items))
+	for i := range page.Items {
+		ids[i] = page.Items[i].ID
+	}
+	slots, err := s.schedules.NextAvailableSlots(ctx, ids, params.ClinicID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Page[models.DoctorDirectoryEntry]{
+		Items:      make([]models.DoctorDirectoryEntry, len(page.Items)),
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}
+	for i := range page.Items {
+		result.Items[i] = directoryEntry(&page.Items[i], slots)
+	}
+
+	s.logger.Info("каталог врачей получен", "count", len(result.Items), "total", result.Total)
+	return result, nil
+}
+
+func (s *doctorDirectoryService) Profile(ctx context.Context, id uint, clinicID uint) (*models.DoctorDirectoryEntry, error) {
+	doctor, err := s.doctors.GetProfile(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDoctorNotFound
+		}
+		return nil, err
+	}
+
+	slots, err := s.schedules.NextAvailableSlots(ctx, []uint{doctor.ID}, clinicID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.reviews.GetDoctorRating(ctx, doctor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := directoryEntry(doctor, slots)
+	entry.Rating.Summary = summary
+	return &entry, nil
+}
+
+func directoryEntry(d *models.Doctor, slots map[uint]models.Schedule) models.DoctorDirectoryEntry {
+	entry := models.DoctorDirectoryEntry{
+		ID:              d.ID,
+		PhotoURL:        d.PhotoURL,
+		Specialty:       d.Specialty,
+		Specialization:  d.Specialization,
+		ExperienceYears: d.ExperienceYears,
+		Bio:             d.Bio,
+		Education:       d.Education,
+		Certifications:  d.Certifications,
+		Languages:       d.Languages,
+		Rating:          models.DoctorRating{Average: d.AvgRating, Count: d.ReviewCount},
+	}
+	if d.User != nil {
+		entry.FirstName = d.User.FirstName
+		entry.LastName = d.User.LastName
+	}
+	if entry.Education == nil {
+		entry.Education = []models.DoctorEducation{}
+	}
+	if entry.Certifications == nil {
+		entry.Certifications = []models.DoctorCertification{}
+	}
+	if entry.Languages == nil {
+		entry.Languages = []string{}
+	}
+	if slot, ok := slots[d.ID]; ok {
+		entry.NextAvailable = &slot
+	}
+
+	return entry
+}
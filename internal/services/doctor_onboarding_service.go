@@ -0,0 +1,488 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/localtime"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/notifications"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+// maxTemplateWeeks ограничивает разворачивание шаблона расписания.
+const maxTemplateWeeks = 12
+
+var (
+	ErrInvalidOnboarding       = errors.New("укажите либо user_id существующего пользователя, либо имя, фамилию, email и телефон нового (user)")
+	ErrInvalidDoctorProfile    = errors.New("некорректный профиль врача")
+	ErrEmailTaken              = errors.New("пользователь с таким email уже есть")
+	ErrDoctorExists            = errors.New("пользователь уже является врачом")
+	ErrAdminCannotBeDoctor     = errors.New("администратора нельзя сделать врачом")
+	ErrInvalidScheduleTemplate = errors.New("некорректный шаблон расписания: укажите дату начала ГГГГ-ММ-ДД, от 1 до 12 недель и смены с днём недели 0–6 и временем ЧЧ:ММ")
+	ErrInvitationNotFound      = errors.New("приглашение не найдено")
+	ErrInvitationExpired       = errors.New("срок действия приглашения истёк")
+	ErrInvitationUsed          = errors.New("приглашение уже принято, пароль задан")
+	ErrWeakPassword            = errors.New("пароль должен быть не короче 8 символов")
+)
+
+type DoctorOnboardingConfig struct {
+	InvitationTTL time.Duration
+	// AcceptURL — страница, где врач задаёт пароль; токен передаётся в
+	// параметре token.
+	AcceptURL string
+	// Location — часовой пояс срока действия в письме.
+	Location *time.Location
+}
+
+type DoctorOnboardingService interface {
+	// Onboard заводит врача целиком или не заводит ничего: учётную запись
+	// (новую или повышенную до врача), профиль, услуги, закрепление за
+	// филиалом, смены по шаблону и приглашение. Письмо отправляется после
+	// фиксации транзакции.
+	Onboard(ctx context.Context, req models.DoctorOnboardingRequest) (*models.DoctorOnboardingResponse, error)
+
+	// ResendInvitation выпускает новое приглашение врачу, который ещё не
+	// задал пароль; прежние ссылки перестают действовать.
+	ResendInvitation(ctx context.Context, doctorID uint) (*models.DoctorInvitation, bool, error)
+
+	// AcceptInvitation задаёт пароль по токену из приглашения.
+	AcceptInvitation(ctx context.Context, req models.InvitationAcceptRequest) error
+}
+
+type doctorOnboardingService struct {
+	doctors     repository.DoctorRepository
+	users       repository.UserRepository
+	services    repository.ServiceRepository
+	specialties repository.SpecialtyRepository
+	clinics     repository.ClinicRepository
+	invitations repository.InvitationRepository
+	schedules   ScheduleService
+	dispatcher  *notifications.Dispatcher
+	cfg         DoctorOnboardingConfig
+	logger      *slog.Logger
+}
+
+func NewDoctorOnboardingService(
+	doctors repository.DoctorRepository,
+	users repository.UserRepository,
+	services repository.ServiceRepository,
+	specialties repository.SpecialtyRepository,
+	clinics repository.ClinicRepository,
+	invitations repository.InvitationRepository,
+	schedules ScheduleService,
+	dispatcher *notifications.Dispatcher,
+	cfg DoctorOnboardingConfig,
+	logger *slog.Logger,
+) DoctorOnboardingService {
+	if cfg.InvitationTTL <= 0 {
+		cfg.InvitationTTL = 72 * time.Hour
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+
+	return &doctorOnboardingService{
+		doctors:     doctors,
+		users:       users,
+		services:    services,
+		specialties: specialties,
+		clinics:     clinics,
+		invitations: invitations,
+		schedules:   schedules,
+		dispatcher:  dispatcher,
+		cfg:         cfg,
+		logger:      logger,
+	}
+}
+
+func (s *doctorOnboardingService) Onboard(ctx context.Context, req models.DoctorOnboardingRequest) (*models.DoctorOnboardingResponse, error) {
+	s.logger.Debug("Onboard вызван", "user_id", req.UserID, "services", len(req.ServiceIDs))
+
+	if err := validateDoctorProfile(req.DoctorCreateRequest); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDoctorProfile, err)
+	}
+
+	specialty, err := s.specialties.GetByID(ctx, req.SpecialtyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSpecialtyNotFound
+		}
+		return nil, err
+	}
+	languages, err := normalizeLanguages(req.Languages)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.onboardingUser(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var shifts []models.ScheduleCreateRequest
+	if req.Schedule != nil {
+		if shifts, err = expandScheduleTemplate(*req.Schedule, req.RoomNumber); err != nil {
+			return nil, err
+		}
+	}
+
+	// Приглашение нужно только новой учётной записи: у существующего
+	// пользователя пароль уже есть.
+	var (
+		invitation *models.DoctorInvitation
+		token      string
+	)
+	if user.ID == 0 {
+		var hash string
+		if token, hash, err = newInvitationToken(); err != nil {
+			return nil, err
+		}
+		invitation = &models.DoctorInvitation{
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(s.cfg.InvitationTTL),
+			Language:  invitationLanguage(req.Language),
+		}
+	}
+
+	doctor := &models.Doctor{
+		SpecialtyID:     &specialty.ID,
+		Specialization:  specialty.Name,
+		ExperienceYears: req.ExperienceYears,
+		Bio:             req.Bio,
+		RoomNumber:      req.RoomNumber,
+		Education:       req.Education,
+		Certifications:  req.Certifications,
+		Languages:       languages,
+	}
+	var schedules []models.Schedule
+
+	err = s.doctors.Transaction(ctx, func(tx *gorm.DB) error {
+		if user.ID == 0 {
+			if err := s.users.CreateTx(tx, user); err != nil {
+				if errors.Is(err, repository.ErrEmailExists) {
+					return ErrEmailTaken
+				}
+				return err
+			}
+		} else {
+			user.Role = models.Doc
+			if err := s.users.UpdateTx(tx, user); err != nil {
+				return err
+			}
+		}
+
+		doctor.UserID = user.ID
+		if err := s.doctors.CreateTx(tx, doctor); err != nil {
+			return err
+		}
+
+		if len(req.ServiceIDs) > 0 {
+			if err := s.services.AssignDoctorTx(tx, req.ServiceIDs, doctor.ID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrServiceNotfound
+				}
+				return err
+			}
+		}
+
+		if len(shifts) > 0 {
+			if clinicID := req.Schedule.ClinicID; clinicID != nil {
+				if err := s.clinics.AddDoctorTx(tx, *clinicID, doctor.ID); err != nil {
+					return err
+				}
+			}
+			for i := range shifts {
+				shifts[i].DoctorID = doctor.ID
+			}
+			var err error
+			if schedules, err = s.schedules.CreateScheduleTx(ctx, tx, shifts); err != nil {
+				return err
+			}
+		}
+
+		if invitation != nil {
+			invitation.UserID = user.ID
+			if err := s.invitations.CreateTx(tx, invitation); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("онбординг врача не выполнен", "error", err, "email", user.Email)
+		return nil, err
+	}
+
+	s.logger.Info("врач заведён", "doctor_id", doctor.ID, "user_id", user.ID, "schedules", len(schedules))
+
+	resp := &models.DoctorOnboardingResponse{
+		User:       user,
+		Doctor:     doctor,
+		Schedules:  schedules,
+		Invitation: invitation,
+	}
+	if invitation != nil {
+		resp.InvitationSent = s.sendInvitation(ctx, user, invitation, token)
+	}
+	return resp, nil
+}
+
+func (s *doctorOnboardingService) ResendInvitation(ctx context.Context, doctorID uint) (*models.DoctorInvitation, bool, error) {
+	doctor, err := s.doctors.GetByID(doctorID, ctx)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrDoctorNotFound
+		}
+		return nil, false, err
+	}
+
+	user, err := s.users.GetByID(doctor.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrUserNotFound
+		}
+		return nil, false, err
+	}
+	if user.Password != "" {
+		return nil, false, ErrInvitationUsed
+	}
+
+	token, hash, err := newInvitationToken()
+	if err != nil {
+		return nil, false, err
+	}
+	invitation := &models.DoctorInvitation{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.cfg.InvitationTTL),
+		Language:  notifications.DefaultLanguage,
+	}
+
+	err = s.invitations.Transaction(ctx, func(tx *gorm.DB) error {
+		previous, err := s.invitations.LatestTx(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		if previous != nil {
+			invitation.Language = previous.Language
+		}
+		if err := s.invitations.RevokePendingTx(tx, user.ID); err != nil {
+			return err
+		}
+		return s.invitations.CreateTx(tx, invitation)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return invitation, s.sendInvitation(ctx, user, invitation, token), nil
+}
+
+func (s *doctorOnboardingService) AcceptInvitation(ctx context.Context, req models.InvitationAcceptRequest) error {
+	if len([]rune(req.Password)) < 8 {
+		return ErrWeakPassword
+	}
+	hashed, err := hashPassword(req.Password)
+	if err != nil {
+		return err
+	}
+
+	return s.invitations.Transaction(ctx, func(tx *gorm.DB) error {
+		invitation, err := s.invitations.GetByTokenHashTx(tx, hashInvitationToken(req.Token))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvitationNotFound
+			}
+			return err
+		}
+		if invitation.AcceptedAt != nil {
+			return ErrInvitationUsed
+		}
+		now := time.Now()
+		if now.After(invitation.ExpiresAt) {
+			return ErrInvitationExpired
+		}
+
+		user, err := s.users.GetByID(invitation.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		user.Password = hashed
+		// Ссылка пришла на email, значит адрес подтверждён.
+		user.EmailVerified = true
+		if err := s.users.UpdateTx(tx, user); err != nil {
+			return err
+		}
+
+		invitation.AcceptedAt = &now
+		if err := s.invitations.UpdateTx(tx, invitation); err != nil {
+			return err
+		}
+
+		s.logger.Info("приглашение принято", "invitation_id", invitation.ID, "user_id", user.ID)
+		return nil
+	})
+}
+
+// onboardingUser возвращает существующего пользователя, которого можно
+// сделать врачом, или ещё не сохранённую учётную запись нового.
+func (s *doctorOnboardingService) onboardingUser(ctx context.Context, req models.DoctorOnboardingRequest) (*models.User, error) {
+	if (req.UserID == 0) == (req.User == nil) {
+		return nil, ErrInvalidOnboarding
+	}
+
+	if req.UserID != 0 {
+		user, err := s.users.GetByID(req.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrUserNotFound
+			}
+			return nil, err
+		}
+		if user.Role == models.Admin {
+			return nil, ErrAdminCannotBeDoctor
+		}
+
+		_, err = s.doctors.GetByUserID(ctx, user.ID)
+		switch {
+		case err == nil:
+			return nil, ErrDoctorExists
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, err
+		}
+		return user, nil
+	}
+
+	u := req.User
+	user := &models.User{
+		FirstName: strings.TrimSpace(u.FirstName),
+		LastName:  strings.TrimSpace(u.LastName),
+		Email:     strings.TrimSpace(u.Email),
+		Phone:     strings.TrimSpace(u.Phone),
+		Role:      models.Doc,
+		Gender:    u.Gender,
+	}
+	if user.FirstName == "" || user.LastName == "" || user.Email == "" || user.Phone == "" {
+		return nil, ErrInvalidOnboarding
+	}
+	switch user.Gender {
+	case "", models.Male, models.Female:
+	default:
+		return nil, ErrInvalidOnboarding
+	}
+	if u.DateOfBirth != "" {
+		dob, err := time.Parse(localtime.DateLayout, u.DateOfBirth)
+		if err != nil {
+			return nil, localtime.ErrInvalidDate
+		}
+		user.DateOfBirth = dob
+	}
+
+	_, err := s.users.GetByEmail(user.Email)
+	switch {
+	case err == nil:
+		return nil, ErrEmailTaken
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *doctorOnboardingService) sendInvitation(ctx context.Context, user *models.User, invitation *models.DoctorInvitation, token string) bool {
+	data := notifications.DoctorInvitationData{
+		DoctorName: strings.TrimSpace(user.FirstName + " " + user.LastName),
+		AcceptURL:  s.cfg.AcceptURL + "?token=" + token,
+		ExpiresAt:  invitation.ExpiresAt.In(s.cfg.Location).Format("02.01.2006 15:04"),
+	}
+
+	subject, body, err := notifications.RenderDoctorInvitation(invitation.Language, data)
+	if err != nil {
+		s.logger.Error("не удалось сформировать приглашение", "error", err, "user_id", user.ID)
+		return false
+	}
+
+	if err := s.dispatcher.Send(ctx, notifications.Message{
+		Channel:   models.ChannelEmail,
+		Recipient: user.Email,
+		Subject:   subject,
+		Body:      body,
+	}); err != nil {
+		s.logger.Warn("не удалось отправить приглашение врачу", "error", err, "user_id", user.ID)
+		return false
+	}
+
+	s.logger.Info("приглашение врачу отправлено", "user_id", user.ID, "invitation_id", invitation.ID)
+	return true
+}
+
+// expandScheduleTemplate разворачивает недельный шаблон в смены по местному
+// времени; проверки филиала и пересечений делает ScheduleService.
+func expandScheduleTemplate(t models.DoctorScheduleTemplate, defaultRoom int) ([]models.ScheduleCreateRequest, error) {
+	from, err := time.Parse(localtime.DateLayout, t.From)
+	if err != nil || t.Weeks < 1 || t.Weeks > maxTemplateWeeks || len(t.Days) == 0 {
+		return nil, ErrInvalidScheduleTemplate
+	}
+
+	byWeekday := make(map[time.Weekday][]models.DoctorScheduleDay, len(t.Days))
+	for _, d := range t.Days {
+		if d.Weekday < time.Sunday || d.Weekday > time.Saturday {
+			return nil, ErrInvalidScheduleTemplate
+		}
+		byWeekday[d.Weekday] = append(byWeekday[d.Weekday], d)
+	}
+
+	var shifts []models.ScheduleCreateRequest
+	for i := 0; i < 7*t.Weeks; i++ {
+		day := from.AddDate(0, 0, i)
+		for _, d := range byWeekday[day.Weekday()] {
+			room := d.RoomNumber
+			if room == 0 {
+				room = defaultRoom
+			}
+			shifts = append(shifts, models.ScheduleCreateRequest{
+				ClinicID:   t.ClinicID,
+				RoomNumber: room,
+				LocalDate:  day.Format(localtime.DateLayout),
+				LocalStart: d.Start,
+				LocalEnd:   d.End,
+			})
+		}
+	}
+
+	return shifts, nil
+}
+
+func invitationLanguage(language string) string {
+	if language == "en" {
+		return language
+	}
+	return notifications.DefaultLanguage
+}
+
+// newInvitationToken возвращает токен для письма и его хеш для базы.
+func newInvitationToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(b)
+	return token, hashInvitationToken(token), nil
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,13 +2,49 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
 )
 
+var (
+	ErrDoctorNotFound      = errors.New("врач не найден")
+	ErrDoctorForbidden     = errors.New("нет доступа к профилю врача")
+	ErrInvalidDoctorLang   = errors.New("язык указывается двухбуквенным кодом ISO 639-1, например ru или en")
+	ErrInvalidCredential   = errors.New("у образования и сертификата должны быть название и корректный год")
+	ErrInvalidDoctorPhoto  = errors.New("фото должно быть в формате JPEG, PNG или WebP")
+	ErrDoctorPhotoTooLarge = errors.New("файл фото слишком большой")
+)
+
+// photoExtensions — допустимые форматы фото врача по типу содержимого.
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+var languageCode = regexp.MustCompile(`^[a-z]{2}$`)
+
+type DoctorConfig struct {
+	// PhotoDir — каталог, куда сохраняются фото; раздаётся по PhotoURLPrefix.
+	PhotoDir       string
+	PhotoURLPrefix string
+	MaxPhotoBytes  int64
+}
+
 type DoctorService interface {
 	CreateDoctor(context.Context, models.DoctorCreateRequest) (*models.Doctor, error)
 
@@ -23,26 +59,46 @@ type DoctorService interface {
 	GetDoctorServices(context.Context, uint) ([]models.Service, error)
 
 	GetScheduleByDoctorID(context.Context, uint) ([]models.Schedule, error)
+
+	// UploadPhoto заменяет фото врача (JPEG, PNG или WebP). Загрузить фото
+	// может администратор или сам врач.
+	UploadPhoto(ctx context.Context, userID uint, role string, id uint, photo io.Reader) (*models.Doctor, error)
 }
 
 type doctorService struct {
-	doctors  repository.DoctorRepository
-	service  repository.ServiceRepository
-	schedule repository.ScheduleRepository
-	logger   *slog.Logger
+	doctors     repository.DoctorRepository
+	service     repository.ServiceRepository
+	schedule    repository.ScheduleRepository
+	specialties repository.SpecialtyRepository
+	cfg         DoctorConfig
+	logger      *slog.Logger
 }
 
 func NewDoctorService(
 	doctors repository.DoctorRepository,
 	service repository.ServiceRepository,
 	schedule repository.ScheduleRepository,
+	specialties repository.SpecialtyRepository,
+	cfg DoctorConfig,
 	logger *slog.Logger,
 ) DoctorService {
+	if cfg.PhotoDir == "" {
+		cfg.PhotoDir = "uploads/doctors"
+	}
+	if cfg.PhotoURLPrefix == "" {
+		cfg.PhotoURLPrefix = "/uploads/doctors"
+	}
+	if cfg.MaxPhotoBytes <= 0 {
+		cfg.MaxPhotoBytes = 5 << 20
+	}
+
 	return &doctorService{
-		doctors:  doctors,
-		service:  service,
-		schedule: schedule,
-		logger:   logger,
+		doctors:     doctors,
+		service:     service,
+		schedule:    schedule,
+		specialties: specialties,
+		cfg:         cfg,
+		logger:      logger,
 	}
 }
 
@@ -54,13 +110,26 @@ func (s *doctorService) CreateDoctor(ctx context.Context, req models.DoctorCreat
 		return nil, err
 	}
 
+	specialty, err := s.specialty(ctx, req.SpecialtyID)
+	if err != nil {
+		return nil, err
+	}
+	languages, err := normalizeLanguages(req.Languages)
+	if err != nil {
+		return nil, err
+	}
+
 	doctor := &models.Doctor{
 		UserID:          req.UserID,
-		Specialization:  req.Specialization,
+		SpecialtyID:     &specialty.ID,
+		Specialization:  specialty.Name,
 		ExperienceYears: req.ExperienceYears,
 		Bio:             req.Bio,
 		AvgRating:       0,
 		RoomNumber:      req.RoomNumber,
+		Education:       req.Education,
+		Certifications:  req.Certifications,
+		Languages:       languages,
 	}
 
 	if err := s.doctors.Create(ctx, doctor); err != nil {
@@ -120,11 +189,19 @@ func (s *doctorService) UpdateDoctor(ctx context.Context, id uint, req models.Do
 	doctor, err := s.doctors.GetByID(id, ctx)
 	if err != nil {
 		s.logger.Error("ошибка при получении врача для обновления", "error", err, "doctor_id", id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDoctorNotFound
+		}
 		return nil, err
 	}
 
-	if req.Specialization != nil && *req.Specialization != "" {
-		doctor.Specialization = *req.Specialization
+	if req.SpecialtyID != nil {
+		specialty, err := s.specialty(ctx, *req.SpecialtyID)
+		if err != nil {
+			return nil, err
+		}
+		doctor.SpecialtyID = &specialty.ID
+		doctor.Specialization = specialty.Name
 	}
 	if req.ExperienceYears != nil {
 		doctor.ExperienceYears = *req.ExperienceYears
@@ -135,6 +212,28 @@ func (s *doctorService) UpdateDoctor(ctx context.Context, id uint, req models.Do
 	if req.RoomNumber != nil {
 		doctor.RoomNumber = *req.RoomNumber
 	}
+	if req.Education != nil {
+		if err := validateEducation(*req.Education); err != nil {
+			return nil, err
+		}
+		doctor.Education = *req.Education
+	}
+	if req.Certifications != nil {
+		if err := validateCertifications(*req.Certifications); err != nil {
+			return nil, err
+		}
+		doctor.Certifications = *req.Certifications
+	}
+	if req.Languages != nil {
+		if doctor.Languages, err = normalizeLanguages(*req.Languages); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.doctors.Update(ctx, doctor); err != nil {
+		s.logger.Error("ошибка при сохранении врача", "error", err, "doctor_id", id)
+		return nil, err
+	}
 
 	s.logger.Info("врач обновлён", "doctor_id", id)
 	return doctor, nil
@@ -154,8 +253,13 @@ func (s *doctorService) ValidateCreateDoctor(req models.DoctorCreateRequest) err
 	if req.UserID <= 0 {
 		return errors.New("user_id is required")
 	}
-	if req.Specialization == "" {
-		return errors.New("specialization is required")
+	return validateDoctorProfile(req)
+}
+
+// validateDoctorProfile проверяет поля профиля врача, кроме user_id.
+func validateDoctorProfile(req models.DoctorCreateRequest) error {
+	if req.SpecialtyID == 0 {
+		return errors.New("specialty_id is required")
 	}
 	if req.RoomNumber <= 0 {
 		return errors.New("room_number must be > 0")
@@ -166,6 +270,124 @@ func (s *doctorService) ValidateCreateDoctor(req models.DoctorCreateRequest) err
 	if req.Bio == "" {
 		return errors.New("bio is required")
 	}
+	if err := validateEducation(req.Education); err != nil {
+		return err
+	}
+
+	return validateCertifications(req.Certifications)
+}
+
+func (s *doctorService) UploadPhoto(ctx context.Context, userID uint, role string, id uint, photo io.Reader) (*models.Doctor, error) {
+	doctor, err := s.doctors.GetByID(id, ctx)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDoctorNotFound
+		}
+		return nil, err
+	}
+	if models.Role(role) != models.Admin && doctor.UserID != userID {
+		return nil, ErrDoctorForbidden
+	}
+
+	data, err := io.ReadAll(io.LimitReader(photo, s.cfg.MaxPhotoBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.cfg.MaxPhotoBytes {
+		return nil, ErrDoctorPhotoTooLarge
+	}
+	ext, ok := photoExtensions[http.DetectContentType(data)]
+	if !ok {
+		return nil, ErrInvalidDoctorPhoto
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("doctor-%d-%s%s", doctor.ID, hex.EncodeToString(suffix), ext)
+
+	if err := os.MkdirAll(s.cfg.PhotoDir, 0o755); err != nil {
+		return nil, err
+	}
+	// Пишем во временный файл, чтобы по ссылке никогда не отдавался
+	// недописанный файл.
+	tmp := filepath.Join(s.cfg.PhotoDir, name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.cfg.PhotoDir, name)); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	previous := doctor.PhotoURL
+	doctor.PhotoURL = strings.TrimRight(s.cfg.PhotoURLPrefix, "/") + "/" + name
+	if err := s.doctors.Update(ctx, doctor); err != nil {
+		os.Remove(filepath.Join(s.cfg.PhotoDir, name))
+		return nil, err
+	}
 
+	if old, ok := strings.CutPrefix(previous, strings.TrimRight(s.cfg.PhotoURLPrefix, "/")+"/"); ok && old != "" {
+		if err := os.Remove(filepath.Join(s.cfg.PhotoDir, filepath.Base(old))); err != nil && !os.IsNotExist(err) {
+			s.logger.Warn("не удалось удалить старое фото врача", "error", err, "doctor_id", doctor.ID)
+		}
+	}
+
+	s.logger.Info("фото врача обновлено", "doctor_id", doctor.ID)
+	return doctor, nil
+}
+
+func (s *doctorService) specialty(ctx context.Context, id uint) (*models.Specialty, error) {
+	specialty, err := s.specialties.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSpecialtyNotFound
+		}
+		return nil, err
+	}
+	return specialty, nil
+}
+
+// normalizeLanguages приводит коды языков к нижнему регистру и убирает
+// повторы.
+func normalizeLanguages(languages []string) ([]string, error) {
+	result := make([]string, 0, len(languages))
+	seen := make(map[string]bool, len(languages))
+
+	for _, lang := range languages {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if !languageCode.MatchString(lang) {
+			return nil, ErrInvalidDoctorLang
+		}
+		if !seen[lang] {
+			seen[lang] = true
+			result = append(result, lang)
+		}
+	}
+
+	return result, nil
+}
+
+func validateEducation(items []models.DoctorEducation) error {
+	for _, e := range items {
+		if strings.TrimSpace(e.Institution) == "" || !validCredentialYear(e.Year) {
+			return ErrInvalidCredential
+		}
+	}
 	return nil
 }
+
+func validateCertifications(items []models.DoctorCertification) error {
+	for _, c := range items {
+		if strings.TrimSpace(c.Title) == "" || !validCredentialYear(c.Year) {
+			return ErrInvalidCredential
+		}
+	}
+	return nil
+}
+
+// validCredentialYear допускает пустой год.
+func validCredentialYear(year int) bool {
+	return year == 0 || (year >= 1900 && year <= time.Now().Year())
+}
@@ -0,0 +1,19 @@
+package services
+
+import (
+	"github.com/mutsaevz/team-4-dentistry/internal/events"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+// publishTx записывает доменное событие в outbox в рамках транзакции tx.
+// Состояние, которое должно меняться вместе с записью (например, занятость
+// слота), обновляется в той же транзакции; события — только для побочных
+// эффектов.
+func publishTx(tx *gorm.DB, outbox repository.OutboxRepository, eventType, aggregateType string, aggregateID uint, payload any) error {
+	event, err := events.New(eventType, aggregateType, aggregateID, payload)
+	if err != nil {
+		return err
+	}
+	return outbox.AddTx(tx, event)
+}
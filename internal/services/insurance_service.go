@@ -0,0 +1,475 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInsurerNotFound        = errors.New("страховая компания не найдена")
+	ErrPolicyNotFound         = errors.New("страховой полис не найден")
+	ErrClaimNotFound          = errors.New("страховая заявка не найдена")
+	ErrInvalidInsurer         = errors.New("некорректные данные страховой компании")
+	ErrInvalidCoverageRule    = errors.New("некорректное правило покрытия")
+	ErrInvalidPolicy          = errors.New("некорректные данные полиса")
+	ErrNotCovered             = errors.New("приём не покрывается страховкой пациента")
+	ErrClaimAppointment       = errors.New("страховую заявку можно подать только по завершённому приёму")
+	ErrInvalidClaimTransition = errors.New("недопустимая смена статуса заявки")
+)
+
+type InsuranceService interface {
+	CreateInsurer(ctx context.Context, req models.InsurerCreateRequest) (*models.Insurer, error)
+
+	ListInsurers(ctx context.Context) ([]models.Insurer, error)
+
+	GetInsurer(ctx context.Context, id uint) (*models.Insurer, error)
+
+	UpdateInsurer(ctx context.Context, id uint, req models.InsurerUpdateRequest) (*models.Insurer, error)
+
+	SetCoverageRule(ctx context.Context, insurerID uint, req models.CoverageRuleRequest) (*models.CoverageRule, error)
+
+	DeleteCoverageRule(ctx context.Context, insurerID uint, category string) error
+
+	CreatePolicy(ctx context.Context, req models.PolicyCreateRequest) (*models.InsurancePolicy, error)
+
+	UpdatePolicy(ctx context.Context, id uint, req models.PolicyUpdateRequest) (*models.InsurancePolicy, error)
+
+	ListPatientPolicies(ctx context.Context, patientID uint) ([]models.InsurancePolicy, error)
+
+	QuoteAppointment(ctx context.Context, userID uint, role string, appointmentID uint) (*models.CoverageQuote, error)
+
+	Quote(ctx context.Context, appointment *models.Appointment) (*models.CoverageQuote, error)
+
+	SubmitClaim(ctx context.Context, req models.ClaimCreateRequest) (*models.InsuranceClaim, error)
+
+	UpdateClaimStatus(ctx context.Context, id uint, req models.ClaimStatusRequest) (*models.InsuranceClaim, error)
+
+	ListClaims(ctx context.Context, params models.ClaimQueryParams) ([]models.InsuranceClaim, error)
+
+	ExportClaims(ctx context.Context, insurerID uint, w io.Writer) (string, int, error)
+}
+
+type insuranceService struct {
+	insurance    repository.InsuranceRepository
+	appointments repository.AppointmentRepository
+	services     repository.ServiceRepository
+	logger       *slog.Logger
+}
+
+func NewInsuranceService(
+	insurance repository.InsuranceRepository,
+	appointments repository.AppointmentRepository,
+	services repository.ServiceRepository,
+	logger *slog.Logger,
+) InsuranceService {
+	return &insuranceService{
+		insurance:    insurance,
+		appointments: appointments,
+		services:     services,
+		logger:       logger,
+	}
+}
+
+func (s *insuranceService) CreateInsurer(ctx context.Context, req models.InsurerCreateRequest) (*models.Insurer, error) {
+	s.logger.Debug("CreateInsurer вызван", "code", req.Code)
+
+	if strings.TrimSpace(req.Name) == "" || strings.TrimSpace(req.Code) == "" {
+		return nil, ErrInvalidInsurer
+	}
+
+	insurer := &models.Insurer{
+		Name:     strings.TrimSpace(req.Name),
+		Code:     strings.ToUpper(strings.TrimSpace(req.Code)),
+		Email:    strings.TrimSpace(req.Email),
+		Phone:    strings.TrimSpace(req.Phone),
+		IsActive: true,
+	}
+
+	if err := s.insurance.CreateInsurer(ctx, insurer); err != nil {
+		return nil, err
+	}
+
+	return insurer, nil
+}
+
+func (s *insuranceService) ListInsurers(ctx context.Context) ([]models.Insurer, error) {
+	return s.insurance.ListInsurers(ctx)
+}
+
+func (s *insuranceService) GetInsurer(ctx context.Context, id uint) (*models.Insurer, error) {
+	insurer, err := s.insurance.GetInsurerByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInsurerNotFound
+		}
+		return nil, err
+	}
+	return insurer, nil
+}
+
+func (s *insuranceService) UpdateInsurer(ctx context.Context, id uint, req models.InsurerUpdateRequest) (*models.Insurer, error) {
+	insurer, err := s.GetInsurer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		trimmed := strings.TrimSpace(*req.Name)
+		if trimmed == "" {
+			return nil, ErrInvalidInsurer
+		}
+		insurer.Name = trimmed
+	}
+	if req.Email != nil {
+		insurer.Email = strings.TrimSpace(*req.Email)
+	}
+	if req.Phone != nil {
+		insurer.Phone = strings.TrimSpace(*req.Phone)
+	}
+	if req.IsActive != nil {
+		insurer.IsActive = *req.IsActive
+	}
+
+	if err := s.insurance.UpdateInsurer(ctx, insurer); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("страховая компания обновлена", "insurer_id", id)
+	return insurer, nil
+}
+
+func (s *insuranceService) SetCoverageRule(ctx context.Context, insurerID uint, req models.CoverageRuleRequest) (*models.CoverageRule, error) {
+	if _, err := s.GetInsurer(ctx, insurerID); err != nil {
+		return nil, err
+	}
+
+	category := strings.TrimSpace(req.Category)
+	if category == "" || req.CoveragePercent < 0 || req.CoveragePercent > 100 || req.AnnualLimit < 0 || req.CoPay < 0 {
+		return nil, ErrInvalidCoverageRule
+	}
+
+	rule := &models.CoverageRule{
+		InsurerID:       insurerID,
+		Category:        category,
+		CoveragePercent: req.CoveragePercent,
+		AnnualLimit:     req.AnnualLimit,
+		CoPay:           req.CoPay,
+	}
+
+	if err := s.insurance.UpsertRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *insuranceService) DeleteCoverageRule(ctx context.Context, insurerID uint, category string) error {
+	return s.insurance.DeleteRule(ctx, insurerID, strings.TrimSpace(category))
+}
+
+func (s *insuranceService) CreatePolicy(ctx context.Context, req models.PolicyCreateRequest) (*models.InsurancePolicy, error) {
+	s.logger.Debug("CreatePolicy вызван", "patient_id", req.PatientID, "insurer_id", req.InsurerID)
+
+	if req.PatientID == 0 || strings.TrimSpace(req.PolicyNumber) == "" || !req.ValidFrom.Before(req.ValidTo) {
+		return nil, ErrInvalidPolicy
+	}
+
+	if _, err := s.GetInsurer(ctx, req.InsurerID); err != nil {
+		return nil, err
+	}
+
+	policy := &models.InsurancePolicy{
+		PatientID:    req.PatientID,
+		InsurerID:    req.InsurerID,
+		PolicyNumber: strings.TrimSpace(req.PolicyNumber),
+		ValidFrom:    req.ValidFrom,
+		ValidTo:      req.ValidTo,
+		IsActive:     true,
+	}
+
+	if err := s.insurance.CreatePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (s *insuranceService) UpdatePolicy(ctx context.Context, id uint, req models.PolicyUpdateRequest) (*models.InsurancePolicy, error) {
+	policy, err := s.insurance.GetPolicyByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPolicyNotFound
+		}
+		return nil, err
+	}
+
+	if req.PolicyNumber != nil {
+		trimmed := strings.TrimSpace(*req.PolicyNumber)
+		if trimmed == "" {
+			return nil, ErrInvalidPolicy
+		}
+		policy.PolicyNumber = trimmed
+	}
+	if req.ValidFrom != nil {
+		policy.ValidFrom = *req.ValidFrom
+	}
+	if req.ValidTo != nil {
+		policy.ValidTo = *req.ValidTo
+	}
+	if req.IsActive != nil {
+		policy.IsActive = *req.IsActive
+	}
+
+	if !policy.ValidFrom.Before(policy.ValidTo) {
+		return nil, ErrInvalidPolicy
+	}
+
+	if err := s.insurance.UpdatePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (s *insuranceService) ListPatientPolicies(ctx context.Context, patientID uint) ([]models.InsurancePolicy, error) {
+	return s.insurance.ListPoliciesByPatientID(ctx, patientID)
+}
+
+func (s *insuranceService) QuoteAppointment(ctx context.Context, userID uint, role string, appointmentID uint) (*models.CoverageQuote, error) {
+	appointment, err := s.appointments.GetByID(appointmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, constants.ErrGetByIDAppointments
+		}
+		return nil, err
+	}
+
+	if role != string(models.Admin) && appointment.PatientID != userID {
+		return nil, ErrPaymentForbidden
+	}
+
+	return s.Quote(ctx, appointment)
+}
+
+func (s *insuranceService) Quote(ctx context.Context, appointment *models.Appointment) (*models.CoverageQuote, error) {
+	quote := &models.CoverageQuote{AppointmentID: appointment.ID}
+
+	total := appointment.Price
+	if appointment.ServiceID != 0 {
+		service, err := s.services.GetByID(appointment.ServiceID)
+		if err != nil {
+			return nil, err
+		}
+		quote.Category = service.Category
+		if total <= 0 {
+			total = service.Price
+		}
+	}
+
+	quote.TotalAmount = roundMoney(total)
+	quote.PatientAmount = quote.TotalAmount
+
+	policy, err := s.insurance.GetActivePolicy(ctx, appointment.PatientID, appointment.StartAt)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return quote, nil
+		}
+		return nil, err
+	}
+
+	rule, err := s.insurance.GetRule(ctx, policy.InsurerID, quote.Category)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return quote, nil
+		}
+		return nil, err
+	}
+
+	insurerAmount := math.Max(quote.TotalAmount-rule.CoPay, 0) * rule.CoveragePercent / 100
+
+	if rule.AnnualLimit > 0 {
+		used, err := s.insurance.UsedCoverage(ctx, policy.ID, rule.Category, appointment.StartAt.Year())
+		if err != nil {
+			return nil, err
+		}
+		remaining := math.Max(rule.AnnualLimit-used, 0)
+		insurerAmount = math.Min(insurerAmount, remaining)
+		quote.LimitRemaining = roundMoney(remaining - insurerAmount)
+	}
+
+	quote.PolicyID = policy.ID
+	quote.InsurerID = policy.InsurerID
+	quote.InsurerAmount = roundMoney(insurerAmount)
+	quote.PatientAmount = roundMoney(quote.TotalAmount - quote.InsurerAmount)
+	quote.Covered = quote.InsurerAmount > 0
+
+	return quote, nil
+}
+
+func (s *insuranceService) SubmitClaim(ctx context.Context, req models.ClaimCreateRequest) (*models.InsuranceClaim, error) {
+	s.logger.Debug("SubmitClaim вызван", "appointment_id", req.AppointmentID)
+
+	appointment, err := s.appointments.GetByID(req.AppointmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, constants.ErrGetByIDAppointments
+		}
+		return nil, err
+	}
+
+	if appointment.Status != models.AppointmentCompleted {
+		return nil, ErrClaimAppointment
+	}
+
+	quote, err := s.Quote(ctx, appointment)
+	if err != nil {
+		return nil, err
+	}
+
+	if !quote.Covered {
+		return nil, ErrNotCovered
+	}
+
+	claim := &models.InsuranceClaim{
+		AppointmentID: appointment.ID,
+		PolicyID:      quote.PolicyID,
+		InsurerID:     quote.InsurerID,
+		PatientID:     appointment.PatientID,
+		Category:      quote.Category,
+		ServiceDate:   appointment.StartAt,
+		TotalAmount:   quote.TotalAmount,
+		InsurerAmount: quote.InsurerAmount,
+		PatientAmount: quote.PatientAmount,
+		Status:        models.ClaimSubmitted,
+		SubmittedAt:   time.Now(),
+	}
+
+	if err := s.insurance.CreateClaim(ctx, claim); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("страховая заявка подана", "claim_id", claim.ID, "insurer_amount", claim.InsurerAmount)
+	return claim, nil
+}
+
+func (s *insuranceService) UpdateClaimStatus(ctx context.Context, id uint, req models.ClaimStatusRequest) (*models.InsuranceClaim, error) {
+	claim, err := s.insurance.GetClaimByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClaimNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+
+	switch {
+	case claim.Status == models.ClaimSubmitted && req.Status == models.ClaimApproved:
+		claim.DecidedAt = &now
+	case claim.Status == models.ClaimSubmitted && req.Status == models.ClaimRejected:
+		claim.DecidedAt = &now
+		claim.RejectionReason = strings.TrimSpace(req.Reason)
+	case claim.Status == models.ClaimApproved && req.Status == models.ClaimPaid:
+		claim.PaidAt = &now
+	default:
+		return nil, ErrInvalidClaimTransition
+	}
+
+	claim.Status = req.Status
+
+	if err := s.insurance.UpdateClaim(ctx, claim); err != nil {
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+func (s *insuranceService) ListClaims(ctx context.Context, params models.ClaimQueryParams) ([]models.InsuranceClaim, error) {
+	return s.insurance.ListClaims(ctx, params)
+}
+
+// ExportClaims выгружает ещё не отправленные заявки страховщика в CSV-пакет
+// и помечает их идентификатором пакета. Файл собирается в памяти и
+// отдаётся только после того, как пометка сохранена.
+func (s *insuranceService) ExportClaims(ctx context.Context, insurerID uint, w io.Writer) (string, int, error) {
+	insurer, err := s.GetInsurer(ctx, insurerID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	claims, err := s.insurance.ListClaims(ctx, models.ClaimQueryParams{
+		InsurerID: insurerID,
+		Status:    models.ClaimSubmitted,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	batchID := fmt.Sprintf("%s-%s", insurer.Code, time.Now().UTC().Format("20060102T150405"))
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write([]string{
+		"batch_id", "claim_id", "policy_id", "patient_id", "appointment_id",
+		"service_date", "category", "total_amount", "insurer_amount", "patient_amount",
+	}); err != nil {
+		return "", 0, err
+	}
+
+	ids := make([]uint, 0, len(claims))
+	for _, c := range claims {
+		if c.BatchID != "" {
+			continue
+		}
+
+		if err := writer.Write([]string{
+			batchID,
+			strconv.FormatUint(uint64(c.ID), 10),
+			strconv.FormatUint(uint64(c.PolicyID), 10),
+			strconv.FormatUint(uint64(c.PatientID), 10),
+			strconv.FormatUint(uint64(c.AppointmentID), 10),
+			c.ServiceDate.Format("2006-01-02"),
+			c.Category,
+			strconv.FormatFloat(c.TotalAmount, 'f', 2, 64),
+			strconv.FormatFloat(c.InsurerAmount, 'f', 2, 64),
+			strconv.FormatFloat(c.PatientAmount, 'f', 2, 64),
+		}); err != nil {
+			return "", 0, err
+		}
+		ids = append(ids, c.ID)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", 0, err
+	}
+
+	if err := s.insurance.SetClaimsBatch(ctx, ids, batchID); err != nil {
+		return "", 0, err
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return "", 0, err
+	}
+
+	s.logger.Info("пакет страховых заявок выгружен", "batch_id", batchID, "count", len(ids))
+	return batchID, len(ids), nil
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
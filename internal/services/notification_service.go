@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/notifications"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReminderObsolete        = errors.New("запись отменена или приём уже начался")
+	ErrInvalidNotificationLang = errors.New("некорректный язык уведомлений: ожидается ru или en")
+)
+
+type NotificationConfig struct {
+	// Offsets — за сколько до начала приёма отправлять напоминания.
+	Offsets     []time.Duration
+	MaxAttempts int
+	// Location — часовой пояс для приёмов вне филиалов; время приёма
+	// филиала показывается по поясу филиала.
+	Location *time.Location
+}
+
+type NotificationService interface {
+	SendDueReminders(ctx context.Context) error
+
+	GetPreferences(ctx context.Context, userID uint) (*models.NotificationPreference, error)
+
+	UpdatePreferences(ctx context.Context, userID uint, req models.NotificationPreferenceUpdateRequest) (*models.NotificationPreference, error)
+
+	ListForUser(ctx context.Context, userID uint) ([]models.Notification, error)
+
+	List(ctx context.Context, params models.NotificationQueryParams) ([]models.Notification, error)
+}
+
+type notificationService struct {
+	notifications repository.NotificationRepository
+	appointments  repository.AppointmentRepository
+	clinics       repository.ClinicRepository
+	dispatcher    *notifications.Dispatcher
+	cfg           NotificationConfig
+	logger        *slog.Logger
+}
+
+func NewNotificationService(
+	notificationRepo repository.NotificationRepository,
+	appointments repository.AppointmentRepository,
+	clinics repository.ClinicRepository,
+	dispatcher *notifications.Dispatcher,
+	cfg NotificationConfig,
+	logger *slog.Logger,
+) NotificationService {
+	offsets := append([]time.Duration(nil), cfg.Offsets...)
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	cfg.Offsets = offsets
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+
+	return &notificationService{
+		notifications: notificationRepo,
+		appointments:  appointments,
+		clinics:       clinics,
+		dispatcher:    dispatcher,
+		cfg:           cfg,
+		logger:        logger,
+	}
+}
+
+// SendDueReminders повторяет неудачные отправки и создаёт напоминания,
+// срок которых наступил. Повторный запуск не дублирует уведомления:
+// уникальный индекс (appointment, kind, offset, channel) отсекает копии.
+func (s *notificationService) SendDueReminders(ctx context.Context) error {
+	if len(s.cfg.Offsets) == 0 {
+		return nil
+	}
+
+	if err := s.retryFailed(ctx); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	maxOffset := s.cfg.Offsets[len(s.cfg.Offsets)-1]
+
+	appointments, err := s.appointments.GetStartingBetween(now, now.Add(maxOffset))
+	if err != nil {
+		return err
+	}
+
+	locations := newClinicLocations(s.clinics, s.cfg.Location)
+	for i := range appointments {
+		if err := s.remind(ctx, &appointments[i], now, locations); err != nil {
+			s.logger.Error("не удалось подготовить напоминание", "error", err, "appointment_id", appointments[i].ID)
+		}
+	}
+
+	return nil
+}
+
+func (s *notificationService) remind(ctx context.Context, appointment *models.Appointment, now time.Time, locations *clinicLocations) error {
+	if appointment.Patient == nil {
+		return nil
+	}
+
+	// Берём наименьший наступивший отступ: если планировщик простаивал,
+	// пациент получит одно актуальное напоминание, а не все пропущенные.
+	var offset time.Duration
+	found := false
+	for _, o := range s.cfg.Offsets {
+		if !now.Before(appointment.StartAt.Add(-o)) {
+			offset = o
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	// Запись создана уже внутри окна — напоминать о ней на этом отступе незачем.
+	if appointment.CreatedAt.After(appointment.StartAt.Add(-offset)) {
+		return nil
+	}
+
+	pref, err := s.GetPreferences(ctx, appointment.PatientID)
+	if err != nil {
+		return err
+	}
+	if pref.RemindersOptOut {
+		return nil
+	}
+
+	loc, err := locations.get(ctx, appointment.ClinicID)
+	if err != nil {
+		return err
+	}
+
+	patientName := strings.TrimSpace(appointment.Patient.FirstName + " " + appointment.Patient.LastName)
+	serviceName := ""
+	if appointment.Service != nil {
+		serviceName = appointment.Service.Name
+	}
+
+	data := notifications.ReminderData{
+		PatientName: patientName,
+		ServiceName: serviceName,
+		StartAt:     appointment.StartAt.In(loc).Format("02.01.2006 15:04"),
+		TimeLeft:    notifications.FormatOffset(pref.Language, offset),
+	}
+
+	for _, channel := range s.dispatcher.Channels() {
+		recipient := ""
+		switch channel {
+		case models.ChannelEmail:
+			if !pref.EmailEnabled {
+				continue
+			}
+			recipient = appointment.Patient.Email
+		case models.ChannelSMS:
+			if !pref.SMSEnabled {
+				continue
+			}
+			recipient = appointment.Patient.Phone
+		}
+		if recipient == "" {
+			continue
+		}
+
+		subject, body, err := notifications.RenderReminder(pref.Language, channel, data)
+		if err != nil {
+			return err
+		}
+
+		notification := &models.Notification{
+			UserID:        appointment.PatientID,
+			AppointmentID: appointment.ID,
+			Kind:          models.NotificationKindReminder,
+			OffsetMinutes: int(offset / time.Minute),
+			Channel:       channel,
+			Language:      pref.Language,
+			Recipient:     recipient,
+			Subject:       subject,
+			Body:          body,
+			Status:        models.NotificationPending,
+		}
+
+		created, err := s.notifications.CreateIfAbsent(ctx, notification)
+		if err != nil {
+			return err
+		}
+		if !created {
+			continue
+		}
+
+		s.deliver(ctx, notification)
+	}
+
+	return nil
+}
+
+func (s *notificationService) retryFailed(ctx context.Context) error {
+	pending, err := s.notifications.ListRetryable(ctx, s.cfg.MaxAttempts)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range pending {
+		notification := &pending[i]
+
+		appointment, err := s.appointments.GetByID(notification.AppointmentID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if appointment == nil || appointment.Status != models.AppointmentScheduled || !appointment.StartAt.After(now) {
+			notification.Status = models.NotificationFailed
+			notification.LastError = ErrReminderObsolete.Error()
+			notification.Attempts = s.cfg.MaxAttempts
+			if err := s.notifications.Update(ctx, notification); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s.deliver(ctx, notification)
+	}
+
+	return nil
+}
+
+func (s *notificationService) deliver(ctx context.Context, notification *models.Notification) {
+	notification.Attempts++
+
+	err := s.dispatcher.Send(ctx, notifications.Message{
+		Channel:   notification.Channel,
+		Recipient: notification.Recipient,
+		Subject:   notification.Subject,
+		Body:      notification.Body,
+	})
+	if err != nil {
+		notification.Status = models.NotificationFailed
+		notification.LastError = err.Error()
+		s.logger.Warn("не удалось отправить уведомление",
+			"error", err,
+			"notification_id", notification.ID,
+			"attempt", notification.Attempts,
+		)
+	} else {
+		sentAt := time.Now()
+		notification.Status = models.NotificationSent
+		notification.LastError = ""
+		notification.SentAt = &sentAt
+	}
+
+	if err := s.notifications.Update(ctx, notification); err != nil {
+		s.logger.Error("не удалось сохранить статус уведомления", "error", err, "notification_id", notification.ID)
+	}
+}
+
+// GetPreferences возвращает сохранённые настройки или настройки по умолчанию:
+// русский язык, все каналы включены.
+func (s *notificationService) GetPreferences(ctx context.Context, userID uint) (*models.NotificationPreference, error) {
+	pref, err := s.notifications.GetPreference(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.NotificationPreference{
+				UserID:       userID,
+				Language:     notifications.DefaultLanguage,
+				EmailEnabled: true,
+				SMSEnabled:   true,
+			}, nil
+		}
+		return nil, err
+	}
+
+	if pref.Language == "" {
+		pref.Language = notifications.DefaultLanguage
+	}
+
+	return pref, nil
+}
+
+func (s *notificationService) UpdatePreferences(
+	ctx context.Context,
+	userID uint,
+	req models.NotificationPreferenceUpdateRequest,
+) (*models.NotificationPreference, error) {
+	pref, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Language != nil {
+		if *req.Language != "ru" && *req.Language != "en" {
+			return nil, ErrInvalidNotificationLang
+		}
+		pref.Language = *req.Language
+	}
+	if req.EmailEnabled != nil {
+		pref.EmailEnabled = *req.EmailEnabled
+	}
+	if req.SMSEnabled != nil {
+		pref.SMSEnabled = *req.SMSEnabled
+	}
+	if req.RemindersOptOut != nil {
+		pref.RemindersOptOut = *req.RemindersOptOut
+	}
+
+	if err := s.notifications.SavePreference(ctx, pref); err != nil {
+		return nil, err
+	}
+
+	return pref, nil
+}
+
+func (s *notificationService) ListForUser(ctx context.Context, userID uint) ([]models.Notification, error) {
+	return s.notifications.List(ctx, models.NotificationQueryParams{UserID: userID})
+}
+
+func (s *notificationService) List(ctx context.Context, params models.NotificationQueryParams) ([]models.Notification, error) {
+	return s.notifications.List(ctx, params)
+}
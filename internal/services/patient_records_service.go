@@ -1,31 +1,37 @@
 package services
 
 import (
+	"context"
 	"log/slog"
 
 	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/events"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
 )
 
+// PatientRecordService работает только с записями врачей из филиалов
+// сотрудника (см. ClinicScope).
 type PatientRecordService interface {
-	Create(req *models.PatientRecordCreate) (*models.PatientRecord, error)
-	GetByID(ID uint) (*models.PatientRecord, error)
-	GetAll() ([]models.PatientRecord, error)
-	Update(id uint, req *models.PatientRecordUpdate) error
-	Delete(ID uint) error
+	Create(ctx context.Context, req *models.PatientRecordCreate) (*models.PatientRecord, error)
+	GetByID(ctx context.Context, ID uint) (*models.PatientRecord, error)
+	GetAll(ctx context.Context, q models.ListQuery) (*models.Page[models.PatientRecord], error)
+	Update(ctx context.Context, id uint, req *models.PatientRecordUpdate) error
+	Delete(ctx context.Context, ID uint) error
 }
 
 type patientRecord struct {
 	repo   repository.PatientRecordRepo
+	outbox repository.OutboxRepository
 	logger *slog.Logger
 }
 
-func NewPatientRecordService(repo repository.PatientRecordRepo, logger *slog.Logger) PatientRecordService {
-	return &patientRecord{repo: repo, logger: logger}
+func NewPatientRecordService(repo repository.PatientRecordRepo, outbox repository.OutboxRepository, logger *slog.Logger) PatientRecordService {
+	return &patientRecord{repo: repo, outbox: outbox, logger: logger}
 }
 
-func (s *patientRecord) Create(req *models.PatientRecordCreate) (*models.PatientRecord, error) {
+func (s *patientRecord) Create(ctx context.Context, req *models.PatientRecordCreate) (*models.PatientRecord, error) {
 	if req == nil {
 		s.logger.Warn("передан nil PatientRecordCreate")
 		return nil, constants.PatientRecord_IS_nil
@@ -48,6 +54,10 @@ func (s *patientRecord) Create(req *models.PatientRecordCreate) (*models.Patient
 		return nil, constants.DoctorID_IS_incorrect
 	}
 
+	if err := s.checkDoctor(ctx, req.DoctorID); err != nil {
+		return nil, err
+	}
+
 	patientRecord := &models.PatientRecord{
 		PatientID: req.PatientID,
 		Diagnosis: req.Diagnosis,
@@ -63,7 +73,7 @@ func (s *patientRecord) Create(req *models.PatientRecordCreate) (*models.Patient
 	return patientRecord, nil
 }
 
-func (s *patientRecord) GetByID(ID uint) (*models.PatientRecord, error) {
+func (s *patientRecord) GetByID(ctx context.Context, ID uint) (*models.PatientRecord, error) {
 	s.logger.Debug("GetByID PatientRecord вызван", "id", ID)
 
 	if ID <= 0 {
@@ -71,7 +81,8 @@ func (s *patientRecord) GetByID(ID uint) (*models.PatientRecord, error) {
 		return nil, constants.PatientID_IS_incorrect
 	}
 
-	patientRecord, err := s.repo.GetID(ID)
+	scope, _ := ClinicScopeIDs(ctx)
+	patientRecord, err := s.repo.GetID(ID, scope)
 	if err != nil {
 		s.logger.Error("ошибка при получении patient record по ID", "error", err, "id", ID)
 		return nil, err
@@ -81,26 +92,28 @@ func (s *patientRecord) GetByID(ID uint) (*models.PatientRecord, error) {
 	return patientRecord, nil
 }
 
-func (s *patientRecord) GetAll() ([]models.PatientRecord, error) {
+func (s *patientRecord) GetAll(ctx context.Context, q models.ListQuery) (*models.Page[models.PatientRecord], error) {
 	s.logger.Debug("GetAll PatientRecords вызван")
-	patientRecords, err := s.repo.Get()
+	scope, _ := ClinicScopeIDs(ctx)
+	page, err := s.repo.List(q, scope)
 	if err != nil {
 		s.logger.Error("ошибка при получении всех patient records", "error", err)
 		return nil, err
 	}
 
-	s.logger.Info("patient records получены", "count", len(patientRecords))
-	return patientRecords, nil
+	s.logger.Info("patient records получены", "count", len(page.Items), "total", page.Total)
+	return page, nil
 }
 
-func (s *patientRecord) Update(id uint, req *models.PatientRecordUpdate) error {
+func (s *patientRecord) Update(ctx context.Context, id uint, req *models.PatientRecordUpdate) error {
 	s.logger.Debug("Update PatientRecord вызван", "id", id)
 	if req == nil {
 		s.logger.Warn("передан nil PatientRecordUpdate", "id", id)
 		return constants.PatientRecord_IS_nil
 	}
 
-	patientRecord, err := s.repo.GetID(id)
+	scope, _ := ClinicScopeIDs(ctx)
+	patientRecord, err := s.repo.GetID(id, scope)
 	if err != nil {
 		s.logger.Error("ошибка при получении patient record для обновления", "error", err, "id", id)
 		return err
@@ -121,10 +134,23 @@ func (s *patientRecord) Update(id uint, req *models.PatientRecordUpdate) error {
 	}
 
 	if req.DoctorID != nil {
+		if err := s.checkDoctor(ctx, *req.DoctorID); err != nil {
+			return err
+		}
 		patientRecord.DoctorID = *req.DoctorID
 	}
 
-	if err := s.repo.Update(patientRecord); err != nil {
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.UpdateTx(tx, patientRecord); err != nil {
+			return err
+		}
+		return publishTx(tx, s.outbox, events.RecordUpdated, events.AggregatePatientRecord, patientRecord.ID, events.RecordPayload{
+			RecordID:  patientRecord.ID,
+			PatientID: patientRecord.PatientID,
+			DoctorID:  patientRecord.DoctorID,
+		})
+	})
+	if err != nil {
 		s.logger.Error("ошибка при обновлении patient record", "error", err, "id", id)
 		return err
 	}
@@ -133,13 +159,19 @@ func (s *patientRecord) Update(id uint, req *models.PatientRecordUpdate) error {
 	return nil
 }
 
-func (r *patientRecord) Delete(ID uint) error {
+func (r *patientRecord) Delete(ctx context.Context, ID uint) error {
 	r.logger.Debug("Delete PatientRecord вызван", "id", ID)
 	if ID <= 0 {
 		r.logger.Warn("некорректный ID при Delete patient record", "id", ID)
 		return constants.PatientID_IS_incorrect
 	}
 
+	scope, _ := ClinicScopeIDs(ctx)
+	if _, err := r.repo.GetID(ID, scope); err != nil {
+		r.logger.Error("ошибка при получении patient record для удаления", "error", err, "id", ID)
+		return err
+	}
+
 	if err := r.repo.Delete(ID); err != nil {
 		r.logger.Error("ошибка при удалении patient record", "error", err, "id", ID)
 		return err
@@ -148,3 +180,17 @@ func (r *patientRecord) Delete(ID uint) error {
 	r.logger.Info("patient record удален", "id", ID)
 	return nil
 }
+
+// checkDoctor проверяет, что врач работает в филиале сотрудника.
+func (s *patientRecord) checkDoctor(ctx context.Context, doctorID uint) error {
+	scope, _ := ClinicScopeIDs(ctx)
+	ok, err := s.repo.DoctorInClinics(doctorID, scope)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.logger.Warn("врач вне филиалов сотрудника", "doctor_id", doctorID)
+		return ErrClinicForbidden
+	}
+	return nil
+}
@@ -0,0 +1,585 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/events"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/payments"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPaymentNotFound      = errors.New("платёж не найден")
+	ErrPaymentForbidden     = errors.New("нет доступа к оплате этой записи")
+	ErrPaymentInvalidMode   = errors.New("некорректный режим оплаты: ожидается deposit или full")
+	ErrPaymentNotAllowed    = errors.New("запись отменена или уже оплачена")
+	ErrPaymentNotRefundable = errors.New("вернуть можно только успешный платёж")
+	ErrPaymentInvalidAmount = errors.New("некорректная сумма оплаты")
+	ErrNothingToPay         = errors.New("оплата не требуется: стоимость приёма покрыта страховкой")
+	ErrPaymentNotPending    = errors.New("платёж уже не ожидает подтверждения")
+	ErrPaymentNoAppointment = errors.New("запись для оплаты не найдена")
+	ErrPaymentsDisabled     = errors.New("онлайн-оплата в клинике не подключена")
+)
+
+type PaymentConfig struct {
+	Currency       string
+	DepositPercent float64
+	HoldTTL        time.Duration
+}
+
+type PaymentService interface {
+	// Enabled сообщает, подключён ли платёжный провайдер. Без него оплата
+	// принимается только в клинике.
+	Enabled() bool
+
+	CreateIntent(ctx context.Context, userID uint, role string, req models.PaymentCreateRequest) (*models.Payment, error)
+
+	CreateForAppointment(ctx context.Context, appointment *models.Appointment, mode models.PaymentMode) (*models.Payment, error)
+
+	// CreateForAppointmentTx создаёт оплату в транзакции записи, чтобы она
+	// не осталась без записи при откате.
+	CreateForAppointmentTx(ctx context.Context, tx *gorm.DB, appointment *models.Appointment, mode models.PaymentMode) (*models.Payment, error)
+
+	// Owes сообщает, остаётся ли за пациентом доплата после страхового
+	// покрытия. Запись может быть ещё не сохранена.
+	Owes(ctx context.Context, appointment *models.Appointment) (bool, error)
+
+	Confirm(ctx context.Context, userID uint, role string, id uint) (*models.Payment, error)
+
+	Refund(ctx context.Context, id uint, amount float64) (*models.Payment, error)
+
+	GetByID(ctx context.Context, userID uint, role string, id uint) (*models.Payment, error)
+
+	ListByAppointmentID(ctx context.Context, appointmentID uint) ([]models.Payment, error)
+
+	HandleWebhook(ctx context.Context, payload []byte, signature string) error
+
+	ExpireUnpaid(ctx context.Context) error
+}
+
+type paymentService struct {
+	payments     repository.PaymentRepository
+	appointments repository.AppointmentRepository
+	insurance    InsuranceService
+	provider     payments.Provider
+	outbox       repository.OutboxRepository
+	cfg          PaymentConfig
+	logger       *slog.Logger
+}
+
+func NewPaymentService(
+	paymentRepo repository.PaymentRepository,
+	appointments repository.AppointmentRepository,
+	insurance InsuranceService,
+	provider payments.Provider,
+	outbox repository.OutboxRepository,
+	cfg PaymentConfig,
+	logger *slog.Logger,
+) PaymentService {
+	return &paymentService{
+		payments:     paymentRepo,
+		appointments: appointments,
+		insurance:    insurance,
+		provider:     provider,
+		outbox:       outbox,
+		cfg:          cfg,
+		logger:       logger,
+	}
+}
+
+func (s *paymentService) Enabled() bool {
+	return s.provider != nil
+}
+
+func (s *paymentService) CreateIntent(
+	ctx context.Context,
+	userID uint,
+	role string,
+	req models.PaymentCreateRequest,
+) (*models.Payment, error) {
+	s.logger.Debug("CreateIntent вызван", "appointment_id", req.AppointmentID, "mode", req.Mode)
+
+	appointment, err := s.appointments.GetByID(req.AppointmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNoAppointment
+		}
+		return nil, err
+	}
+
+	if role != string(models.Admin) && appointment.PatientID != userID {
+		s.logger.Warn("попытка оплатить чужую запись", "user_id", userID, "appointment_id", appointment.ID)
+		return nil, ErrPaymentForbidden
+	}
+	if !ClinicAllowed(ctx, appointment.ClinicID) {
+		return nil, ErrClinicForbidden
+	}
+
+	return s.CreateForAppointment(ctx, appointment, req.Mode)
+}
+
+func (s *paymentService) CreateForAppointment(
+	ctx context.Context,
+	appointment *models.Appointment,
+	mode models.PaymentMode,
+) (*models.Payment, error) {
+	return s.createForAppointment(ctx, appointment, mode, func(payment *models.Payment) error {
+		return s.payments.Create(ctx, payment)
+	})
+}
+
+func (s *paymentService) CreateForAppointmentTx(
+	ctx context.Context,
+	tx *gorm.DB,
+	appointment *models.Appointment,
+	mode models.PaymentMode,
+) (*models.Payment, error) {
+	return s.createForAppointment(ctx, appointment, mode, func(payment *models.Payment) error {
+		return s.payments.CreateTx(tx, payment)
+	})
+}
+
+func (s *paymentService) createForAppointment(
+	ctx context.Context,
+	appointment *models.Appointment,
+	mode models.PaymentMode,
+	save func(*models.Payment) error,
+) (*models.Payment, error) {
+	if !s.Enabled() {
+		return nil, ErrPaymentsDisabled
+	}
+	if mode != models.PaymentModeDeposit && mode != models.PaymentModeFull {
+		return nil, ErrPaymentInvalidMode
+	}
+
+	if appointment.Status == models.AppointmentCancelled || appointment.Paid {
+		return nil, ErrPaymentNotAllowed
+	}
+
+	amount, err := s.amountFor(ctx, appointment, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	intent, err := s.provider.CreateIntent(ctx, payments.CreateIntentParams{
+		Amount:      amount,
+		Currency:    s.cfg.Currency,
+		Description: "appointment #" + strconv.FormatUint(uint64(appointment.ID), 10),
+		Metadata: map[string]string{
+			"appointment_id": strconv.FormatUint(uint64(appointment.ID), 10),
+			"mode":           string(mode),
+		},
+	})
+	if err != nil {
+		s.logger.Error("провайдер не создал платёжное намерение", "error", err, "appointment_id", appointment.ID)
+		return nil, err
+	}
+
+	payment := &models.Payment{
+		AppointmentID: appointment.ID,
+		PatientID:     appointment.PatientID,
+		Mode:          mode,
+		Amount:        amount,
+		Currency:      s.cfg.Currency,
+		Provider:      s.provider.Name(),
+		IntentID:      intent.ID,
+		Status:        models.PaymentPending,
+		ExpiresAt:     time.Now().Add(s.cfg.HoldTTL),
+	}
+
+	if err := save(payment); err != nil {
+		return nil, err
+	}
+
+	payment.ClientSecret = intent.ClientSecret
+
+	s.logger.Info("платёжное намерение создано", "payment_id", payment.ID, "appointment_id", appointment.ID, "amount", amount)
+	return payment, nil
+}
+
+func (s *paymentService) Owes(ctx context.Context, appointment *models.Appointment) (bool, error) {
+	quote, err := s.insurance.Quote(ctx, appointment)
+	if err != nil {
+		return false, err
+	}
+	return quote.PatientAmount > 0, nil
+}
+
+// amountFor считает сумму к оплате пациентом: из стоимости приёма вычитается
+// доля, которую покрывает страховка.
+func (s *paymentService) amountFor(ctx context.Context, appointment *models.Appointment, mode models.PaymentMode) (float64, error) {
+	quote, err := s.insurance.Quote(ctx, appointment)
+	if err != nil {
+		return 0, err
+	}
+
+	base := quote.PatientAmount
+	if base <= 0 {
+		return 0, ErrNothingToPay
+	}
+
+	amount := base
+	if mode == models.PaymentModeDeposit {
+		amount = base * s.cfg.DepositPercent / 100
+	}
+
+	amount = roundMoney(amount)
+	if amount <= 0 {
+		return 0, ErrPaymentInvalidAmount
+	}
+
+	return amount, nil
+}
+
+func (s *paymentService) Confirm(ctx context.Context, userID uint, role string, id uint) (*models.Payment, error) {
+	s.logger.Debug("Confirm payment вызван", "payment_id", id)
+
+	payment, err := s.GetByID(ctx, userID, role, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status != models.PaymentPending {
+		return nil, ErrPaymentNotPending
+	}
+
+	intent, err := s.provider.Confirm(ctx, payment.IntentID)
+	if err != nil {
+		s.logger.Error("провайдер не подтвердил платёж", "error", err, "payment_id", id)
+		return nil, err
+	}
+
+	switch intent.Status {
+	case payments.IntentSucceeded:
+		if err := s.markSucceeded(ctx, payment, ""); err != nil {
+			return nil, err
+		}
+	case payments.IntentFailed:
+		if err := s.markFailed(ctx, payment, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return payment, nil
+}
+
+func (s *paymentService) Refund(ctx context.Context, id uint, amount float64) (*models.Payment, error) {
+	s.logger.Debug("Refund payment вызван", "payment_id", id, "amount", amount)
+
+	payment, err := s.payments.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.checkClinic(ctx, payment.AppointmentID); err != nil {
+		return nil, err
+	}
+
+	err = s.payments.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.lockTx(tx, payment); err != nil {
+			return err
+		}
+		if payment.Status != models.PaymentSucceeded {
+			return ErrPaymentNotRefundable
+		}
+
+		remaining := payment.Amount - payment.RefundedAmount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount < 0 || amount > remaining {
+			return ErrPaymentInvalidAmount
+		}
+
+		// Провайдер вызывается под блокировкой платежа, чтобы параллельный
+		// возврат не прошёл ту же проверку остатка.
+		if _, err := s.provider.Refund(ctx, payment.IntentID, amount); err != nil {
+			s.logger.Error("провайдер не выполнил возврат", "error", err, "payment_id", id)
+			return err
+		}
+
+		return s.applyRefundTx(tx, payment, amount, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("возврат выполнен", "payment_id", id, "amount", amount)
+	return payment, nil
+}
+
+func (s *paymentService) GetByID(ctx context.Context, userID uint, role string, id uint) (*models.Payment, error) {
+	payment, err := s.payments.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	if role != string(models.Admin) && payment.PatientID != userID {
+		return nil, ErrPaymentForbidden
+	}
+	if role == string(models.Admin) {
+		if err := s.checkClinic(ctx, payment.AppointmentID); err != nil {
+			return nil, err
+		}
+	}
+
+	return payment, nil
+}
+
+func (s *paymentService) ListByAppointmentID(ctx context.Context, appointmentID uint) ([]models.Payment, error) {
+	if err := s.checkClinic(ctx, appointmentID); err != nil {
+		return nil, err
+	}
+	return s.payments.ListByAppointmentID(ctx, appointmentID)
+}
+
+// checkClinic проверяет, что запись относится к филиалу сотрудника.
+func (s *paymentService) checkClinic(ctx context.Context, appointmentID uint) error {
+	if _, scoped := ClinicScopeIDs(ctx); !scoped {
+		return nil
+	}
+
+	appointment, err := s.appointments.GetByID(appointmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPaymentNoAppointment
+		}
+		return err
+	}
+	if !ClinicAllowed(ctx, appointment.ClinicID) {
+		return ErrClinicForbidden
+	}
+	return nil
+}
+
+func (s *paymentService) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	event, err := s.provider.ParseWebhook(payload, signature)
+	if err != nil {
+		s.logger.Warn("отклонён webhook платёжного провайдера", "error", err)
+		return err
+	}
+
+	s.logger.Debug("webhook получен", "event_id", event.ID, "type", event.Type, "intent_id", event.IntentID)
+
+	payment, err := s.payments.GetByIntentID(ctx, event.IntentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPaymentNotFound
+		}
+		return err
+	}
+
+	if payment.LastEventID == event.ID {
+		s.logger.Info("повторный webhook проигнорирован", "event_id", event.ID)
+		return nil
+	}
+
+	switch event.Type {
+	case payments.EventPaymentSucceeded:
+		return s.markSucceeded(ctx, payment, event.ID)
+	case payments.EventPaymentFailed:
+		return s.markFailed(ctx, payment, event.ID)
+	case payments.EventPaymentRefunded:
+		return s.payments.Transaction(ctx, func(tx *gorm.DB) error {
+			if err := s.lockTx(tx, payment); err != nil {
+				return err
+			}
+			if payment.Status != models.PaymentSucceeded {
+				return nil
+			}
+			return s.applyRefundTx(tx, payment, payment.Amount-payment.RefundedAmount, event.ID)
+		})
+	}
+
+	s.logger.Warn("неизвестный тип webhook события", "type", event.Type)
+	return nil
+}
+
+func (s *paymentService) ExpireUnpaid(ctx context.Context) error {
+	now := time.Now()
+	expired, err := s.payments.ListExpired(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for i := range expired {
+		payment := &expired[i]
+		released := false
+
+		err := s.payments.Transaction(ctx, func(tx *gorm.DB) error {
+			if err := s.lockTx(tx, payment); err != nil {
+				return err
+			}
+			// Оплата могла прийти, пока шёл обход.
+			if payment.Status != models.PaymentPending || payment.ExpiresAt.After(now) {
+				return nil
+			}
+
+			payment.Status = models.PaymentExpired
+			if err := s.payments.UpdateTx(tx, payment); err != nil {
+				return err
+			}
+			released = true
+
+			return s.releaseHoldTx(tx, payment.AppointmentID)
+		})
+		if err != nil {
+			s.logger.Error("не удалось снять удержание неоплаченной записи", "error", err, "payment_id", payment.ID)
+			continue
+		}
+		if !released {
+			continue
+		}
+
+		s.logger.Info("удержание неоплаченной записи истекло", "payment_id", payment.ID, "appointment_id", payment.AppointmentID)
+	}
+
+	return nil
+}
+
+// markSucceeded фиксирует оплату. Запись переводится в scheduled, только
+// если она ещё ждёт оплаты; если удержание уже снято (запись отменена или
+// изменена, срок оплаты истёк), деньги сразу возвращаются. Уже обработанный
+// платёж не меняется.
+func (s *paymentService) markSucceeded(ctx context.Context, payment *models.Payment, eventID string) error {
+	now := time.Now()
+
+	return s.payments.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.lockTx(tx, payment); err != nil {
+			return err
+		}
+		if payment.Status != models.PaymentPending && payment.Status != models.PaymentExpired {
+			return nil
+		}
+
+		payment.Status = models.PaymentSucceeded
+		payment.PaidAt = &now
+		if eventID != "" {
+			payment.LastEventID = eventID
+		}
+
+		appointment, err := s.appointments.GetByIDForUpdateTx(tx, payment.AppointmentID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && appointment.Status == models.AppointmentPendingPayment {
+			if err := s.payments.UpdateTx(tx, payment); err != nil {
+				return err
+			}
+			return s.appointments.SetPaymentStateTx(
+				tx,
+				payment.AppointmentID,
+				models.AppointmentScheduled,
+				payment.Mode == models.PaymentModeFull,
+			)
+		}
+
+		// Слот уже мог занять другой пациент. Запись не трогаем: её статус и
+		// отметку об оплате определяют другие платежи.
+		s.logger.Warn("оплата пришла для записи, которая уже не ждёт оплаты, выполняется возврат",
+			"payment_id", payment.ID, "appointment_id", payment.AppointmentID)
+		if _, err := s.provider.Refund(ctx, payment.IntentID, payment.Amount); err != nil {
+			s.logger.Error("провайдер не выполнил возврат", "error", err, "payment_id", payment.ID)
+			return err
+		}
+
+		payment.RefundedAmount = payment.Amount
+		payment.Status = models.PaymentRefunded
+		return s.payments.UpdateTx(tx, payment)
+	})
+}
+
+// markFailed фиксирует отказ в оплате и вместе с ним снимает удержание
+// слота, чтобы запись не висела в pending_payment до истечения срока.
+func (s *paymentService) markFailed(ctx context.Context, payment *models.Payment, eventID string) error {
+	return s.payments.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.lockTx(tx, payment); err != nil {
+			return err
+		}
+		if payment.Status != models.PaymentPending {
+			return nil
+		}
+
+		payment.Status = models.PaymentFailed
+		if eventID != "" {
+			payment.LastEventID = eventID
+		}
+
+		if err := s.payments.UpdateTx(tx, payment); err != nil {
+			return err
+		}
+
+		return s.releaseHoldTx(tx, payment.AppointmentID)
+	})
+}
+
+// lockTx перечитывает платёж под блокировкой строки: статус и сумма
+// возврата проверяются по актуальному состоянию, а не по прочитанному до
+// транзакции.
+func (s *paymentService) lockTx(tx *gorm.DB, payment *models.Payment) error {
+	locked, err := s.payments.GetByIDForUpdateTx(tx, payment.ID)
+	if err != nil {
+		return err
+	}
+	*payment = *locked
+	return nil
+}
+
+// releaseHoldTx отменяет запись, которая всё ещё ждёт оплаты, и публикует
+// AppointmentCancelled в той же транзакции.
+func (s *paymentService) releaseHoldTx(tx *gorm.DB, appointmentID uint) error {
+	appointment, err := s.appointments.GetByIDForUpdateTx(tx, appointmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if appointment.Status != models.AppointmentPendingPayment {
+		return nil
+	}
+
+	if err := s.appointments.CancelTx(tx, appointment); err != nil {
+		return err
+	}
+	return publishTx(tx, s.outbox, events.AppointmentCancelled, events.AggregateAppointment, appointment.ID, events.NewAppointmentPayload(appointment))
+}
+
+// applyRefundTx учитывает возврат по платежу, заблокированному lockTx.
+func (s *paymentService) applyRefundTx(tx *gorm.DB, payment *models.Payment, amount float64, eventID string) error {
+	payment.RefundedAmount = roundMoney(payment.RefundedAmount + amount)
+	if payment.RefundedAmount >= payment.Amount {
+		payment.Status = models.PaymentRefunded
+	}
+	if eventID != "" {
+		payment.LastEventID = eventID
+	}
+
+	if err := s.payments.UpdateTx(tx, payment); err != nil {
+		return err
+	}
+
+	if payment.Status != models.PaymentRefunded {
+		return nil
+	}
+
+	appointment, err := s.appointments.GetByIDForUpdateTx(tx, payment.AppointmentID)
+	if err != nil {
+		return err
+	}
+
+	return s.appointments.SetPaymentStateTx(tx, appointment.ID, appointment.Status, false)
+}
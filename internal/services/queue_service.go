@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/localtime"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/realtime"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCheckInNotToday   = errors.New("отметить приход можно только для сегодняшнего приёма")
+	ErrCheckInNotAllowed = errors.New("приём отменён или уже завершён")
+	ErrAlreadyCheckedIn  = errors.New("пациент уже отмечен как пришедший")
+	ErrNotCheckedIn      = errors.New("пациент ещё не отмечен как пришедший")
+)
+
+const (
+	QueueEventCheckedIn = "checked_in"
+	QueueEventCalled    = "called"
+)
+
+type QueueConfig struct {
+	// Location — часовой пояс для приёмов вне филиалов; у приёмов филиала
+	// границы «сегодня» берутся по поясу филиала.
+	Location *time.Location
+}
+
+type QueueService interface {
+	CheckIn(ctx context.Context, appointmentID uint) (*models.QueueItem, error)
+
+	// Call приглашает пришедшего пациента в кабинет и оповещает табло.
+	Call(ctx context.Context, appointmentID uint) (*models.QueueItem, error)
+
+	// Queue возвращает сегодняшнюю очередь пришедших пациентов в порядке
+	// времени записи и прихода с оценкой ожидания по текущей задержке врача.
+	// Сотрудник, закреплённый за филиалами, видит только их очередь.
+	Queue(ctx context.Context, params models.QueueQueryParams) ([]models.QueueItem, error)
+
+	Subscribe() (<-chan models.QueueEvent, func())
+}
+
+type queueService struct {
+	appointments repository.AppointmentRepository
+	schedules    repository.ScheduleRepository
+	clinics      repository.ClinicRepository
+	hub          *realtime.Hub[models.QueueEvent]
+	cfg          QueueConfig
+	logger       *slog.Logger
+}
+
+func NewQueueService(
+	appointments repository.AppointmentRepository,
+	schedules repository.ScheduleRepository,
+	clinics repository.ClinicRepository,
+	hub *realtime.Hub[models.QueueEvent],
+	cfg QueueConfig,
+	logger *slog.Logger,
+) QueueService {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+
+	return &queueService{appointments: appointments, schedules: schedules, clinics: clinics, hub: hub, cfg: cfg, logger: logger}
+}
+
+func (s *queueService) CheckIn(ctx context.Context, appointmentID uint) (*models.QueueItem, error) {
+	appointment, err := s.getAppointment(appointmentID)
+	if err != nil {
+		return nil, err
+	}
+	if !ClinicAllowed(ctx, appointment.ClinicID) {
+		return nil, ErrClinicForbidden
+	}
+
+	if appointment.Status != models.AppointmentScheduled && appointment.Status != models.AppointmentPendingPayment {
+		return nil, ErrCheckInNotAllowed
+	}
+	today, err := s.isToday(ctx, newClinicLocations(s.clinics, s.cfg.Location), appointment, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !today {
+		return nil, ErrCheckInNotToday
+	}
+
+	updated, err := s.appointments.SetCheckedIn(appointment.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrAlreadyCheckedIn
+	}
+
+	return s.publish(ctx, QueueEventCheckedIn, appointment)
+}
+
+func (s *queueService) Call(ctx context.Context, appointmentID uint) (*models.QueueItem, error) {
+	appointment, err := s.getAppointment(appointmentID)
+	if err != nil {
+		return nil, err
+	}
+	if !ClinicAllowed(ctx, appointment.ClinicID) {
+		return nil, ErrClinicForbidden
+	}
+
+	if appointment.Status != models.AppointmentScheduled && appointment.Status != models.AppointmentPendingPayment {
+		return nil, ErrCheckInNotAllowed
+	}
+
+	updated, err := s.appointments.SetCalled(appointment.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrNotCheckedIn
+	}
+
+	return s.publish(ctx, QueueEventCalled, appointment)
+}
+
+func (s *queueService) getAppointment(id uint) (*models.Appointment, error) {
+	appointment, err := s.appointments.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, constants.ErrInvalidAppointmentID
+		}
+		return nil, err
+	}
+	return appointment, nil
+}
+
+// publish пересчитывает очередь врача и рассылает актуальную строку
+// изменившегося приёма подписчикам потока.
+func (s *queueService) publish(ctx context.Context, eventType string, appointment *models.Appointment) (*models.QueueItem, error) {
+	items, err := s.Queue(ctx, models.QueueQueryParams{DoctorID: appointment.DoctorID})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		if items[i].AppointmentID == appointment.ID {
+			s.hub.Publish(models.QueueEvent{Type: eventType, Item: items[i]})
+			s.logger.Info("очередь обновлена", "event", eventType, "appointment_id", appointment.ID, "doctor_id", appointment.DoctorID)
+			return &items[i], nil
+		}
+	}
+
+	return nil, constants.ErrInvalidAppointmentID
+}
+
+func (s *queueService) Queue(ctx context.Context, params models.QueueQueryParams) ([]models.QueueItem, error) {
+	now := time.Now()
+	from, to := todayWindow(now)
+
+	fetched, err := s.appointments.GetForQueue(from, to, params.DoctorID)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := newClinicLocations(s.clinics, s.cfg.Location)
+	appointments := make([]models.Appointment, 0, len(fetched))
+	for i := range fetched {
+		today, err := s.isToday(ctx, locations, &fetched[i], now)
+		if err != nil {
+			return nil, err
+		}
+		if today {
+			appointments = append(appointments, fetched[i])
+		}
+	}
+	schedules, err := s.schedules.GetBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Текущая задержка врача — насколько позже записи был приглашён
+	// последний вызванный пациент.
+	delays := make(map[uint]time.Duration)
+	lastCalled := make(map[uint]time.Time)
+	// cursor — когда врач освободится для следующего пациента.
+	cursor := make(map[uint]time.Time)
+	for _, a := range appointments {
+		if a.CalledAt == nil || a.CalledAt.Before(lastCalled[a.DoctorID]) {
+			continue
+		}
+		lastCalled[a.DoctorID] = *a.CalledAt
+		delays[a.DoctorID] = max(a.CalledAt.Sub(a.StartAt), 0)
+		cursor[a.DoctorID] = a.CalledAt.Add(a.EndAt.Sub(a.StartAt))
+	}
+
+	items := make([]models.QueueItem, 0, len(appointments))
+	for _, a := range appointments {
+		if a.CheckedInAt == nil {
+			continue
+		}
+
+		if params.ClinicID != 0 && (a.ClinicID == nil || *a.ClinicID != params.ClinicID) {
+			continue
+		}
+		if !ClinicAllowed(ctx, a.ClinicID) {
+			continue
+		}
+
+		item := models.QueueItem{
+			AppointmentID: a.ID,
+			DoctorID:      a.DoctorID,
+			ClinicID:      a.ClinicID,
+			RoomNumber:    queueRoom(&a, schedules),
+			PatientName:   queueDisplayName(a.Patient),
+			StartAt:       a.StartAt,
+			CheckedInAt:   *a.CheckedInAt,
+			CalledAt:      a.CalledAt,
+			Status:        models.QueueWaiting,
+		}
+		if a.CalledAt != nil {
+			item.Status = models.QueueCalled
+		}
+
+		if params.RoomNumber != 0 && item.RoomNumber != params.RoomNumber {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].StartAt.Equal(items[j].StartAt) {
+			return items[i].StartAt.Before(items[j].StartAt)
+		}
+		return items[i].CheckedInAt.Before(items[j].CheckedInAt)
+	})
+
+	durations := make(map[uint]time.Duration, len(appointments))
+	for _, a := range appointments {
+		durations[a.ID] = a.EndAt.Sub(a.StartAt)
+	}
+
+	for i := range items {
+		item := &items[i]
+		if item.Status != models.QueueWaiting {
+			continue
+		}
+
+		estimate := item.StartAt.Add(delays[item.DoctorID])
+		if c := cursor[item.DoctorID]; c.After(estimate) {
+			estimate = c
+		}
+		if now.After(estimate) {
+			estimate = now
+		}
+
+		item.EstimatedCallAt = &estimate
+		item.EstimatedWaitMinutes = int(estimate.Sub(now).Round(time.Minute) / time.Minute)
+		cursor[item.DoctorID] = estimate.Add(durations[item.AppointmentID])
+	}
+
+	return items, nil
+}
+
+func (s *queueService) Subscribe() (<-chan models.QueueEvent, func()) {
+	return s.hub.Subscribe()
+}
+
+// todayWindow покрывает «сегодня» в любом часовом поясе; точные границы
+// дня проверяет isToday по поясу филиала приёма.
+func todayWindow(now time.Time) (time.Time, time.Time) {
+	return now.Add(-26 * time.Hour), now.Add(26 * time.Hour)
+}
+
+// isToday сообщает, приходится ли приём на сегодняшний день своего филиала.
+func (s *queueService) isToday(ctx context.Context, locations *clinicLocations, a *models.Appointment, now time.Time) (bool, error) {
+	loc, err := locations.get(ctx, a.ClinicID)
+	if err != nil {
+		return false, err
+	}
+
+	from := localtime.StartOfDay(now, loc)
+	to := localtime.AddDays(from, loc, 1)
+	return !a.StartAt.Before(from) && a.StartAt.Before(to), nil
+}
+
+// queueRoom берёт кабинет из смены врача, а если смена не найдена —
+// кабинет из профиля врача.
+func queueRoom(a *models.Appointment, schedules []models.Schedule) int {
+	for _, sc := range schedules {
+		if sc.DoctorID == a.DoctorID && !sc.StartTime.After(a.StartAt) && sc.EndTime.After(a.StartAt) && sc.RoomNumber != 0 {
+			return sc.RoomNumber
+		}
+	}
+	if a.Doctor != nil {
+		return a.Doctor.RoomNumber
+	}
+	return 0
+}
+
+// queueDisplayName сокращает имя для публичного табло: «Иван П.».
+func queueDisplayName(patient *models.User) string {
+	if patient == nil {
+		return ""
+	}
+	name := strings.TrimSpace(patient.FirstName)
+	if r, _ := utf8.DecodeRuneInString(patient.LastName); r != utf8.RuneError {
+		name += " " + string(r) + "."
+	}
+	return strings.TrimSpace(name)
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+const defaultRoomKind = "general"
+
+var (
+	ErrRoomNotFound      = errors.New("кабинет не найден")
+	ErrEquipmentNotFound = errors.New("оборудование не найдено")
+	ErrInvalidRoom       = errors.New("некорректный кабинет: номер должен быть положительным")
+	ErrInvalidEquipment  = errors.New("некорректное оборудование: укажите название и вид")
+)
+
+type ResourceService interface {
+	CreateRoom(ctx context.Context, req models.RoomCreateRequest) (*models.Room, error)
+
+	GetRoom(ctx context.Context, id uint) (*models.Room, error)
+
+	// ListRooms и ListEquipment при clinicID = 0 возвращают ресурсы всех
+	// филиалов, доступных сотруднику.
+	ListRooms(ctx context.Context, clinicID uint) ([]models.Room, error)
+
+	UpdateRoom(ctx context.Context, id uint, req models.RoomUpdateRequest) (*models.Room, error)
+
+	DeleteRoom(ctx context.Context, id uint) error
+
+	CreateEquipment(ctx context.Context, req models.EquipmentCreateRequest) (*models.Equipment, error)
+
+	ListEquipment(ctx context.Context, clinicID uint) ([]models.Equipment, error)
+
+	UpdateEquipment(ctx context.Context, id uint, req models.EquipmentUpdateRequest) (*models.Equipment, error)
+
+	DeleteEquipment(ctx context.Context, id uint) error
+}
+
+type resourceService struct {
+	repo    repository.ResourceRepository
+	clinics repository.ClinicRepository
+	logger  *slog.Logger
+}
+
+func NewResourceService(repo repository.ResourceRepository, clinics repository.ClinicRepository, logger *slog.Logger) ResourceService {
+	return &resourceService{repo: repo, clinics: clinics, logger: logger}
+}
+
+func (s *resourceService) CreateRoom(ctx context.Context, req models.RoomCreateRequest) (*models.Room, error) {
+	if req.Number <= 0 {
+		return nil, ErrInvalidRoom
+	}
+
+	if err := s.checkClinic(ctx, req.ClinicID); err != nil {
+		return nil, err
+	}
+
+	kind := strings.TrimSpace(req.Kind)
+	if kind == "" {
+		kind = defaultRoomKind
+	}
+
+	room := &models.Room{
+		Number:   req.Number,
+		Name:     strings.TrimSpace(req.Name),
+		Kind:     kind,
+		IsActive: true,
+		ClinicID: req.ClinicID,
+	}
+
+	if err := s.repo.CreateRoom(ctx, room); err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+func (s *resourceService) GetRoom(ctx context.Context, id uint) (*models.Room, error) {
+	room, err := s.repo.GetRoom(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoomNotFound
+		}
+		return nil, err
+	}
+	return room, nil
+}
+
+func (s *resourceService) ListRooms(ctx context.Context, clinicID uint) ([]models.Room, error) {
+	ids, err := s.listScope(ctx, clinicID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListRooms(ctx, ids)
+}
+
+func (s *resourceService) UpdateRoom(ctx context.Context, id uint, req models.RoomUpdateRequest) (*models.Room, error) {
+	room, err := s.GetRoom(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ClinicAllowed(ctx, room.ClinicID) {
+		return nil, ErrClinicForbidden
+	}
+
+	if req.Name != nil {
+		room.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Kind != nil {
+		kind := strings.TrimSpace(*req.Kind)
+		if kind == "" {
+			kind = defaultRoomKind
+		}
+		room.Kind = kind
+	}
+	if req.IsActive != nil {
+		room.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdateRoom(ctx, room); err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+func (s *resourceService) DeleteRoom(ctx context.Context, id uint) error {
+	room, err := s.GetRoom(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ClinicAllowed(ctx, room.ClinicID) {
+		return ErrClinicForbidden
+	}
+	return s.repo.DeleteRoom(ctx, id)
+}
+
+func (s *resourceService) CreateEquipment(ctx context.Context, req models.EquipmentCreateRequest) (*models.Equipment, error) {
+	name := strings.TrimSpace(req.Name)
+	kind := strings.TrimSpace(req.Kind)
+	if name == "" || kind == "" {
+		return nil, ErrInvalidEquipment
+	}
+
+	// Стационарное оборудование находится в филиале своего кабинета.
+	clinicID := req.ClinicID
+	if req.RoomID != nil {
+		room, err := s.GetRoom(ctx, *req.RoomID)
+		if err != nil {
+			return nil, err
+		}
+		clinicID = room.ClinicID
+	}
+	if err := s.checkClinic(ctx, clinicID); err != nil {
+		return nil, err
+	}
+
+	equipment := &models.Equipment{
+		Name:     name,
+		Kind:     kind,
+		RoomID:   req.RoomID,
+		IsActive: true,
+		ClinicID: clinicID,
+	}
+
+	if err := s.repo.CreateEquipment(ctx, equipment); err != nil {
+		return nil, err
+	}
+
+	return equipment, nil
+}
+
+func (s *resourceService) ListEquipment(ctx context.Context, clinicID uint) ([]models.Equipment, error) {
+	ids, err := s.listScope(ctx, clinicID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListEquipment(ctx, ids)
+}
+
+func (s *resourceService) UpdateEquipment(ctx context.Context, id uint, req models.EquipmentUpdateRequest) (*models.Equipment, error) {
+	equipment, err := s.repo.GetEquipment(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEquipmentNotFound
+		}
+		return nil, err
+	}
+	if !ClinicAllowed(ctx, equipment.ClinicID) {
+		return nil, ErrClinicForbidden
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, ErrInvalidEquipment
+		}
+		equipment.Name = name
+	}
+	if req.Kind != nil {
+		kind := strings.TrimSpace(*req.Kind)
+		if kind == "" {
+			return nil, ErrInvalidEquipment
+		}
+		equipment.Kind = kind
+	}
+	if req.ClinicID != nil && equipment.RoomID == nil {
+		equipment.ClinicID = req.ClinicID
+		if *req.ClinicID == 0 {
+			equipment.ClinicID = nil
+		}
+	}
+	if req.RoomID != nil {
+		if *req.RoomID == 0 {
+			equipment.RoomID = nil
+		} else {
+			room, err := s.GetRoom(ctx, *req.RoomID)
+			if err != nil {
+				return nil, err
+			}
+			equipment.RoomID = req.RoomID
+			equipment.ClinicID = room.ClinicID
+		}
+	}
+	if err := s.checkClinic(ctx, equipment.ClinicID); err != nil {
+		return nil, err
+	}
+	if req.IsActive != nil {
+		equipment.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdateEquipment(ctx, equipment); err != nil {
+		return nil, err
+	}
+
+	return equipment, nil
+}
+
+func (s *resourceService) DeleteEquipment(ctx context.Context, id uint) error {
+	equipment, err := s.repo.GetEquipment(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrEquipmentNotFound
+		}
+		return err
+	}
+	if !ClinicAllowed(ctx, equipment.ClinicID) {
+		return ErrClinicForbidden
+	}
+	return s.repo.DeleteEquipment(ctx, id)
+}
+
+// checkClinic проверяет, что филиал ресурса существует и доступен сотруднику.
+func (s *resourceService) checkClinic(ctx context.Context, clinicID *uint) error {
+	if !ClinicAllowed(ctx, clinicID) {
+		return ErrClinicForbidden
+	}
+	if clinicID == nil {
+		return nil
+	}
+
+	if _, err := s.clinics.GetByID(ctx, *clinicID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrClinicNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// listScope определяет филиалы для списка ресурсов: запрошенный или все
+// доступные сотруднику.
+func (s *resourceService) listScope(ctx context.Context, clinicID uint) ([]uint, error) {
+	if clinicID != 0 {
+		if !ClinicAllowed(ctx, &clinicID) {
+			return nil, ErrClinicForbidden
+		}
+		return []uint{clinicID}, nil
+	}
+
+	ids, _ := ClinicScopeIDs(ctx)
+	return ids, nil
+}
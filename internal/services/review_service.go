@@ -4,61 +4,164 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"math"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/mutsaevz/team-4-dentistry/internal/events"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
 )
 
+var (
+	ErrReviewNotFound       = errors.New("отзыв не найден")
+	ErrReviewForbidden      = errors.New("нет доступа к этому отзыву")
+	ErrInvalidReview        = errors.New("некорректный отзыв: укажите приём и оценку от 1 до 5")
+	ErrReviewAppointment    = errors.New("отзыв можно оставить только на свой приём")
+	ErrReviewNotCompleted   = errors.New("отзыв можно оставить только после завершённого приёма")
+	ErrReviewWindowClosed   = errors.New("срок, в который можно оставить отзыв на приём, истёк")
+	ErrReviewAlreadyExists  = errors.New("отзыв на этот приём уже оставлен")
+	ErrReviewNotPublished   = errors.New("отзыв не опубликован")
+	ErrReviewRejectReason   = errors.New("укажите причину отклонения отзыва")
+	ErrReviewReplyExists    = errors.New("врач уже ответил на этот отзыв")
+	ErrInvalidReviewReply   = errors.New("ответ на отзыв не может быть пустым")
+	ErrReviewAlreadyFlagged = errors.New("вы уже пожаловались на этот отзыв")
+)
+
+type ReviewConfig struct {
+	// Window — сколько времени после приёма можно оставить отзыв; 0 — без
+	// ограничения.
+	Window time.Duration
+
+	// AutoPublish публикует отзывы, прошедшие автоматическую проверку, без
+	// модератора. Отзывы с замечаниями всегда ждут модерации.
+	AutoPublish bool
+
+	// BannedWords дополняет встроенный список нецензурных слов; слова
+	// сравниваются по началу слова без учёта регистра.
+	BannedWords []string
+}
+
 type ReviewService interface {
-	CreateReview(context.Context, models.ReviewCreateRequest) (*models.Review, error)
+	// CreateReview оставляет отзыв от имени пациента userID на его
+	// завершённый приём; врач берётся из приёма.
+	CreateReview(ctx context.Context, userID uint, req models.ReviewCreateRequest) (*models.Review, error)
 
 	GetByID(context.Context, uint) (*models.Review, error)
 
-	UpdateReview(context.Context, uint, models.ReviewUpdateRequest) (*models.Review, error)
+	// UpdateReview меняет оценку и текст; доступно только автору.
+	UpdateReview(ctx context.Context, userID uint, id uint, req models.ReviewUpdateRequest) (*models.Review, error)
+
+	// DeleteReview удаляет отзыв; доступно автору и администратору.
+	DeleteReview(ctx context.Context, userID uint, role string, id uint) error
+
+	// ModerationQueue возвращает отзывы на модерации и опубликованные
+	// отзывы с жалобами, старые первыми.
+	ModerationQueue(ctx context.Context, q models.ListQuery) (*models.Page[models.Review], error)
+
+	// PublishReview публикует отзыв и снимает жалобы на него.
+	PublishReview(ctx context.Context, adminID uint, id uint) (*models.Review, error)
+
+	RejectReview(ctx context.Context, adminID uint, id uint, req models.ReviewRejectRequest) (*models.Review, error)
 
-	DeleteReview(context.Context, uint) error
+	// ReplyToReview публикует ответ врача, о котором оставлен отзыв. Ответ
+	// можно дать один раз.
+	ReplyToReview(ctx context.Context, userID uint, id uint, req models.ReviewReplyRequest) (*models.Review, error)
 
-	GetDoctorReviews(context.Context, uint) ([]models.Review, error)
+	// GetDoctorRating возвращает сводку оценок врача: число, среднее,
+	// распределение по звёздам и динамику за последние месяцы.
+	GetDoctorRating(ctx context.Context, doctorID uint) (*models.RatingSummary, error)
 
-	GetPatientReviews(context.Context, uint) ([]models.Review, error)
+	// ReconcileRatings пересчитывает рейтинг всех врачей и возвращает число
+	// врачей, у которых он изменился.
+	ReconcileRatings(ctx context.Context) (int, error)
+
+	// FlagReview сохраняет жалобу пациента на опубликованный отзыв и
+	// возвращает его в очередь модерации.
+	FlagReview(ctx context.Context, userID uint, id uint, req models.ReviewFlagRequest) error
+
+	GetDoctorReviews(ctx context.Context, doctorID uint, q models.ListQuery) (*models.Page[models.Review], error)
+
+	GetPatientReviews(ctx context.Context, patientID uint, q models.ListQuery) (*models.Page[models.Review], error)
 }
 
 type reviewService struct {
-	review  repository.ReviewRepository
-	doctor  repository.DoctorRepository
-	patient repository.UserRepository
-	logger  *slog.Logger
+	review       repository.ReviewRepository
+	doctor       repository.DoctorRepository
+	patient      repository.UserRepository
+	appointments repository.AppointmentRepository
+	outbox       repository.OutboxRepository
+	cfg          ReviewConfig
+	logger       *slog.Logger
 }
 
 func NewReviewService(review repository.ReviewRepository,
 	doctor repository.DoctorRepository,
 	patient repository.UserRepository,
+	appointments repository.AppointmentRepository,
+	outbox repository.OutboxRepository,
+	cfg ReviewConfig,
 	logger *slog.Logger) ReviewService {
 	return &reviewService{
-		review:  review,
-		doctor:  doctor,
-		patient: patient,
-		logger:  logger,
+		review:       review,
+		doctor:       doctor,
+		patient:      patient,
+		appointments: appointments,
+		outbox:       outbox,
+		cfg:          cfg,
+		logger:       logger,
 	}
 }
 
-func (s *reviewService) CreateReview(ctx context.Context, req models.ReviewCreateRequest) (*models.Review, error) {
-	s.logger.Debug("CreateReview вызван", "appointment_id", req.AppointmentID, "user_id", req.UserID, "doctor_id", req.DoctorID)
+func (s *reviewService) CreateReview(ctx context.Context, userID uint, req models.ReviewCreateRequest) (*models.Review, error) {
+	s.logger.Debug("CreateReview вызван", "appointment_id", req.AppointmentID, "user_id", userID)
 
 	if err := s.ValidateCreateReview(req); err != nil {
-		s.logger.Error("валидация CreateReview провалилась", "error", err)
+		s.logger.Warn("валидация CreateReview провалилась", "error", err)
+		return nil, err
+	}
+
+	appointment, err := s.appointments.GetByID(req.AppointmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReviewAppointment
+		}
+		return nil, err
+	}
+	if err := s.checkAppointment(appointment, userID); err != nil {
+		s.logger.Warn("отзыв на приём отклонён", "error", err, "appointment_id", appointment.ID, "user_id", userID)
 		return nil, err
 	}
 
 	var review = models.Review{
-		AppointmentID: req.AppointmentID,
-		UserID:        req.UserID,
-		DoctorID:      req.DoctorID,
+		AppointmentID: appointment.ID,
+		UserID:        appointment.PatientID,
+		DoctorID:      appointment.DoctorID,
 		Rating:        req.Rating,
 		Comment:       req.Comment,
 	}
+	s.screen(&review)
 
-	if err := s.review.Create(&review); err != nil {
+	err = s.review.Transaction(ctx, func(tx *gorm.DB) error {
+		exists, err := s.review.ExistsForAppointmentTx(tx, appointment.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrReviewAlreadyExists
+		}
+
+		if err := s.review.CreateTx(tx, &review); err != nil {
+			return err
+		}
+		if err := s.recomputeRatingTx(tx, review.DoctorID); err != nil {
+			return err
+		}
+		return s.publishCreatedTx(tx, &review, "")
+	})
+	if err != nil {
 		s.logger.Error("ошибка при создании review", "error", err)
 		return nil, err
 	}
@@ -71,6 +174,9 @@ func (s *reviewService) GetByID(ctx context.Context, id uint) (*models.Review, e
 	s.logger.Debug("GetByID review вызван", "review_id", id)
 	rev, err := s.review.GetByID(ctx, id)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReviewNotFound
+		}
 		s.logger.Error("ошибка получения review по ID", "error", err, "review_id", id)
 		return nil, err
 	}
@@ -78,15 +184,20 @@ func (s *reviewService) GetByID(ctx context.Context, id uint) (*models.Review, e
 	return rev, nil
 }
 
-func (s *reviewService) UpdateReview(ctx context.Context, id uint, req models.ReviewUpdateRequest) (*models.Review, error) {
-	s.logger.Debug("UpdateReview вызван", "review_id", id)
-	review, err := s.review.GetByID(ctx, id)
+func (s *reviewService) UpdateReview(ctx context.Context, userID uint, id uint, req models.ReviewUpdateRequest) (*models.Review, error) {
+	s.logger.Debug("UpdateReview вызван", "review_id", id, "user_id", userID)
+	review, err := s.GetByID(ctx, id)
 	if err != nil {
-		s.logger.Error("ошибка получения review для обновления", "error", err, "review_id", id)
 		return nil, err
 	}
+	if review.UserID != userID {
+		return nil, ErrReviewForbidden
+	}
 
 	if req.Rating != nil {
+		if *req.Rating < 1 || *req.Rating > 5 {
+			return nil, ErrInvalidReview
+		}
 		review.Rating = *req.Rating
 	}
 
@@ -94,7 +205,23 @@ func (s *reviewService) UpdateReview(ctx context.Context, id uint, req models.Re
 		review.Comment = *req.Comment
 	}
 
-	if err := s.review.Update(review); err != nil {
+	// Изменённый отзыв проходит проверку заново.
+	previous := review.Status
+	review.RejectionReason = ""
+	review.ModeratedBy = nil
+	review.ModeratedAt = nil
+	s.screen(review)
+
+	err = s.review.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.review.UpdateTx(tx, review); err != nil {
+			return err
+		}
+		if err := s.recomputeRatingTx(tx, review.DoctorID); err != nil {
+			return err
+		}
+		return s.publishCreatedTx(tx, review, previous)
+	})
+	if err != nil {
 		s.logger.Error("ошибка при обновлении review", "error", err, "review_id", id)
 		return nil, err
 	}
@@ -103,9 +230,23 @@ func (s *reviewService) UpdateReview(ctx context.Context, id uint, req models.Re
 	return review, nil
 }
 
-func (s *reviewService) DeleteReview(ctx context.Context, id uint) error {
-	s.logger.Debug("DeleteReview вызван", "review_id", id)
-	if err := s.review.Delete(ctx, id); err != nil {
+func (s *reviewService) DeleteReview(ctx context.Context, userID uint, role string, id uint) error {
+	s.logger.Debug("DeleteReview вызван", "review_id", id, "user_id", userID)
+	review, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if review.UserID != userID && models.Role(role) != models.Admin {
+		return ErrReviewForbidden
+	}
+
+	err = s.review.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.review.DeleteTx(tx, id); err != nil {
+			return err
+		}
+		return s.recomputeRatingTx(tx, review.DoctorID)
+	})
+	if err != nil {
 		s.logger.Error("ошибка при удалении review", "error", err, "review_id", id)
 		return err
 	}
@@ -113,41 +254,366 @@ func (s *reviewService) DeleteReview(ctx context.Context, id uint) error {
 	return nil
 }
 
-func (s *reviewService) GetDoctorReviews(ctx context.Context, doctorID uint) ([]models.Review, error) {
+func (s *reviewService) ModerationQueue(ctx context.Context, q models.ListQuery) (*models.Page[models.Review], error) {
+	page, err := s.review.ListModerationQueue(ctx, q)
+	if err != nil {
+		s.logger.Error("ошибка при получении очереди модерации", "error", err)
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *reviewService) PublishReview(ctx context.Context, adminID uint, id uint) (*models.Review, error) {
+	return s.moderate(ctx, adminID, id, models.ReviewPublished, "")
+}
+
+func (s *reviewService) RejectReview(ctx context.Context, adminID uint, id uint, req models.ReviewRejectRequest) (*models.Review, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, ErrReviewRejectReason
+	}
+	return s.moderate(ctx, adminID, id, models.ReviewRejected, reason)
+}
+
+func (s *reviewService) moderate(ctx context.Context, adminID uint, id uint, status, reason string) (*models.Review, error) {
+	review, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	previous := review.Status
+	review.Status = status
+	review.RejectionReason = reason
+	review.ModeratedBy = &adminID
+	review.ModeratedAt = &now
+	review.FlagCount = 0
+
+	err = s.review.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.review.UpdateTx(tx, review); err != nil {
+			return err
+		}
+		if err := s.recomputeRatingTx(tx, review.DoctorID); err != nil {
+			return err
+		}
+		return s.publishCreatedTx(tx, review, previous)
+	})
+	if err != nil {
+		s.logger.Error("ошибка при модерации review", "error", err, "review_id", id)
+		return nil, err
+	}
+
+	s.logger.Info("отзыв промодерирован", "review_id", id, "status", status, "admin_id", adminID)
+	return review, nil
+}
+
+func (s *reviewService) ReplyToReview(ctx context.Context, userID uint, id uint, req models.ReviewReplyRequest) (*models.Review, error) {
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		return nil, ErrInvalidReviewReply
+	}
+
+	review, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	doctor, err := s.doctor.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReviewForbidden
+		}
+		return nil, err
+	}
+	if doctor.ID != review.DoctorID {
+		return nil, ErrReviewForbidden
+	}
+	if review.Status != models.ReviewPublished {
+		return nil, ErrReviewNotPublished
+	}
+	if review.RepliedAt != nil {
+		return nil, ErrReviewReplyExists
+	}
+
+	now := time.Now()
+	review.Reply = text
+	review.RepliedAt = &now
+
+	err = s.review.Transaction(ctx, func(tx *gorm.DB) error {
+		return s.review.UpdateTx(tx, review)
+	})
+	if err != nil {
+		s.logger.Error("ошибка при сохранении ответа на review", "error", err, "review_id", id)
+		return nil, err
+	}
+
+	s.logger.Info("врач ответил на отзыв", "review_id", id, "doctor_id", doctor.ID)
+	return review, nil
+}
+
+func (s *reviewService) FlagReview(ctx context.Context, userID uint, id uint, req models.ReviewFlagRequest) error {
+	review, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if review.Status != models.ReviewPublished {
+		return ErrReviewNotPublished
+	}
+	if review.UserID == userID {
+		return ErrReviewForbidden
+	}
+
+	err = s.review.Transaction(ctx, func(tx *gorm.DB) error {
+		flagged, err := s.review.HasFlagTx(tx, id, userID)
+		if err != nil {
+			return err
+		}
+		if flagged {
+			return ErrReviewAlreadyFlagged
+		}
+
+		return s.review.AddFlagTx(tx, &models.ReviewFlag{
+			ReviewID: id,
+			UserID:   userID,
+			Reason:   strings.TrimSpace(req.Reason),
+		})
+	})
+	if err != nil {
+		s.logger.Warn("жалоба на отзыв не сохранена", "error", err, "review_id", id, "user_id", userID)
+		return err
+	}
+
+	return nil
+}
+
+// ratingTrendMonths — за сколько месяцев, включая текущий, строится динамика.
+const ratingTrendMonths = 6
+
+func (s *reviewService) GetDoctorRating(ctx context.Context, doctorID uint) (*models.RatingSummary, error) {
+	doctor, err := s.doctor.GetByID(doctorID, ctx)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDoctorNotFound
+		}
+		return nil, err
+	}
+
+	distribution, err := s.review.GetRatingDistribution(ctx, doctor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.RatingSummary{
+		DoctorID:     doctor.ID,
+		Distribution: make(map[int]int64, 5),
+	}
+	var sum int64
+	for stars := 1; stars <= 5; stars++ {
+		summary.Distribution[stars] = distribution[stars]
+		summary.Count += distribution[stars]
+		sum += int64(stars) * distribution[stars]
+	}
+	if summary.Count > 0 {
+		summary.Average = float64(sum) / float64(summary.Count)
+	}
+
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -(ratingTrendMonths - 1), 0)
+	points, err := s.review.GetRatingTrend(ctx, doctor.ID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	// Месяцы без отзывов тоже попадают в динамику, с нулями.
+	byMonth := make(map[string]models.RatingTrendPoint, len(points))
+	for _, p := range points {
+		byMonth[p.Month] = p
+	}
+	summary.Trend = make([]models.RatingTrendPoint, 0, ratingTrendMonths)
+	for month := since; !month.After(now); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+		point, ok := byMonth[key]
+		if !ok {
+			point = models.RatingTrendPoint{Month: key}
+		}
+		summary.Trend = append(summary.Trend, point)
+	}
+
+	return summary, nil
+}
+
+func (s *reviewService) ReconcileRatings(ctx context.Context) (int, error) {
+	ids, err := s.doctor.ListIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, id := range ids {
+		var fixed bool
+		err := s.review.Transaction(ctx, func(tx *gorm.DB) error {
+			if err := s.doctor.LockTx(tx, id); err != nil {
+				return err
+			}
+			doctor, err := s.doctor.GetByID(id, ctx)
+			if err != nil {
+				return err
+			}
+
+			count, avg, err := s.review.GetRatingStatsTx(tx, id)
+			if err != nil {
+				return err
+			}
+			if doctor.ReviewCount == int(count) && math.Abs(doctor.AvgRating-avg) < 1e-9 {
+				return nil
+			}
+
+			fixed = true
+			return s.doctor.UpdateRatingTx(tx, id, avg, count)
+		})
+		if err != nil {
+			s.logger.Error("ошибка при пересчёте рейтинга врача", "error", err, "doctor_id", id)
+			return changed, err
+		}
+		if fixed {
+			changed++
+			s.logger.Info("рейтинг врача исправлен", "doctor_id", id)
+		}
+	}
+
+	s.logger.Info("пересчёт рейтингов завершён", "doctors", len(ids), "changed", changed)
+	return changed, nil
+}
+
+// recomputeRatingTx пересчитывает рейтинг врача по опубликованным отзывам.
+// Строка врача блокируется, чтобы параллельные изменения отзывов не
+// перезаписали рейтинг устаревшим значением.
+// publishCreatedTx сообщает о новом отзыве, только когда он становится
+// опубликованным: отзывы на модерации и отклонённые подписчикам не видны.
+func (s *reviewService) publishCreatedTx(tx *gorm.DB, review *models.Review, previous string) error {
+	if review.Status != models.ReviewPublished || previous == models.ReviewPublished {
+		return nil
+	}
+	return publishTx(tx, s.outbox, events.ReviewCreated, events.AggregateReview, review.ID, events.ReviewPayload{
+		ReviewID:      review.ID,
+		AppointmentID: review.AppointmentID,
+		DoctorID:      review.DoctorID,
+		UserID:        review.UserID,
+		Rating:        review.Rating,
+	})
+}
+
+func (s *reviewService) recomputeRatingTx(tx *gorm.DB, doctorID uint) error {
+	if err := s.doctor.LockTx(tx, doctorID); err != nil {
+		return err
+	}
+
+	count, avg, err := s.review.GetRatingStatsTx(tx, doctorID)
+	if err != nil {
+		return err
+	}
+
+	return s.doctor.UpdateRatingTx(tx, doctorID, avg, count)
+}
+
+func (s *reviewService) GetDoctorReviews(ctx context.Context, doctorID uint, q models.ListQuery) (*models.Page[models.Review], error) {
 	s.logger.Debug("GetDoctorReviews вызван", "doctor_id", doctorID)
-	revs, err := s.review.GetByDoctorID(ctx, doctorID)
+	page, err := s.review.ListByDoctor(ctx, doctorID, q)
 	if err != nil {
 		s.logger.Error("ошибка при получении отзывов врача", "error", err, "doctor_id", doctorID)
 		return nil, err
 	}
-	s.logger.Info("отзывы врача получены", "doctor_id", doctorID, "count", len(revs))
-	return revs, nil
+	s.logger.Info("отзывы врача получены", "doctor_id", doctorID, "count", len(page.Items))
+	return page, nil
 }
 
-func (s *reviewService) GetPatientReviews(ctx context.Context, patientID uint) ([]models.Review, error) {
+func (s *reviewService) GetPatientReviews(ctx context.Context, patientID uint, q models.ListQuery) (*models.Page[models.Review], error) {
 	s.logger.Debug("GetPatientReviews вызван", "patient_id", patientID)
-	revs, err := s.review.GetByPatientID(ctx, patientID)
+	page, err := s.review.ListByPatient(ctx, patientID, q)
 	if err != nil {
 		s.logger.Error("ошибка при получении отзывов пациента", "error", err, "patient_id", patientID)
 		return nil, err
 	}
-	s.logger.Info("отзывы пациента получены", "patient_id", patientID, "count", len(revs))
-	return revs, nil
+	s.logger.Info("отзывы пациента получены", "patient_id", patientID, "count", len(page.Items))
+	return page, nil
 }
 
 func (s *reviewService) ValidateCreateReview(req models.ReviewCreateRequest) error {
 	if req.AppointmentID == 0 {
-		return errors.New("")
+		return ErrInvalidReview
 	}
-	if req.UserID == 0 {
-		return errors.New("user_id is required")
+	if req.Rating < 1 || req.Rating > 5 {
+		return ErrInvalidReview
 	}
-	if req.DoctorID == 0 {
-		return errors.New("doctor_id is required")
+	s.logger.Debug("ValidateCreateReview успешно", "appointment_id", req.AppointmentID)
+	return nil
+}
+
+// checkAppointment проверяет, что отзыв оставляет пациент этого приёма, приём
+// завершён и срок для отзыва не истёк.
+func (s *reviewService) checkAppointment(appointment *models.Appointment, userID uint) error {
+	if appointment.PatientID != userID {
+		return ErrReviewAppointment
 	}
-	if req.Rating < 1 || req.Rating > 5 {
-		return errors.New("rating must be between 1 and 5")
+	if appointment.Status != models.AppointmentCompleted {
+		return ErrReviewNotCompleted
+	}
+	if s.cfg.Window > 0 && time.Since(appointment.EndAt) > s.cfg.Window {
+		return ErrReviewWindowClosed
 	}
-	s.logger.Debug("ValidateCreateReview успешно", "appointment_id", req.AppointmentID, "user_id", req.UserID)
 	return nil
 }
+
+// screen проверяет текст отзыва и выставляет статус: отзыв с замечаниями
+// или при выключенной автопубликации уходит на модерацию.
+func (s *reviewService) screen(review *models.Review) {
+	review.ScreeningFlags = screenReviewText(review.Comment, s.cfg.BannedWords)
+
+	if s.cfg.AutoPublish && len(review.ScreeningFlags) == 0 {
+		review.Status = models.ReviewPublished
+		return
+	}
+	review.Status = models.ReviewPending
+}
+
+// defaultBannedWords — основы нецензурных слов, с которых начинаются
+// запрещённые формы.
+var defaultBannedWords = []string{"хуй", "хуе", "хуя", "пизд", "ебал", "ебан", "бля", "сука", "сучк", "мудак", "пидор", "fuck", "shit", "bitch"}
+
+var (
+	reviewWordRe  = regexp.MustCompile(`\p{L}+`)
+	reviewLinkRe  = regexp.MustCompile(`(?i)(https?://|www\.|[\p{L}0-9-]+\.(ru|com|net|org|info|io|su|рф)([^\p{L}0-9]|$))`)
+	reviewEmailRe = regexp.MustCompile(`(?i)[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}`)
+	// Телефоны и номера документов: длинные последовательности цифр,
+	// возможно разделённые пробелами, скобками и дефисами.
+	reviewDigitsRe = regexp.MustCompile(`\+?\d[\d\s()-]{8,}\d`)
+)
+
+// screenReviewText возвращает замечания автоматической проверки текста:
+// нецензурная лексика, ссылки и персональные данные.
+func screenReviewText(text string, banned []string) []string {
+	var flags []string
+
+	words := reviewWordRe.FindAllString(strings.ToLower(text), -1)
+profanity:
+	for _, word := range words {
+		for _, list := range [][]string{defaultBannedWords, banned} {
+			for _, bad := range list {
+				if bad != "" && strings.HasPrefix(word, strings.ToLower(bad)) {
+					flags = append(flags, models.ReviewScreenProfanity)
+					break profanity
+				}
+			}
+		}
+	}
+
+	// Адрес почты похож на ссылку, поэтому ссылки ищем в тексте без адресов.
+	if reviewLinkRe.MatchString(reviewEmailRe.ReplaceAllString(text, "")) {
+		flags = append(flags, models.ReviewScreenLink)
+	}
+	if reviewEmailRe.MatchString(text) || reviewDigitsRe.MatchString(text) {
+		flags = append(flags, models.ReviewScreenPersonalData)
+	}
+
+	return flags
+}
@@ -4,38 +4,98 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/events"
+	"github.com/mutsaevz/team-4-dentistry/internal/localtime"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
 )
 
+var (
+	ErrScheduleConflicts       = errors.New("смены конфликтуют между собой или с существующим расписанием")
+	ErrScheduleHasAppointments = errors.New("на смену есть записи: чтобы изменить её, передайте reschedule, а перед удалением перенесите или отмените приёмы")
+	ErrScheduleDateMismatch    = errors.New("дата смены не совпадает с местным днём её начала")
+)
+
+// ScheduleConflictsError перечисляет все конфликты пакета смен.
+type ScheduleConflictsError struct {
+	Conflicts []models.ScheduleConflict
+}
+
+func (e *ScheduleConflictsError) Error() string { return ErrScheduleConflicts.Error() }
+
+func (e *ScheduleConflictsError) Unwrap() error { return ErrScheduleConflicts }
+
 type ScheduleService interface {
+	// CreateSchedule создаёт пакет смен целиком или не создаёт ничего;
+	// при конфликтах возвращает *ScheduleConflictsError.
 	CreateSchedule(ctx context.Context, req []models.ScheduleCreateRequest) ([]models.Schedule, error)
 
+	// CreateScheduleTx создаёт смены врача, который заводится в той же
+	// транзакции tx: закрепление за филиалом вне tx ещё не видно и не
+	// проверяется, пересечения проверяются внутри tx.
+	CreateScheduleTx(ctx context.Context, tx *gorm.DB, req []models.ScheduleCreateRequest) ([]models.Schedule, error)
+
+	// ValidateSchedules проверяет пакет без сохранения и возвращает все
+	// найденные конфликты.
+	ValidateSchedules(ctx context.Context, req []models.ScheduleCreateRequest) (*models.ScheduleValidationResult, error)
+
 	GetSchedulesByID(ctx context.Context, id uint) ([]models.Schedule, error)
 
-	ListSchedules(ctx context.Context) ([]models.Schedule, error)
+	// ListSchedules возвращает страницу смен в филиалах сотрудника.
+	ListSchedules(ctx context.Context, q models.ListQuery) (*models.Page[models.Schedule], error)
 
 	UpdateSchedule(ctx context.Context, id uint, req models.ScheduleUpdateRequest) (*models.Schedule, error)
 
 	DeleteSchedule(ctx context.Context, id uint) error
 
-	GetAvailableSlots(ctx context.Context, doctorID uint, week int) ([]models.Schedule, error)
+	GetAvailableSlots(ctx context.Context, doctorID uint, clinicID uint, week int) ([]models.Schedule, error)
+
+	// NextAvailableSlots возвращает ближайший свободный слот каждого врача
+	// по местному времени филиала; врачей без свободных слотов в ответе нет.
+	NextAvailableSlots(ctx context.Context, doctorIDs []uint, clinicID uint) (map[uint]models.Schedule, error)
+}
+
+type ScheduleConfig struct {
+	// Location — часовой пояс для смен вне филиалов и по умолчанию.
+	Location *time.Location
 }
 
 type scheduleService struct {
-	schedule repository.ScheduleRepository
-	doctor   repository.DoctorRepository
-	logger   *slog.Logger
+	schedule     repository.ScheduleRepository
+	doctor       repository.DoctorRepository
+	clinics      repository.ClinicRepository
+	appointments repository.AppointmentRepository
+	outbox       repository.OutboxRepository
+	cfg          ScheduleConfig
+	logger       *slog.Logger
 }
 
-func NewScheduleService(repoSchedule repository.ScheduleRepository, repoDoctor repository.DoctorRepository, logger *slog.Logger) ScheduleService {
+func NewScheduleService(
+	repoSchedule repository.ScheduleRepository,
+	repoDoctor repository.DoctorRepository,
+	clinics repository.ClinicRepository,
+	appointments repository.AppointmentRepository,
+	outbox repository.OutboxRepository,
+	cfg ScheduleConfig,
+	logger *slog.Logger,
+) ScheduleService {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+
 	return &scheduleService{
-		schedule: repoSchedule,
-		doctor:   repoDoctor,
-		logger:   logger,
+		schedule:     repoSchedule,
+		doctor:       repoDoctor,
+		clinics:      clinics,
+		appointments: appointments,
+		outbox:       outbox,
+		cfg:          cfg,
+		logger:       logger,
 	}
 }
 
@@ -56,36 +116,225 @@ func (s *scheduleService) CreateSchedule(
 		"count", len(req),
 	)
 
-	if err := s.ValidateScheduleCreate(req); err != nil {
-		s.logger.Error("валидация CreateSchedule провалилась", "error", err)
+	schedules, conflicts, err := s.validateBatch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		s.logger.Warn("смены не созданы из-за конфликтов", "doctor_id", first.DoctorID, "conflicts", len(conflicts))
+		return nil, &ScheduleConflictsError{Conflicts: conflicts}
+	}
+
+	err = s.schedule.Transaction(ctx, func(tx *gorm.DB) error {
+		return s.createTx(tx, schedules)
+	})
+	if err != nil {
+		s.logger.Error("ошибка при создании schedules", "error", err)
 		return nil, err
 	}
 
+	s.logger.Info(
+		"schedules созданы",
+		"doctor_id", first.DoctorID,
+		"count", len(schedules),
+	)
+
+	return s.localize(ctx, schedules)
+}
+
+func (s *scheduleService) CreateScheduleTx(ctx context.Context, tx *gorm.DB, req []models.ScheduleCreateRequest) ([]models.Schedule, error) {
+	if len(req) == 0 {
+		return nil, errors.New("empty schedule request")
+	}
+
 	schedules := make([]models.Schedule, 0, len(req))
+	conflicts := []models.ScheduleConflict{}
 
-	for _, r := range req {
-		schedules = append(schedules, models.Schedule{
+	for i := range req {
+		r := req[i]
+
+		err := s.resolveLocalCreate(ctx, &r)
+		if err == nil {
+			err = s.ValidateScheduleCreate([]models.ScheduleCreateRequest{r})
+		}
+		if err == nil {
+			_, err = s.openClinic(ctx, r.ClinicID, r.StartTime, r.EndTime)
+		}
+		if err != nil {
+			if errors.Is(err, ErrClinicForbidden) || !(isScheduleInputError(err) || isScheduleValidationError(err)) {
+				return nil, err
+			}
+			conflicts = append(conflicts, models.ScheduleConflict{Index: i, Reason: models.ScheduleConflictInvalid, Error: err.Error()})
+			continue
+		}
+
+		sch := models.Schedule{
 			DoctorID:    r.DoctorID,
-			Date:        r.Date,
 			StartTime:   r.StartTime,
 			EndTime:     r.EndTime,
 			RoomNumber:  r.RoomNumber,
 			IsAvailable: true,
-		})
+			ClinicID:    r.ClinicID,
+		}
+		if err := s.normalize(ctx, &sch); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, sch)
+	}
+	if len(conflicts) > 0 {
+		return nil, &ScheduleConflictsError{Conflicts: conflicts}
 	}
 
-	if err := s.schedule.Create(ctx, schedules); err != nil {
-		s.logger.Error("ошибка при создании schedules", "error", err)
+	if err := s.createTx(tx, schedules); err != nil {
 		return nil, err
 	}
 
-	s.logger.Info(
-		"schedules созданы",
-		"doctor_id", first.DoctorID,
-		"count", len(schedules),
-	)
+	s.logger.Info("schedules созданы в транзакции", "doctor_id", req[0].DoctorID, "count", len(schedules))
+	return s.localize(ctx, schedules)
+}
 
-	return schedules, nil
+// createTx сохраняет смены и публикует ScheduleCreated по каждому врачу.
+func (s *scheduleService) createTx(tx *gorm.DB, schedules []models.Schedule) error {
+	if err := s.schedule.CreateTx(tx, schedules); err != nil {
+		return err
+	}
+
+	// Один запрос может содержать слоты нескольких врачей.
+	byDoctor := make(map[uint]*events.SchedulePayload)
+	var order []uint
+	for _, sch := range schedules {
+		p, ok := byDoctor[sch.DoctorID]
+		if !ok {
+			p = &events.SchedulePayload{DoctorID: sch.DoctorID}
+			byDoctor[sch.DoctorID] = p
+			order = append(order, sch.DoctorID)
+		}
+		p.Slots = append(p.Slots, events.ScheduleSlot{ScheduleID: sch.ID, StartTime: sch.StartTime, EndTime: sch.EndTime})
+	}
+
+	for _, doctorID := range order {
+		if err := publishTx(tx, s.outbox, events.ScheduleCreated, events.AggregateDoctor, doctorID, byDoctor[doctorID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *scheduleService) ValidateSchedules(ctx context.Context, req []models.ScheduleCreateRequest) (*models.ScheduleValidationResult, error) {
+	if len(req) == 0 {
+		return nil, errors.New("empty schedule request")
+	}
+
+	schedules, conflicts, err := s.validateBatch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules, err = s.localize(ctx, schedules)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ScheduleValidationResult{
+		Valid:     len(conflicts) == 0,
+		Schedules: schedules,
+		Conflicts: conflicts,
+	}, nil
+}
+
+// validateBatch проверяет каждую смену пакета и собирает все конфликты:
+// некорректные данные, расхождение Date со временем начала, пересечения
+// смен врача и кабинета внутри пакета и с базой. Ошибка возвращается только
+// при сбое хранилища или отказе в доступе к филиалу.
+func (s *scheduleService) validateBatch(ctx context.Context, req []models.ScheduleCreateRequest) ([]models.Schedule, []models.ScheduleConflict, error) {
+	schedules := make([]models.Schedule, 0, len(req))
+	// indexes[k] — индекс в запросе для schedules[k].
+	indexes := make([]int, 0, len(req))
+	conflicts := []models.ScheduleConflict{}
+
+	invalid := func(i int, reason string, err error) {
+		conflicts = append(conflicts, models.ScheduleConflict{Index: i, Reason: reason, Error: err.Error()})
+	}
+
+	for i := range req {
+		r := req[i]
+
+		if err := s.resolveLocalCreate(ctx, &r); err != nil {
+			if !isScheduleInputError(err) {
+				return nil, nil, err
+			}
+			invalid(i, models.ScheduleConflictInvalid, err)
+			continue
+		}
+		if err := s.ValidateScheduleCreate([]models.ScheduleCreateRequest{r}); err != nil {
+			invalid(i, models.ScheduleConflictInvalid, err)
+			continue
+		}
+		if err := s.checkClinic(ctx, r.DoctorID, r.ClinicID, r.StartTime, r.EndTime); err != nil {
+			if errors.Is(err, ErrClinicForbidden) || !isScheduleInputError(err) {
+				return nil, nil, err
+			}
+			invalid(i, models.ScheduleConflictInvalid, err)
+			continue
+		}
+
+		sch := models.Schedule{
+			DoctorID:    r.DoctorID,
+			StartTime:   r.StartTime,
+			EndTime:     r.EndTime,
+			RoomNumber:  r.RoomNumber,
+			IsAvailable: true,
+			ClinicID:    r.ClinicID,
+		}
+		if err := s.normalize(ctx, &sch); err != nil {
+			return nil, nil, err
+		}
+
+		// Date в запросе необязателен, но если передан — должен совпадать
+		// с местным днём начала смены.
+		if !r.Date.IsZero() {
+			loc, err := s.location(ctx, sch.ClinicID)
+			if err != nil {
+				return nil, nil, err
+			}
+			if r.Date.Format(localtime.DateLayout) != sch.StartTime.In(loc).Format(localtime.DateLayout) {
+				invalid(i, models.ScheduleConflictDateMismatch, ErrScheduleDateMismatch)
+				continue
+			}
+		}
+
+		for k := range schedules {
+			other := &schedules[k]
+			if !sch.StartTime.Before(other.EndTime) || !sch.EndTime.After(other.StartTime) {
+				continue
+			}
+			with := indexes[k]
+			if sch.DoctorID == other.DoctorID {
+				conflicts = append(conflicts, models.ScheduleConflict{Index: i, Reason: models.ScheduleConflictDoctorOverlap, Error: constants.ErrDoctorDoubleBooked.Error(), WithIndex: &with})
+			}
+			if sch.RoomNumber == other.RoomNumber && repository.SameClinic(sch.ClinicID, other.ClinicID) {
+				conflicts = append(conflicts, models.ScheduleConflict{Index: i, Reason: models.ScheduleConflictRoomOverlap, Error: constants.ErrRoomDoubleBooked.Error(), WithIndex: &with})
+			}
+		}
+
+		byDoctor, byRoom, err := s.schedule.Overlaps(ctx, &sch)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, other := range byDoctor {
+			id := other.ID
+			conflicts = append(conflicts, models.ScheduleConflict{Index: i, Reason: models.ScheduleConflictDoctorOverlap, Error: constants.ErrDoctorDoubleBooked.Error(), ScheduleID: &id})
+		}
+		for _, other := range byRoom {
+			id := other.ID
+			conflicts = append(conflicts, models.ScheduleConflict{Index: i, Reason: models.ScheduleConflictRoomOverlap, Error: constants.ErrRoomDoubleBooked.Error(), ScheduleID: &id})
+		}
+
+		schedules = append(schedules, sch)
+		indexes = append(indexes, i)
+	}
+
+	return schedules, conflicts, nil
 }
 
 func (s *scheduleService) GetSchedulesByID(ctx context.Context, id uint) ([]models.Schedule, error) {
@@ -96,18 +345,23 @@ func (s *scheduleService) GetSchedulesByID(ctx context.Context, id uint) ([]mode
 		return nil, err
 	}
 	s.logger.Info("расписание получено по doctor_id", "doctor_id", id, "count", len(sch))
-	return sch, nil
+	return s.localize(ctx, sch)
 }
 
-func (s *scheduleService) ListSchedules(ctx context.Context) ([]models.Schedule, error) {
+func (s *scheduleService) ListSchedules(ctx context.Context, q models.ListQuery) (*models.Page[models.Schedule], error) {
 	s.logger.Debug("ListSchedules вызван")
-	sch, err := s.schedule.GetAll(ctx)
+	scope, _ := ClinicScopeIDs(ctx)
+	page, err := s.schedule.List(ctx, q, scope)
 	if err != nil {
 		s.logger.Error("ошибка при получении всех расписаний", "error", err)
 		return nil, err
 	}
-	s.logger.Info("все расписания получены", "count", len(sch))
-	return sch, nil
+	s.logger.Info("все расписания получены", "count", len(page.Items), "total", page.Total)
+
+	if page.Items, err = s.localize(ctx, page.Items); err != nil {
+		return nil, err
+	}
+	return page, nil
 }
 
 func (s *scheduleService) UpdateSchedule(ctx context.Context, id uint, req models.ScheduleUpdateRequest) (*models.Schedule, error) {
@@ -117,24 +371,118 @@ func (s *scheduleService) UpdateSchedule(ctx context.Context, id uint, req model
 		s.logger.Error("ошибка при получении schedule для обновления", "error", err, "schedule_id", id)
 		return nil, err
 	}
+	if !ClinicAllowed(ctx, schedule.ClinicID) {
+		return nil, ErrClinicForbidden
+	}
+
+	original := *schedule
 
 	if err := s.ValidateScheduleUpdate(schedule, req); err != nil {
 		s.logger.Error("валидация UpdateSchedule провалилась", "error", err, "schedule_id", id)
 		return nil, err
 	}
 
-	if err := s.schedule.Update(ctx, schedule); err != nil {
+	if err := s.resolveLocalUpdate(ctx, schedule, req); err != nil {
+		s.logger.Warn("некорректное местное время смены", "error", err, "schedule_id", id)
+		return nil, err
+	}
+	if !schedule.StartTime.Before(schedule.EndTime) {
+		return nil, constants.ErrInvalidTimeRange
+	}
+	if err := s.normalize(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkClinic(ctx, schedule.DoctorID, schedule.ClinicID, schedule.StartTime, schedule.EndTime); err != nil {
+		s.logger.Warn("смена не прошла проверку филиала", "error", err, "schedule_id", id)
+		return nil, err
+	}
+
+	// Доступность слота можно менять свободно; время, врача, филиал и
+	// кабинет смены с записями — только с переносом приёмов.
+	moved := !schedule.StartTime.Equal(original.StartTime) ||
+		!schedule.EndTime.Equal(original.EndTime) ||
+		schedule.DoctorID != original.DoctorID ||
+		schedule.RoomNumber != original.RoomNumber ||
+		!repository.SameClinic(schedule.ClinicID, original.ClinicID)
+
+	err = s.schedule.Transaction(ctx, func(tx *gorm.DB) error {
+		var booked []models.Appointment
+		if moved {
+			var err error
+			booked, err = s.appointments.GetBookedInRangeTx(tx, original.DoctorID, original.StartTime, original.EndTime)
+			if err != nil {
+				return err
+			}
+			if len(booked) > 0 && !req.Reschedule {
+				return ErrScheduleHasAppointments
+			}
+		}
+
+		if err := s.schedule.UpdateTx(tx, schedule); err != nil {
+			return err
+		}
+
+		// Приёмы идут по времени начала; при сдвиге вперёд переносим с конца,
+		// чтобы перенесённый приём не пересёкся с ещё не перенесённым.
+		shift := schedule.StartTime.Sub(original.StartTime)
+		if shift > 0 {
+			slices.Reverse(booked)
+		}
+		for i := range booked {
+			appointment := &booked[i]
+			previous := *appointment
+			appointment.DoctorID = schedule.DoctorID
+			appointment.StartAt = appointment.StartAt.Add(shift)
+			appointment.EndAt = appointment.EndAt.Add(shift)
+			if err := s.appointments.UpdateTx(tx, appointment); err != nil {
+				s.logger.Warn("не удалось перенести приём вместе со сменой", "error", err, "schedule_id", id, "appointment_id", appointment.ID)
+				return err
+			}
+			if err := publishTx(tx, s.outbox, events.AppointmentRescheduled, events.AggregateAppointment, appointment.ID, events.NewAppointmentRescheduledPayload(appointment, &previous)); err != nil {
+				return err
+			}
+		}
+		if len(booked) > 0 {
+			s.logger.Info("приёмы перенесены вместе со сменой", "schedule_id", id, "count", len(booked), "shift", shift)
+		}
+		return nil
+	})
+	if err != nil {
 		s.logger.Error("ошибка при обновлении schedule", "error", err, "schedule_id", id)
 		return nil, err
 	}
 
 	s.logger.Info("schedule успешно обновлен", "schedule_id", id)
-	return schedule, nil
+	localized, err := s.localize(ctx, []models.Schedule{*schedule})
+	if err != nil {
+		return nil, err
+	}
+	return &localized[0], nil
 }
 
 func (s *scheduleService) DeleteSchedule(ctx context.Context, id uint) error {
 	s.logger.Debug("DeleteSchedule вызван", "schedule_id", id)
-	if err := s.schedule.Delete(ctx, id); err != nil {
+	schedule, err := s.schedule.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("ошибка при получении schedule для удаления", "error", err, "schedule_id", id)
+		return err
+	}
+	if !ClinicAllowed(ctx, schedule.ClinicID) {
+		return ErrClinicForbidden
+	}
+
+	err = s.schedule.Transaction(ctx, func(tx *gorm.DB) error {
+		booked, err := s.appointments.GetBookedInRangeTx(tx, schedule.DoctorID, schedule.StartTime, schedule.EndTime)
+		if err != nil {
+			return err
+		}
+		if len(booked) > 0 {
+			return ErrScheduleHasAppointments
+		}
+		return s.schedule.DeleteTx(tx, id)
+	})
+	if err != nil {
 		s.logger.Error("ошибка при удалении schedule", "error", err, "schedule_id", id)
 		return err
 	}
@@ -184,17 +532,244 @@ func (s *scheduleService) ValidateScheduleUpdate(existing *models.Schedule, req
 		existing.IsAvailable = *req.IsAvailable
 	}
 
+	if req.ClinicID != nil {
+		existing.ClinicID = req.ClinicID
+		if *req.ClinicID == 0 {
+			existing.ClinicID = nil
+		}
+	}
+
 	return nil
 }
 
-func (s *scheduleService) GetAvailableSlots(ctx context.Context, doctorID uint, week int) ([]models.Schedule, error) {
-	start := time.Now().AddDate(0, 0, 7*week)
-	s.logger.Debug("GetAvailableSlots вызван", "doctor_id", doctorID, "week", week, "start", start)
-	slots, err := s.schedule.GetAvailableSlots(ctx, doctorID, start)
+// checkClinic проверяет смену филиала: доступ сотрудника, работу филиала,
+// закрепление врача и часы работы по часовому поясу филиала.
+func (s *scheduleService) checkClinic(ctx context.Context, doctorID uint, clinicID *uint, start, end time.Time) error {
+	clinic, err := s.openClinic(ctx, clinicID, start, end)
+	if err != nil || clinic == nil {
+		return err
+	}
+
+	works, err := s.clinics.DoctorWorksAt(ctx, doctorID, clinic.ID)
+	if err != nil {
+		return err
+	}
+	if !works {
+		return ErrDoctorNotInClinic
+	}
+
+	return nil
+}
+
+// openClinic проверяет всё, кроме закрепления врача: доступ сотрудника,
+// работу филиала и часы работы. Для смены вне филиалов возвращает nil.
+func (s *scheduleService) openClinic(ctx context.Context, clinicID *uint, start, end time.Time) (*models.Clinic, error) {
+	if !ClinicAllowed(ctx, clinicID) {
+		return nil, ErrClinicForbidden
+	}
+	if clinicID == nil {
+		return nil, nil
+	}
+
+	clinic, err := s.clinics.GetByID(ctx, *clinicID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClinicNotFound
+		}
+		return nil, err
+	}
+	if !clinic.IsActive {
+		return nil, ErrClinicInactive
+	}
+
+	ok, err := withinWorkingHours(clinic, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrOutsideClinicHours
+	}
+
+	return clinic, nil
+}
+
+func (s *scheduleService) GetAvailableSlots(ctx context.Context, doctorID uint, clinicID uint, week int) ([]models.Schedule, error) {
+	var clinic *uint
+	if clinicID != 0 {
+		clinic = &clinicID
+	}
+	loc, err := s.location(ctx, clinic)
+	if err != nil {
+		return nil, err
+	}
+
+	// Неделя отсчитывается от местной полуночи и всегда содержит семь
+	// календарных дней, даже если в ней переводят часы.
+	from := localtime.AddDays(localtime.StartOfDay(time.Now(), loc), loc, 7*week)
+	to := localtime.AddDays(from, loc, 7)
+
+	s.logger.Debug("GetAvailableSlots вызван", "doctor_id", doctorID, "clinic_id", clinicID, "week", week, "from", from, "to", to)
+	slots, err := s.schedule.GetAvailableSlots(ctx, doctorID, clinicID, from, to)
 	if err != nil {
 		s.logger.Error("ошибка при получении доступных слотов", "error", err, "doctor_id", doctorID)
 		return nil, err
 	}
 	s.logger.Info("доступные слоты получены", "doctor_id", doctorID, "count", len(slots))
-	return slots, nil
+	return s.localize(ctx, slots)
+}
+
+func (s *scheduleService) NextAvailableSlots(ctx context.Context, doctorIDs []uint, clinicID uint) (map[uint]models.Schedule, error) {
+	slots, err := s.schedule.GetNextAvailable(ctx, doctorIDs, clinicID, time.Now())
+	if err != nil {
+		s.logger.Error("ошибка при получении ближайших слотов", "error", err)
+		return nil, err
+	}
+
+	if slots, err = s.localize(ctx, slots); err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint]models.Schedule, len(slots))
+	for _, slot := range slots {
+		result[slot.DoctorID] = slot
+	}
+	return result, nil
+}
+
+// location возвращает часовой пояс филиала или пояс по умолчанию.
+func (s *scheduleService) location(ctx context.Context, clinicID *uint) (*time.Location, error) {
+	if clinicID == nil {
+		return s.cfg.Location, nil
+	}
+
+	clinic, err := s.clinics.GetByID(ctx, *clinicID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClinicNotFound
+		}
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(clinic.Timezone)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+// resolveLocalCreate переводит смену, заданную по настенным часам филиала,
+// в моменты времени.
+func (s *scheduleService) resolveLocalCreate(ctx context.Context, r *models.ScheduleCreateRequest) error {
+	if r.LocalDate == "" && r.LocalStart == "" && r.LocalEnd == "" {
+		return nil
+	}
+
+	loc, err := s.location(ctx, r.ClinicID)
+	if err != nil {
+		return err
+	}
+
+	if r.StartTime, err = localtime.At(r.LocalDate, r.LocalStart, loc); err != nil {
+		return err
+	}
+	if r.EndTime, err = localtime.At(r.LocalDate, r.LocalEnd, loc); err != nil {
+		return err
+	}
+	return nil
+}
+
+// resolveLocalUpdate применяет изменения местной даты и времени смены;
+// незаданные части берутся из текущей смены по часам филиала.
+func (s *scheduleService) resolveLocalUpdate(ctx context.Context, existing *models.Schedule, req models.ScheduleUpdateRequest) error {
+	if req.LocalDate == nil && req.LocalStart == nil && req.LocalEnd == nil {
+		return nil
+	}
+
+	loc, err := s.location(ctx, existing.ClinicID)
+	if err != nil {
+		return err
+	}
+
+	start, end := existing.StartTime.In(loc), existing.EndTime.In(loc)
+	date := start.Format(localtime.DateLayout)
+	startClock, endClock := start.Format(localtime.ClockLayout), end.Format(localtime.ClockLayout)
+	if req.LocalDate != nil {
+		date = *req.LocalDate
+	}
+	if req.LocalStart != nil {
+		startClock = *req.LocalStart
+	}
+	if req.LocalEnd != nil {
+		endClock = *req.LocalEnd
+	}
+
+	if existing.StartTime, err = localtime.At(date, startClock, loc); err != nil {
+		return err
+	}
+	if existing.EndTime, err = localtime.At(date, endClock, loc); err != nil {
+		return err
+	}
+	return nil
+}
+
+// normalize приводит время смены к UTC и пересчитывает Date как местную
+// полночь дня начала смены.
+func (s *scheduleService) normalize(ctx context.Context, sch *models.Schedule) error {
+	loc, err := s.location(ctx, sch.ClinicID)
+	if err != nil {
+		return err
+	}
+
+	sch.StartTime = sch.StartTime.UTC()
+	sch.EndTime = sch.EndTime.UTC()
+	sch.Date = localtime.StartOfDay(sch.StartTime, loc).UTC()
+	return nil
+}
+
+// localize переводит время смен в часовой пояс их филиалов для ответа API.
+func (s *scheduleService) localize(ctx context.Context, schedules []models.Schedule) ([]models.Schedule, error) {
+	locations := make(map[uint]*time.Location)
+
+	for i := range schedules {
+		sch := &schedules[i]
+
+		loc := s.cfg.Location
+		if sch.ClinicID != nil {
+			cached, ok := locations[*sch.ClinicID]
+			if !ok {
+				var err error
+				if cached, err = s.location(ctx, sch.ClinicID); err != nil {
+					return nil, err
+				}
+				locations[*sch.ClinicID] = cached
+			}
+			loc = cached
+		}
+
+		sch.Date = sch.Date.In(loc)
+		sch.StartTime = sch.StartTime.In(loc)
+		sch.EndTime = sch.EndTime.In(loc)
+		sch.Timezone = loc.String()
+	}
+
+	return schedules, nil
+}
+
+// isScheduleInputError отделяет ошибки данных смены от сбоев хранилища.
+func isScheduleInputError(err error) bool {
+	return errors.Is(err, localtime.ErrInvalidDate) ||
+		errors.Is(err, localtime.ErrInvalidClock) ||
+		errors.Is(err, localtime.ErrNonexistentTime) ||
+		errors.Is(err, ErrClinicNotFound) ||
+		errors.Is(err, ErrClinicInactive) ||
+		errors.Is(err, ErrDoctorNotInClinic) ||
+		errors.Is(err, ErrOutsideClinicHours) ||
+		errors.Is(err, ErrInvalidTimezone)
+}
+
+// isScheduleValidationError — ошибки ValidateScheduleCreate.
+func isScheduleValidationError(err error) bool {
+	return errors.Is(err, constants.ErrInvalidDoctorID) ||
+		errors.Is(err, constants.ErrInvalidTimeRange) ||
+		errors.Is(err, constants.ErrInvalidRoomNumber)
 }
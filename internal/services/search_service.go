@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+)
+
+var (
+	ErrInvalidSearchQuery = errors.New("поисковый запрос должен содержать от 2 до 200 символов")
+	ErrInvalidSearchType  = errors.New("тип поиска должен быть doctor или service")
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+type SearchConfig struct {
+	// MinSimilarity — порог триграммной похожести (0..1), после которого
+	// запрос с опечатками считается совпавшим.
+	MinSimilarity float64
+}
+
+type SearchService interface {
+	// Search ищет врачей и услуги и возвращает их одним списком по
+	// убыванию релевантности.
+	Search(ctx context.Context, params models.SearchQueryParams) (*models.SearchResponse, error)
+}
+
+type searchService struct {
+	repo   repository.SearchRepository
+	cfg    SearchConfig
+	logger *slog.Logger
+}
+
+func NewSearchService(repo repository.SearchRepository, cfg SearchConfig, logger *slog.Logger) SearchService {
+	if cfg.MinSimilarity <= 0 || cfg.MinSimilarity > 1 {
+		cfg.MinSimilarity = 0.4
+	}
+
+	return &searchService{repo: repo, cfg: cfg, logger: logger}
+}
+
+func (s *searchService) Search(ctx context.Context, params models.SearchQueryParams) (*models.SearchResponse, error) {
+	query := strings.Join(strings.Fields(params.Query), " ")
+	if n := utf8.RuneCountInString(query); n < 2 || n > 200 {
+		return nil, ErrInvalidSearchQuery
+	}
+	if params.Type != "" && params.Type != models.SearchDoctor && params.Type != models.SearchService {
+		return nil, ErrInvalidSearchType
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	hits := []models.SearchHit{}
+
+	if params.Type == "" || params.Type == models.SearchDoctor {
+		doctors, err := s.repo.SearchDoctors(ctx, query, s.cfg.MinSimilarity, limit)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, doctors...)
+	}
+
+	if params.Type == "" || params.Type == models.SearchService {
+		services, err := s.repo.SearchServices(ctx, query, s.cfg.MinSimilarity, limit)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, services...)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	s.logger.Info("поиск выполнен", "query", query, "type", params.Type, "count", len(hits))
+	return &models.SearchResponse{Query: query, Hits: hits}, nil
+}
@@ -1,47 +1,69 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"strings"
 
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"github.com/mutsaevz/team-4-dentistry/internal/repository"
 	"gorm.io/gorm"
 )
 
-var ErrServiceNotfound = errors.New("услуга не найдена")
+var (
+	ErrServiceNotfound = errors.New("услуга не найдена")
+	ErrInvalidOffering = errors.New("цена не должна быть отрицательной, а длительность должна быть больше нуля")
+)
 
 type ServService interface {
 	CreateService(req models.ServiceCreateRequest) (*models.Service, error)
 
 	GetServiceByID(id uint) (*models.Service, error)
 
-	ListServices(offset, limit int) ([]models.Service, error)
+	ListServices(offset, limit int, clinicID uint) ([]models.Service, error)
+
+	ListServicesByCategory(category string, offset, limit int, clinicID uint) ([]models.Service, error)
 
-	ListServicesByCategory(category string, offset, limit int) ([]models.Service, error)
+	// ListServicesByCategoryTree возвращает услуги категории вместе с
+	// подкатегориями.
+	ListServicesByCategoryTree(categoryID uint, offset, limit int, clinicID uint) ([]models.Service, error)
 
 	UpdateService(id uint, req models.ServiceUpdateRequest) (*models.Service, error)
 
 	DeleteService(id uint) error
+
+	// ListServiceDoctors возвращает врачей, оказывающих услугу, с их ценами.
+	ListServiceDoctors(ctx context.Context, serviceID uint) ([]models.DoctorOffering, error)
+
+	// SetDoctorOffering добавляет услугу врачу или меняет его цену и
+	// длительность.
+	SetDoctorOffering(ctx context.Context, doctorID, serviceID uint, req models.DoctorOfferingRequest) (*models.DoctorOffering, error)
+
+	RemoveDoctorOffering(ctx context.Context, doctorID, serviceID uint) error
 }
 
 type servService struct {
-	services repository.ServiceRepository
-	logger   *slog.Logger
+	services   repository.ServiceRepository
+	doctors    repository.DoctorRepository
+	categories repository.ServiceCategoryRepository
+	logger     *slog.Logger
 }
 
 func NewServService(
 	services repository.ServiceRepository,
+	doctors repository.DoctorRepository,
+	categories repository.ServiceCategoryRepository,
 	logger *slog.Logger,
 ) ServService {
-	return &servService{services: services, logger: logger}
+	return &servService{services: services, doctors: doctors, categories: categories, logger: logger}
 }
 
 func (s *servService) CreateService(
 	req models.ServiceCreateRequest,
 ) (*models.Service, error) {
-	s.logger.Debug("CreateService called", "name", req.Name, "doctor_id", req.DoctorID)
+	s.logger.Debug("CreateService called", "name", req.Name)
 
 	if err := s.ValidateCreateServ(req); err != nil {
 		s.logger.Error("validation failed for CreateService", "error", err)
@@ -49,12 +71,22 @@ func (s *servService) CreateService(
 	}
 
 	service := &models.Service{
-		DoctorID:    req.DoctorID,
 		Name:        strings.TrimSpace(req.Name),
 		Description: strings.TrimSpace(req.Description),
 		Category:    strings.TrimSpace(req.Category),
 		Duration:    req.Duration,
 		Price:       req.Price,
+
+		RequiredRoomKind:  strings.TrimSpace(req.RequiredRoomKind),
+		RequiredEquipment: req.RequiredEquipment,
+	}
+
+	if req.CategoryID != nil {
+		if err := s.setCategory(service, *req.CategoryID); err != nil {
+			return nil, err
+		}
+	} else if err := s.linkCategoryName(service); err != nil {
+		return nil, err
 	}
 
 	if err := s.services.Create(service); err != nil {
@@ -83,9 +115,9 @@ func (s *servService) GetServiceByID(id uint) (*models.Service, error) {
 	return service, nil
 }
 
-func (s *servService) ListServices(offset, limit int) ([]models.Service, error) {
-	s.logger.Debug("ListServices called", "offset", offset, "limit", limit)
-	services, err := s.services.List(offset, limit)
+func (s *servService) ListServices(offset, limit int, clinicID uint) ([]models.Service, error) {
+	s.logger.Debug("ListServices called", "offset", offset, "limit", limit, "clinic_id", clinicID)
+	services, err := s.services.List(offset, limit, clinicID)
 	if err != nil {
 		s.logger.Error("error listing services", "error", err)
 		return nil, err
@@ -97,9 +129,10 @@ func (s *servService) ListServices(offset, limit int) ([]models.Service, error)
 func (s *servService) ListServicesByCategory(
 	category string,
 	offset,
-	limit int) ([]models.Service, error) {
-	s.logger.Debug("ListServicesByCategory called", "category", category, "offset", offset, "limit", limit)
-	services, err := s.services.ListByCategory(category, offset, limit)
+	limit int,
+	clinicID uint) ([]models.Service, error) {
+	s.logger.Debug("ListServicesByCategory called", "category", category, "offset", offset, "limit", limit, "clinic_id", clinicID)
+	services, err := s.services.ListByCategory(category, offset, limit, clinicID)
 	if err != nil {
 		s.logger.Error("error listing services by category", "error", err, "category", category)
 		return nil, err
@@ -108,6 +141,21 @@ func (s *servService) ListServicesByCategory(
 	return services, nil
 }
 
+func (s *servService) ListServicesByCategoryTree(
+	categoryID uint,
+	offset,
+	limit int,
+	clinicID uint) ([]models.Service, error) {
+	s.logger.Debug("ListServicesByCategoryTree called", "category_id", categoryID, "offset", offset, "limit", limit, "clinic_id", clinicID)
+	services, err := s.services.ListByCategoryTree(categoryID, offset, limit, clinicID)
+	if err != nil {
+		s.logger.Error("error listing services by category tree", "error", err, "category_id", categoryID)
+		return nil, err
+	}
+	s.logger.Info("services by category tree listed", "category_id", categoryID, "count", len(services))
+	return services, nil
+}
+
 func (s *servService) UpdateService(
 	id uint, req models.ServiceUpdateRequest,
 ) (*models.Service, error) {
@@ -122,11 +170,29 @@ func (s *servService) UpdateService(
 		return nil, err
 	}
 
+	category := service.Category
 	if err := s.ApplyServUpdate(service, req); err != nil {
 		s.logger.Error("validation failed when applying service update", "error", err, "service_id", id)
 		return nil, err
 	}
 
+	// Категория из дерева важнее названия, переданного строкой; новое
+	// название без category_id переносит услугу в корневую категорию с этим
+	// названием, а 0 убирает категорию совсем.
+	switch {
+	case req.CategoryID != nil && *req.CategoryID != 0:
+		if err := s.setCategory(service, *req.CategoryID); err != nil {
+			return nil, err
+		}
+	case req.CategoryID != nil:
+		service.CategoryID = nil
+		service.Category = ""
+	case service.Category != category:
+		if err := s.linkCategoryName(service); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.services.Update(service); err != nil {
 		s.logger.Error("failed to update service in repo", "error", err, "service_id", id)
 		return nil, err
@@ -157,12 +223,96 @@ func (s *servService) DeleteService(id uint) error {
 	return nil
 }
 
+func (s *servService) ListServiceDoctors(ctx context.Context, serviceID uint) ([]models.DoctorOffering, error) {
+	if _, err := s.GetServiceByID(serviceID); err != nil {
+		return nil, err
+	}
+
+	offerings, err := s.services.ListOfferings(ctx, serviceID)
+	if err != nil {
+		s.logger.Error("error listing service doctors", "error", err, "service_id", serviceID)
+		return nil, err
+	}
+	return offerings, nil
+}
+
+func (s *servService) SetDoctorOffering(ctx context.Context, doctorID, serviceID uint, req models.DoctorOfferingRequest) (*models.DoctorOffering, error) {
+	if (req.Price != nil && *req.Price < 0) || (req.Duration != nil && *req.Duration <= 0) {
+		return nil, ErrInvalidOffering
+	}
+
+	service, err := s.GetServiceByID(serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.doctors.GetByID(doctorID, ctx); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDoctorNotFound
+		}
+		return nil, err
+	}
+
+	offering := &models.DoctorOffering{
+		DoctorID:  doctorID,
+		ServiceID: service.ID,
+		Price:     req.Price,
+		Duration:  req.Duration,
+	}
+	if err := s.services.SetOffering(ctx, offering); err != nil {
+		s.logger.Error("failed to save doctor offering", "error", err, "doctor_id", doctorID, "service_id", serviceID)
+		return nil, err
+	}
+
+	s.logger.Info("doctor offering saved", "doctor_id", doctorID, "service_id", serviceID)
+	return offering, nil
+}
+
+func (s *servService) RemoveDoctorOffering(ctx context.Context, doctorID, serviceID uint) error {
+	if err := s.services.RemoveOffering(ctx, doctorID, serviceID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return constants.ErrServiceNotOffered
+		}
+		return err
+	}
+
+	s.logger.Info("doctor offering removed", "doctor_id", doctorID, "service_id", serviceID)
+	return nil
+}
+
+// setCategory привязывает услугу к категории дерева и копирует её название.
+func (s *servService) setCategory(service *models.Service, categoryID uint) error {
+	category, err := s.categories.GetByID(context.Background(), categoryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+
+	service.CategoryID = &category.ID
+	service.Category = category.Name
+	return nil
+}
+
+// linkCategoryName привязывает услугу с категорией, заданной только
+// названием, к корневой категории дерева — так же, как это делает миграция
+// строковых категорий.
+func (s *servService) linkCategoryName(service *models.Service) error {
+	category, err := s.categories.EnsureRoot(context.Background(), service.Category)
+	if err != nil {
+		return err
+	}
+
+	service.CategoryID = &category.ID
+	return nil
+}
+
 func (s *servService) ValidateCreateServ(req models.ServiceCreateRequest) error {
 	if strings.TrimSpace(req.Name) == "" {
 		return errors.New("название не должно быть пустым")
 	}
 
-	if strings.TrimSpace(req.Category) == "" {
+	if req.CategoryID == nil && strings.TrimSpace(req.Category) == "" {
 		return errors.New("категория не должна быть пустой")
 	}
 
@@ -174,10 +324,6 @@ func (s *servService) ValidateCreateServ(req models.ServiceCreateRequest) error
 		return errors.New("цена не должна быть отрицательной")
 	}
 
-	if req.DoctorID == 0 {
-		return errors.New("doctor_id обязателен")
-	}
-
 	return nil
 }
 
@@ -215,5 +361,13 @@ func (s *servService) ApplyServUpdate(
 		}
 		service.Category = trimmed
 	}
+
+	if req.RequiredRoomKind != nil {
+		service.RequiredRoomKind = strings.TrimSpace(*req.RequiredRoomKind)
+	}
+
+	if req.RequiredEquipment != nil {
+		service.RequiredEquipment = *req.RequiredEquipment
+	}
 	return nil
 }
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrHoldForbidden   = errors.New("нет доступа к этому удержанию")
+	ErrInvalidHold     = errors.New("некорректный запрос удержания: проверьте врача, услугу и время")
+	ErrSlotUnavailable = errors.New("выбранное время недоступно")
+)
+
+type SlotHoldConfig struct {
+	TTL time.Duration
+}
+
+type SlotHoldService interface {
+	// Hold резервирует время врача на TTL и возвращает удержание с токеном,
+	// который передаётся в hold_token при создании записи.
+	Hold(ctx context.Context, userID uint, role string, req models.SlotHoldCreateRequest) (*models.SlotHold, error)
+
+	Release(ctx context.Context, userID uint, role string, token string) error
+
+	ExpireHolds(ctx context.Context) error
+}
+
+type slotHoldService struct {
+	holds        repository.SlotHoldRepository
+	appointments repository.AppointmentRepository
+	services     repository.ServiceRepository
+	schedules    repository.ScheduleRepository
+	waitlist     repository.WaitlistRepository
+	cfg          SlotHoldConfig
+	logger       *slog.Logger
+}
+
+func NewSlotHoldService(
+	holds repository.SlotHoldRepository,
+	appointments repository.AppointmentRepository,
+	serviceRepo repository.ServiceRepository,
+	schedules repository.ScheduleRepository,
+	waitlist repository.WaitlistRepository,
+	cfg SlotHoldConfig,
+	logger *slog.Logger,
+) SlotHoldService {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 10 * time.Minute
+	}
+
+	return &slotHoldService{
+		holds:        holds,
+		appointments: appointments,
+		services:     serviceRepo,
+		schedules:    schedules,
+		waitlist:     waitlist,
+		cfg:          cfg,
+		logger:       logger,
+	}
+}
+
+func (s *slotHoldService) Hold(ctx context.Context, userID uint, role string, req models.SlotHoldCreateRequest) (*models.SlotHold, error) {
+	patientID := userID
+	if models.Role(role) == models.Admin && req.PatientID != 0 {
+		patientID = req.PatientID
+	}
+
+	if req.DoctorID == 0 || req.ServiceID == 0 {
+		return nil, ErrInvalidHold
+	}
+	if !req.StartAt.After(time.Now()) {
+		return nil, constants.ErrInvalidAppointmentTime
+	}
+
+	service, err := s.services.GetForDoctor(ctx, req.DoctorID, req.ServiceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, constants.ErrServiceNotOffered
+		}
+		return nil, err
+	}
+	end := req.StartAt.Add(time.Duration(service.Duration) * time.Minute)
+
+	if err := s.checkFree(ctx, req.DoctorID, req.StartAt, end); err != nil {
+		return nil, err
+	}
+
+	// У пациента одновременно может быть только одно удержание: выбор нового
+	// времени освобождает предыдущее.
+	if err := s.holds.ReleaseByPatient(ctx, patientID); err != nil {
+		return nil, err
+	}
+
+	token, err := newOfferToken()
+	if err != nil {
+		return nil, err
+	}
+
+	hold := &models.SlotHold{
+		DoctorID:  req.DoctorID,
+		PatientID: patientID,
+		ServiceID: req.ServiceID,
+		StartAt:   req.StartAt,
+		EndAt:     end,
+		Token:     token,
+		ExpiresAt: time.Now().Add(s.cfg.TTL),
+		Status:    models.HoldActive,
+	}
+
+	created, err := s.holds.CreateIfFree(ctx, hold)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, constants.ErrSlotHeld
+	}
+
+	return hold, nil
+}
+
+// checkFree проверяет, что время входит в расписание врача и не
+// занято записью или предложением из листа ожидания.
+func (s *slotHoldService) checkFree(ctx context.Context, doctorID uint, start, end time.Time) error {
+	schedules, err := s.schedules.GetSchedulesByDoctorID(ctx, doctorID)
+	if err != nil {
+		return err
+	}
+
+	inSchedule := false
+	for _, sc := range schedules {
+		if !sc.StartTime.After(start) && !sc.EndTime.Before(end) {
+			inSchedule = true
+			break
+		}
+	}
+	if !inSchedule {
+		return constants.ErrTimeNotInSchedule
+	}
+
+	busy, err := s.appointments.HasDoctorConflict(doctorID, start, end)
+	if err != nil {
+		return err
+	}
+	if busy {
+		return ErrSlotUnavailable
+	}
+
+	offered, err := s.waitlist.HasActiveOffer(ctx, doctorID, start, end)
+	if err != nil {
+		return err
+	}
+	if offered {
+		return constants.ErrSlotHeld
+	}
+
+	return nil
+}
+
+func (s *slotHoldService) Release(ctx context.Context, userID uint, role string, token string) error {
+	hold, err := s.holds.GetByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrHoldNotFound
+		}
+		return err
+	}
+
+	if models.Role(role) != models.Admin && hold.PatientID != userID {
+		return ErrHoldForbidden
+	}
+	if hold.Status != models.HoldActive {
+		return ErrHoldNotFound
+	}
+
+	hold.Status = models.HoldReleased
+	if err := s.holds.Update(ctx, hold); err != nil {
+		return err
+	}
+
+	s.logger.Info("удержание слота снято", "hold_id", hold.ID, "doctor_id", hold.DoctorID)
+	return nil
+}
+
+// ExpireHolds помечает брошенные удержания истёкшими. Доступность слота от
+// этого не зависит — просроченное удержание и так не учитывается, — но
+// статус нужен для отчётов и чтобы токен нельзя было использовать.
+func (s *slotHoldService) ExpireHolds(ctx context.Context) error {
+	n, err := s.holds.ExpireBefore(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		s.logger.Info("просроченные удержания слотов сняты", "count", n)
+	}
+	return nil
+}
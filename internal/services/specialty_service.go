@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSpecialtyNotFound = errors.New("специализация не найдена")
+	ErrInvalidSpecialty  = errors.New("некорректная специализация: нужны название и slug из латинских букв, цифр и дефисов")
+	ErrSpecialtyExists   = errors.New("специализация с таким slug уже есть")
+	ErrSpecialtyInUse    = errors.New("специализация указана у врачей")
+)
+
+var specialtySlug = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type SpecialtyService interface {
+	Create(ctx context.Context, req models.SpecialtyCreateRequest) (*models.Specialty, error)
+
+	List(ctx context.Context) ([]models.Specialty, error)
+
+	Update(ctx context.Context, id uint, req models.SpecialtyUpdateRequest) (*models.Specialty, error)
+
+	// Delete удаляет специализацию, если она не указана ни у одного врача.
+	Delete(ctx context.Context, id uint) error
+}
+
+type specialtyService struct {
+	repo   repository.SpecialtyRepository
+	logger *slog.Logger
+}
+
+func NewSpecialtyService(repo repository.SpecialtyRepository, logger *slog.Logger) SpecialtyService {
+	return &specialtyService{repo: repo, logger: logger}
+}
+
+func (s *specialtyService) Create(ctx context.Context, req models.SpecialtyCreateRequest) (*models.Specialty, error) {
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	name := strings.TrimSpace(req.Name)
+	if name == "" || !specialtySlug.MatchString(slug) {
+		return nil, ErrInvalidSpecialty
+	}
+
+	if _, err := s.repo.GetBySlug(ctx, slug); err == nil {
+		return nil, ErrSpecialtyExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	specialty := &models.Specialty{
+		Slug:        slug,
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+	}
+	if err := s.repo.Create(ctx, specialty); err != nil {
+		return nil, err
+	}
+
+	return specialty, nil
+}
+
+func (s *specialtyService) List(ctx context.Context) ([]models.Specialty, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *specialtyService) Update(ctx context.Context, id uint, req models.SpecialtyUpdateRequest) (*models.Specialty, error) {
+	specialty, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, ErrInvalidSpecialty
+		}
+		specialty.Name = name
+	}
+	if req.Description != nil {
+		specialty.Description = strings.TrimSpace(*req.Description)
+	}
+
+	if err := s.repo.Update(ctx, specialty); err != nil {
+		return nil, err
+	}
+
+	return specialty, nil
+}
+
+func (s *specialtyService) Delete(ctx context.Context, id uint) error {
+	if _, err := s.get(ctx, id); err != nil {
+		return err
+	}
+
+	inUse, err := s.repo.InUse(ctx, id)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrSpecialtyInUse
+	}
+
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *specialtyService) get(ctx context.Context, id uint) (*models.Specialty, error) {
+	specialty, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSpecialtyNotFound
+		}
+		return nil, err
+	}
+	return specialty, nil
+}
@@ -0,0 +1,576 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/events"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/notifications"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWaitlistEntryNotFound = errors.New("заявка в листе ожидания не найдена")
+	ErrWaitlistForbidden     = errors.New("нет доступа к этой заявке")
+	ErrInvalidWaitlistEntry  = errors.New("некорректная заявка: проверьте врача, услугу, даты и желаемое время (HH:MM)")
+	ErrOfferNotFound         = errors.New("предложение не найдено")
+	ErrOfferNotPending       = errors.New("предложение уже неактуально")
+)
+
+type WaitlistConfig struct {
+	OfferTTL time.Duration
+	BaseURL  string
+	// Location — часовой пояс для окон вне филиалов; окна филиала
+	// сравниваются с датами и желаемым временем по поясу филиала.
+	Location *time.Location
+}
+
+type WaitlistService interface {
+	Join(ctx context.Context, userID uint, role string, req models.WaitlistJoinRequest) (*models.WaitlistEntry, error)
+
+	Leave(ctx context.Context, userID uint, role string, id uint) error
+
+	ListMy(ctx context.Context, userID uint) ([]models.WaitlistEntry, error)
+
+	List(ctx context.Context, params models.WaitlistQueryParams) ([]models.WaitlistEntry, error)
+
+	ListMyOffers(ctx context.Context, userID uint) ([]models.WaitlistOffer, error)
+
+	// Предложения адресуются секретным токеном из ссылки в уведомлении,
+	// поэтому подтвердить запись можно без входа в систему.
+	GetOffer(ctx context.Context, token string) (*models.WaitlistOffer, error)
+
+	AcceptOffer(ctx context.Context, token string) (*models.Appointment, error)
+
+	DeclineOffer(ctx context.Context, token string) error
+
+	// OfferSlot предлагает окно [start, slotEnd) врача следующему подходящему
+	// пациенту из очереди.
+	OfferSlot(ctx context.Context, doctorID uint, start, slotEnd time.Time) error
+
+	HandleAppointmentCancelled(ctx context.Context, event *models.OutboxEvent) error
+
+	HandleScheduleCreated(ctx context.Context, event *models.OutboxEvent) error
+
+	ExpireOffers(ctx context.Context) error
+}
+
+type waitlistService struct {
+	waitlist      repository.WaitlistRepository
+	appointments  repository.AppointmentRepository
+	services      repository.ServiceRepository
+	schedules     repository.ScheduleRepository
+	clinics       repository.ClinicRepository
+	users         repository.UserRepository
+	booking       AppointmentService
+	notifications NotificationService
+	dispatcher    *notifications.Dispatcher
+	cfg           WaitlistConfig
+	logger        *slog.Logger
+}
+
+func NewWaitlistService(
+	waitlist repository.WaitlistRepository,
+	appointments repository.AppointmentRepository,
+	serviceRepo repository.ServiceRepository,
+	schedules repository.ScheduleRepository,
+	clinics repository.ClinicRepository,
+	users repository.UserRepository,
+	booking AppointmentService,
+	notificationService NotificationService,
+	dispatcher *notifications.Dispatcher,
+	cfg WaitlistConfig,
+	logger *slog.Logger,
+) WaitlistService {
+	if cfg.OfferTTL <= 0 {
+		cfg.OfferTTL = 30 * time.Minute
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &waitlistService{
+		waitlist:      waitlist,
+		appointments:  appointments,
+		services:      serviceRepo,
+		schedules:     schedules,
+		clinics:       clinics,
+		users:         users,
+		booking:       booking,
+		notifications: notificationService,
+		dispatcher:    dispatcher,
+		cfg:           cfg,
+		logger:        logger,
+	}
+}
+
+func (s *waitlistService) Join(ctx context.Context, userID uint, role string, req models.WaitlistJoinRequest) (*models.WaitlistEntry, error) {
+	patientID := userID
+	if models.Role(role) == models.Admin && req.PatientID != 0 {
+		patientID = req.PatientID
+	}
+
+	if req.DoctorID == 0 || req.ServiceID == 0 || req.DateTo.Before(req.DateFrom) {
+		return nil, ErrInvalidWaitlistEntry
+	}
+	from, okFrom := parseClock(req.PreferredFrom)
+	to, okTo := parseClock(req.PreferredTo)
+	if !okFrom || !okTo || (req.PreferredFrom != "" && req.PreferredTo != "" && to <= from) {
+		return nil, ErrInvalidWaitlistEntry
+	}
+
+	if _, err := s.services.GetForDoctor(ctx, req.DoctorID, req.ServiceID); err != nil {
+		return nil, ErrInvalidWaitlistEntry
+	}
+
+	entry := &models.WaitlistEntry{
+		PatientID:     patientID,
+		DoctorID:      req.DoctorID,
+		ServiceID:     req.ServiceID,
+		DateFrom:      req.DateFrom,
+		DateTo:        req.DateTo,
+		PreferredFrom: req.PreferredFrom,
+		PreferredTo:   req.PreferredTo,
+		Status:        models.WaitlistWaiting,
+	}
+
+	if err := s.waitlist.CreateEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (s *waitlistService) Leave(ctx context.Context, userID uint, role string, id uint) error {
+	entry, err := s.waitlist.GetEntry(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWaitlistEntryNotFound
+		}
+		return err
+	}
+
+	if models.Role(role) != models.Admin && entry.PatientID != userID {
+		return ErrWaitlistForbidden
+	}
+
+	entry.Status = models.WaitlistCancelled
+	return s.waitlist.Transaction(ctx, func(tx *gorm.DB) error {
+		return s.waitlist.UpdateEntryTx(tx, entry)
+	})
+}
+
+func (s *waitlistService) ListMy(ctx context.Context, userID uint) ([]models.WaitlistEntry, error) {
+	return s.waitlist.ListEntries(ctx, models.WaitlistQueryParams{PatientID: userID})
+}
+
+func (s *waitlistService) List(ctx context.Context, params models.WaitlistQueryParams) ([]models.WaitlistEntry, error) {
+	return s.waitlist.ListEntries(ctx, params)
+}
+
+func (s *waitlistService) ListMyOffers(ctx context.Context, userID uint) ([]models.WaitlistOffer, error) {
+	return s.waitlist.ListOffersByPatient(ctx, userID)
+}
+
+func (s *waitlistService) GetOffer(ctx context.Context, token string) (*models.WaitlistOffer, error) {
+	offer, _, err := s.loadOffer(ctx, token)
+	return offer, err
+}
+
+func (s *waitlistService) AcceptOffer(ctx context.Context, token string) (*models.Appointment, error) {
+	offer, _, err := s.loadOffer(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Предложение и заявка блокируются, чтобы повторное принятие, истечение
+	// или выход из очереди не прошли параллельно с записью; запись и
+	// закрытие предложения фиксируются вместе.
+	var appointment *models.Appointment
+	err = s.waitlist.Transaction(ctx, func(tx *gorm.DB) error {
+		if offer, err = s.waitlist.GetOfferForUpdateTx(tx, offer.ID); err != nil {
+			return err
+		}
+		if offer.Status != models.OfferPending || time.Now().After(offer.ExpiresAt) {
+			return ErrOfferNotPending
+		}
+		entry, err := s.waitlist.GetEntryForUpdateTx(tx, offer.EntryID)
+		if err != nil {
+			return err
+		}
+		if entry.Status != models.WaitlistOffered {
+			return ErrOfferNotPending
+		}
+
+		if appointment, err = s.booking.CreateTx(ctx, tx, &models.AppointmentCreateRequest{
+			PatientID: offer.PatientID,
+			DoctorID:  offer.DoctorID,
+			ServiceID: offer.ServiceID,
+			StartAt:   offer.StartAt,
+		}); err != nil {
+			return err
+		}
+
+		offer.Status = models.OfferAccepted
+		offer.AppointmentID = &appointment.ID
+		entry.Status = models.WaitlistBooked
+
+		if err := s.waitlist.UpdateOfferTx(tx, offer); err != nil {
+			return err
+		}
+		return s.waitlist.UpdateEntryTx(tx, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("предложение из листа ожидания принято", "offer_id", offer.ID, "appointment_id", appointment.ID)
+	return appointment, nil
+}
+
+func (s *waitlistService) DeclineOffer(ctx context.Context, token string) error {
+	offer, entry, err := s.loadOffer(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if offer.Status != models.OfferPending {
+		return ErrOfferNotPending
+	}
+
+	return s.release(ctx, offer, entry, models.OfferDeclined)
+}
+
+func (s *waitlistService) loadOffer(ctx context.Context, token string) (*models.WaitlistOffer, *models.WaitlistEntry, error) {
+	offer, err := s.waitlist.GetOfferByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrOfferNotFound
+		}
+		return nil, nil, err
+	}
+
+	entry, err := s.waitlist.GetEntry(ctx, offer.EntryID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return offer, entry, nil
+}
+
+// release закрывает предложение, возвращает пациента в очередь и
+// предлагает окно следующему.
+func (s *waitlistService) release(ctx context.Context, offer *models.WaitlistOffer, entry *models.WaitlistEntry, status models.WaitlistOfferStatus) error {
+	offer.Status = status
+
+	// Заявка перечитывается под блокировкой: пациент мог выйти из очереди,
+	// пока предложение было открыто.
+	if err := s.waitlist.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.waitlist.UpdateOfferTx(tx, offer); err != nil {
+			return err
+		}
+		entry, err := s.waitlist.GetEntryForUpdateTx(tx, entry.ID)
+		if err != nil {
+			return err
+		}
+		if entry.Status != models.WaitlistOffered {
+			return nil
+		}
+		entry.Status = models.WaitlistWaiting
+		return s.waitlist.UpdateEntryTx(tx, entry)
+	}); err != nil {
+		return err
+	}
+
+	s.logger.Info("предложение из листа ожидания закрыто", "offer_id", offer.ID, "status", status)
+
+	if !offer.StartAt.After(time.Now()) {
+		return nil
+	}
+	return s.OfferSlot(ctx, offer.DoctorID, offer.StartAt, offer.SlotEndAt)
+}
+
+func (s *waitlistService) OfferSlot(ctx context.Context, doctorID uint, start, slotEnd time.Time) error {
+	if !start.After(time.Now()) {
+		return nil
+	}
+
+	entries, err := s.waitlist.ListWaiting(ctx, doctorID)
+	if err != nil {
+		return err
+	}
+
+	loc, err := s.slotLocation(ctx, doctorID, start)
+	if err != nil {
+		return err
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+
+		if !eligible(entry, start.In(loc)) {
+			continue
+		}
+
+		service, err := s.services.GetForDoctor(ctx, doctorID, entry.ServiceID)
+		if err != nil {
+			continue
+		}
+		end := start.Add(time.Duration(service.Duration) * time.Minute)
+		if end.After(slotEnd) {
+			continue
+		}
+
+		offered, err := s.waitlist.WasOffered(ctx, entry.ID, doctorID, start)
+		if err != nil {
+			return err
+		}
+		if offered {
+			continue
+		}
+
+		busy, err := s.appointments.HasDoctorConflict(doctorID, start, end)
+		if err != nil {
+			return err
+		}
+		held, err := s.waitlist.HasActiveOffer(ctx, doctorID, start, end)
+		if err != nil {
+			return err
+		}
+		if busy || held {
+			return nil
+		}
+
+		return s.offer(ctx, entry, start, end, slotEnd, loc)
+	}
+
+	// Желающих нет — окно снова доступно для обычной записи, если его
+	// тем временем не заняли.
+	return s.appointments.Transaction(func(tx *gorm.DB) error {
+		return s.appointments.SetSlotAvailabilityTx(tx.WithContext(ctx), doctorID, start, true)
+	})
+}
+
+// slotLocation возвращает часовой пояс филиала, в смене которого лежит окно.
+func (s *waitlistService) slotLocation(ctx context.Context, doctorID uint, start time.Time) (*time.Location, error) {
+	return newClinicLocations(s.clinics, s.cfg.Location).forShift(ctx, s.schedules, doctorID, start)
+}
+
+func (s *waitlistService) offer(ctx context.Context, entry *models.WaitlistEntry, start, end, slotEnd time.Time, loc *time.Location) error {
+	token, err := newOfferToken()
+	if err != nil {
+		return err
+	}
+
+	offer := &models.WaitlistOffer{
+		EntryID:   entry.ID,
+		PatientID: entry.PatientID,
+		DoctorID:  entry.DoctorID,
+		ServiceID: entry.ServiceID,
+		StartAt:   start,
+		EndAt:     end,
+		SlotEndAt: slotEnd,
+		Token:     token,
+		ExpiresAt: time.Now().Add(s.cfg.OfferTTL),
+		Status:    models.OfferPending,
+	}
+	entry.Status = models.WaitlistOffered
+
+	// Удерживаемый слот скрывается вместе с созданием предложения.
+	if err := s.waitlist.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.waitlist.CreateOfferTx(tx, offer); err != nil {
+			return err
+		}
+		if err := s.waitlist.UpdateEntryTx(tx, entry); err != nil {
+			return err
+		}
+		return s.appointments.SetSlotAvailabilityTx(tx, entry.DoctorID, start, false)
+	}); err != nil {
+		return err
+	}
+
+	s.notifyOffer(ctx, offer, loc)
+	return nil
+}
+
+func (s *waitlistService) notifyOffer(ctx context.Context, offer *models.WaitlistOffer, loc *time.Location) {
+	user, err := s.users.GetByID(offer.PatientID)
+	if err != nil {
+		s.logger.Warn("не удалось получить пациента для уведомления о предложении", "error", err, "offer_id", offer.ID)
+		return
+	}
+
+	pref, err := s.notifications.GetPreferences(ctx, offer.PatientID)
+	if err != nil {
+		s.logger.Warn("не удалось получить настройки уведомлений", "error", err, "patient_id", offer.PatientID)
+		return
+	}
+
+	data := notifications.WaitlistOfferData{
+		PatientName: strings.TrimSpace(user.FirstName + " " + user.LastName),
+		StartAt:     offer.StartAt.In(loc).Format("02.01.2006 15:04"),
+		ExpiresAt:   offer.ExpiresAt.In(loc).Format("15:04"),
+		AcceptURL:   fmt.Sprintf("%s/api/waitlist/offers/%s", s.cfg.BaseURL, offer.Token),
+	}
+
+	for _, channel := range s.dispatcher.Channels() {
+		recipient := ""
+		switch {
+		case channel == models.ChannelEmail && pref.EmailEnabled:
+			recipient = user.Email
+		case channel == models.ChannelSMS && pref.SMSEnabled:
+			recipient = user.Phone
+		}
+		if recipient == "" {
+			continue
+		}
+
+		subject, body, err := notifications.RenderWaitlistOffer(pref.Language, channel, data)
+		if err != nil {
+			s.logger.Error("не удалось сформировать уведомление о предложении", "error", err)
+			continue
+		}
+
+		if err := s.dispatcher.Send(ctx, notifications.Message{
+			Channel:   channel,
+			Recipient: recipient,
+			Subject:   subject,
+			Body:      body,
+		}); err != nil {
+			s.logger.Warn("не удалось отправить уведомление о предложении", "error", err, "offer_id", offer.ID, "channel", channel)
+		}
+	}
+}
+
+// eligible сверяет окно, заданное по местному времени филиала, с датами
+// и желаемым временем заявки.
+func eligible(entry *models.WaitlistEntry, local time.Time) bool {
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+	from := time.Date(entry.DateFrom.Year(), entry.DateFrom.Month(), entry.DateFrom.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(entry.DateTo.Year(), entry.DateTo.Month(), entry.DateTo.Day(), 0, 0, 0, 0, time.UTC)
+	if day.Before(from) || day.After(to) {
+		return false
+	}
+
+	minutes := local.Hour()*60 + local.Minute()
+	if pf, ok := parseClock(entry.PreferredFrom); ok && entry.PreferredFrom != "" && minutes < pf {
+		return false
+	}
+	if pt, ok := parseClock(entry.PreferredTo); ok && entry.PreferredTo != "" && minutes >= pt {
+		return false
+	}
+
+	return true
+}
+
+func (s *waitlistService) HandleAppointmentCancelled(ctx context.Context, event *models.OutboxEvent) error {
+	var p events.AppointmentPayload
+	if err := events.Decode(event, &p); err != nil {
+		return err
+	}
+	return s.OfferSlot(ctx, p.DoctorID, p.StartAt, p.EndAt)
+}
+
+func (s *waitlistService) HandleScheduleCreated(ctx context.Context, event *models.OutboxEvent) error {
+	var p events.SchedulePayload
+	if err := events.Decode(event, &p); err != nil {
+		return err
+	}
+
+	for _, slot := range p.Slots {
+		if err := s.OfferSlot(ctx, p.DoctorID, slot.StartTime, slot.EndTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *waitlistService) ExpireOffers(ctx context.Context) error {
+	now := time.Now()
+
+	today, err := s.earliestToday(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	if n, err := s.waitlist.ExpireEntries(ctx, today); err != nil {
+		return err
+	} else if n > 0 {
+		s.logger.Info("устаревшие заявки листа ожидания закрыты", "count", n)
+	}
+
+	offers, err := s.waitlist.ListExpiredOffers(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for i := range offers {
+		offer := &offers[i]
+
+		entry, err := s.waitlist.GetEntry(ctx, offer.EntryID)
+		if err != nil {
+			s.logger.Error("не удалось получить заявку для просроченного предложения", "error", err, "offer_id", offer.ID)
+			continue
+		}
+
+		if err := s.release(ctx, offer, entry, models.OfferExpired); err != nil {
+			s.logger.Error("не удалось обработать просроченное предложение", "error", err, "offer_id", offer.ID)
+		}
+	}
+
+	return nil
+}
+
+// earliestToday — самая ранняя из сегодняшних дат по поясам филиалов:
+// заявка без филиала закрывается, только когда её последний день
+// закончился везде, где принимает врач.
+func (s *waitlistService) earliestToday(ctx context.Context, now time.Time) (time.Time, error) {
+	clinics, err := s.clinics.List(ctx, models.ClinicQueryParams{})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	local := now.In(s.cfg.Location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	for _, clinic := range clinics {
+		loc, err := time.LoadLocation(clinic.Timezone)
+		if err != nil {
+			continue
+		}
+		local := now.In(loc)
+		if day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC); day.Before(today) {
+			today = day
+		}
+	}
+	return today, nil
+}
+
+// parseClock разбирает "HH:MM" в минуты от полуночи; пустая строка допустима.
+func parseClock(v string) (int, bool) {
+	if v == "" {
+		return 0, true
+	}
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+func newOfferToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
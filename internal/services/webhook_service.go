@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/mutsaevz/team-4-dentistry/internal/events"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/repository"
+	"github.com/mutsaevz/team-4-dentistry/internal/webhooks"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook подписка не найдена")
+	ErrWebhookDeliveryNotFound = errors.New("webhook доставка не найдена")
+	ErrInvalidWebhookURL       = errors.New("некорректный URL webhook: ожидается http(s) адрес")
+	ErrInvalidWebhookEvents    = errors.New("некорректный список событий webhook")
+)
+
+// WebhookEventTypes — события, на которые могут подписаться партнёры.
+var WebhookEventTypes = []string{
+	events.AppointmentBooked,
+	events.AppointmentCancelled,
+	events.AppointmentRescheduled,
+	events.AppointmentCompleted,
+	events.AppointmentNoShow,
+	events.ReviewCreated,
+}
+
+type WebhookConfig struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	BatchSize   int
+}
+
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, req models.WebhookSubscriptionCreateRequest) (*models.WebhookSubscriptionCreated, error)
+
+	GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error)
+
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+
+	UpdateSubscription(ctx context.Context, id uint, req models.WebhookSubscriptionUpdateRequest) (*models.WebhookSubscription, error)
+
+	DeleteSubscription(ctx context.Context, id uint) error
+
+	ListDeliveries(ctx context.Context, params models.WebhookDeliveryQueryParams) ([]models.WebhookDelivery, error)
+
+	Redeliver(ctx context.Context, deliveryID uint) (*models.WebhookDelivery, error)
+
+	// HandleEvent ставит событие outbox в очередь всем подходящим подпискам.
+	HandleEvent(ctx context.Context, event *models.OutboxEvent) error
+
+	DeliverDue(ctx context.Context) error
+}
+
+type webhookService struct {
+	repo   repository.WebhookRepository
+	client *webhooks.Client
+	cfg    WebhookConfig
+	logger *slog.Logger
+}
+
+func NewWebhookService(repo repository.WebhookRepository, client *webhooks.Client, cfg WebhookConfig, logger *slog.Logger) WebhookService {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 30 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 6 * time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+
+	return &webhookService{repo: repo, client: client, cfg: cfg, logger: logger}
+}
+
+func (s *webhookService) CreateSubscription(ctx context.Context, req models.WebhookSubscriptionCreateRequest) (*models.WebhookSubscriptionCreated, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEvents(req.EventTypes); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret = newWebhookSecret()
+	}
+
+	sub := &models.WebhookSubscription{
+		Name:       req.Name,
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     secret,
+		IsActive:   true,
+	}
+
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	return &models.WebhookSubscriptionCreated{Subscription: sub, Secret: secret}, nil
+}
+
+func (s *webhookService) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	sub, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *webhookService) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(ctx)
+}
+
+func (s *webhookService) UpdateSubscription(ctx context.Context, id uint, req models.WebhookSubscriptionUpdateRequest) (*models.WebhookSubscription, error) {
+	sub, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		sub.Name = *req.Name
+	}
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+		sub.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		if err := validateWebhookEvents(*req.EventTypes); err != nil {
+			return nil, err
+		}
+		sub.EventTypes = *req.EventTypes
+	}
+	if req.Secret != nil && *req.Secret != "" {
+		sub.Secret = *req.Secret
+	}
+	if req.IsActive != nil {
+		sub.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+func (s *webhookService) DeleteSubscription(ctx context.Context, id uint) error {
+	if _, err := s.GetSubscription(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(ctx, id)
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, params models.WebhookDeliveryQueryParams) ([]models.WebhookDelivery, error) {
+	return s.repo.ListDeliveries(ctx, params)
+}
+
+// Redeliver ставит доставку (в том числе из dead-letter) на немедленную
+// повторную отправку с обнулённым счётчиком попыток.
+func (s *webhookService) Redeliver(ctx context.Context, deliveryID uint) (*models.WebhookDelivery, error) {
+	delivery, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("webhook поставлен на повторную доставку", "delivery_id", delivery.ID)
+	return delivery, nil
+}
+
+type webhookEnvelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+func (s *webhookService) HandleEvent(ctx context.Context, event *models.OutboxEvent) error {
+	subs, err := s.repo.ListActiveSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(webhookEnvelope{
+		ID:         event.EventID,
+		Type:       event.Type,
+		OccurredAt: event.CreatedAt,
+		Data:       json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if !slices.Contains(sub.EventTypes, event.Type) {
+			continue
+		}
+
+		delivery := &models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.EventID,
+			EventType:      event.Type,
+			Payload:        string(body),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  time.Now(),
+		}
+		if err := s.repo.EnqueueDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *webhookService) DeliverDue(ctx context.Context) error {
+	due, err := s.repo.ListDueDeliveries(ctx, time.Now(), s.cfg.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	subs := make(map[uint]*models.WebhookSubscription)
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		delivery := &due[i]
+
+		sub, ok := subs[delivery.SubscriptionID]
+		if !ok {
+			sub, err = s.repo.GetSubscription(ctx, delivery.SubscriptionID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			subs[delivery.SubscriptionID] = sub
+		}
+
+		s.attempt(ctx, sub, delivery)
+	}
+
+	return nil
+}
+
+func (s *webhookService) attempt(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) {
+	now := time.Now()
+	delivery.Attempts++
+
+	var sendErr error
+	if sub == nil || !sub.IsActive {
+		sendErr = ErrWebhookNotFound
+		delivery.Attempts = s.cfg.MaxAttempts
+	} else {
+		delivery.ResponseCode, sendErr = s.client.Send(ctx, webhooks.Request{
+			URL:       sub.URL,
+			Secret:    sub.Secret,
+			EventID:   delivery.EventID,
+			EventType: delivery.EventType,
+			Body:      []byte(delivery.Payload),
+		})
+	}
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= s.cfg.MaxAttempts:
+		delivery.Status = models.WebhookDeliveryDead
+		delivery.LastError = sendErr.Error()
+		s.logger.Error("webhook перемещён в dead-letter", "delivery_id", delivery.ID, "subscription_id", delivery.SubscriptionID, "error", sendErr)
+	default:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = now.Add(s.backoff(delivery.Attempts))
+		s.logger.Warn("webhook не доставлен, будет повтор", "delivery_id", delivery.ID, "attempt", delivery.Attempts, "error", sendErr)
+	}
+
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		s.logger.Error("не удалось сохранить результат доставки webhook", "error", err, "delivery_id", delivery.ID)
+	}
+}
+
+func (s *webhookService) backoff(attempt int) time.Duration {
+	delay := s.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= s.cfg.MaxBackoff {
+			return s.cfg.MaxBackoff
+		}
+	}
+	return delay
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.ParseRequestURI(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+func validateWebhookEvents(types []string) error {
+	if len(types) == 0 {
+		return ErrInvalidWebhookEvents
+	}
+	for _, t := range types {
+		if !slices.Contains(WebhookEventTypes, t) {
+			return ErrInvalidWebhookEvents
+		}
+	}
+	return nil
+}
+
+func newWebhookSecret() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
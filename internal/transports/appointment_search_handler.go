@@ -0,0 +1,85 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/localtime"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type AppointmentSearchHandler struct {
+	service services.AppointmentSearchService
+	logger  *slog.Logger
+}
+
+func NewAppointmentSearchHandler(service services.AppointmentSearchService, logger *slog.Logger) *AppointmentSearchHandler {
+	return &AppointmentSearchHandler{service: service, logger: logger}
+}
+
+// RegisterRoutes регистрирует поиск и доску приёмов для регистратуры.
+// protected должна проходить через ClinicScope.
+func (h *AppointmentSearchHandler) RegisterRoutes(protected *gin.RouterGroup) {
+	staff := protected.Group("/appointments")
+	staff.Use(RequireRole("admin"))
+	staff.GET("/search", h.Search)
+	staff.GET("/board", h.Board)
+}
+
+// Search: ?patient=Иван&doctor_id=3&service_id=5&status=scheduled,pending_payment&paid=false&from=2025-03-01&to=2025-03-07
+func (h *AppointmentSearchHandler) Search(c *gin.Context) {
+	q, ok := bindListQuery(c, "patient")
+	if !ok {
+		return
+	}
+
+	page, err := h.service.Search(c.Request.Context(), q, c.Query("patient"))
+	if err != nil {
+		h.logger.Error("Ошибка поиска записей (appointments)", "error", err.Error())
+		c.JSON(appointmentSearchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// Board: ?view=week&date=2025-03-05&clinic_id=1 и те же фильтры, что у Search,
+// кроме from, to, limit, cursor и sort.
+func (h *AppointmentSearchHandler) Board(c *gin.Context) {
+	q, ok := bindListQuery(c, "patient", "view", "date")
+	if !ok {
+		return
+	}
+
+	board, err := h.service.Board(c.Request.Context(), models.AppointmentBoardQuery{
+		View: c.Query("view"),
+		Date: c.Query("date"),
+	}, q, c.Query("patient"))
+	if err != nil {
+		h.logger.Error("Ошибка получения доски записей", "error", err.Error())
+		c.JSON(appointmentSearchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+func appointmentSearchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, constants.ErrInvalidListQuery),
+		errors.Is(err, services.ErrInvalidBoardView),
+		errors.Is(err, services.ErrBoardPeriodInQuery),
+		errors.Is(err, localtime.ErrInvalidDate):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrClinicForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrClinicNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
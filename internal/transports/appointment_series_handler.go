@@ -0,0 +1,167 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type AppointmentSeriesHandler struct {
+	service services.AppointmentSeriesService
+	logger  *slog.Logger
+}
+
+func NewAppointmentSeriesHandler(service services.AppointmentSeriesService, logger *slog.Logger) *AppointmentSeriesHandler {
+	return &AppointmentSeriesHandler{service: service, logger: logger}
+}
+
+func (h *AppointmentSeriesHandler) RegisterRoutes(clinicScoped *gin.RouterGroup) {
+	series := clinicScoped.Group("/appointments/series")
+	series.POST("", h.Create)
+	series.GET("/:id", h.GetByID)
+	// ?scope=this|following|all, по умолчанию this
+	series.PATCH("/:id/appointments/:appointment_id", h.UpdateOccurrences)
+	series.DELETE("/:id/appointments/:appointment_id", h.CancelOccurrences)
+}
+
+func (h *AppointmentSeriesHandler) Create(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	var req models.AppointmentSeriesCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в AppointmentSeries.Create", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	result, err := h.service.Create(c.Request.Context(), userID, role, req)
+	if err != nil {
+		if errors.Is(err, services.ErrSeriesConflicts) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "conflicts": result.Conflicts})
+			return
+		}
+		h.logger.Error("Ошибка создания серии приёмов", "error", err.Error(), "user_id", userID)
+		c.JSON(seriesErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *AppointmentSeriesHandler) GetByID(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	series, err := h.service.GetByID(c.Request.Context(), userID, role, id)
+	if err != nil {
+		c.JSON(seriesErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+func (h *AppointmentSeriesHandler) UpdateOccurrences(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	seriesID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	appointmentID, ok := parseIDParam(c, "appointment_id")
+	if !ok {
+		return
+	}
+
+	var req models.AppointmentSeriesUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в AppointmentSeries.UpdateOccurrences", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	scope := models.SeriesScope(c.Query("scope"))
+	updated, err := h.service.UpdateOccurrences(c.Request.Context(), userID, role, seriesID, appointmentID, scope, req)
+	if err != nil {
+		c.JSON(seriesErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *AppointmentSeriesHandler) CancelOccurrences(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	seriesID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	appointmentID, ok := parseIDParam(c, "appointment_id")
+	if !ok {
+		return
+	}
+
+	scope := models.SeriesScope(c.Query("scope"))
+	cancelled, err := h.service.CancelOccurrences(c.Request.Context(), userID, role, seriesID, appointmentID, scope)
+	if err != nil {
+		c.JSON(seriesErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cancelled)
+}
+
+func seriesErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrSeriesNotFound),
+		errors.Is(err, services.ErrSeriesOccurrenceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrSeriesForbidden),
+		errors.Is(err, services.ErrSeriesNeedsStaff),
+		errors.Is(err, services.ErrSeriesPolicyRestricted),
+		errors.Is(err, services.ErrClinicForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrSeriesOccurrenceLocked),
+		errors.Is(err, constants.ErrTimeConflict),
+		errors.Is(err, constants.ErrSlotHeld),
+		errors.Is(err, services.ErrTooManyFutureBookings):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidSeries),
+		errors.Is(err, services.ErrSeriesTooLong),
+		errors.Is(err, services.ErrInvalidSeriesScope),
+		errors.Is(err, constants.ErrTimeNotInSchedule),
+		errors.Is(err, constants.ErrInvalidAppointmentTime),
+		errors.Is(err, constants.ErrInvalidPrice),
+		errors.Is(err, constants.ServiceIDIsIncorrect),
+		errors.Is(err, constants.ErrServiceNotOffered):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,6 +1,7 @@
 package transports
 
 import (
+	"errors"
 	"log/slog"
 	"strconv"
 
@@ -31,9 +32,20 @@ func (h *AppointmentsHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	admin := appointments.Group("")
 	admin.Use(RequireRole("admin"))
 	admin.GET("", h.GetAll)
+
+	appointments.POST("/:id/complete", RequireRole("admin", "doctor"), h.Complete)
+	appointments.POST("/:id/no-show", RequireRole("admin", "doctor"), h.MarkNoShow)
+	appointments.POST("/:id/approve", RequireRole("admin"), h.Approve)
+	appointments.POST("/:id/reject", RequireRole("admin"), h.Reject)
 }
 
 func (h *AppointmentsHandler) Create(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "неавторизован"})
+		return
+	}
+
 	var req models.AppointmentCreateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -43,6 +55,13 @@ func (h *AppointmentsHandler) Create(c *gin.Context) {
 		})
 		return
 	}
+
+	// Пациент записывается только сам; patient_id из тела учитывается для
+	// администратора и врача.
+	if models.Role(role) == models.Patient {
+		req.PatientID = userID
+	}
+
 	appointment, err := h.service.Create(&req)
 	if err != nil {
 		h.logger.Error("Ошибка создания записи (appointment)", "error", err.Error(), "patient_id", req.PatientID)
@@ -57,7 +76,12 @@ func (h *AppointmentsHandler) Create(c *gin.Context) {
 }
 
 func (h *AppointmentsHandler) GetAll(c *gin.Context) {
-	appointments, err := h.service.GetAll()
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	appointments, err := h.service.GetAll(c.Request.Context(), q)
 	if err != nil {
 		h.logger.Error("Ошибка получения всех записей (appointments)", "error", err.Error())
 		c.JSON(400, gin.H{
@@ -66,7 +90,7 @@ func (h *AppointmentsHandler) GetAll(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("Список записей получен", "count", len(appointments))
+	h.logger.Info("Список записей получен", "count", len(appointments.Items), "total", appointments.Total)
 	c.JSON(200, appointments)
 }
 
@@ -85,7 +109,7 @@ func (h *AppointmentsHandler) GetByID(c *gin.Context) {
 	if err != nil {
 		h.logger.Error("Запись не найдена по ID", "error", err.Error(), "id", id)
 		c.JSON(404, gin.H{
-			"error":err.Error(),
+			"error": err.Error(),
 		})
 		return
 	}
@@ -113,6 +137,15 @@ func (h *AppointmentsHandler) Update(c *gin.Context) {
 		return
 	}
 
+	userID, ok := h.ownAppointment(c, uint(id))
+	if !ok {
+		return
+	}
+	if userID != 0 && req.PatientID != nil && *req.PatientID != userID {
+		c.JSON(403, gin.H{"error": "нет доступа к этой записи"})
+		return
+	}
+
 	if err := h.service.Update(uint(id), &req); err != nil {
 		h.logger.Error("Ошибка обновления записи (appointment)", "error", err.Error(), "appointment_id", id)
 		c.JSON(400, gin.H{
@@ -134,6 +167,11 @@ func (h *AppointmentsHandler) Delete(c *gin.Context) {
 		})
 		return
 	}
+
+	if _, ok := h.ownAppointment(c, uint(id)); !ok {
+		return
+	}
+
 	if err := h.service.Delete(uint(id)); err != nil {
 		h.logger.Error("Ошибка удаления записи (appointment)", "error", err.Error(), "appointment_id", id)
 		c.JSON(400, gin.H{
@@ -146,6 +184,180 @@ func (h *AppointmentsHandler) Delete(c *gin.Context) {
 	c.JSON(200, gin.H{"message": "appointment deleted successfully"})
 }
 
+// ownAppointment пропускает пациента только к его собственной записи и
+// возвращает его id; для сотрудников id равен нулю. При отказе ответ уже
+// записан.
+func (h *AppointmentsHandler) ownAppointment(c *gin.Context, id uint) (uint, bool) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "неавторизован"})
+		return 0, false
+	}
+	if models.Role(role) != models.Patient {
+		return 0, true
+	}
+
+	appointment, err := h.service.GetByID(id)
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	if appointment.PatientID != userID {
+		h.logger.Warn("попытка изменить чужую запись", "user_id", userID, "appointment_id", id)
+		c.JSON(403, gin.H{"error": "нет доступа к этой записи"})
+		return 0, false
+	}
+
+	return userID, true
+}
+
+func (h *AppointmentsHandler) Complete(c *gin.Context) {
+	idstr := c.Param("id")
+	id, err := strconv.ParseUint(idstr, 10, 64)
+	if err != nil {
+		h.logger.Warn("Ошибка парсинга ID в Appointments.Complete", "param", idstr)
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.Complete(c.Request.Context(), uint(id)); err != nil {
+		status := 500
+		switch {
+		case errors.Is(err, constants.ErrGetByIDAppointments):
+			status = 404
+		case errors.Is(err, services.ErrClinicForbidden):
+			status = 403
+		case errors.Is(err, constants.ErrAppointmentNotCompletable):
+			status = 409
+		}
+		h.logger.Error("Ошибка завершения записи (appointment)", "error", err.Error(), "appointment_id", id)
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.logger.Info("Запись завершена", "appointment_id", id)
+	c.JSON(200, gin.H{"message": "appointment completed successfully"})
+}
+
+func (h *AppointmentsHandler) MarkNoShow(c *gin.Context) {
+	idstr := c.Param("id")
+	id, err := strconv.ParseUint(idstr, 10, 64)
+	if err != nil {
+		h.logger.Warn("Ошибка парсинга ID в Appointments.MarkNoShow", "param", idstr)
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.MarkNoShow(c.Request.Context(), uint(id)); err != nil {
+		status := 500
+		switch {
+		case errors.Is(err, constants.ErrGetByIDAppointments):
+			status = 404
+		case errors.Is(err, services.ErrClinicForbidden):
+			status = 403
+		case errors.Is(err, constants.ErrAppointmentNotNoShow):
+			status = 409
+		}
+		h.logger.Error("Ошибка отметки неявки", "error", err.Error(), "appointment_id", id)
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.logger.Info("Неявка отмечена", "appointment_id", id)
+	c.JSON(200, gin.H{"message": "appointment marked as no-show"})
+}
+
+func (h *AppointmentsHandler) Approve(c *gin.Context) {
+	idstr := c.Param("id")
+	id, err := strconv.ParseUint(idstr, 10, 64)
+	if err != nil {
+		h.logger.Warn("Ошибка парсинга ID в Appointments.Approve", "param", idstr)
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Тело необязательно: без него используется оплата, запрошенная при записи.
+	var req models.AppointmentApproveRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.Warn("Ошибка парсинга JSON в Appointments.Approve", "error", err.Error())
+			c.JSON(400, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+	if req.Payment != "" && req.Payment != models.PaymentModeDeposit && req.Payment != models.PaymentModeFull {
+		c.JSON(400, gin.H{
+			"error": services.ErrPaymentInvalidMode.Error(),
+		})
+		return
+	}
+
+	appointment, err := h.service.Approve(c.Request.Context(), uint(id), req)
+	if err != nil {
+		h.logger.Error("Ошибка подтверждения записи", "error", err.Error(), "appointment_id", id)
+		c.JSON(approvalErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.logger.Info("Запись подтверждена", "appointment_id", id)
+	c.JSON(200, appointment)
+}
+
+func (h *AppointmentsHandler) Reject(c *gin.Context) {
+	idstr := c.Param("id")
+	id, err := strconv.ParseUint(idstr, 10, 64)
+	if err != nil {
+		h.logger.Warn("Ошибка парсинга ID в Appointments.Reject", "param", idstr)
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.Reject(c.Request.Context(), uint(id)); err != nil {
+		h.logger.Error("Ошибка отклонения записи", "error", err.Error(), "appointment_id", id)
+		c.JSON(approvalErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.logger.Info("Запись отклонена", "appointment_id", id)
+	c.JSON(200, gin.H{"message": "appointment rejected"})
+}
+
+func approvalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, constants.ErrGetByIDAppointments):
+		return 404
+	case errors.Is(err, services.ErrClinicForbidden):
+		return 403
+	case errors.Is(err, constants.ErrAppointmentNotPendingApproval):
+		return 409
+	case errors.Is(err, services.ErrPaymentInvalidMode),
+		errors.Is(err, services.ErrPaymentInvalidAmount),
+		errors.Is(err, services.ErrPaymentNotAllowed),
+		errors.Is(err, services.ErrPaymentsDisabled):
+		return 400
+	default:
+		return 500
+	}
+}
+
 func (h *AppointmentsHandler) GetByPatientID(c *gin.Context) {
 	idstr := c.Param("id")
 	id, err := strconv.ParseUint(idstr, 10, 64)
@@ -94,3 +94,20 @@ func RequireRole(roles ...string) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// CurrentUser достаёт userID и роль, которые положил в context AuthMiddleware.
+func CurrentUser(ctx *gin.Context) (uint, string, bool) {
+	idVal, existsID := ctx.Get("userID")
+	roleVal, existsRole := ctx.Get("userRole")
+	if !existsID || !existsRole {
+		return 0, "", false
+	}
+
+	userID, okID := idVal.(uint)
+	role, okRole := roleVal.(string)
+	if !okID || !okRole {
+		return 0, "", false
+	}
+
+	return userID, role, true
+}
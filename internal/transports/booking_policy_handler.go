@@ -0,0 +1,111 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type BookingPolicyHandler struct {
+	service services.BookingPolicyService
+	logger  *slog.Logger
+}
+
+func NewBookingPolicyHandler(service services.BookingPolicyService, logger *slog.Logger) *BookingPolicyHandler {
+	return &BookingPolicyHandler{service: service, logger: logger}
+}
+
+func (h *BookingPolicyHandler) RegisterRoutes(protected *gin.RouterGroup) {
+	patients := protected.Group("/patients")
+	patients.GET("/:id/no-shows", RequireRole("admin", "doctor"), h.Stats)
+	patients.PUT("/:id/booking-policy", RequireRole("admin"), h.SetOverride)
+
+	protected.GET("/reports/no-shows", RequireRole("admin"), h.Report)
+}
+
+func (h *BookingPolicyHandler) Stats(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	stats, err := h.service.Stats(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *BookingPolicyHandler) SetOverride(c *gin.Context) {
+	adminID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.BookingPolicyOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в BookingPolicy.SetOverride", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	override, err := h.service.SetOverride(c.Request.Context(), adminID, id, req)
+	if err != nil {
+		h.logger.Error("Ошибка сохранения исключения из правил бронирования", "error", err.Error(), "patient_id", id)
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrClinicForbidden) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, override)
+}
+
+// Report — отчёт по неявкам за период (?from=&to= в RFC 3339, по умолчанию
+// окно правил бронирования), опционально по врачу (?doctor_id=).
+func (h *BookingPolicyHandler) Report(c *gin.Context) {
+	var params models.NoShowReportParams
+
+	for key, dst := range map[string]*time.Time{"from": &params.From, "to": &params.To} {
+		raw := c.Query(key)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный параметр " + key + ": ожидается RFC 3339"})
+			return
+		}
+		*dst = t
+	}
+	doctorID, _ := strconv.Atoi(c.Query("doctor_id"))
+	params.DoctorID = uint(doctorID)
+
+	report, err := h.service.Report(c.Request.Context(), params)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidNoShowReport) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,216 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type BundleHandler struct {
+	service services.BundleService
+	logger  *slog.Logger
+}
+
+func NewBundleHandler(service services.BundleService, logger *slog.Logger) *BundleHandler {
+	return &BundleHandler{service: service, logger: logger}
+}
+
+// RegisterRoutes регистрирует публичные комплексы услуг, их ведение
+// администратором и наборы визитов пациентов.
+func (h *BundleHandler) RegisterRoutes(public *gin.RouterGroup, protected *gin.RouterGroup) {
+	public.GET("/bundles", h.List)
+	public.GET("/bundles/:id", h.Get)
+
+	admin := protected.Group("/bundles")
+	admin.Use(RequireRole("admin"))
+	admin.POST("", h.Create)
+	admin.PATCH("/:id", h.Update)
+	admin.DELETE("/:id", h.Delete)
+
+	protected.GET("/packages/my", RequireRole("patient"), h.ListMyPackages)
+
+	patients := protected.Group("/patients")
+	patients.Use(RequireRole("admin"))
+	patients.POST("/:id/packages", h.SellPackage)
+	patients.GET("/:id/packages", h.ListPatientPackages)
+	patients.POST("/:id/packages/:package_id/cancel", h.CancelPackage)
+}
+
+func (h *BundleHandler) List(c *gin.Context) {
+	bundles, err := h.service.List(c.Request.Context(), false)
+	if err != nil {
+		h.logger.Error("Ошибка получения комплексов услуг", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bundles)
+}
+
+func (h *BundleHandler) Get(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	bundle, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(bundleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bundle)
+}
+
+func (h *BundleHandler) Create(c *gin.Context) {
+	var req models.ServiceBundleCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Bundle.Create", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	bundle, err := h.service.Create(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Warn("Не удалось создать комплекс", "error", err.Error())
+		c.JSON(bundleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Комплекс создан", "bundle_id", bundle.ID)
+	c.JSON(http.StatusCreated, bundle)
+}
+
+func (h *BundleHandler) Update(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ServiceBundleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Bundle.Update", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	bundle, err := h.service.Update(c.Request.Context(), id, req)
+	if err != nil {
+		h.logger.Warn("Не удалось изменить комплекс", "error", err.Error(), "bundle_id", id)
+		c.JSON(bundleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bundle)
+}
+
+func (h *BundleHandler) Delete(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		c.JSON(bundleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *BundleHandler) SellPackage(c *gin.Context) {
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.PackagePurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Bundle.SellPackage", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	pkg, err := h.service.SellPackage(c.Request.Context(), patientID, req)
+	if err != nil {
+		h.logger.Warn("Не удалось продать набор визитов", "error", err.Error(), "patient_id", patientID)
+		c.JSON(bundleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Набор визитов продан", "package_id", pkg.ID, "patient_id", patientID)
+	c.JSON(http.StatusCreated, pkg)
+}
+
+func (h *BundleHandler) CancelPackage(c *gin.Context) {
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	packageID, ok := parseIDParam(c, "package_id")
+	if !ok {
+		return
+	}
+
+	pkg, err := h.service.CancelPackage(c.Request.Context(), patientID, packageID)
+	if err != nil {
+		h.logger.Warn("Не удалось отменить набор визитов", "error", err.Error(), "package_id", packageID)
+		c.JSON(bundleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Набор визитов отменён", "package_id", pkg.ID, "patient_id", patientID)
+	c.JSON(http.StatusOK, pkg)
+}
+
+func (h *BundleHandler) ListPatientPackages(c *gin.Context) {
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	h.listPackages(c, patientID)
+}
+
+func (h *BundleHandler) ListMyPackages(c *gin.Context) {
+	userID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	h.listPackages(c, userID)
+}
+
+func (h *BundleHandler) listPackages(c *gin.Context, patientID uint) {
+	packages, err := h.service.ListPackages(c.Request.Context(), patientID)
+	if err != nil {
+		h.logger.Error("Ошибка получения наборов визитов", "error", err.Error(), "patient_id", patientID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, packages)
+}
+
+func bundleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrBundleNotFound),
+		errors.Is(err, services.ErrUserNotFound),
+		errors.Is(err, services.ErrPackageNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrBundleNotForSale),
+		errors.Is(err, services.ErrPackageUnavailable):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidBundle),
+		errors.Is(err, services.ErrServiceNotfound),
+		errors.Is(err, services.ErrCategoryNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,119 @@
+package transports
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+const icsContentType = "text/calendar; charset=utf-8"
+
+type CalendarHandler struct {
+	service services.CalendarService
+	logger  *slog.Logger
+}
+
+func NewCalendarHandler(service services.CalendarService, logger *slog.Logger) *CalendarHandler {
+	return &CalendarHandler{service: service, logger: logger}
+}
+
+func (h *CalendarHandler) RegisterRoutes(public *gin.RouterGroup, protected *gin.RouterGroup) {
+	// Календарные клиенты не передают JWT, доступ по секретному токену в URL.
+	public.GET("/calendar/feed/:token", h.Feed)
+
+	cal := protected.Group("/calendar")
+	cal.GET("/feed", h.GetFeedURL)
+	cal.POST("/feed/rotate", h.RotateFeedToken)
+	cal.GET("/appointments/:id", h.AppointmentICS)
+}
+
+func (h *CalendarHandler) GetFeedURL(c *gin.Context) {
+	userID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	url, err := h.service.GetFeedURL(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Ошибка получения календарной ссылки", "error", err.Error(), "user_id", userID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CalendarFeedResponse{URL: url})
+}
+
+func (h *CalendarHandler) RotateFeedToken(c *gin.Context) {
+	userID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	url, err := h.service.RotateFeedToken(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Ошибка перевыпуска календарной ссылки", "error", err.Error(), "user_id", userID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CalendarFeedResponse{URL: url})
+}
+
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	data, err := h.service.Feed(c.Request.Context(), token)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrCalendarTokenNotFound) {
+			status = http.StatusNotFound
+		} else {
+			h.logger.Error("Ошибка формирования календарной ленты", "error", err.Error())
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, icsContentType, data)
+}
+
+func (h *CalendarHandler) AppointmentICS(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	data, err := h.service.AppointmentICS(c.Request.Context(), userID, role, id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, constants.ErrGetByIDAppointments):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrCalendarForbidden):
+			status = http.StatusForbidden
+		default:
+			h.logger.Error("Ошибка формирования .ics для записи", "error", err.Error(), "appointment_id", id)
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="appointment-%d.ics"`, id))
+	c.Data(http.StatusOK, icsContentType, data)
+}
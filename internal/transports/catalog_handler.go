@@ -0,0 +1,124 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type CatalogHandler struct {
+	service services.CatalogService
+	logger  *slog.Logger
+}
+
+func NewCatalogHandler(service services.CatalogService, logger *slog.Logger) *CatalogHandler {
+	return &CatalogHandler{service: service, logger: logger}
+}
+
+// RegisterRoutes регистрирует публичный каталог с деревом категорий и
+// админское управление категориями.
+func (h *CatalogHandler) RegisterRoutes(public *gin.RouterGroup, protected *gin.RouterGroup) {
+	public.GET("/catalog", h.Catalog)
+	public.GET("/service-categories", h.ListCategories)
+
+	admin := protected.Group("/service-categories")
+	admin.Use(RequireRole("admin"))
+	admin.POST("", h.CreateCategory)
+	admin.PATCH("/:id", h.UpdateCategory)
+	admin.DELETE("/:id", h.DeleteCategory)
+}
+
+func (h *CatalogHandler) Catalog(c *gin.Context) {
+	catalog, err := h.service.Catalog(c.Request.Context(), QueryClinicID(c))
+	if err != nil {
+		h.logger.Error("Ошибка получения каталога услуг", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, catalog)
+}
+
+func (h *CatalogHandler) ListCategories(c *gin.Context) {
+	categories, err := h.service.ListCategories(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Ошибка получения категорий услуг", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+func (h *CatalogHandler) CreateCategory(c *gin.Context) {
+	var req models.ServiceCategoryCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Catalog.CreateCategory", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	category, err := h.service.CreateCategory(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(catalogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Категория услуг создана", "category_id", category.ID)
+	c.JSON(http.StatusCreated, category)
+}
+
+func (h *CatalogHandler) UpdateCategory(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ServiceCategoryUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Catalog.UpdateCategory", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	category, err := h.service.UpdateCategory(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(catalogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+func (h *CatalogHandler) DeleteCategory(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteCategory(c.Request.Context(), id); err != nil {
+		c.JSON(catalogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func catalogErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrCategoryExists),
+		errors.Is(err, services.ErrCategoryInUse):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidCategory),
+		errors.Is(err, services.ErrCategoryCycle):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,244 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type ClinicHandler struct {
+	service services.ClinicService
+	logger  *slog.Logger
+}
+
+func NewClinicHandler(service services.ClinicService, logger *slog.Logger) *ClinicHandler {
+	return &ClinicHandler{service: service, logger: logger}
+}
+
+// RegisterRoutes регистрирует публичный справочник филиалов и админские
+// маршруты. protected должна проходить через ClinicScope.
+func (h *ClinicHandler) RegisterRoutes(public *gin.RouterGroup, protected *gin.RouterGroup) {
+	public.GET("/clinics", h.List)
+	public.GET("/clinics/:id", h.GetByID)
+
+	admin := protected.Group("/clinics")
+	admin.Use(RequireRole("admin"))
+	admin.POST("", h.Create)
+	admin.PATCH("/:id", h.Update)
+	admin.DELETE("/:id", h.Delete)
+	admin.PUT("/:id/doctors", h.SetDoctors)
+	admin.PUT("/:id/services", h.SetServices)
+	admin.PUT("/:id/staff", h.SetStaff)
+
+	protected.PUT("/users/:id/all-clinics", RequireRole("admin"), h.SetAllClinics)
+}
+
+// ClinicScope ограничивает запрос филиалами, за которыми закреплён
+// сотрудник. Ставится после AuthMiddleware.
+func ClinicScope(clinics services.ClinicService, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, role, ok := CurrentUser(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		ctx, err := clinics.Scope(c.Request.Context(), userID, role)
+		if err != nil {
+			logger.Error("Ошибка получения филиалов сотрудника", "error", err.Error(), "user_id", userID)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func (h *ClinicHandler) Create(c *gin.Context) {
+	var req models.ClinicCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Clinic.Create", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	clinic, err := h.service.Create(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Ошибка создания филиала", "error", err.Error(), "name", req.Name)
+		c.JSON(clinicErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Филиал создан", "clinic_id", clinic.ID)
+	c.JSON(http.StatusCreated, clinic)
+}
+
+func (h *ClinicHandler) GetByID(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	clinic, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(clinicErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, clinic)
+}
+
+func (h *ClinicHandler) List(c *gin.Context) {
+	doctorID, _ := strconv.ParseUint(c.Query("doctor_id"), 10, 64)
+
+	clinics, err := h.service.List(c.Request.Context(), models.ClinicQueryParams{
+		DoctorID:   uint(doctorID),
+		OnlyActive: true,
+	})
+	if err != nil {
+		h.logger.Error("Ошибка получения списка филиалов", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, clinics)
+}
+
+func (h *ClinicHandler) Update(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ClinicUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Clinic.Update", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	clinic, err := h.service.Update(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(clinicErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, clinic)
+}
+
+func (h *ClinicHandler) Delete(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		c.JSON(clinicErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ClinicHandler) SetDoctors(c *gin.Context) {
+	id, req, ok := h.bindMembers(c)
+	if !ok {
+		return
+	}
+
+	clinic, err := h.service.SetDoctors(c.Request.Context(), id, req.IDs)
+	if err != nil {
+		c.JSON(clinicErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, clinic)
+}
+
+func (h *ClinicHandler) SetServices(c *gin.Context) {
+	id, req, ok := h.bindMembers(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.SetServices(c.Request.Context(), id, req.IDs); err != nil {
+		c.JSON(clinicErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ClinicHandler) SetStaff(c *gin.Context) {
+	id, req, ok := h.bindMembers(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.SetStaff(c.Request.Context(), id, req.IDs); err != nil {
+		c.JSON(clinicErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ClinicHandler) SetAllClinics(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ClinicNetworkAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Clinic.SetAllClinics", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	if err := h.service.SetAllClinics(c.Request.Context(), id, req.AllClinics); err != nil {
+		c.JSON(clinicErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ClinicHandler) bindMembers(c *gin.Context) (uint, models.ClinicMembersRequest, bool) {
+	var req models.ClinicMembersRequest
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return 0, req, false
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Clinic", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return 0, req, false
+	}
+
+	return id, req, true
+}
+
+func clinicErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrClinicNotFound),
+		errors.Is(err, services.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrClinicForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidClinic),
+		errors.Is(err, services.ErrInvalidTimezone),
+		errors.Is(err, services.ErrInvalidWorkingHours):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,76 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type DoctorDirectoryHandler struct {
+	service services.DoctorDirectoryService
+	logger  *slog.Logger
+}
+
+func NewDoctorDirectoryHandler(service services.DoctorDirectoryService, logger *slog.Logger) *DoctorDirectoryHandler {
+	return &DoctorDirectoryHandler{service: service, logger: logger}
+}
+
+func (h *DoctorDirectoryHandler) RegisterRoutes(public *gin.RouterGroup) {
+	directory := public.Group("/directory/doctors")
+	directory.GET("", h.List)
+	directory.GET("/:id", h.Profile)
+}
+
+// List: ?specialty=orthodontist&language=en&clinic_id=1&sort=-rating&limit=20
+func (h *DoctorDirectoryHandler) List(c *gin.Context) {
+	q, ok := bindListQuery(c, "specialty", "language", "clinic_id")
+	if !ok {
+		return
+	}
+
+	page, err := h.service.List(c.Request.Context(), q, models.DoctorDirectoryParams{
+		Specialty: c.Query("specialty"),
+		Language:  c.Query("language"),
+		ClinicID:  QueryClinicID(c),
+	})
+	if err != nil {
+		c.JSON(directoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *DoctorDirectoryHandler) Profile(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	profile, err := h.service.Profile(c.Request.Context(), id, QueryClinicID(c))
+	if err != nil {
+		c.JSON(directoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func directoryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrDoctorNotFound),
+		errors.Is(err, services.ErrSpecialtyNotFound),
+		errors.Is(err, services.ErrClinicNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, constants.ErrInvalidListQuery),
+		errors.Is(err, services.ErrInvalidDoctorLang):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
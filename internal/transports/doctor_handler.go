@@ -1,6 +1,7 @@
 package transports
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -42,6 +43,7 @@ func (h *DoctorHandler) RegisterRoutes(r *gin.RouterGroup) {
 		doctor.GET("", h.ListDoctors)
 
 		doctor.GET("/:id/reviews", h.GetDoctorReviews)
+		doctor.GET("/:id/rating", h.GetDoctorRating)
 
 		doctor.GET("/:id/services", h.ListDoctorServices)
 
@@ -127,13 +129,48 @@ func (h *DoctorHandler) UpdateDoctor(c *gin.Context) {
 	doctor, err := h.doctor.UpdateDoctor(c.Request.Context(), uint(id), input)
 	if err != nil {
 		h.logger.Error("Ошибка обновления врача", "error", err.Error(), "doctor_id", id)
-		c.JSON(http.StatusInternalServerError, "Failed to update doctor")
+		c.JSON(doctorErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	h.logger.Info("Врач обновлён", "doctor_id", doctor.ID)
 	c.JSON(http.StatusOK, doctor)
 }
 
+// UploadPhoto принимает multipart-форму с файлом в поле photo.
+func (h *DoctorHandler) UploadPhoto(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("photo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ожидается файл в поле photo"})
+		return
+	}
+	photo, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer photo.Close()
+
+	doctor, err := h.doctor.UploadPhoto(c.Request.Context(), userID, role, id, photo)
+	if err != nil {
+		h.logger.Warn("Ошибка загрузки фото врача", "error", err.Error(), "doctor_id", id)
+		c.JSON(doctorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, doctor)
+}
+
 func (h *DoctorHandler) DeleteDoctor(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -161,16 +198,41 @@ func (h *DoctorHandler) GetDoctorReviews(c *gin.Context) {
 		return
 	}
 
-	reviews, err := h.review.GetDoctorReviews(c.Request.Context(), uint(id))
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	reviews, err := h.review.GetDoctorReviews(c.Request.Context(), uint(id), q)
 	if err != nil {
 		h.logger.Error("Ошибка получения отзывов врача", "error", err.Error(), "doctor_id", id)
-		c.JSON(http.StatusInternalServerError, "Failed to get doctor reviews")
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	h.logger.Info("Отзывы врача получены", "doctor_id", id, "count", len(reviews))
+	h.logger.Info("Отзывы врача получены", "doctor_id", id, "count", len(reviews.Items))
 	c.JSON(http.StatusOK, reviews)
 }
 
+func (h *DoctorHandler) GetDoctorRating(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	summary, err := h.review.GetDoctorRating(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrDoctorNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("Ошибка получения рейтинга врача", "error", err.Error(), "doctor_id", id)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
 func (h *DoctorHandler) ListSchedules(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -217,17 +279,35 @@ func (h *DoctorHandler) GetAvailableSlots(c *gin.Context) {
 		return
 	}
 
-	available, err := h.schedule.GetAvailableSlots(c.Request.Context(), uint(doctorID), QueryWeek(c))
+	available, err := h.schedule.GetAvailableSlots(c.Request.Context(), uint(doctorID), QueryClinicID(c), QueryWeek(c))
 
 	if err != nil {
 		h.logger.Error("Ошибка получения доступных слотов", "error", err.Error(), "doctor_id", doctorID)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	h.logger.Info("Доступные слоты получены", "doctor_id", doctorID, "count", len(available))
 	c.JSON(http.StatusOK, available)
 }
 
+func doctorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrDoctorNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrDoctorForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrDoctorPhotoTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrSpecialtyNotFound),
+		errors.Is(err, services.ErrInvalidDoctorLang),
+		errors.Is(err, services.ErrInvalidCredential),
+		errors.Is(err, services.ErrInvalidDoctorPhoto):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func QueryWeek(c *gin.Context) int {
 	week := c.Query("week")
 
@@ -235,6 +315,14 @@ func QueryWeek(c *gin.Context) int {
 
 	return w
 }
+
+// QueryClinicID читает фильтр ?clinic_id; 0 — все филиалы.
+func QueryClinicID(c *gin.Context) uint {
+	id, _ := strconv.ParseUint(c.Query("clinic_id"), 10, 64)
+
+	return uint(id)
+}
+
 func GetDoctorQueryParams(c *gin.Context) models.DoctorQueryParams {
 	specialization := c.Query("specialization")
 	experience := c.Query("experience")
@@ -252,6 +340,7 @@ func GetDoctorQueryParams(c *gin.Context) models.DoctorQueryParams {
 		Specialization:  specialization,
 		ExperienceYears: eID,
 		AvgRating:       float64(aID),
+		ClinicID:        QueryClinicID(c),
 
 		FilOr: v,
 	}
@@ -0,0 +1,124 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/localtime"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type DoctorOnboardingHandler struct {
+	service services.DoctorOnboardingService
+	logger  *slog.Logger
+}
+
+func NewDoctorOnboardingHandler(service services.DoctorOnboardingService, logger *slog.Logger) *DoctorOnboardingHandler {
+	return &DoctorOnboardingHandler{service: service, logger: logger}
+}
+
+// RegisterRoutes регистрирует онбординг врача для администратора и
+// публичное принятие приглашения.
+func (h *DoctorOnboardingHandler) RegisterRoutes(public *gin.RouterGroup, clinicScoped *gin.RouterGroup) {
+	public.POST("/auth/invitations/accept", h.Accept)
+
+	admin := clinicScoped.Group("/doctors")
+	admin.Use(RequireRole("admin"))
+	admin.POST("/onboard", h.Onboard)
+	admin.POST("/:id/invitation", h.ResendInvitation)
+}
+
+func (h *DoctorOnboardingHandler) Onboard(c *gin.Context) {
+	var req models.DoctorOnboardingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в DoctorOnboarding.Onboard", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	resp, err := h.service.Onboard(c.Request.Context(), req)
+	if err != nil {
+		var conflicts *services.ScheduleConflictsError
+		if errors.As(err, &conflicts) {
+			h.logger.Warn("Врач не заведён из-за конфликтов расписания", "conflicts", len(conflicts.Conflicts))
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "conflicts": conflicts.Conflicts})
+			return
+		}
+
+		h.logger.Warn("Не удалось завести врача", "error", err.Error())
+		c.JSON(onboardingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Врач заведён", "doctor_id", resp.Doctor.ID, "user_id", resp.User.ID)
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *DoctorOnboardingHandler) ResendInvitation(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	invitation, sent, err := h.service.ResendInvitation(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Warn("Не удалось выслать приглашение", "error", err.Error(), "doctor_id", id)
+		c.JSON(onboardingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"invitation": invitation, "invitation_sent": sent})
+}
+
+func (h *DoctorOnboardingHandler) Accept(c *gin.Context) {
+	var req models.InvitationAcceptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в DoctorOnboarding.Accept", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	if err := h.service.AcceptInvitation(c.Request.Context(), req); err != nil {
+		h.logger.Warn("Приглашение не принято", "error", err.Error())
+		c.JSON(onboardingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func onboardingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound),
+		errors.Is(err, services.ErrDoctorNotFound),
+		errors.Is(err, services.ErrInvitationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrClinicForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvitationExpired):
+		return http.StatusGone
+	case errors.Is(err, services.ErrEmailTaken),
+		errors.Is(err, services.ErrDoctorExists),
+		errors.Is(err, services.ErrAdminCannotBeDoctor),
+		errors.Is(err, services.ErrInvitationUsed),
+		errors.Is(err, constants.ErrDoctorDoubleBooked),
+		errors.Is(err, constants.ErrRoomDoubleBooked),
+		errors.Is(err, constants.ErrRoomInactive):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidOnboarding),
+		errors.Is(err, services.ErrInvalidDoctorProfile),
+		errors.Is(err, services.ErrSpecialtyNotFound),
+		errors.Is(err, services.ErrServiceNotfound),
+		errors.Is(err, services.ErrInvalidDoctorLang),
+		errors.Is(err, services.ErrInvalidScheduleTemplate),
+		errors.Is(err, services.ErrWeakPassword),
+		errors.Is(err, localtime.ErrInvalidDate):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
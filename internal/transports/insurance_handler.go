@@ -0,0 +1,362 @@
+package transports
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type InsuranceHandler struct {
+	service services.InsuranceService
+	logger  *slog.Logger
+}
+
+func NewInsuranceHandler(service services.InsuranceService, logger *slog.Logger) *InsuranceHandler {
+	return &InsuranceHandler{service: service, logger: logger}
+}
+
+func (h *InsuranceHandler) RegisterRoutes(r *gin.RouterGroup) {
+	ins := r.Group("/insurance")
+
+	//-----patient------
+	ins.GET("/policies/my", h.ListMyPolicies)
+	ins.GET("/quote/:appointment_id", h.Quote)
+
+	//-----admin------
+	admin := ins.Group("")
+	admin.Use(RequireRole("admin"))
+
+	admin.POST("/insurers", h.CreateInsurer)
+	admin.GET("/insurers", h.ListInsurers)
+	admin.GET("/insurers/:id", h.GetInsurer)
+	admin.PATCH("/insurers/:id", h.UpdateInsurer)
+	admin.PUT("/insurers/:id/rules", h.SetRule)
+	admin.DELETE("/insurers/:id/rules/:category", h.DeleteRule)
+
+	admin.POST("/policies", h.CreatePolicy)
+	admin.PATCH("/policies/:id", h.UpdatePolicy)
+	admin.GET("/patients/:id/policies", h.ListPatientPolicies)
+
+	admin.POST("/claims", h.SubmitClaim)
+	admin.GET("/claims", h.ListClaims)
+	admin.PATCH("/claims/:id/status", h.UpdateClaimStatus)
+	admin.GET("/claims/export", h.ExportClaims)
+}
+
+func (h *InsuranceHandler) CreateInsurer(c *gin.Context) {
+	var req models.InsurerCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Insurance.CreateInsurer", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	insurer, err := h.service.CreateInsurer(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Ошибка создания страховой компании", "error", err.Error(), "code", req.Code)
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Страховая компания создана", "insurer_id", insurer.ID)
+	c.JSON(http.StatusCreated, insurer)
+}
+
+func (h *InsuranceHandler) ListInsurers(c *gin.Context) {
+	insurers, err := h.service.ListInsurers(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Ошибка получения страховых компаний", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, insurers)
+}
+
+func (h *InsuranceHandler) GetInsurer(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	insurer, err := h.service.GetInsurer(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, insurer)
+}
+
+func (h *InsuranceHandler) UpdateInsurer(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.InsurerUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	insurer, err := h.service.UpdateInsurer(c.Request.Context(), id, req)
+	if err != nil {
+		h.logger.Error("Ошибка обновления страховой компании", "error", err.Error(), "insurer_id", id)
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, insurer)
+}
+
+func (h *InsuranceHandler) SetRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.CoverageRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	rule, err := h.service.SetCoverageRule(c.Request.Context(), id, req)
+	if err != nil {
+		h.logger.Error("Ошибка сохранения правила покрытия", "error", err.Error(), "insurer_id", id)
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Правило покрытия сохранено", "insurer_id", id, "category", rule.Category)
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *InsuranceHandler) DeleteRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteCoverageRule(c.Request.Context(), id, c.Param("category")); err != nil {
+		h.logger.Error("Ошибка удаления правила покрытия", "error", err.Error(), "insurer_id", id)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *InsuranceHandler) CreatePolicy(c *gin.Context) {
+	var req models.PolicyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Insurance.CreatePolicy", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	policy, err := h.service.CreatePolicy(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Ошибка создания полиса", "error", err.Error(), "patient_id", req.PatientID)
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Полис создан", "policy_id", policy.ID, "patient_id", policy.PatientID)
+	c.JSON(http.StatusCreated, policy)
+}
+
+func (h *InsuranceHandler) UpdatePolicy(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.PolicyUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	policy, err := h.service.UpdatePolicy(c.Request.Context(), id, req)
+	if err != nil {
+		h.logger.Error("Ошибка обновления полиса", "error", err.Error(), "policy_id", id)
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *InsuranceHandler) ListPatientPolicies(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	policies, err := h.service.ListPatientPolicies(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Ошибка получения полисов пациента", "error", err.Error(), "patient_id", id)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+func (h *InsuranceHandler) ListMyPolicies(c *gin.Context) {
+	userID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	policies, err := h.service.ListPatientPolicies(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Ошибка получения полисов пациента", "error", err.Error(), "patient_id", userID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+func (h *InsuranceHandler) Quote(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	id, ok := parseIDParam(c, "appointment_id")
+	if !ok {
+		return
+	}
+
+	quote, err := h.service.QuoteAppointment(c.Request.Context(), userID, role, id)
+	if err != nil {
+		h.logger.Error("Ошибка расчёта страхового покрытия", "error", err.Error(), "appointment_id", id)
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+func (h *InsuranceHandler) SubmitClaim(c *gin.Context) {
+	var req models.ClaimCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	claim, err := h.service.SubmitClaim(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Ошибка подачи страховой заявки", "error", err.Error(), "appointment_id", req.AppointmentID)
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Страховая заявка подана", "claim_id", claim.ID)
+	c.JSON(http.StatusCreated, claim)
+}
+
+func (h *InsuranceHandler) ListClaims(c *gin.Context) {
+	insurerID, _ := strconv.Atoi(c.Query("insurer_id"))
+	patientID, _ := strconv.Atoi(c.Query("patient_id"))
+
+	claims, err := h.service.ListClaims(c.Request.Context(), models.ClaimQueryParams{
+		InsurerID: uint(insurerID),
+		PatientID: uint(patientID),
+		Status:    models.ClaimStatus(c.Query("status")),
+	})
+	if err != nil {
+		h.logger.Error("Ошибка получения страховых заявок", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, claims)
+}
+
+func (h *InsuranceHandler) UpdateClaimStatus(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ClaimStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	claim, err := h.service.UpdateClaimStatus(c.Request.Context(), id, req)
+	if err != nil {
+		h.logger.Error("Ошибка смены статуса страховой заявки", "error", err.Error(), "claim_id", id)
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Статус страховой заявки изменён", "claim_id", id, "status", claim.Status)
+	c.JSON(http.StatusOK, claim)
+}
+
+func (h *InsuranceHandler) ExportClaims(c *gin.Context) {
+	insurerID, err := strconv.Atoi(c.Query("insurer_id"))
+	if err != nil || insurerID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный insurer_id"})
+		return
+	}
+
+	var buf bytes.Buffer
+	batchID, count, err := h.service.ExportClaims(c.Request.Context(), uint(insurerID), &buf)
+	if err != nil {
+		h.logger.Error("Ошибка выгрузки страховых заявок", "error", err.Error(), "insurer_id", insurerID)
+		c.JSON(insuranceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Пакет страховых заявок выгружен", "batch_id", batchID, "count", count)
+	c.Header("Content-Disposition", "attachment; filename=\""+batchID+".csv\"")
+	c.Header("X-Batch-ID", batchID)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func insuranceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInsurerNotFound),
+		errors.Is(err, services.ErrPolicyNotFound),
+		errors.Is(err, services.ErrClaimNotFound),
+		errors.Is(err, constants.ErrGetByIDAppointments):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPaymentForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidInsurer),
+		errors.Is(err, services.ErrInvalidCoverageRule),
+		errors.Is(err, services.ErrInvalidPolicy),
+		errors.Is(err, services.ErrNotCovered),
+		errors.Is(err, services.ErrClaimAppointment),
+		errors.Is(err, services.ErrInvalidClaimTransition):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,101 @@
+package transports
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/localtime"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+)
+
+// listParams — общие параметры списка; остальные параметры запроса
+// считаются фильтрами по полям.
+var listParams = []string{"limit", "cursor", "sort", "from", "to"}
+
+// parseListQuery разбирает общий запрос списка:
+//
+//	?limit=50&cursor=...&sort=-start_at,created_at&from=2025-01-01&to=2025-02-01&doctor_id=3
+//
+// from и to принимают RFC 3339 или дату ГГГГ-ММ-ДД (UTC); to не
+// включительно, дата в to означает конец этого дня. Параметры из skip
+// обрабатывает сам обработчик, в фильтры они не попадают.
+func parseListQuery(c *gin.Context, skip ...string) (models.ListQuery, error) {
+	var q models.ListQuery
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return q, fmt.Errorf("%w: некорректный limit", constants.ErrInvalidListQuery)
+		}
+		q.Limit = limit
+	}
+
+	q.Cursor = c.Query("cursor")
+
+	if raw := c.Query("sort"); raw != "" {
+		for _, field := range strings.Split(raw, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+			if field == "" {
+				return q, fmt.Errorf("%w: некорректный sort", constants.ErrInvalidListQuery)
+			}
+			q.Sort = append(q.Sort, models.SortField{Field: field, Desc: desc})
+		}
+	}
+
+	var err error
+	if q.From, err = parseListTime(c.Query("from"), false); err != nil {
+		return q, fmt.Errorf("%w: некорректный from", constants.ErrInvalidListQuery)
+	}
+	if q.To, err = parseListTime(c.Query("to"), true); err != nil {
+		return q, fmt.Errorf("%w: некорректный to", constants.ErrInvalidListQuery)
+	}
+
+	for name, values := range c.Request.URL.Query() {
+		if slices.Contains(listParams, name) || slices.Contains(skip, name) || len(values) == 0 {
+			continue
+		}
+		if q.Filters == nil {
+			q.Filters = make(map[string]string)
+		}
+		q.Filters[name] = values[len(values)-1]
+	}
+
+	return q, nil
+}
+
+// bindListQuery разбирает запрос списка и при ошибке отвечает 400.
+func bindListQuery(c *gin.Context, skip ...string) (models.ListQuery, bool) {
+	q, err := parseListQuery(c, skip...)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return q, false
+	}
+	return q, true
+}
+
+func parseListTime(raw string, end bool) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+
+	day, err := time.Parse(localtime.DateLayout, raw)
+	if err != nil {
+		return nil, err
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return &day, nil
+}
@@ -0,0 +1,112 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type NotificationHandler struct {
+	service services.NotificationService
+	logger  *slog.Logger
+}
+
+func NewNotificationHandler(service services.NotificationService, logger *slog.Logger) *NotificationHandler {
+	return &NotificationHandler{service: service, logger: logger}
+}
+
+func (h *NotificationHandler) RegisterRoutes(r *gin.RouterGroup) {
+	n := r.Group("/notifications")
+
+	n.GET("/preferences", h.GetPreferences)
+	n.PUT("/preferences", h.UpdatePreferences)
+	n.GET("/my", h.ListMy)
+
+	n.GET("", RequireRole("admin"), h.List)
+}
+
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	pref, err := h.service.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Ошибка получения настроек уведомлений", "error", err.Error(), "user_id", userID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pref)
+}
+
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	var req models.NotificationPreferenceUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Notification.UpdatePreferences", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	pref, err := h.service.UpdatePreferences(c.Request.Context(), userID, req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidNotificationLang) {
+			status = http.StatusBadRequest
+		}
+		h.logger.Error("Ошибка обновления настроек уведомлений", "error", err.Error(), "user_id", userID)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Настройки уведомлений обновлены", "user_id", userID)
+	c.JSON(http.StatusOK, pref)
+}
+
+func (h *NotificationHandler) ListMy(c *gin.Context) {
+	userID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	list, err := h.service.ListForUser(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Ошибка получения уведомлений пользователя", "error", err.Error(), "user_id", userID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *NotificationHandler) List(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Query("user_id"))
+	appointmentID, _ := strconv.Atoi(c.Query("appointment_id"))
+
+	list, err := h.service.List(c.Request.Context(), models.NotificationQueryParams{
+		UserID:        uint(userID),
+		AppointmentID: uint(appointmentID),
+		Status:        models.NotificationStatus(c.Query("status")),
+	})
+	if err != nil {
+		h.logger.Error("Ошибка получения уведомлений", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
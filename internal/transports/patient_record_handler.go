@@ -1,6 +1,7 @@
 package transports
 
 import (
+	"errors"
 	"log/slog"
 	"strconv"
 
@@ -39,10 +40,10 @@ func (h *PatientRecordHandler) Create(c *gin.Context) {
 		return
 	}
 
-	record, err := h.service.Create(&req)
+	record, err := h.service.Create(c.Request.Context(), &req)
 	if err != nil {
 		h.logger.Error("Ошибка создания записи пациента", "error", err.Error(), "patient_id", req.PatientID)
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.JSON(patientRecordErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -51,14 +52,19 @@ func (h *PatientRecordHandler) Create(c *gin.Context) {
 }
 
 func (h *PatientRecordHandler) GetAll(c *gin.Context) {
-	records, err := h.service.GetAll()
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	records, err := h.service.GetAll(c.Request.Context(), q)
 	if err != nil {
 		h.logger.Error("Ошибка получения записей пациентов", "error", err.Error())
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.JSON(patientRecordErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	h.logger.Info("Список patient records получен", "count", len(records))
+	h.logger.Info("Список patient records получен", "count", len(records.Items), "total", records.Total)
 	c.JSON(200, records)
 }
 
@@ -71,10 +77,10 @@ func (h *PatientRecordHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	record, err := h.service.GetByID(uint(id))
+	record, err := h.service.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
 		h.logger.Error("Ошибка получения patient record", "error", err.Error(), "id", id)
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.JSON(patientRecordErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -97,9 +103,9 @@ func (h *PatientRecordHandler) Update(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Update(uint(id), &req); err != nil {
+	if err := h.service.Update(c.Request.Context(), uint(id), &req); err != nil {
 		h.logger.Error("Ошибка обновления patient record", "error", err.Error(), "id", id)
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.JSON(patientRecordErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -116,12 +122,19 @@ func (h *PatientRecordHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Delete(uint(id)); err != nil {
+	if err := h.service.Delete(c.Request.Context(), uint(id)); err != nil {
 		h.logger.Error("Ошибка удаления patient record", "error", err.Error(), "id", id)
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.JSON(patientRecordErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	h.logger.Info("Patient record удалён", "id", id)
 	c.JSON(200, gin.H{"message": "запись успешно удалена"})
 }
+
+func patientRecordErrorStatus(err error) int {
+	if errors.Is(err, services.ErrClinicForbidden) {
+		return 403
+	}
+	return 400
+}
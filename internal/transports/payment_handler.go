@@ -0,0 +1,226 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/payments"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+const PaymentSignatureHeader = "X-Payment-Signature"
+
+type PaymentHandler struct {
+	service   services.PaymentService
+	simulator *payments.FakeProvider
+	logger    *slog.Logger
+}
+
+func NewPaymentHandler(
+	service services.PaymentService,
+	simulator *payments.FakeProvider,
+	logger *slog.Logger,
+) *PaymentHandler {
+	return &PaymentHandler{service: service, simulator: simulator, logger: logger}
+}
+
+func (h *PaymentHandler) RegisterRoutes(public *gin.RouterGroup, protected *gin.RouterGroup) {
+	public.POST("/payments/webhook", h.Webhook)
+
+	pay := protected.Group("/payments")
+	pay.POST("", h.CreateIntent)
+	pay.GET("/:id", h.GetByID)
+	pay.POST("/:id/confirm", h.Confirm)
+
+	admin := pay.Group("")
+	admin.Use(RequireRole("admin"))
+	admin.POST("/:id/refund", h.Refund)
+	admin.GET("/appointment/:id", h.ListByAppointment)
+
+	// Симулятор доступен только с локальным шлюзом.
+	if h.simulator != nil {
+		admin.POST("/simulate/:intent_id", h.Simulate)
+	}
+}
+
+func (h *PaymentHandler) CreateIntent(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	var req models.PaymentCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Payment.CreateIntent", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	payment, err := h.service.CreateIntent(c.Request.Context(), userID, role, req)
+	if err != nil {
+		h.logger.Error("Ошибка создания оплаты", "error", err.Error(), "appointment_id", req.AppointmentID)
+		c.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Оплата создана", "payment_id", payment.ID, "appointment_id", payment.AppointmentID)
+	c.JSON(http.StatusCreated, payment)
+}
+
+func (h *PaymentHandler) GetByID(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный id"})
+		return
+	}
+
+	payment, err := h.service.GetByID(c.Request.Context(), userID, role, uint(id))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+func (h *PaymentHandler) Confirm(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный id"})
+		return
+	}
+
+	payment, err := h.service.Confirm(c.Request.Context(), userID, role, uint(id))
+	if err != nil {
+		h.logger.Error("Ошибка подтверждения оплаты", "error", err.Error(), "payment_id", id)
+		c.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Оплата подтверждена", "payment_id", payment.ID, "status", payment.Status)
+	c.JSON(http.StatusOK, payment)
+}
+
+func (h *PaymentHandler) Refund(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный id"})
+		return
+	}
+
+	var req models.PaymentRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Payment.Refund", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	payment, err := h.service.Refund(c.Request.Context(), uint(id), req.Amount)
+	if err != nil {
+		h.logger.Error("Ошибка возврата оплаты", "error", err.Error(), "payment_id", id)
+		c.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Возврат выполнен", "payment_id", payment.ID, "refunded", payment.RefundedAmount)
+	c.JSON(http.StatusOK, payment)
+}
+
+func (h *PaymentHandler) ListByAppointment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный id"})
+		return
+	}
+
+	list, err := h.service.ListByAppointmentID(c.Request.Context(), uint(id))
+	if err != nil {
+		h.logger.Error("Ошибка получения оплат записи", "error", err.Error(), "appointment_id", id)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "не удалось прочитать тело запроса"})
+		return
+	}
+
+	if err := h.service.HandleWebhook(c.Request.Context(), payload, c.GetHeader(PaymentSignatureHeader)); err != nil {
+		c.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// Simulate изображает шлюз: подписывает событие и прогоняет его через тот же
+// обработчик webhook, что и настоящий провайдер.
+func (h *PaymentHandler) Simulate(c *gin.Context) {
+	var req models.PaymentSimulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	payload, signature, err := h.simulator.SimulateEvent(c.Param("intent_id"), req.Event)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.HandleWebhook(c.Request.Context(), payload, signature); err != nil {
+		h.logger.Error("Ошибка обработки симулированного webhook", "error", err.Error())
+		c.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Webhook симулирован", "intent_id", c.Param("intent_id"), "event", req.Event)
+	c.JSON(http.StatusOK, gin.H{"event": req.Event, "signature": signature})
+}
+
+func paymentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrPaymentNotFound),
+		errors.Is(err, services.ErrPaymentNoAppointment),
+		errors.Is(err, payments.ErrIntentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPaymentForbidden),
+		errors.Is(err, services.ErrClinicForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, payments.ErrInvalidSignature):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrPaymentInvalidMode),
+		errors.Is(err, services.ErrPaymentInvalidAmount),
+		errors.Is(err, services.ErrPaymentNotAllowed),
+		errors.Is(err, services.ErrNothingToPay),
+		errors.Is(err, services.ErrPaymentNotPending),
+		errors.Is(err, services.ErrPaymentNotRefundable),
+		errors.Is(err, payments.ErrInvalidPayload),
+		errors.Is(err, payments.ErrRefundTooLarge):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
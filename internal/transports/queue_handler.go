@@ -0,0 +1,171 @@
+package transports
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type QueueHandler struct {
+	service services.QueueService
+	// displayToken защищает поток для табло: EventSource не умеет
+	// передавать Authorization, поэтому токен приходит в query. Без токена
+	// поток не регистрируется.
+	displayToken string
+	logger       *slog.Logger
+}
+
+func NewQueueHandler(service services.QueueService, displayToken string, logger *slog.Logger) *QueueHandler {
+	return &QueueHandler{service: service, displayToken: displayToken, logger: logger}
+}
+
+func (h *QueueHandler) RegisterRoutes(public *gin.RouterGroup, protected *gin.RouterGroup) {
+	if h.displayToken != "" {
+		public.GET("/queue/stream", h.Stream)
+	}
+
+	staff := protected.Group("")
+	staff.Use(RequireRole("admin", "doctor"))
+	staff.POST("/appointments/:id/check-in", h.CheckIn)
+	staff.POST("/appointments/:id/call", h.Call)
+	staff.GET("/queue", h.Queue)
+}
+
+func (h *QueueHandler) CheckIn(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	item, err := h.service.CheckIn(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Warn("Не удалось отметить приход пациента", "error", err.Error(), "appointment_id", id)
+		c.JSON(queueErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *QueueHandler) Call(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	item, err := h.service.Call(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Warn("Не удалось вызвать пациента", "error", err.Error(), "appointment_id", id)
+		c.JSON(queueErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *QueueHandler) Queue(c *gin.Context) {
+	items, err := h.service.Queue(c.Request.Context(), queueParams(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// Stream — Server-Sent Events для табло в зале ожидания. Сразу после
+// подключения отправляется снимок очереди, затем события checked_in и called.
+func (h *QueueHandler) Stream(c *gin.Context) {
+	if h.displayToken == "" || subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(h.displayToken)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неверный токен табло"})
+		return
+	}
+
+	params := queueParams(c)
+
+	events, unsubscribe := h.service.Subscribe()
+	defer unsubscribe()
+
+	snapshot, err := h.service.Queue(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	writeSSE(c, "snapshot", snapshot)
+
+	heartbeat := time.NewTicker(25 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if params.DoctorID != 0 && event.Item.DoctorID != params.DoctorID {
+				continue
+			}
+			if params.RoomNumber != 0 && event.Item.RoomNumber != params.RoomNumber {
+				continue
+			}
+			if params.ClinicID != 0 && (event.Item.ClinicID == nil || *event.Item.ClinicID != params.ClinicID) {
+				continue
+			}
+			writeSSE(c, event.Type, event.Item)
+		}
+	}
+}
+
+func writeSSE(c *gin.Context, event string, data any) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, body)
+	c.Writer.Flush()
+}
+
+func queueParams(c *gin.Context) models.QueueQueryParams {
+	doctorID, _ := strconv.Atoi(c.Query("doctor_id"))
+	room, _ := strconv.Atoi(c.Query("room"))
+	return models.QueueQueryParams{DoctorID: uint(doctorID), RoomNumber: room, ClinicID: QueryClinicID(c)}
+}
+
+func queueErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, constants.ErrInvalidAppointmentID):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrAlreadyCheckedIn),
+		errors.Is(err, services.ErrNotCheckedIn),
+		errors.Is(err, services.ErrCheckInNotAllowed):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrCheckInNotToday):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrClinicForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
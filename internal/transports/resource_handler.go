@@ -0,0 +1,194 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type ResourceHandler struct {
+	service services.ResourceService
+	logger  *slog.Logger
+}
+
+func NewResourceHandler(service services.ResourceService, logger *slog.Logger) *ResourceHandler {
+	return &ResourceHandler{service: service, logger: logger}
+}
+
+func (h *ResourceHandler) RegisterRoutes(protected *gin.RouterGroup) {
+	rooms := protected.Group("/rooms")
+	rooms.GET("", RequireRole("admin", "doctor"), h.ListRooms)
+	rooms.GET("/:id", RequireRole("admin", "doctor"), h.GetRoom)
+	rooms.POST("", RequireRole("admin"), h.CreateRoom)
+	rooms.PATCH("/:id", RequireRole("admin"), h.UpdateRoom)
+	rooms.DELETE("/:id", RequireRole("admin"), h.DeleteRoom)
+
+	equipment := protected.Group("/equipment")
+	equipment.GET("", RequireRole("admin", "doctor"), h.ListEquipment)
+	equipment.POST("", RequireRole("admin"), h.CreateEquipment)
+	equipment.PATCH("/:id", RequireRole("admin"), h.UpdateEquipment)
+	equipment.DELETE("/:id", RequireRole("admin"), h.DeleteEquipment)
+}
+
+func (h *ResourceHandler) CreateRoom(c *gin.Context) {
+	var req models.RoomCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Resource.CreateRoom", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	room, err := h.service.CreateRoom(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Ошибка создания кабинета", "error", err.Error(), "number", req.Number)
+		c.JSON(resourceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, room)
+}
+
+func (h *ResourceHandler) GetRoom(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	room, err := h.service.GetRoom(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(resourceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, room)
+}
+
+func (h *ResourceHandler) ListRooms(c *gin.Context) {
+	rooms, err := h.service.ListRooms(c.Request.Context(), QueryClinicID(c))
+	if err != nil {
+		c.JSON(resourceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rooms)
+}
+
+func (h *ResourceHandler) UpdateRoom(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.RoomUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Resource.UpdateRoom", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	room, err := h.service.UpdateRoom(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(resourceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, room)
+}
+
+func (h *ResourceHandler) DeleteRoom(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRoom(c.Request.Context(), id); err != nil {
+		c.JSON(resourceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ResourceHandler) CreateEquipment(c *gin.Context) {
+	var req models.EquipmentCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Resource.CreateEquipment", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	equipment, err := h.service.CreateEquipment(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Ошибка создания оборудования", "error", err.Error(), "kind", req.Kind)
+		c.JSON(resourceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, equipment)
+}
+
+func (h *ResourceHandler) ListEquipment(c *gin.Context) {
+	equipment, err := h.service.ListEquipment(c.Request.Context(), QueryClinicID(c))
+	if err != nil {
+		c.JSON(resourceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, equipment)
+}
+
+func (h *ResourceHandler) UpdateEquipment(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.EquipmentUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Resource.UpdateEquipment", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	equipment, err := h.service.UpdateEquipment(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(resourceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, equipment)
+}
+
+func (h *ResourceHandler) DeleteEquipment(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteEquipment(c.Request.Context(), id); err != nil {
+		c.JSON(resourceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func resourceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrRoomNotFound),
+		errors.Is(err, services.ErrEquipmentNotFound),
+		errors.Is(err, services.ErrClinicNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrClinicForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidRoom),
+		errors.Is(err, services.ErrInvalidEquipment):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
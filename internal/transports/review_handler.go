@@ -1,11 +1,13 @@
 package transports
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"github.com/mutsaevz/team-4-dentistry/internal/services"
 )
@@ -26,9 +28,11 @@ func (h *ReviewHandler) RegisterRoutes(r *gin.RouterGroup) {
 	review := r.Group("/reviews")
 	{
 		//------user---------
-		review.POST("", h.CreateReview)
+		review.POST("", RequireRole("patient"), h.CreateReview)
 		review.PUT("/:id", h.UpdateReview)
 		review.DELETE("/:id", h.DeleteReview)
+		review.POST("/:id/flag", RequireRole("patient"), h.FlagReview)
+		review.POST("/:id/reply", RequireRole("doctor"), h.ReplyToReview)
 
 		review.GET("/doctor/:id", h.GetDoctorReviews)
 
@@ -38,10 +42,19 @@ func (h *ReviewHandler) RegisterRoutes(r *gin.RouterGroup) {
 
 		admin.GET("/:id", h.GetReviewByID)
 		admin.GET("/patient/:patient_id", h.GetPatientReviews)
+		admin.GET("/moderation", h.ModerationQueue)
+		admin.POST("/:id/publish", h.PublishReview)
+		admin.POST("/:id/reject", h.RejectReview)
 	}
 }
 
 func (h *ReviewHandler) CreateReview(c *gin.Context) {
+	userID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
 	var input models.ReviewCreateRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
 		h.logger.Warn("Ошибка парсинга JSON в Review.CreateReview", "error", err.Error())
@@ -49,10 +62,10 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 		return
 	}
 
-	review, err := h.review.CreateReview(c.Request.Context(), input)
+	review, err := h.review.CreateReview(c.Request.Context(), userID, input)
 	if err != nil {
-		h.logger.Error("Ошибка создания отзыва", "error", err.Error(), "appointment_id", input.AppointmentID)
-		c.JSON(500, gin.H{"error": "Failed to create review"})
+		h.logger.Error("Ошибка создания отзыва", "error", err.Error(), "appointment_id", input.AppointmentID, "user_id", userID)
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -72,7 +85,7 @@ func (h *ReviewHandler) GetReviewByID(c *gin.Context) {
 	review, err := h.review.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
 		h.logger.Error("Ошибка получения отзыва", "error", err.Error(), "review_id", id)
-		c.JSON(500, gin.H{"error": "Failed to get review"})
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	h.logger.Info("Отзыв получен", "review_id", review.ID)
@@ -80,6 +93,12 @@ func (h *ReviewHandler) GetReviewByID(c *gin.Context) {
 }
 
 func (h *ReviewHandler) UpdateReview(c *gin.Context) {
+	userID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -95,10 +114,10 @@ func (h *ReviewHandler) UpdateReview(c *gin.Context) {
 		return
 	}
 
-	review, err := h.review.UpdateReview(c.Request.Context(), uint(id), input)
+	review, err := h.review.UpdateReview(c.Request.Context(), userID, uint(id), input)
 	if err != nil {
 		h.logger.Error("Ошибка обновления отзыва", "error", err.Error(), "review_id", id)
-		c.JSON(500, gin.H{"error": "Failed to update review"})
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	h.logger.Info("Отзыв обновлён", "review_id", review.ID)
@@ -106,6 +125,12 @@ func (h *ReviewHandler) UpdateReview(c *gin.Context) {
 }
 
 func (h *ReviewHandler) DeleteReview(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
 	param := c.Param("id")
 	id, err := strconv.Atoi(param)
 	if err != nil {
@@ -114,9 +139,9 @@ func (h *ReviewHandler) DeleteReview(c *gin.Context) {
 		return
 	}
 
-	if err := h.review.DeleteReview(c.Request.Context(), uint(id)); err != nil {
+	if err := h.review.DeleteReview(c.Request.Context(), userID, role, uint(id)); err != nil {
 		h.logger.Error("Ошибка удаления отзыва", "error", err.Error(), "review_id", id)
-		c.JSON(500, gin.H{"error": "Failed to delete review"})
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	h.logger.Info("Отзыв удалён", "review_id", id)
@@ -132,13 +157,18 @@ func (h *ReviewHandler) GetDoctorReviews(c *gin.Context) {
 		return
 	}
 
-	reviews, err := h.review.GetDoctorReviews(c.Request.Context(), uint(doctorID))
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	reviews, err := h.review.GetDoctorReviews(c.Request.Context(), uint(doctorID), q)
 	if err != nil {
 		h.logger.Error("Ошибка получения отзывов врача", "error", err.Error(), "doctor_id", doctorID)
-		c.JSON(500, gin.H{"error": "Failed to get reviews"})
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	h.logger.Info("Отзывы врача получены", "doctor_id", doctorID, "count", len(reviews))
+	h.logger.Info("Отзывы врача получены", "doctor_id", doctorID, "count", len(reviews.Items))
 	c.JSON(http.StatusOK, reviews)
 }
 
@@ -151,13 +181,167 @@ func (h *ReviewHandler) GetPatientReviews(c *gin.Context) {
 		return
 	}
 
-	reviews, err := h.review.GetPatientReviews(c.Request.Context(), uint(id))
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	reviews, err := h.review.GetPatientReviews(c.Request.Context(), uint(id), q)
 
 	if err != nil {
 		h.logger.Error("Ошибка получения отзывов пациента", "error", err.Error(), "patient_id", id)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.logger.Info("Отзывы пациента получены", "patient_id", id, "count", len(reviews.Items))
+	c.JSON(http.StatusOK, reviews)
+}
+
+func (h *ReviewHandler) ModerationQueue(c *gin.Context) {
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	reviews, err := h.review.ModerationQueue(c.Request.Context(), q)
+	if err != nil {
+		h.logger.Error("Ошибка получения очереди модерации отзывов", "error", err.Error())
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	h.logger.Info("Отзывы пациента получены", "patient_id", id, "count", len(reviews))
+
 	c.JSON(http.StatusOK, reviews)
 }
+
+func (h *ReviewHandler) PublishReview(c *gin.Context) {
+	adminID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	review, err := h.review.PublishReview(c.Request.Context(), adminID, id)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Отзыв опубликован", "review_id", id, "admin_id", adminID)
+	c.JSON(http.StatusOK, review)
+}
+
+func (h *ReviewHandler) RejectReview(c *gin.Context) {
+	adminID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ReviewRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Review.RejectReview", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	review, err := h.review.RejectReview(c.Request.Context(), adminID, id, req)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Отзыв отклонён", "review_id", id, "admin_id", adminID)
+	c.JSON(http.StatusOK, review)
+}
+
+func (h *ReviewHandler) ReplyToReview(c *gin.Context) {
+	userID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ReviewReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Review.ReplyToReview", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	review, err := h.review.ReplyToReview(c.Request.Context(), userID, id, req)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+func (h *ReviewHandler) FlagReview(c *gin.Context) {
+	userID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	// Причина жалобы необязательна, тело запроса может быть пустым.
+	var req models.ReviewFlagRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.Warn("Ошибка парсинга JSON в Review.FlagReview", "error", err.Error())
+			c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+			return
+		}
+	}
+
+	if err := h.review.FlagReview(c.Request.Context(), userID, id, req); err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func reviewErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrReviewNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrReviewForbidden),
+		errors.Is(err, services.ErrReviewAppointment):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrReviewAlreadyExists),
+		errors.Is(err, services.ErrReviewNotCompleted),
+		errors.Is(err, services.ErrReviewWindowClosed),
+		errors.Is(err, services.ErrReviewNotPublished),
+		errors.Is(err, services.ErrReviewReplyExists),
+		errors.Is(err, services.ErrReviewAlreadyFlagged):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidReview),
+		errors.Is(err, constants.ErrInvalidListQuery),
+		errors.Is(err, services.ErrReviewRejectReason),
+		errors.Is(err, services.ErrInvalidReviewReply):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"log/slog"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/payments"
 	"github.com/mutsaevz/team-4-dentistry/internal/services"
 )
 
@@ -20,6 +21,27 @@ func RegisterRoutes(
 	reviewService services.ReviewService,
 	patientRecordService services.PatientRecordService,
 	appointmentService services.AppointmentService,
+	paymentService services.PaymentService,
+	paymentSimulator *payments.FakeProvider,
+	insuranceService services.InsuranceService,
+	notificationService services.NotificationService,
+	webhookService services.WebhookService,
+	calendarService services.CalendarService,
+	waitlistService services.WaitlistService,
+	slotHoldService services.SlotHoldService,
+	seriesService services.AppointmentSeriesService,
+	queueService services.QueueService,
+	queueDisplayToken string,
+	bookingPolicyService services.BookingPolicyService,
+	resourceService services.ResourceService,
+	clinicService services.ClinicService,
+	appointmentSearchService services.AppointmentSearchService,
+	searchService services.SearchService,
+	specialtyService services.SpecialtyService,
+	directoryService services.DoctorDirectoryService,
+	onboardingService services.DoctorOnboardingService,
+	catalogService services.CatalogService,
+	bundleService services.BundleService,
 ) {
 	api := router.Group("/api")
 
@@ -31,11 +53,19 @@ func RegisterRoutes(
 	protected := api.Group("")
 	protected.Use(AuthMiddleware(jwtCfg))
 
+	// Маршруты персонала, ограниченные филиалами сотрудника
+	clinicScoped := protected.Group("", ClinicScope(clinicService, logger))
+
+	// Филиалы
+	clinicHandler := NewClinicHandler(clinicService, logger)
+	clinicHandler.RegisterRoutes(api, clinicScoped)
+
 	// Наши публичные услуги
 	serviceHandler := NewServiceHandler(servService, logger)
 	servicePublic := api.Group("/services")
 	servicePublic.GET("", serviceHandler.List)
 	servicePublic.GET("/:id", serviceHandler.GetByID)
+	servicePublic.GET("/:id/doctors", serviceHandler.ListDoctors)
 
 	// Защищенные
 	serviceAdmin := protected.Group("/services")
@@ -51,6 +81,7 @@ func RegisterRoutes(
 	docPublic.GET("", docHandler.ListDoctors)
 	docPublic.GET("/:id", docHandler.GetDoctorByID)
 	docPublic.GET("/:id/reviews", docHandler.GetDoctorReviews)
+	docPublic.GET("/:id/rating", docHandler.GetDoctorRating)
 	docPublic.GET("/:id/services", docHandler.ListDoctorServices)
 	docPublic.GET("/:id/schedules/available", docHandler.GetAvailableSlots)
 
@@ -62,6 +93,28 @@ func RegisterRoutes(
 	docAdmin.PATCH("/:id", docHandler.UpdateDoctor)
 	docAdmin.DELETE("/:id", docHandler.DeleteDoctor)
 	docAdmin.GET("/:id/schedules", docHandler.ListSchedules)
+	docAdmin.PUT("/:id/services/:service_id", serviceHandler.SetDoctorOffering)
+	docAdmin.DELETE("/:id/services/:service_id", serviceHandler.RemoveDoctorOffering)
+
+	protected.POST("/doctors/:id/photo", RequireRole("admin", "doctor"), docHandler.UploadPhoto)
+
+	// Онбординг врача одной операцией и приглашение задать пароль
+	onboardingHandler := NewDoctorOnboardingHandler(onboardingService, logger)
+	onboardingHandler.RegisterRoutes(api, clinicScoped)
+
+	// Справочник специализаций и публичный каталог врачей
+	specialtyHandler := NewSpecialtyHandler(specialtyService, logger)
+	specialtyHandler.RegisterRoutes(api, protected)
+
+	directoryHandler := NewDoctorDirectoryHandler(directoryService, logger)
+	directoryHandler.RegisterRoutes(api)
+
+	// Дерево категорий услуг, комплексы и наборы визитов
+	catalogHandler := NewCatalogHandler(catalogService, logger)
+	catalogHandler.RegisterRoutes(api, protected)
+
+	bundleHandler := NewBundleHandler(bundleService, logger)
+	bundleHandler.RegisterRoutes(api, protected)
 
 	// все остальное защищенное, можем потом изменить по желанию
 
@@ -71,7 +124,7 @@ func RegisterRoutes(
 
 	// Schedules только admin
 	scheduleHandler := NewScheduleHandler(scheduleService, logger)
-	scheduleHandler.RegisterRoutes(protected)
+	scheduleHandler.RegisterRoutes(clinicScoped)
 
 	// Recommendations пациент читает "my", доктор/админ создаёт/удаляет
 	recHandler := NewRecommendationHandler(recService, logger)
@@ -82,7 +135,7 @@ func RegisterRoutes(
 
 	// Patient records как минимум админ/доктор
 	patientRecordHandler := NewPatientRecordHandler(patientRecordService, logger)
-	records := protected.Group("/patient-records")
+	records := clinicScoped.Group("/patient-records")
 	records.Use(RequireRole("admin", "doctor"))
 	records.POST("", patientRecordHandler.Create)
 	records.GET("", patientRecordHandler.GetAll)
@@ -93,28 +146,100 @@ func RegisterRoutes(
 	// Review
 	reviewHandler := NewReviewHandler(reviewService, logger)
 	reviewPublic := api.Group("/reviews")
-	reviewPublic.POST("", reviewHandler.CreateReview)
 	reviewPublic.GET("/doctor/:id", reviewHandler.GetDoctorReviews)
-	reviewPublic.PUT("/:id", reviewHandler.UpdateReview)
-	reviewPublic.DELETE("/:id", reviewHandler.DeleteReview)
+
+	// Отзыв оставляет пациент на свой завершённый приём
+	reviewUser := protected.Group("/reviews")
+	reviewUser.POST("", RequireRole("patient"), reviewHandler.CreateReview)
+	reviewUser.PUT("/:id", reviewHandler.UpdateReview)
+	reviewUser.DELETE("/:id", reviewHandler.DeleteReview)
+	reviewUser.POST("/:id/flag", RequireRole("patient"), reviewHandler.FlagReview)
+	reviewUser.POST("/:id/reply", RequireRole("doctor"), reviewHandler.ReplyToReview)
 
 	// Защищенные review
 	reviewAdmin := protected.Group("/reviews")
 	reviewAdmin.Use(RequireRole("admin"))
 	reviewAdmin.GET("/:id", reviewHandler.GetReviewByID)
 	reviewAdmin.GET("/patient/:patient_id", reviewHandler.GetPatientReviews)
+	reviewAdmin.GET("/moderation", reviewHandler.ModerationQueue)
+	reviewAdmin.POST("/:id/publish", reviewHandler.PublishReview)
+	reviewAdmin.POST("/:id/reject", reviewHandler.RejectReview)
 
 	// Appointment
 	appointmentHandler := NewAppointmentsHandler(appointmentService, logger)
 	apPublic := api.Group("/appointments")
-	apPublic.POST("", appointmentHandler.Create)
 	apPublic.GET("/:id", appointmentHandler.GetByID)
-	apPublic.PATCH("/:id", appointmentHandler.Update)
-	apPublic.DELETE("/:id", appointmentHandler.Delete)
 	apPublic.GET("/patients/:id", appointmentHandler.GetByPatientID)
 
+	// Запись и её изменение проходят правила бронирования пациента, поэтому
+	// пациент берётся из токена.
+	apUser := protected.Group("/appointments")
+	apUser.POST("", appointmentHandler.Create)
+	apUser.PATCH("/:id", appointmentHandler.Update)
+	apUser.DELETE("/:id", appointmentHandler.Delete)
+
 	// защищенные
-	apAdmin := protected.Group("/appointments")
+	apAdmin := clinicScoped.Group("/appointments")
 	apAdmin.Use(RequireRole("admin"))
 	apAdmin.GET("", appointmentHandler.GetAll)
+
+	// Поиск и доска приёмов для регистратуры
+	appointmentSearchHandler := NewAppointmentSearchHandler(appointmentSearchService, logger)
+	appointmentSearchHandler.RegisterRoutes(clinicScoped)
+
+	apProtected := clinicScoped.Group("/appointments")
+	apProtected.POST("/:id/complete", RequireRole("admin", "doctor"), appointmentHandler.Complete)
+	apProtected.POST("/:id/no-show", RequireRole("admin", "doctor"), appointmentHandler.MarkNoShow)
+	apProtected.POST("/:id/approve", RequireRole("admin"), appointmentHandler.Approve)
+	apProtected.POST("/:id/reject", RequireRole("admin"), appointmentHandler.Reject)
+
+	// Временное удержание слота на время оформления записи
+	slotHoldHandler := NewSlotHoldHandler(slotHoldService, logger)
+	slotHoldHandler.RegisterRoutes(protected)
+
+	// Повторяющиеся серии приёмов
+	seriesHandler := NewAppointmentSeriesHandler(seriesService, logger)
+	seriesHandler.RegisterRoutes(clinicScoped)
+
+	// Регистратура: отметка прихода и электронная очередь
+	queueHandler := NewQueueHandler(queueService, queueDisplayToken, logger)
+	queueHandler.RegisterRoutes(api, clinicScoped)
+
+	// Неявки и правила бронирования
+	bookingPolicyHandler := NewBookingPolicyHandler(bookingPolicyService, logger)
+	bookingPolicyHandler.RegisterRoutes(clinicScoped)
+
+	// Кабинеты и оборудование
+	resourceHandler := NewResourceHandler(resourceService, logger)
+	resourceHandler.RegisterRoutes(clinicScoped)
+
+	// Payments: без платёжного провайдера маршруты не публикуются.
+	if paymentService.Enabled() {
+		paymentHandler := NewPaymentHandler(paymentService, paymentSimulator, logger)
+		paymentHandler.RegisterRoutes(api, clinicScoped)
+	}
+
+	// Insurance
+	insuranceHandler := NewInsuranceHandler(insuranceService, logger)
+	insuranceHandler.RegisterRoutes(protected)
+
+	// Notifications
+	notificationHandler := NewNotificationHandler(notificationService, logger)
+	notificationHandler.RegisterRoutes(protected)
+
+	// Webhooks для партнёров
+	webhookHandler := NewWebhookHandler(webhookService, logger)
+	webhookHandler.RegisterRoutes(protected)
+
+	// Полнотекстовый поиск по врачам и услугам
+	searchHandler := NewSearchHandler(searchService, logger)
+	searchHandler.RegisterRoutes(api)
+
+	// Календарь (iCal)
+	calendarHandler := NewCalendarHandler(calendarService, logger)
+	calendarHandler.RegisterRoutes(api, protected)
+
+	// Лист ожидания
+	waitlistHandler := NewWaitlistHandler(waitlistService, logger)
+	waitlistHandler.RegisterRoutes(api, protected)
 }
@@ -1,11 +1,14 @@
 package transports
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/localtime"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"github.com/mutsaevz/team-4-dentistry/internal/services"
 )
@@ -44,12 +47,37 @@ func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
 		return
 	}
 
+	if len(req) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "пустой список смен"})
+		return
+	}
+
 	h.logger.Debug("Запрос на создание расписания", "doctor_id", req[0].DoctorID, "date", req[0].Date)
 
+	// ?dry_run=true только проверяет пакет и возвращает все конфликты.
+	if dryRun, _ := strconv.ParseBool(c.Query("dry_run")); dryRun {
+		result, err := h.schedule.ValidateSchedules(c.Request.Context(), req)
+		if err != nil {
+			h.logger.Error("Не удалось проверить расписание", "error", err.Error())
+			c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
 	schedule, err := h.schedule.CreateSchedule(c.Request.Context(), req)
 	if err != nil {
+		var conflicts *services.ScheduleConflictsError
+		if errors.As(err, &conflicts) {
+			h.logger.Warn("Расписание не создано из-за конфликтов", "conflicts", len(conflicts.Conflicts))
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "conflicts": conflicts.Conflicts})
+			return
+		}
+
 		h.logger.Error("Не удалось создать расписание", "error", err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -61,15 +89,20 @@ func (h *ScheduleHandler) GetSchedules(c *gin.Context) {
 
 	h.logger.Debug("Запрос списка расписаний")
 
-	schedules, err := h.schedule.ListSchedules(c.Request.Context())
+	q, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	schedules, err := h.schedule.ListSchedules(c.Request.Context(), q)
 
 	if err != nil {
 		h.logger.Error("Не удалось получить список расписаний", "error", err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	h.logger.Info("Список расписаний получен", "count", len(schedules))
+	h.logger.Info("Список расписаний получен", "count", len(schedules.Items))
 	c.JSON(http.StatusOK, schedules)
 }
 
@@ -117,7 +150,7 @@ func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
 	update, err := h.schedule.UpdateSchedule(c.Request.Context(), uint(id), req)
 	if err != nil {
 		h.logger.Error("Не удалось обновить расписание", "error", err.Error(), "schedule_id", id)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -138,10 +171,45 @@ func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
 
 	if err := h.schedule.DeleteSchedule(c.Request.Context(), uint(id)); err != nil {
 		h.logger.Error("Не удалось удалить расписание", "error", err.Error(), "schedule_id", id)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(scheduleErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	h.logger.Info("Расписание удалено", "schedule_id", id)
 	c.JSON(http.StatusOK, gin.H{"message": "schedule deleted"})
 }
+
+func scheduleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, constants.ErrRoomDoubleBooked),
+		errors.Is(err, constants.ErrDoctorDoubleBooked),
+		errors.Is(err, constants.ErrRoomInactive),
+		errors.Is(err, services.ErrClinicInactive),
+		errors.Is(err, services.ErrScheduleConflicts),
+		errors.Is(err, services.ErrScheduleHasAppointments),
+		// Перенос приёмов вместе со сменой не удался.
+		errors.Is(err, constants.ErrTimeConflict),
+		errors.Is(err, constants.ErrTimeNotInSchedule),
+		errors.Is(err, constants.ErrRoomUnsuitable),
+		errors.Is(err, constants.ErrEquipmentUnavailable),
+		errors.Is(err, constants.ErrServiceNotInClinic):
+		return http.StatusConflict
+	case errors.Is(err, constants.ErrInvalidDoctorID),
+		errors.Is(err, constants.ErrInvalidTimeRange),
+		errors.Is(err, constants.ErrInvalidRoomNumber),
+		errors.Is(err, services.ErrDoctorNotInClinic),
+		errors.Is(err, services.ErrOutsideClinicHours),
+		errors.Is(err, services.ErrScheduleDateMismatch),
+		errors.Is(err, constants.ErrInvalidListQuery),
+		errors.Is(err, localtime.ErrInvalidDate),
+		errors.Is(err, localtime.ErrInvalidClock),
+		errors.Is(err, localtime.ErrNonexistentTime):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrClinicForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrClinicNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
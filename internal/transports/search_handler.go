@@ -0,0 +1,54 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type SearchHandler struct {
+	service services.SearchService
+	logger  *slog.Logger
+}
+
+func NewSearchHandler(service services.SearchService, logger *slog.Logger) *SearchHandler {
+	return &SearchHandler{service: service, logger: logger}
+}
+
+func (h *SearchHandler) RegisterRoutes(public *gin.RouterGroup) {
+	public.GET("/search", h.Search)
+}
+
+// Search: ?q=удаление зуба мудрости&type=service&limit=10
+func (h *SearchHandler) Search(c *gin.Context) {
+	params := models.SearchQueryParams{
+		Query: c.Query("q"),
+		Type:  c.Query("type"),
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный limit"})
+			return
+		}
+		params.Limit = limit
+	}
+
+	result, err := h.service.Search(c.Request.Context(), params)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSearchQuery) || errors.Is(err, services.ErrInvalidSearchType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("Ошибка поиска", "error", err.Error(), "query", params.Query)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
 	"github.com/mutsaevz/team-4-dentistry/internal/models"
 	"github.com/mutsaevz/team-4-dentistry/internal/services"
 )
@@ -28,6 +29,7 @@ func (h *ServiceHandler) RegisterRoutes(r *gin.RouterGroup) {
 
 	services.GET("/:id", h.GetByID)
 	services.GET("", h.List)
+	services.GET("/:id/doctors", h.ListDoctors)
 
 	admin := services.Group("")
 	admin.Use(RequireRole("admin"))
@@ -35,6 +37,10 @@ func (h *ServiceHandler) RegisterRoutes(r *gin.RouterGroup) {
 	admin.PUT("/:id", h.Update)
 	admin.DELETE("/:id", h.Delete)
 
+	doctors := r.Group("/doctors")
+	doctors.Use(RequireRole("admin"))
+	doctors.PUT("/:id/services/:service_id", h.SetDoctorOffering)
+	doctors.DELETE("/:id/services/:service_id", h.RemoveDoctorOffering)
 }
 
 func (h *ServiceHandler) Create(c *gin.Context) {
@@ -104,8 +110,25 @@ func (h *ServiceHandler) List(c *gin.Context) {
 		return
 	}
 
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		id, err := strconv.ParseUint(categoryID, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный category_id"})
+			return
+		}
+
+		services, err := h.service.ListServicesByCategoryTree(uint(id), offset, limit, QueryClinicID(c))
+		if err != nil {
+			h.logger.Error("Ошибка получения услуг по дереву категорий", "error", err.Error(), "category_id", id)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, services)
+		return
+	}
+
 	if category != "" {
-		services, err := h.service.ListServicesByCategory(category, offset, limit)
+		services, err := h.service.ListServicesByCategory(category, offset, limit, QueryClinicID(c))
 		if err != nil {
 			h.logger.Error("Ошибка получения услуг по категории", "error", err.Error(), "category", category)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -116,7 +139,7 @@ func (h *ServiceHandler) List(c *gin.Context) {
 		return
 	}
 
-	services, err := h.service.ListServices(offset, limit)
+	services, err := h.service.ListServices(offset, limit, QueryClinicID(c))
 	if err != nil {
 		h.logger.Error("Ошибка получения списка услуг", "error", err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -151,6 +174,10 @@ func (h *ServiceHandler) Update(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, services.ErrCategoryNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error("Ошибка обновления услуги", "error", err.Error(), "service_id", id)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -184,3 +211,80 @@ func (h *ServiceHandler) Delete(c *gin.Context) {
 	h.logger.Info("Услуга удалена", "service_id", id)
 	c.Status(http.StatusOK)
 }
+
+func (h *ServiceHandler) ListDoctors(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	offerings, err := h.service.ListServiceDoctors(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Warn("Не удалось получить врачей услуги", "error", err.Error(), "service_id", id)
+		c.JSON(offeringErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, offerings)
+}
+
+func (h *ServiceHandler) SetDoctorOffering(c *gin.Context) {
+	doctorID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	serviceID, ok := parseIDParam(c, "service_id")
+	if !ok {
+		return
+	}
+
+	var req models.DoctorOfferingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Service.SetDoctorOffering", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	offering, err := h.service.SetDoctorOffering(c.Request.Context(), doctorID, serviceID, req)
+	if err != nil {
+		h.logger.Warn("Не удалось назначить услугу врачу", "error", err.Error(), "doctor_id", doctorID, "service_id", serviceID)
+		c.JSON(offeringErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Услуга врача сохранена", "doctor_id", doctorID, "service_id", serviceID)
+	c.JSON(http.StatusOK, offering)
+}
+
+func (h *ServiceHandler) RemoveDoctorOffering(c *gin.Context) {
+	doctorID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	serviceID, ok := parseIDParam(c, "service_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveDoctorOffering(c.Request.Context(), doctorID, serviceID); err != nil {
+		h.logger.Warn("Не удалось снять услугу с врача", "error", err.Error(), "doctor_id", doctorID, "service_id", serviceID)
+		c.JSON(offeringErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Услуга снята с врача", "doctor_id", doctorID, "service_id", serviceID)
+	c.Status(http.StatusNoContent)
+}
+
+func offeringErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrServiceNotfound),
+		errors.Is(err, services.ErrDoctorNotFound),
+		errors.Is(err, constants.ErrServiceNotOffered):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidOffering):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,85 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type SlotHoldHandler struct {
+	service services.SlotHoldService
+	logger  *slog.Logger
+}
+
+func NewSlotHoldHandler(service services.SlotHoldService, logger *slog.Logger) *SlotHoldHandler {
+	return &SlotHoldHandler{service: service, logger: logger}
+}
+
+func (h *SlotHoldHandler) RegisterRoutes(protected *gin.RouterGroup) {
+	holds := protected.Group("/appointments/holds")
+	holds.POST("", h.Hold)
+	holds.DELETE("/:token", h.Release)
+}
+
+func (h *SlotHoldHandler) Hold(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	var req models.SlotHoldCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в SlotHold.Hold", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	hold, err := h.service.Hold(c.Request.Context(), userID, role, req)
+	if err != nil {
+		h.logger.Warn("Не удалось удержать слот", "error", err.Error(), "doctor_id", req.DoctorID, "start_at", req.StartAt)
+		c.JSON(slotHoldErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+func (h *SlotHoldHandler) Release(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	if err := h.service.Release(c.Request.Context(), userID, role, c.Param("token")); err != nil {
+		c.JSON(slotHoldErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func slotHoldErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrHoldNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrHoldForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrSlotUnavailable),
+		errors.Is(err, constants.ErrSlotHeld):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidHold),
+		errors.Is(err, constants.ErrServiceNotOffered),
+		errors.Is(err, constants.ErrTimeNotInSchedule),
+		errors.Is(err, constants.ErrInvalidAppointmentTime):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
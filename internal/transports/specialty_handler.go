@@ -0,0 +1,111 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type SpecialtyHandler struct {
+	service services.SpecialtyService
+	logger  *slog.Logger
+}
+
+func NewSpecialtyHandler(service services.SpecialtyService, logger *slog.Logger) *SpecialtyHandler {
+	return &SpecialtyHandler{service: service, logger: logger}
+}
+
+// RegisterRoutes регистрирует публичный справочник специализаций и
+// админские маршруты.
+func (h *SpecialtyHandler) RegisterRoutes(public *gin.RouterGroup, protected *gin.RouterGroup) {
+	public.GET("/specialties", h.List)
+
+	admin := protected.Group("/specialties")
+	admin.Use(RequireRole("admin"))
+	admin.POST("", h.Create)
+	admin.PATCH("/:id", h.Update)
+	admin.DELETE("/:id", h.Delete)
+}
+
+func (h *SpecialtyHandler) List(c *gin.Context) {
+	specialties, err := h.service.List(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Ошибка получения специализаций", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, specialties)
+}
+
+func (h *SpecialtyHandler) Create(c *gin.Context) {
+	var req models.SpecialtyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Specialty.Create", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	specialty, err := h.service.Create(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(specialtyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Специализация создана", "specialty_id", specialty.ID)
+	c.JSON(http.StatusCreated, specialty)
+}
+
+func (h *SpecialtyHandler) Update(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.SpecialtyUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Specialty.Update", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	specialty, err := h.service.Update(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(specialtyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, specialty)
+}
+
+func (h *SpecialtyHandler) Delete(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		c.JSON(specialtyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func specialtyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrSpecialtyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrSpecialtyExists),
+		errors.Is(err, services.ErrSpecialtyInUse):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidSpecialty):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,185 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/constants"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type WaitlistHandler struct {
+	service services.WaitlistService
+	logger  *slog.Logger
+}
+
+func NewWaitlistHandler(service services.WaitlistService, logger *slog.Logger) *WaitlistHandler {
+	return &WaitlistHandler{service: service, logger: logger}
+}
+
+func (h *WaitlistHandler) RegisterRoutes(public *gin.RouterGroup, protected *gin.RouterGroup) {
+	// Ссылка из уведомления: токен предложения сам по себе даёт доступ.
+	offers := public.Group("/waitlist/offers")
+	offers.GET("/:token", h.GetOffer)
+	offers.POST("/:token/accept", h.AcceptOffer)
+	offers.POST("/:token/decline", h.DeclineOffer)
+
+	wl := protected.Group("/waitlist")
+	wl.POST("", h.Join)
+	wl.GET("/my", h.ListMy)
+	wl.GET("/my/offers", h.ListMyOffers)
+	wl.DELETE("/:id", h.Leave)
+	wl.GET("", RequireRole("admin"), h.List)
+}
+
+func (h *WaitlistHandler) Join(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	var req models.WaitlistJoinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Waitlist.Join", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	entry, err := h.service.Join(c.Request.Context(), userID, role, req)
+	if err != nil {
+		h.logger.Error("Ошибка добавления в лист ожидания", "error", err.Error(), "user_id", userID)
+		c.JSON(waitlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Пациент добавлен в лист ожидания", "entry_id", entry.ID)
+	c.JSON(http.StatusCreated, entry)
+}
+
+func (h *WaitlistHandler) Leave(c *gin.Context) {
+	userID, role, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Leave(c.Request.Context(), userID, role, id); err != nil {
+		h.logger.Error("Ошибка выхода из листа ожидания", "error", err.Error(), "entry_id", id)
+		c.JSON(waitlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WaitlistHandler) ListMy(c *gin.Context) {
+	userID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	entries, err := h.service.ListMy(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+func (h *WaitlistHandler) ListMyOffers(c *gin.Context) {
+	userID, _, ok := CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "неавторизован"})
+		return
+	}
+
+	offers, err := h.service.ListMyOffers(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, offers)
+}
+
+func (h *WaitlistHandler) List(c *gin.Context) {
+	doctorID, _ := strconv.Atoi(c.Query("doctor_id"))
+	patientID, _ := strconv.Atoi(c.Query("patient_id"))
+
+	entries, err := h.service.List(c.Request.Context(), models.WaitlistQueryParams{
+		DoctorID:  uint(doctorID),
+		PatientID: uint(patientID),
+		Status:    models.WaitlistStatus(c.Query("status")),
+	})
+	if err != nil {
+		h.logger.Error("Ошибка получения листа ожидания", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+func (h *WaitlistHandler) GetOffer(c *gin.Context) {
+	offer, err := h.service.GetOffer(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		c.JSON(waitlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, offer)
+}
+
+func (h *WaitlistHandler) AcceptOffer(c *gin.Context) {
+	appointment, err := h.service.AcceptOffer(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		h.logger.Error("Ошибка подтверждения предложения из листа ожидания", "error", err.Error())
+		c.JSON(waitlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Предложение из листа ожидания подтверждено", "appointment_id", appointment.ID)
+	c.JSON(http.StatusCreated, appointment)
+}
+
+func (h *WaitlistHandler) DeclineOffer(c *gin.Context) {
+	if err := h.service.DeclineOffer(c.Request.Context(), c.Param("token")); err != nil {
+		h.logger.Error("Ошибка отказа от предложения из листа ожидания", "error", err.Error())
+		c.JSON(waitlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func waitlistErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrWaitlistEntryNotFound),
+		errors.Is(err, services.ErrOfferNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrWaitlistForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrOfferNotPending),
+		errors.Is(err, constants.ErrTimeConflict),
+		errors.Is(err, constants.ErrSlotHeld):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidWaitlistEntry),
+		errors.Is(err, constants.ErrTimeNotInSchedule),
+		errors.Is(err, constants.ErrInvalidAppointmentTime):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
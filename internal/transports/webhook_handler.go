@@ -0,0 +1,179 @@
+package transports
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mutsaevz/team-4-dentistry/internal/models"
+	"github.com/mutsaevz/team-4-dentistry/internal/services"
+)
+
+type WebhookHandler struct {
+	service services.WebhookService
+	logger  *slog.Logger
+}
+
+func NewWebhookHandler(service services.WebhookService, logger *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{service: service, logger: logger}
+}
+
+func (h *WebhookHandler) RegisterRoutes(r *gin.RouterGroup) {
+	wh := r.Group("/webhooks")
+	wh.Use(RequireRole("admin"))
+
+	wh.POST("", h.CreateSubscription)
+	wh.GET("", h.ListSubscriptions)
+	wh.GET("/:id", h.GetSubscription)
+	wh.PATCH("/:id", h.UpdateSubscription)
+	wh.DELETE("/:id", h.DeleteSubscription)
+
+	wh.GET("/deliveries", h.ListDeliveries)
+	wh.GET("/dead-letter", h.ListDeadLetter)
+	wh.POST("/deliveries/:id/redeliver", h.Redeliver)
+}
+
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var req models.WebhookSubscriptionCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Ошибка парсинга JSON в Webhook.CreateSubscription", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	created, err := h.service.CreateSubscription(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Ошибка создания webhook подписки", "error", err.Error(), "url", req.URL)
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Webhook подписка создана", "subscription_id", created.Subscription.ID)
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	subs, err := h.service.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Ошибка получения webhook подписок", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, subs)
+}
+
+func (h *WebhookHandler) GetSubscription(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	sub, err := h.service.GetSubscription(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *WebhookHandler) UpdateSubscription(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.WebhookSubscriptionUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный JSON"})
+		return
+	}
+
+	sub, err := h.service.UpdateSubscription(c.Request.Context(), id, req)
+	if err != nil {
+		h.logger.Error("Ошибка обновления webhook подписки", "error", err.Error(), "subscription_id", id)
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Webhook подписка обновлена", "subscription_id", id)
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSubscription(c.Request.Context(), id); err != nil {
+		h.logger.Error("Ошибка удаления webhook подписки", "error", err.Error(), "subscription_id", id)
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("Webhook подписка удалена", "subscription_id", id)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	subID, _ := strconv.Atoi(c.Query("subscription_id"))
+
+	h.listDeliveries(c, models.WebhookDeliveryQueryParams{
+		SubscriptionID: uint(subID),
+		Status:         models.WebhookDeliveryStatus(c.Query("status")),
+		EventType:      c.Query("event_type"),
+	})
+}
+
+func (h *WebhookHandler) ListDeadLetter(c *gin.Context) {
+	subID, _ := strconv.Atoi(c.Query("subscription_id"))
+
+	h.listDeliveries(c, models.WebhookDeliveryQueryParams{
+		SubscriptionID: uint(subID),
+		Status:         models.WebhookDeliveryDead,
+	})
+}
+
+func (h *WebhookHandler) listDeliveries(c *gin.Context, params models.WebhookDeliveryQueryParams) {
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), params)
+	if err != nil {
+		h.logger.Error("Ошибка получения журнала webhook доставок", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.Redeliver(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Ошибка повторной доставки webhook", "error", err.Error(), "delivery_id", id)
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound),
+		errors.Is(err, services.ErrWebhookDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidWebhookURL),
+		errors.Is(err, services.ErrInvalidWebhookEvents):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}